@@ -2,32 +2,123 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"kitty/backend/access"
+	"kitty/backend/analysis"
+	"kitty/backend/apperror"
+	"kitty/backend/artwork"
 	"kitty/backend/audio"
+	"kitty/backend/bookmark"
+	"kitty/backend/cloudfile"
 	"kitty/backend/downloader"
+	"kitty/backend/events"
+	"kitty/backend/httpclient"
 	"kitty/backend/library"
 	"kitty/backend/media"
 	"kitty/backend/metadata"
+	"kitty/backend/nowplaying"
+	"kitty/backend/ops"
+	"kitty/backend/player"
+	"kitty/backend/playlist"
+	"kitty/backend/power"
+	"kitty/backend/selftest"
+	"kitty/backend/server"
+	"kitty/backend/shortcuts"
+	"kitty/backend/snapshot"
 	"kitty/backend/soundcloud"
+	"kitty/backend/startup"
+	"kitty/backend/stats"
 	"kitty/backend/storage"
+	"kitty/backend/volumes"
+	"kitty/backend/webhook"
+	"kitty/backend/youtube"
 	"log"
+	"math/rand"
+	"net/http"
 	"net/url"
+	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 type App struct {
 	ctx        context.Context
+	mu         sync.Mutex
 	player     *audio.AudioPlayer
 	library    *library.Manager
 	downloader *downloader.Client
+	downloads  *downloader.History
+	transfers  *downloader.Stats
+	secrets    *downloader.Credentials
 	media      *media.Service
 	sc         *soundcloud.Service
+	hooks      *webhook.Notifier
+	playlists  *playlist.Store
+	yt         *youtube.Client
+	queue      *player.Queue
+	stats      *stats.Store
+	ops        *ops.Manager
+	snapshots  *snapshot.Manager
+	boot       *startup.Tracker
+	bookmarks  *access.Bookmarks
+	trackMarks *bookmark.Store
+	power      *power.Governor
+	covers     *artwork.Cache
+	controls   *nowplaying.Controls
+
+	likesCancel   context.CancelFunc
+	previewCancel context.CancelFunc
+	folderSync    sync.Mutex
+	lastOutput    string
+	prevOutput    string
+
+	chapterPath string
+	chapters    []metadata.Chapter
+	chapter     int
+
+	resumePath  string
+	resumeSaved time.Time
+
+	recordDone chan recordingResult
+
+	headless bool
+	onEvent  func(event string, data ...interface{})
 }
 
+const (
+	importBatchSize = 25
+	startupDeferral = 3 * time.Second
+
+	restartThreshold = 3.0
+	autoDJBatch      = 10
+
+	maxAlbumGainTracks  = 60
+	replayGainReference = -18.0
+
+	maxCustomColumns = 16
+	maxCoverBatch    = 200
+	coverBatchJobs   = 4
+
+	folderPlaylistInterval = 15 * time.Minute
+	failedRetryInterval    = time.Minute
+
+	selfTestSidecarSample = 500
+
+	autoResumeMinSec    = 20 * 60
+	autoResumeEndSec    = 30
+	autoResumeSaveEvery = 15 * time.Second
+)
+
 type BulkMetadataPatch struct {
 	ApplyAlbumArtist bool   `json:"applyAlbumArtist"`
 	AlbumArtist      string `json:"albumArtist"`
@@ -64,51 +155,435 @@ type ExtractAudioResult struct {
 	Errors       []string                `json:"errors,omitempty"`
 }
 
+type PlaylistDownloadRequest struct {
+	Links       []string `json:"links"`
+	TargetDir   string   `json:"targetDir"`
+	Format      string   `json:"format"`
+	Bitrate     string   `json:"bitrate"`
+	Album       string   `json:"album"`
+	AlbumArtist string   `json:"albumArtist"`
+	Numbering   string   `json:"numbering"`
+	Playlist    string   `json:"playlist"`
+}
+
+type PlaylistDownloadResult struct {
+	Total     int                      `json:"total"`
+	Succeeded int                      `json:"succeeded"`
+	Failed    int                      `json:"failed"`
+	Tracks    []metadata.TrackMetadata `json:"tracks"`
+	Errors    []BulkUpdateError        `json:"errors"`
+	Playlist  *playlist.Playlist       `json:"playlist,omitempty"`
+}
+
+type YouTubeImportEntry struct {
+	youtube.Entry
+	InLibrary   bool   `json:"inLibrary"`
+	MatchedPath string `json:"matchedPath,omitempty"`
+}
+
+type YouTubeImportResult struct {
+	PlaylistID   string               `json:"playlistId"`
+	Title        string               `json:"title"`
+	Entries      []YouTubeImportEntry `json:"entries"`
+	Matched      int                  `json:"matched"`
+	MissingLinks []string             `json:"missingLinks"`
+	Truncated    bool                 `json:"truncated,omitempty"`
+}
+
+type SplitMixRequest struct {
+	Path         string  `json:"path"`
+	Tracklist    string  `json:"tracklist"`
+	UseSilence   bool    `json:"useSilence"`
+	ThresholdDb  float64 `json:"thresholdDb"`
+	MinSilenceMs int64   `json:"minSilenceMs"`
+	OutputDir    string  `json:"outputDir"`
+	Album        string  `json:"album"`
+	AlbumArtist  string  `json:"albumArtist"`
+}
+
+type VinylSplitRequest struct {
+	Path         string  `json:"path"`
+	ThresholdDb  float64 `json:"thresholdDb"`
+	MinSilenceMs int64   `json:"minSilenceMs"`
+	SideGapMs    int64   `json:"sideGapMs"`
+}
+
+type ExportSplitRequest struct {
+	Path        string          `json:"path"`
+	OutputDir   string          `json:"outputDir"`
+	Album       string          `json:"album"`
+	AlbumArtist string          `json:"albumArtist"`
+	Segments    []media.Segment `json:"segments"`
+}
+
+type SplitMixResult struct {
+	Tracks []metadata.TrackMetadata `json:"tracks"`
+	Errors []string                 `json:"errors"`
+}
+
 type TrimResult struct {
 	UpdatedTrack *metadata.TrackMetadata `json:"updatedTrack,omitempty"`
 	Backup       *media.TrimBackup       `json:"backup,omitempty"`
 }
 
+type EQState struct {
+	Frequencies []float64 `json:"frequencies"`
+	Gains       []float64 `json:"gains"`
+	Preset      string    `json:"preset"`
+	Presets     []string  `json:"presets"`
+	MaxGainDb   float64   `json:"maxGainDb"`
+}
+
+type FolderImportResult struct {
+	*library.BatchResult
+	Skipped []library.Skipped `json:"skipped"`
+}
+
+type NowPlayingArt struct {
+	Path     string           `json:"path"`
+	Images   []artwork.Image  `json:"images"`
+	Palette  []artwork.Swatch `json:"palette"`
+	Dominant string           `json:"dominant"`
+}
+
 func NewApp() *App {
 	root, _ := filepath.Abs(".")
-	return &App{
+	a := &App{
 		player:     audio.NewAudioPlayer(),
 		library:    library.NewManager(),
 		downloader: downloader.New(filepath.Join(root, "api")),
+		downloads:  downloader.NewHistory(),
+		transfers:  downloader.NewStats(),
+		secrets:    downloader.NewCredentials(),
 		media:      media.NewService(),
 		sc:         soundcloud.New("http://127.0.0.1:17877/oauth/soundcloud/callback", "127.0.0.1:17877"),
+		hooks:      webhook.New(),
+		playlists:  playlist.NewStore(),
+		yt:         youtube.New(),
+		queue:      player.NewQueue(),
+		stats:      stats.NewStore(),
+		bookmarks:  access.NewBookmarks(),
+		trackMarks: bookmark.NewStore(),
+		power:      power.NewGovernor(),
+		covers:     artwork.NewCache(),
 	}
+	a.player.SetOnFinished(a.advanceQueue)
+	a.boot = startup.New(func(t startup.Timing) {
+		a.emit(events.ServiceReady, t)
+	})
+	a.ops = ops.New(func(op ops.Operation) {
+		a.emit(events.OperationUpdate, op)
+	})
+	a.snapshots = snapshot.New(map[string]string{
+		"library.json":        storage.GetConfigPath(),
+		"settings.json":       storage.SettingsPath(),
+		"playlists.json":      a.playlists.Path(),
+		"play_stats.json":     a.stats.Path(),
+		"downloads.json":      a.downloads.Path(),
+		"download_stats.json": a.transfers.Path(),
+		"bookmarks.json":      a.trackMarks.Path(),
+	}, snapshot.DefaultKeep)
+	return a
 }
 
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
-	if err := a.media.CleanupExpiredBackups(); err != nil {
-		log.Printf("[app] trim backup cleanup failed: %v", err)
+	defer a.boot.MarkReady()
+	a.boot.Go(ctx, "cleanup", startupDeferral, func(context.Context) error {
+		if err := a.media.CleanupExpiredBackups(); err != nil {
+			log.Printf("[app] trim backup cleanup failed: %v", err)
+		}
+		_, err := a.downloader.CleanupStaleParts()
+		return err
+	})
+	go a.snapshotDaily(ctx)
+	go a.watchVolumes(ctx)
+	go a.folderPlaylistLoop(ctx)
+	go a.failedRetryLoop(ctx)
+	go a.player.WatchPosition(ctx, audio.PositionInterval, func(p audio.Position) {
+		a.emit(events.PlaybackPosition, events.Position(p))
+		a.trackChapter(p)
+		a.trackResume(p)
+	})
+	a.lastOutput, _ = audio.DefaultOutputID()
+	go audio.WatchDefaultOutput(ctx, audio.DeviceFollowInterval, a.outputChanged)
+	go a.player.WatchStall(ctx, audio.StallTimeout, a.outputStalled)
+	if !a.headless {
+		a.boot.Go(ctx, "media-controls", 0, func(context.Context) error {
+			return a.startMediaControls()
+		})
 	}
-	set, err := storage.LoadSettings()
+	a.boot.Go(ctx, "soundcloud", startupDeferral, func(ctx context.Context) error {
+		go a.sc.MonitorToken(ctx, func(health soundcloud.TokenHealth) {
+			log.Printf("[app] soundcloud reconnect needed: %s", health.Error)
+			a.emit(events.SoundCloudReconnect, health)
+		})
+		return nil
+	})
+	var set storage.Settings
+	err := a.boot.Measure("settings", func() error {
+		var err error
+		set, err = storage.LoadSettings()
+		if err != nil {
+			return err
+		}
+		if err := metadata.SetSidecarLocation(set.Metadata.SidecarLocation); err != nil {
+			log.Printf("[app] sidecar location: %v", err)
+		}
+		library.SetMemoryLimits(set.Cache.CoverMB, set.Cache.MetadataMB)
+		httpclient.Configure(set.Network.Timeouts)
+		if !storage.IsSealed(set.NowPlaying.MQTTPassword) && set.NowPlaying.MQTTPassword != "" {
+			if set.NowPlaying, err = webhook.SealNowPlaying(set.NowPlaying); err == nil {
+				err = storage.SaveSettings(set)
+			}
+			if err != nil {
+				log.Printf("[app] encrypt mqtt password failed: %v", err)
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		log.Printf("[app] load settings failed: %v", err)
 		return
 	}
+	if policy, err := power.NormalizePolicy(powerPolicy(set.Power)); err == nil {
+		a.power.SetPolicy(policy)
+	} else {
+		log.Printf("[app] power policy: %v", err)
+	}
+	go a.power.Watch(ctx, func(st power.State) {
+		a.emit(events.PowerChanged, st)
+	})
+	a.boot.Measure("access", func() error {
+		if restored := a.bookmarks.Restore(); len(restored) > 0 {
+			log.Printf("[app] restored access to %d folders", len(restored))
+		}
+		return nil
+	})
+	a.boot.Measure("player", func() error {
+		a.player.SetSkipSilence(set.Playback.SkipSilence, set.Playback.SkipSilenceMinGap)
+		a.player.SetNightMode(nightModeFor(set.Playback, a.player.OutputDevice()))
+		a.player.SetMono(set.Playback.Mono)
+		if err := a.player.SetBitPerfect(set.Playback.BitPerfect); err != nil {
+			log.Printf("[app] restore bit-perfect output failed: %v", err)
+		}
+		if err := a.player.SetBalance(set.Playback.Balance); err != nil {
+			log.Printf("[app] restore balance failed: %v", err)
+		}
+		if err := a.player.SetCrossfeed(set.Playback.Crossfeed, crossfeedLevel(set.Playback)); err != nil {
+			log.Printf("[app] restore crossfeed failed: %v", err)
+		}
+		if err := a.player.SetPreamp(set.Playback.Preamp); err != nil {
+			log.Printf("[app] restore preamp failed: %v", err)
+		}
+		if err := a.player.SetFadeDuration(fadeDuration(set.Playback)); err != nil {
+			log.Printf("[app] restore fade failed: %v", err)
+		}
+		if err := a.player.SetReplayGainMode(set.Playback.ReplayGain); err != nil {
+			log.Printf("[app] replaygain mode: %v", err)
+		}
+		a.player.SetLoudnessNormalization(set.Playback.Normalize, loudnessTarget(set.Playback))
+		if err := a.player.SetResampleQuality(set.Playback.ResampleQuality); err != nil {
+			log.Printf("[app] resample quality: %v", err)
+		}
+		if len(set.Playback.EQ) > 0 {
+			if err := a.player.SetEQ(set.Playback.EQ); err != nil {
+				log.Printf("[app] restore eq failed: %v", err)
+			}
+		}
+		a.queue.SetShuffleHistory(a.recentPlays, set.Playback.ShuffleMemory, set.Playback.ArtistSpacing)
+		if _, err := a.queue.SetShuffle(set.Playback.Shuffle); err != nil {
+			log.Printf("[app] restore shuffle mode failed: %v", err)
+		}
+		if _, err := a.queue.SetRepeat(set.Playback.Repeat); err != nil {
+			log.Printf("[app] restore repeat mode failed: %v", err)
+		}
+		if _, err := a.queue.SetEndAction(set.Playback.EndAction); err != nil {
+			log.Printf("[app] restore end-of-queue action failed: %v", err)
+		}
+		return nil
+	})
 	if !set.Downloader.AutoStart {
 		return
 	}
-	go func() {
+	a.boot.Go(ctx, "downloader", 0, func(ctx context.Context) error {
 		if err := a.downloader.Start(ctx); err != nil {
 			log.Printf("[app] downloader auto-start failed: %v", err)
+			return err
 		}
-	}()
+		return nil
+	})
+}
+
+func (a *App) GetStartupTimings() startup.Report {
+	return a.boot.Report()
+}
+
+func (a *App) RunSelfTest() selftest.Report {
+	ctx := a.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return selftest.Run(ctx, []selftest.Probe{
+		{
+			Name:       "config",
+			Suggestion: "Make sure the Kitty settings folder exists and is writable by your user.",
+			Run: func(context.Context) (string, error) {
+				dir := filepath.Dir(storage.SettingsPath())
+				if err := selftest.Writable(dir); err != nil {
+					return "", err
+				}
+				if _, err := storage.LoadSettings(); err != nil {
+					return "", fmt.Errorf("settings unreadable: %w", err)
+				}
+				return dir, nil
+			},
+		},
+		{
+			Name:       "sidecars",
+			Suggestion: "Clear the metadata cache from settings, or delete the listed files from the sidecar folder.",
+			Run: func(context.Context) (string, error) {
+				health, err := metadata.CheckSidecars(selfTestSidecarSample)
+				if err != nil {
+					return "", err
+				}
+				if err := selftest.Writable(health.Dir); err != nil {
+					return "", err
+				}
+				detail := fmt.Sprintf("%d sidecars in %s", health.Files, health.Dir)
+				if len(health.Corrupt) > 0 {
+					return detail, selftest.Warnf("%d of %d checked sidecars are unreadable: %s", len(health.Corrupt), health.Checked, strings.Join(health.Corrupt, ", "))
+				}
+				return detail, nil
+			},
+		},
+		{
+			Name:       "library",
+			Suggestion: "Restore the library from a snapshot, or run a rescan to rebuild it.",
+			Run: func(context.Context) (string, error) {
+				health, err := storage.CheckLibrary()
+				if err != nil {
+					return "", fmt.Errorf("library file unreadable: %w", err)
+				}
+				detail := fmt.Sprintf("%d tracks in %s", health.Files, health.Path)
+				if !health.Clean() {
+					return detail, selftest.Warnf("%d duplicate entries, %d orphaned ids, %d duplicate ids, %d orphaned stamps",
+						health.Duplicates, health.OrphanIDs, health.DuplicateIDs, health.OrphanStamps)
+				}
+				return detail, nil
+			},
+		},
+		{
+			Name:       "audio",
+			Suggestion: "Check that an output device is connected and not held exclusively by another app.",
+			Run: func(context.Context) (string, error) {
+				if sr, ok := audio.OutputReady(); ok {
+					return fmt.Sprintf("output open at %d Hz", sr), nil
+				}
+				if device, err := audio.DefaultOutputID(); err == nil && device != "" {
+					return fmt.Sprintf("output opens on first playback (default device %s)", device), nil
+				}
+				return "output opens on first playback", nil
+			},
+		},
+		{
+			Name:       "downloader",
+			Suggestion: "Install Node.js 18+ (or set KITTY_NODE_PATH) and make sure the api folder ships with the app.",
+			Run: func(ctx context.Context) (string, error) {
+				r, err := a.downloader.Readiness(ctx)
+				if err != nil {
+					return "", err
+				}
+				detail := fmt.Sprintf("node %s at %s", r.NodeVersion, r.NodePath)
+				if !r.Installed {
+					return detail, selftest.Warnf("cobalt dependencies are not installed yet; they install on first start")
+				}
+				if r.Running {
+					detail += ", cobalt running"
+				}
+				return detail, nil
+			},
+		},
+	})
 }
 
 func (a *App) shutdown(ctx context.Context) {
+	if err := a.savePlaybackState(); err != nil {
+		log.Printf("[app] save playback state failed: %v", err)
+	}
 	a.downloader.Stop()
+	a.mu.Lock()
+	controls := a.controls
+	a.controls = nil
+	a.mu.Unlock()
+	controls.Close()
+	if err := a.player.StopStreamOutput(); err != nil {
+		log.Printf("[app] stop stream output failed: %v", err)
+	}
+	if a.media.ActiveRecording() != nil {
+		if _, err := a.media.StopRecording(); err != nil {
+			log.Printf("[app] stop recording on shutdown failed: %v", err)
+		}
+	}
+}
+
+type PlaybackResume struct {
+	Path     string       `json:"path"`
+	Position float64      `json:"position"`
+	Queue    player.State `json:"queue"`
+}
+
+func (a *App) savePlaybackState() error {
+	state := storage.ResumeState{Path: a.player.CurrentPath(), Position: a.player.GetPosition(), SavedAt: time.Now().Unix()}
+	q := a.queue.State()
+	for _, it := range q.Items {
+		state.Queue = append(state.Queue, it.Path)
+	}
+	state.QueueIndex = q.Current
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return err
+	}
+	set.Resume = state
+	return storage.SaveSettings(set)
+}
+
+func (a *App) RestorePlaybackState() (*PlaybackResume, error) {
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+	state := set.Resume
+	result := &PlaybackResume{}
+	if len(state.Queue) > 0 {
+		result.Queue = a.queue.Set(a.queueItems(state.Queue), state.QueueIndex)
+	} else {
+		result.Queue = a.queue.State()
+	}
+	if strings.TrimSpace(state.Path) == "" {
+		return result, nil
+	}
+	path := a.trackPath(state.Path)
+	if _, err := os.Stat(metadata.SourcePath(path)); err != nil {
+		return result, apperror.NotFound(fmt.Sprintf("%s is no longer available", filepath.Base(path)))
+	}
+	if _, err := a.openTrack(path, state.Position, true); err != nil {
+		return result, err
+	}
+	a.playbackChanged()
+	result.Path = path
+	result.Position = a.player.GetPosition()
+	return result, nil
 }
 
 func (a *App) SelectFiles() ([]string, error) {
+	if err := a.requireDesktop("file picker"); err != nil {
+		return nil, err
+	}
 	selection, err := runtime.OpenMultipleFilesDialog(a.ctx, runtime.OpenDialogOptions{
 		Title: "Select Music Files",
 		Filters: []runtime.FileFilter{
-			{DisplayName: "Music Files", Pattern: "*.mp3;*.flac;*.wav;*.ogg;*.m4a"},
+			{DisplayName: "Music Files", Pattern: "*.mp3;*.flac;*.wav;*.ogg;*.m4a;*.cue"},
 		},
 	})
 	return selection, err
@@ -124,15 +599,21 @@ func (a *App) LoadLibrary() []string {
 }
 
 func (a *App) LoadMetadata(path string) (*metadata.TrackMetadata, error) {
-	return metadata.LoadMetadata(path)
+	return metadata.LoadMetadata(a.trackPath(path))
 }
 
 func (a *App) SaveMetadata(md metadata.TrackMetadata) error {
+	if err := a.ensureLocal(md.FilePath); err != nil {
+		return err
+	}
 	_, err := a.library.UpdateAndReload(md)
 	return err
 }
 
 func (a *App) SaveMetadataAndRefresh(md metadata.TrackMetadata) (*metadata.TrackMetadata, error) {
+	if err := a.ensureLocal(md.FilePath); err != nil {
+		return nil, err
+	}
 	updated, err := a.library.UpdateAndReload(md)
 	if err != nil {
 		return nil, err
@@ -140,153 +621,3356 @@ func (a *App) SaveMetadataAndRefresh(md metadata.TrackMetadata) (*metadata.Track
 	return &updated, nil
 }
 
-func (a *App) LoadAudio(path string) error {
-	return a.player.Load(path)
+func (a *App) OpenSourcePage(path string) (string, error) {
+	path = a.trackPath(path)
+	md, err := metadata.LoadMetadata(path)
+	if err != nil {
+		return "", err
+	}
+	link := strings.TrimSpace(md.SourceURL)
+	if link == "" {
+		return "", apperror.NotFound(fmt.Sprintf("no source page recorded for %s", filepath.Base(path)))
+	}
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", apperror.Invalid(fmt.Sprintf("invalid source url: %s", link))
+	}
+	a.openURL(link)
+	return link, nil
 }
 
-func (a *App) PlayAudio() {
-	a.player.Play()
+func (a *App) ExtractLinks(text string) []downloader.MediaLink {
+	return downloader.ExtractLinks(text)
 }
 
-func (a *App) PauseAudio() {
-	a.player.Pause()
+func (a *App) ValidateLink(link string) downloader.LinkCheck {
+	return a.downloader.ValidateLink(a.ctx, link)
 }
 
-func (a *App) ToggleAudio() bool {
-	return a.player.TogglePlay()
+func (a *App) OpenLink(link string) error {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return apperror.Invalid(fmt.Sprintf("invalid link: %s", link))
+	}
+	a.openURL(u.String())
+	return nil
 }
 
-func (a *App) SetVolume(vol float64) {
-	a.player.SetVolume(vol)
+func (a *App) LoadAudio(path string) error {
+	path = a.trackPath(path)
+	t, err := a.loadTrack(path)
+	if err != nil {
+		return err
+	}
+	a.playbackChanged()
+	artist := t.Artist
+	if err := a.stats.RecordPlay(path, artist); err != nil {
+		log.Printf("[app] record play failed: %v", err)
+	}
+	a.queue.RefreshHistory()
+	return nil
 }
 
-func (a *App) SeekAudio(percentage float64) {
-	a.player.Seek(percentage)
+func (a *App) loadTrack(path string) (metadata.TrackMetadata, error) {
+	return a.openTrack(path, 0, false)
 }
 
-func (a *App) GetAudioState() map[string]float64 {
-	return map[string]float64{
-		"duration": a.player.GetDuration(),
-		"position": a.player.GetPosition(),
+func (a *App) openTrack(path string, at float64, paused bool) (metadata.TrackMetadata, error) {
+	if err := a.ensureLocal(path); err != nil {
+		return metadata.TrackMetadata{}, err
 	}
+	t, ok := a.library.Track(path)
+	if !ok {
+		if md, err := metadata.LoadMetadata(path); err == nil {
+			t = *md
+		}
+	}
+	a.player.SetReplayGain(replayGainOf(t))
+	a.player.SetAlbumContext(a.queue.AlbumSequential(path))
+	a.player.SetTrackFades(fadesOf(path, t))
+	a.applyLoudness(path)
+	if err := a.player.LoadAt(path, at, paused); err != nil {
+		return t, err
+	}
+	a.loadChapters(path)
+	if at <= 0 {
+		a.resumeLongTrack(path)
+	}
+	go a.preloadNext(path)
+	return t, nil
 }
 
-func (a *App) LoadLibraryWithMetadata() (*library.BatchResult, error) {
-	return a.library.LoadStoredLibrary()
-}
-
-func (a *App) AddFiles(paths []string) (*library.BatchResult, error) {
-	return a.library.AddFiles(paths)
+func (a *App) preloadNext(current string) {
+	item, ok := a.queue.Peek()
+	if !ok || item.Path == current || cloudfile.IsPlaceholder(item.Path) {
+		return
+	}
+	if err := a.player.Preload(item.Path); err != nil {
+		log.Printf("[app] preload %s failed: %v", filepath.Base(item.Path), err)
+	}
 }
 
-func (a *App) DownloaderStatus() downloader.Status {
-	return a.downloader.Status()
+func fadesOf(path string, t metadata.TrackMetadata) (string, time.Duration, time.Duration) {
+	if t.Fades == nil {
+		return path, 0, 0
+	}
+	return path, time.Duration(t.Fades.In * float64(time.Second)), time.Duration(t.Fades.Out * float64(time.Second))
 }
 
-func (a *App) StartDownloader() error {
-	return a.downloader.Start(a.ctx)
+func replayGainOf(t metadata.TrackMetadata) audio.ReplayGain {
+	if t.ReplayGain == nil {
+		return audio.ReplayGain{}
+	}
+	return audio.ReplayGain{
+		TrackGain: t.ReplayGain.TrackGain,
+		TrackPeak: t.ReplayGain.TrackPeak,
+		AlbumGain: t.ReplayGain.AlbumGain,
+		AlbumPeak: t.ReplayGain.AlbumPeak,
+		HasTrack:  t.ReplayGain.HasTrack,
+		HasAlbum:  t.ReplayGain.HasAlbum,
+	}
 }
 
-func (a *App) StopDownloader() {
-	a.downloader.Stop()
+func (a *App) GetNowPlayingArt(sizes []int) (*NowPlayingArt, error) {
+	path := a.player.CurrentPath()
+	if path == "" {
+		if item, ok := a.queue.Current(); ok {
+			path = item.Path
+		}
+	}
+	if path == "" {
+		return nil, apperror.NotFound("nothing is playing")
+	}
+	md, err := metadata.LoadMetadata(path)
+	if err != nil {
+		return nil, err
+	}
+	result := &NowPlayingArt{Path: path, Images: []artwork.Image{}, Palette: []artwork.Swatch{}}
+	cover := ""
+	if md.HasCover {
+		cover = strings.TrimSpace(md.CoverImage)
+	}
+	if ch, ok := a.currentChapter(path); ok && ch.Image != "" {
+		cover = ch.Image
+	}
+	if cover == "" {
+		return result, nil
+	}
+	img, err := artwork.DecodeDataURL(cover)
+	if err != nil {
+		return nil, apperror.Wrap(err, apperror.CodeUnsupported, "cover art could not be decoded")
+	}
+	if len(sizes) == 0 {
+		sizes = []int{128, 512, 1024}
+	}
+	for _, size := range sizes {
+		scaled, err := artwork.Resize(img, size)
+		if err != nil {
+			return nil, apperror.Invalid(err.Error())
+		}
+		enc, err := artwork.EncodeJPEG(scaled, size)
+		if err != nil {
+			return nil, err
+		}
+		result.Images = append(result.Images, enc)
+	}
+	result.Palette = artwork.Palette(img)
+	if len(result.Palette) > 0 {
+		result.Dominant = result.Palette[0].Hex
+	}
+	return result, nil
 }
 
-func (a *App) GetDownloaderAutoStart() (bool, error) {
-	set, err := storage.LoadSettings()
+func (a *App) loadChapters(path string) {
+	chapters, err := metadata.ReadChapters(path)
 	if err != nil {
-		return false, err
+		log.Printf("[app] chapters for %s: %v", path, err)
+		chapters = []metadata.Chapter{}
 	}
-	return set.Downloader.AutoStart, nil
+	a.mu.Lock()
+	a.chapterPath = path
+	a.chapters = chapters
+	a.chapter = -1
+	a.mu.Unlock()
 }
 
-func (a *App) SetDownloaderAutoStart(enabled bool) error {
-	set, err := storage.LoadSettings()
-	if err != nil {
-		return err
+func (a *App) trackChapter(p audio.Position) {
+	a.mu.Lock()
+	if p.Path == "" || p.Path != a.chapterPath || len(a.chapters) == 0 {
+		a.mu.Unlock()
+		return
 	}
-	set.Downloader.AutoStart = enabled
-	return storage.SaveSettings(set)
+	idx := metadata.ChapterAt(a.chapters, p.Position)
+	if idx == a.chapter || idx < 0 {
+		a.mu.Unlock()
+		return
+	}
+	a.chapter = idx
+	ch := a.chapters[idx]
+	a.mu.Unlock()
+	a.emit(events.PlaybackChapter, events.ChapterChange{Path: p.Path, Chapter: ch})
 }
 
-func (a *App) ResetAppData() error {
-	a.downloader.Stop()
-	a.player.Pause()
+func (a *App) currentChapter(path string) (metadata.Chapter, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if path != a.chapterPath || a.chapter < 0 || a.chapter >= len(a.chapters) {
+		return metadata.Chapter{}, false
+	}
+	return a.chapters[a.chapter], true
+}
 
-	if err := storage.ClearLibrary(); err != nil {
-		return err
+func (a *App) AddBookmark(path string, positionSec float64, label string) (bookmark.Bookmark, error) {
+	if strings.TrimSpace(path) == "" {
+		path = a.player.CurrentPath()
+	} else {
+		path = a.trackPath(path)
 	}
-	if err := storage.ClearSettings(); err != nil {
-		return err
+	if path == "" {
+		return bookmark.Bookmark{}, apperror.Invalid("no track selected")
 	}
-	if err := metadata.ClearSidecarCache(); err != nil {
-		return err
+	b, err := a.trackMarks.Add(path, positionSec, label)
+	if err != nil {
+		return bookmark.Bookmark{}, apperror.Invalid(err.Error())
 	}
-	if err := a.media.ClearBackups(); err != nil {
-		return err
+	return b, nil
+}
+
+func (a *App) ListBookmarks(path string) ([]bookmark.Bookmark, error) {
+	if strings.TrimSpace(path) == "" {
+		path = a.player.CurrentPath()
+	} else {
+		path = a.trackPath(path)
 	}
+	return a.trackMarks.List(path)
+}
 
-	a.library = library.NewManager()
-	return nil
+func (a *App) DeleteBookmark(path, id string) error {
+	err := a.trackMarks.Delete(a.trackPath(path), id)
+	if errors.Is(err, bookmark.ErrNotFound) {
+		return apperror.NotFound(err.Error())
+	}
+	return err
 }
 
-func (a *App) ChooseDownloadFolder() (string, error) {
-	dir, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
-		Title: "Select download folder",
-	})
-	return dir, err
+func (a *App) GetAutoResume() bool {
+	set, err := storage.LoadSettings()
+	return err != nil || !set.Playback.DisableResume
 }
 
-func (a *App) SelectVideoFile() (string, error) {
-	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
-		Title: "Select Video File",
-		Filters: []runtime.FileFilter{
-			{DisplayName: "Video Files", Pattern: "*.mp4;*.mkv;*.mov;*.webm;*.avi;*.m4v;*.flv"},
-		},
-	})
-	return path, err
+func (a *App) SetAutoResume(enabled bool) error {
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return err
+	}
+	set.Playback.DisableResume = !enabled
+	return storage.SaveSettings(set)
+}
+
+func (a *App) resumeLongTrack(path string) {
+	if !a.GetAutoResume() {
+		return
+	}
+	duration := a.player.GetDuration()
+	if duration < autoResumeMinSec {
+		return
+	}
+	b, ok, err := a.trackMarks.Latest(path)
+	if err != nil {
+		log.Printf("[app] load bookmarks for %s failed: %v", filepath.Base(path), err)
+		return
+	}
+	if !ok || b.Position <= 0 || b.Position >= duration-autoResumeEndSec {
+		return
+	}
+	log.Printf("[app] resuming %s at %s", filepath.Base(path), bookmark.FormatPosition(b.Position))
+	a.player.SeekTo(b.Position)
+}
+
+func (a *App) trackResume(p audio.Position) {
+	if p.Path == "" || p.Duration < autoResumeMinSec || p.Position < 1 {
+		return
+	}
+	a.mu.Lock()
+	due := p.Path != a.resumePath || !p.Playing || time.Since(a.resumeSaved) >= autoResumeSaveEvery
+	if due {
+		a.resumePath, a.resumeSaved = p.Path, time.Now()
+	}
+	a.mu.Unlock()
+	if !due {
+		return
+	}
+	var err error
+	if p.Duration-p.Position < autoResumeEndSec {
+		err = a.trackMarks.ClearResume(p.Path)
+	} else {
+		err = a.trackMarks.SetResume(p.Path, p.Position)
+	}
+	if err != nil {
+		log.Printf("[app] save resume position failed: %v", err)
+	}
+}
+
+func (a *App) GetChapters(path string) ([]metadata.Chapter, error) {
+	if strings.TrimSpace(path) == "" {
+		path = a.player.CurrentPath()
+	} else {
+		path = a.trackPath(path)
+	}
+	if path == "" {
+		return nil, apperror.Invalid("no track selected")
+	}
+	a.mu.Lock()
+	if path == a.chapterPath && a.chapters != nil {
+		chapters := a.chapters
+		a.mu.Unlock()
+		return chapters, nil
+	}
+	a.mu.Unlock()
+	chapters, err := metadata.ReadChapters(path)
+	if err != nil {
+		return nil, apperror.Wrap(err, apperror.CodeUnsupported, "chapters could not be read")
+	}
+	return chapters, nil
+}
+
+type MediaSource struct {
+	ID       string `json:"id"`
+	Path     string `json:"path"`
+	URL      string `json:"url"`
+	MimeType string `json:"mimeType"`
+	Native   bool   `json:"native"`
+}
+
+func (a *App) mediaHandler() http.Handler {
+	return server.MediaHandler(func(id string) (string, bool) {
+		return a.library.PathForID(id)
+	})
+}
+
+func (a *App) GetMediaSource(path string) (*MediaSource, error) {
+	if strings.TrimSpace(path) == "" {
+		return nil, apperror.Invalid("no track selected")
+	}
+	path = a.trackPath(path)
+	id, ok := a.library.IDForPath(path)
+	if !ok {
+		return nil, apperror.NotFound("track is not in the library")
+	}
+	return &MediaSource{
+		ID:       id,
+		Path:     path,
+		URL:      server.MediaURL(id),
+		MimeType: server.MediaType(path),
+		Native:   audio.CanDecode(path),
+	}, nil
+}
+
+func loadCover(path string) (string, error) {
+	md, err := metadata.LoadMetadata(path)
+	if err != nil {
+		return "", err
+	}
+	if !md.HasCover {
+		return "", nil
+	}
+	return strings.TrimSpace(md.CoverImage), nil
+}
+
+func (a *App) GetCoverThumbnail(path string, size int) (*artwork.Image, error) {
+	path = a.trackPath(path)
+	if strings.TrimSpace(path) == "" {
+		return nil, apperror.Invalid("no track selected")
+	}
+	img, err := a.covers.Thumbnail(path, size, loadCover)
+	if errors.Is(err, artwork.ErrNoCover) {
+		return nil, apperror.NotFound(err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &img, nil
+}
+
+func (a *App) GetLibrarySummaries(offset, limit int, sortBy string) (library.SummaryPage, error) {
+	page, err := a.library.Summaries(offset, limit, sortBy)
+	if err != nil {
+		return library.SummaryPage{}, apperror.Invalid(err.Error())
+	}
+	return page, nil
+}
+
+func (a *App) GetCoverBatch(ids []string, size int) (map[string]artwork.Image, error) {
+	if len(ids) > maxCoverBatch {
+		return nil, apperror.Invalid(fmt.Sprintf("at most %d covers can be requested at once", maxCoverBatch))
+	}
+	out := make(map[string]artwork.Image, len(ids))
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	sem := make(chan struct{}, coverBatchJobs)
+	for _, id := range ids {
+		path, ok := a.library.ResolveTrack(id)
+		if !ok {
+			continue
+		}
+		t, ok := a.library.Track(path)
+		if !ok || !t.HasCover || t.CloudOnly || t.Offline {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id, path string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			img, err := a.covers.Thumbnail(path, size, loadCover)
+			if err != nil {
+				if !errors.Is(err, artwork.ErrNoCover) {
+					log.Printf("[app] cover batch %s: %v", filepath.Base(path), err)
+				}
+				return
+			}
+			mu.Lock()
+			out[id] = img
+			mu.Unlock()
+		}(id, path)
+	}
+	wg.Wait()
+	return out, nil
+}
+
+func (a *App) StartCoverRebuild() ops.Operation {
+	return a.ops.Start(a.ctx, "covers", "Rebuilding covers", func(ctx context.Context, r *ops.Reporter) (interface{}, error) {
+		paths := make([]string, 0)
+		for _, t := range a.library.Tracks() {
+			if t.HasCover && !t.CloudOnly && !t.Offline {
+				paths = append(paths, t.FilePath)
+			}
+		}
+		return a.covers.Rebuild(ctx, paths, loadCover, func(done, total int, path string) {
+			r.Progress(done, total, filepath.Base(path))
+		})
+	})
+}
+
+func (a *App) GenerateShareImage(path string) (*artwork.Image, error) {
+	if strings.TrimSpace(path) == "" {
+		path = a.player.CurrentPath()
+	}
+	path = a.trackPath(path)
+	if path == "" {
+		return nil, apperror.Invalid("no track selected")
+	}
+	md, err := metadata.LoadMetadata(path)
+	if err != nil {
+		return nil, err
+	}
+	card := artwork.ShareCard{Title: md.Title, Artist: md.Artist, Album: md.Album}
+	if md.HasCover && strings.TrimSpace(md.CoverImage) != "" {
+		if img, err := artwork.DecodeDataURL(md.CoverImage); err == nil {
+			card.Cover = img
+		} else {
+			log.Printf("[app] share card cover skipped: %v", err)
+		}
+	}
+	out, err := artwork.RenderShareCard(card)
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (a *App) IsCloudPlaceholder(path string) bool {
+	return cloudfile.IsPlaceholder(path)
+}
+
+func (a *App) HydrateTrack(path string) (*metadata.TrackMetadata, error) {
+	path = a.trackPath(path)
+	if err := a.ensureLocal(path); err != nil {
+		return nil, err
+	}
+	md, err := a.library.Reload(path)
+	if err != nil {
+		return nil, err
+	}
+	return &md, nil
+}
+
+func (a *App) ensureLocal(path string) error {
+	if root, removable := volumes.RootOf(path); removable && !volumes.IsMounted(root) {
+		return apperror.NotFound(fmt.Sprintf("%s is offline; reconnect %s", filepath.Base(path), volumes.Name(root)))
+	}
+	if !cloudfile.IsPlaceholder(path) {
+		return nil
+	}
+	ctx := a.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	err := cloudfile.Hydrate(ctx, path, func(done, total int64) {
+		a.emit(events.CloudHydrate, events.HydrateProgress{Path: path, Done: done, Total: total})
+	})
+	final := events.HydrateProgress{Path: path, Finished: true}
+	if err != nil {
+		final.Error = err.Error()
+		a.emit(events.CloudHydrate, final)
+		return apperror.Wrap(err, apperror.CodeNetwork, fmt.Sprintf("could not download %s from cloud storage", filepath.Base(path)))
+	}
+	a.emit(events.CloudHydrate, final)
+	if _, err := a.library.Reload(path); err != nil {
+		log.Printf("[app] reload after hydrate failed: %v", err)
+	}
+	return nil
+}
+
+func (a *App) recentPlays(n int) []player.Item {
+	plays, err := a.stats.Recent(n)
+	if err != nil {
+		log.Printf("[app] load play history failed: %v", err)
+		return nil
+	}
+	items := make([]player.Item, 0, len(plays))
+	for _, p := range plays {
+		items = append(items, player.Item{Path: p.Path, Artist: p.Artist})
+	}
+	return items
+}
+
+func (a *App) PlayAudio() {
+	a.player.Play()
+	a.playbackChanged()
+}
+
+func (a *App) PauseAudio() {
+	a.player.Pause()
+	a.playbackChanged()
+}
+
+func (a *App) StopAudio() {
+	a.player.Stop()
+	a.playbackChanged()
+}
+
+func (a *App) ToggleAudio() bool {
+	playing := a.player.TogglePlay()
+	a.playbackChanged()
+	return playing
+}
+
+func (a *App) playbackChanged() {
+	np := webhook.NowPlaying{
+		State:    "stopped",
+		Path:     a.player.CurrentPath(),
+		Position: a.player.GetPosition(),
+		Duration: a.player.GetDuration(),
+	}
+	if np.Path != "" {
+		switch {
+		case a.player.IsPlaying():
+			np.State = "playing"
+		case np.Duration > 0 && np.Position >= np.Duration-0.05:
+			np.State = "stopped"
+		default:
+			np.State = "paused"
+		}
+		if t, ok := a.library.Track(np.Path); ok {
+			np.Title, np.Artist, np.Album = t.Title, t.Artist, t.Album
+		} else {
+			np.Title = filepath.Base(np.Path)
+		}
+	}
+	a.hooks.PublishNowPlaying(np)
+	a.updateMediaControls(np)
+}
+
+func (a *App) startMediaControls() error {
+	controls, err := nowplaying.Start(a.mediaCommand)
+	if errors.Is(err, nowplaying.ErrUnsupported) {
+		return nil
+	}
+	if err != nil {
+		log.Printf("[app] system media controls unavailable: %v", err)
+		return err
+	}
+	a.mu.Lock()
+	a.controls = controls
+	a.mu.Unlock()
+	a.playbackChanged()
+	return nil
+}
+
+func (a *App) updateMediaControls(np webhook.NowPlaying) {
+	a.mu.Lock()
+	controls := a.controls
+	a.mu.Unlock()
+	if controls == nil {
+		return
+	}
+	t := nowplaying.Track{Path: np.Path, Title: np.Title, Artist: np.Artist, Album: np.Album, Duration: np.Duration}
+	if np.Path != "" && np.Path != controls.Current() {
+		if full, ok := a.library.Full(np.Path); ok {
+			t.AlbumArtist = full.AlbumArtist
+			t.Cover = full.CoverImage
+		}
+	}
+	controls.Update(t, np.State)
+}
+
+func (a *App) mediaCommand(command string) {
+	var err error
+	switch command {
+	case nowplaying.CommandPlay:
+		a.PlayAudio()
+	case nowplaying.CommandPause:
+		a.PauseAudio()
+	case nowplaying.CommandToggle:
+		a.ToggleAudio()
+	case nowplaying.CommandStop:
+		a.StopAudio()
+	case nowplaying.CommandNext:
+		_, err = a.NextTrack()
+	case nowplaying.CommandPrevious:
+		_, err = a.PreviousTrack()
+	}
+	key := events.MediaKey{Command: command}
+	if err != nil {
+		log.Printf("[app] media key %s failed: %v", command, err)
+		key.Error = err.Error()
+	}
+	a.emit(events.MediaCommand, key)
+}
+
+func (a *App) GetNowPlayingSettings() (storage.NowPlayingSettings, error) {
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return storage.NowPlayingSettings{}, err
+	}
+	cfg := set.NowPlaying
+	if cfg.MQTTPassword, err = storage.Unseal(cfg.MQTTPassword); err != nil {
+		return storage.NowPlayingSettings{}, err
+	}
+	return cfg, nil
+}
+
+func (a *App) SetNowPlayingSettings(cfg storage.NowPlayingSettings) error {
+	if err := webhook.ValidateNowPlaying(cfg); err != nil {
+		return apperror.Invalid(err.Error())
+	}
+	cfg, err := webhook.SealNowPlaying(cfg)
+	if err != nil {
+		return err
+	}
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return err
+	}
+	set.NowPlaying = cfg
+	return storage.SaveSettings(set)
+}
+
+func (a *App) SetVolume(vol float64) {
+	a.player.SetVolume(vol)
+}
+
+func (a *App) SeekAudio(percentage float64) {
+	a.player.Seek(percentage)
+}
+
+func (a *App) GetCompatibleTracks(path string) ([]library.CompatibleTrack, error) {
+	return a.library.CompatibleTracks(a.trackPath(path), library.DefaultTempoTolerance)
+}
+
+func (a *App) GetBrowseMode() string {
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return library.BrowseArtist
+	}
+	mode, err := library.NormalizeBrowseMode(set.Metadata.BrowseMode)
+	if err != nil {
+		return library.BrowseArtist
+	}
+	return mode
+}
+
+func (a *App) SetBrowseMode(mode string) error {
+	mode, err := library.NormalizeBrowseMode(mode)
+	if err != nil {
+		return apperror.Invalid(err.Error())
+	}
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return err
+	}
+	set.Metadata.BrowseMode = mode
+	return storage.SaveSettings(set)
+}
+
+func (a *App) BrowseLibrary(mode string) ([]*library.BrowseNode, error) {
+	if strings.TrimSpace(mode) == "" {
+		mode = a.GetBrowseMode()
+	}
+	nodes, err := a.library.Browse(mode)
+	if err != nil {
+		return nil, apperror.Invalid(err.Error())
+	}
+	return nodes, nil
+}
+
+func (a *App) GetColorLabels() []string {
+	return metadata.ColorLabels
+}
+
+func (a *App) SetColorLabel(paths []string, label string) error {
+	label, err := metadata.NormalizeColorLabel(label)
+	if err != nil {
+		return apperror.Invalid(err.Error())
+	}
+	for _, path := range a.trackPaths(paths) {
+		if err := metadata.SetColorLabel(path, label); err != nil {
+			return err
+		}
+		a.refreshTrack(path)
+	}
+	return nil
+}
+
+func (a *App) SetFadeProfile(path string, profile metadata.FadeProfile) (*metadata.FadeProfile, error) {
+	path = a.trackPath(path)
+	if _, err := metadata.NormalizeFadeProfile(profile); err != nil {
+		return nil, apperror.Invalid(err.Error())
+	}
+	fades, err := metadata.SetFadeProfile(path, profile)
+	if err != nil {
+		return nil, err
+	}
+	a.refreshTrack(path)
+	if path == a.player.CurrentPath() {
+		a.player.SetTrackFades(fadesOf(path, metadata.TrackMetadata{Fades: fades}))
+	}
+	return fades, nil
+}
+
+func (a *App) GetCustomColumns() []storage.CustomColumn {
+	set, err := storage.LoadSettings()
+	if err != nil || set.Metadata.CustomColumns == nil {
+		return []storage.CustomColumn{}
+	}
+	return set.Metadata.CustomColumns
+}
+
+func (a *App) SaveCustomColumn(col storage.CustomColumn) ([]storage.CustomColumn, error) {
+	col.Name = strings.TrimSpace(col.Name)
+	if col.Name == "" {
+		return nil, apperror.Invalid("column name is required")
+	}
+	kind, err := metadata.NormalizeColumnType(col.Type)
+	if err != nil {
+		return nil, apperror.Invalid(err.Error())
+	}
+	col.Type = kind
+	if col.Key = strings.TrimSpace(col.Key); col.Key == "" {
+		col.Key = columnKey(col.Name)
+	}
+	if col.Key == "" {
+		return nil, apperror.Invalid("column name must contain letters or digits")
+	}
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+	cols := set.Metadata.CustomColumns
+	replaced := false
+	for i, c := range cols {
+		if c.Key == col.Key {
+			cols[i] = col
+			replaced = true
+		} else if strings.EqualFold(c.Name, col.Name) {
+			return nil, apperror.New(apperror.CodeAlreadyExists, fmt.Sprintf("a column named %q already exists", col.Name))
+		}
+	}
+	if !replaced {
+		if len(cols) >= maxCustomColumns {
+			return nil, apperror.Invalid(fmt.Sprintf("at most %d custom columns are supported", maxCustomColumns))
+		}
+		cols = append(cols, col)
+	}
+	set.Metadata.CustomColumns = cols
+	if err := storage.SaveSettings(set); err != nil {
+		return nil, err
+	}
+	return cols, nil
+}
+
+func (a *App) DeleteCustomColumn(key string) ([]storage.CustomColumn, error) {
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+	cols := make([]storage.CustomColumn, 0, len(set.Metadata.CustomColumns))
+	for _, c := range set.Metadata.CustomColumns {
+		if c.Key != key {
+			cols = append(cols, c)
+		}
+	}
+	if len(cols) == len(set.Metadata.CustomColumns) {
+		return nil, apperror.NotFound("column not found")
+	}
+	set.Metadata.CustomColumns = cols
+	if err := storage.SaveSettings(set); err != nil {
+		return nil, err
+	}
+	return cols, nil
+}
+
+func (a *App) SetCustomValue(path, key, value string) (map[string]string, error) {
+	var col storage.CustomColumn
+	for _, c := range a.GetCustomColumns() {
+		if c.Key == key {
+			col = c
+			break
+		}
+	}
+	if col.Key == "" {
+		return nil, apperror.NotFound("column not found")
+	}
+	value, err := metadata.NormalizeCustomValue(col.Type, value)
+	if err != nil {
+		return nil, apperror.Invalid(fmt.Sprintf("%s: %v", col.Name, err))
+	}
+	path = a.trackPath(path)
+	values, err := metadata.SetCustomValue(path, key, value)
+	if err != nil {
+		return nil, err
+	}
+	a.refreshTrack(path)
+	return values, nil
+}
+
+func (a *App) QueryTracks(q storage.TrackQuery) ([]metadata.TrackMetadata, error) {
+	tracks, err := a.library.Query(q)
+	if err != nil {
+		return nil, apperror.Invalid(err.Error())
+	}
+	return tracks, nil
+}
+
+func (a *App) GetSavedViews() []storage.SavedView {
+	set, err := storage.LoadSettings()
+	if err != nil || set.Views == nil {
+		return []storage.SavedView{}
+	}
+	return set.Views
+}
+
+func (a *App) SaveView(id, name string, q storage.TrackQuery) (*storage.SavedView, error) {
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+	idx := -1
+	for i, v := range set.Views {
+		if v.ID == id {
+			idx = i
+		} else if strings.EqualFold(v.Name, strings.TrimSpace(name)) {
+			return nil, apperror.New(apperror.CodeAlreadyExists, fmt.Sprintf("a view named %q already exists", strings.TrimSpace(name)))
+		}
+	}
+	var view storage.SavedView
+	switch {
+	case id == "":
+		view, err = library.NewView(name, q)
+	case idx < 0:
+		return nil, apperror.NotFound("view not found")
+	default:
+		view, err = library.UpdateView(set.Views[idx], name, q)
+	}
+	if err != nil {
+		return nil, apperror.Invalid(err.Error())
+	}
+	if idx < 0 {
+		set.Views = append(set.Views, view)
+	} else {
+		set.Views[idx] = view
+	}
+	if err := storage.SaveSettings(set); err != nil {
+		return nil, err
+	}
+	return &view, nil
+}
+
+func (a *App) DeleteView(id string) error {
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return err
+	}
+	views := make([]storage.SavedView, 0, len(set.Views))
+	for _, v := range set.Views {
+		if v.ID != id {
+			views = append(views, v)
+		}
+	}
+	if len(views) == len(set.Views) {
+		return apperror.NotFound("view not found")
+	}
+	set.Views = views
+	return storage.SaveSettings(set)
+}
+
+func (a *App) OpenView(id string) ([]metadata.TrackMetadata, error) {
+	for _, v := range a.GetSavedViews() {
+		if v.ID == id {
+			return a.QueryTracks(v.Query)
+		}
+	}
+	return nil, apperror.NotFound("view not found")
+}
+
+func columnKey(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if b.Len() > 0 && !dash {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+func (a *App) GetCuePoints(path string) []metadata.CuePoint {
+	return metadata.CuePoints(a.trackPath(path))
+}
+
+func (a *App) SetCuePoint(path string, cue metadata.CuePoint) ([]metadata.CuePoint, error) {
+	path = a.trackPath(path)
+	cues, err := metadata.SetCuePoint(path, cue)
+	if err != nil {
+		return nil, err
+	}
+	a.refreshTrack(path)
+	return cues, nil
+}
+
+func (a *App) DeleteCuePoint(path string, index int) ([]metadata.CuePoint, error) {
+	path = a.trackPath(path)
+	cues, err := metadata.DeleteCuePoint(path, index)
+	if err != nil {
+		return nil, err
+	}
+	a.refreshTrack(path)
+	return cues, nil
+}
+
+func (a *App) JumpToCue(path string, index int) (*metadata.CuePoint, error) {
+	path = a.trackPath(path)
+	var cue *metadata.CuePoint
+	for _, c := range metadata.CuePoints(path) {
+		if c.Index == index {
+			c := c
+			cue = &c
+			break
+		}
+	}
+	if cue == nil {
+		return nil, apperror.NotFound(fmt.Sprintf("no cue point %d for %s", index, filepath.Base(path)))
+	}
+	if a.player.CurrentPath() != path {
+		if err := a.LoadAudio(path); err != nil {
+			return nil, err
+		}
+	}
+	a.player.SeekTo(cue.Position)
+	return cue, nil
+}
+
+func (a *App) ExportCuePoints(paths []string, dest string) (string, error) {
+	if len(paths) == 0 {
+		return "", apperror.Invalid("no tracks selected")
+	}
+	dest = strings.TrimSpace(dest)
+	if dest == "" {
+		if err := a.requireDesktop("save dialog"); err != nil {
+			return "", err
+		}
+		chosen, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+			Title:           "Export cue points",
+			DefaultFilename: "kitty-rekordbox.xml",
+		})
+		if err != nil || chosen == "" {
+			return "", err
+		}
+		dest = chosen
+	}
+
+	tracks := make([]metadata.TrackMetadata, 0, len(paths))
+	for _, p := range a.trackPaths(paths) {
+		t, ok := a.library.Track(p)
+		if !ok {
+			md, err := metadata.LoadMetadata(p)
+			if err != nil {
+				return "", err
+			}
+			t = *md
+		}
+		t.CuePoints = metadata.CuePoints(p)
+		tracks = append(tracks, t)
+	}
+
+	f, err := os.Create(dest)
+	if err != nil {
+		return "", err
+	}
+	if err := metadata.ExportRekordboxXML(f, tracks); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	log.Printf("[app] exported cue points for %d tracks to %s", len(tracks), dest)
+	return dest, nil
+}
+
+func (a *App) SaveLyrics(path, plain, synced string) (*metadata.TrackMetadata, error) {
+	path = a.trackPath(path)
+	if err := a.ensureLocal(path); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(synced) != "" {
+		if _, err := metadata.ParseLRC(synced); err != nil {
+			return nil, apperror.Invalid("invalid LRC: " + err.Error())
+		}
+	}
+	md, err := metadata.SaveLyrics(path, plain, synced)
+	if err != nil {
+		return nil, err
+	}
+	a.refreshTrack(path)
+	return md, nil
+}
+
+func (a *App) refreshTrack(path string) {
+	if _, err := a.library.Reload(path); err != nil {
+		log.Printf("[app] refresh %s failed: %v", filepath.Base(path), err)
+	}
+}
+
+func (a *App) SetQueue(paths []string, start int) player.State {
+	return a.queue.Set(a.queueItems(paths), start)
+}
+
+func (a *App) GetQueue() player.State {
+	return a.queue.State()
+}
+
+func (a *App) EnqueueTracks(paths []string) player.State {
+	return a.queue.Enqueue(a.queueItems(paths)...)
+}
+
+func (a *App) PlayNext(paths []string) player.State {
+	return a.queue.PlayNext(a.queueItems(paths)...)
+}
+
+func (a *App) RemoveFromQueue(index int) (player.State, error) {
+	state, err := a.queue.RemoveAt(index)
+	if err != nil {
+		return state, apperror.Invalid(err.Error())
+	}
+	return state, nil
+}
+
+func (a *App) ClearQueue() player.State {
+	return a.queue.Clear()
+}
+
+func (a *App) NextTrack() (player.State, error) {
+	item, ok := a.queue.Next()
+	if !ok {
+		return a.queue.State(), apperror.NotFound("end of queue")
+	}
+	if err := a.LoadAudio(item.Path); err != nil {
+		return a.queue.State(), err
+	}
+	return a.queue.State(), nil
+}
+
+func (a *App) PreviousTrack() (player.State, error) {
+	if a.player.CurrentPath() != "" && a.player.GetPosition() > restartThreshold {
+		a.player.SeekTo(0)
+		return a.queue.State(), nil
+	}
+	item, ok := a.queue.Previous()
+	if !ok {
+		return a.queue.State(), apperror.NotFound("queue is empty")
+	}
+	if err := a.LoadAudio(item.Path); err != nil {
+		return a.queue.State(), err
+	}
+	return a.queue.State(), nil
+}
+
+func (a *App) queueItems(paths []string) []player.Item {
+	items := make([]player.Item, 0, len(paths))
+	for _, p := range a.trackPaths(paths) {
+		items = append(items, a.queueItem(p))
+	}
+	return items
+}
+
+func (a *App) advanceQueue(finished string) {
+	a.emit(events.PlaybackEnded, events.TrackEnded{Path: finished})
+	a.playbackChanged()
+	if _, current := a.queue.Current(); !current && a.queue.State().Repeat == player.RepeatOne {
+		if err := a.LoadAudio(finished); err != nil {
+			log.Printf("[app] repeat %s failed: %v", finished, err)
+		}
+		return
+	}
+	item, action, ok := a.queue.Advance()
+	if !ok && action == player.EndAutoDJ {
+		item, ok = a.autoDJ(finished)
+	}
+	if !ok {
+		a.endOfQueue(action)
+		a.emit(events.QueueEnded, a.queue.State())
+		return
+	}
+	if err := a.LoadAudio(item.Path); err != nil {
+		log.Printf("[app] auto-advance to %s failed: %v", item.Path, err)
+		return
+	}
+	a.emit(events.QueueAdvanced, a.queue.State())
+}
+
+func (a *App) endOfQueue(action string) {
+	switch action {
+	case player.EndSleep:
+		log.Printf("[app] queue ended, going to sleep")
+		if !a.headless && a.ctx != nil {
+			runtime.WindowMinimise(a.ctx)
+		}
+	case player.EndStopDownloader:
+		log.Printf("[app] queue ended, stopping downloader")
+		a.downloader.Stop()
+	}
+}
+
+func (a *App) autoDJ(seed string) (player.Item, bool) {
+	recent := map[string]bool{seed: true}
+	for _, it := range a.recentPlays(autoDJBatch * 4) {
+		recent[it.Path] = true
+	}
+	paths := make([]string, 0, autoDJBatch)
+	if compatible, err := a.library.CompatibleTracks(seed, library.DefaultTempoTolerance); err == nil {
+		for _, c := range compatible {
+			if len(paths) < autoDJBatch && !recent[c.Track.FilePath] && !c.Track.Offline {
+				paths = append(paths, c.Track.FilePath)
+				recent[c.Track.FilePath] = true
+			}
+		}
+	}
+	tracks := a.library.Tracks()
+	rand.Shuffle(len(tracks), func(i, j int) { tracks[i], tracks[j] = tracks[j], tracks[i] })
+	for _, t := range tracks {
+		if len(paths) >= autoDJBatch {
+			break
+		}
+		if !recent[t.FilePath] && !t.Offline && !t.CloudOnly {
+			paths = append(paths, t.FilePath)
+			recent[t.FilePath] = true
+		}
+	}
+	if len(paths) == 0 {
+		return player.Item{}, false
+	}
+	log.Printf("[app] auto-dj queued %d tracks after %s", len(paths), filepath.Base(seed))
+	a.queue.Enqueue(a.queueItems(paths)...)
+	return a.queue.Next()
+}
+
+func (a *App) SetEndOfQueueAction(action string) (player.State, error) {
+	state, err := a.queue.SetEndAction(action)
+	if err != nil {
+		return player.State{}, apperror.Invalid(err.Error())
+	}
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return state, err
+	}
+	set.Playback.EndAction = state.EndAction
+	return state, storage.SaveSettings(set)
+}
+
+func (a *App) SetEndOfQueueOverride(action string) (player.State, error) {
+	state, err := a.queue.SetEndOverride(action)
+	if err != nil {
+		return player.State{}, apperror.Invalid(err.Error())
+	}
+	return state, nil
+}
+
+func (a *App) SetShuffleMode(mode string) (player.State, error) {
+	state, err := a.queue.SetShuffle(mode)
+	if err != nil {
+		return player.State{}, err
+	}
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return state, err
+	}
+	set.Playback.Shuffle = state.Shuffle
+	return state, storage.SaveSettings(set)
+}
+
+func (a *App) SetRepeatMode(mode string) (player.State, error) {
+	state, err := a.queue.SetRepeat(mode)
+	if err != nil {
+		return player.State{}, apperror.Invalid(err.Error())
+	}
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return state, err
+	}
+	set.Playback.Repeat = state.Repeat
+	return state, storage.SaveSettings(set)
+}
+
+func (a *App) queueItem(path string) player.Item {
+	item := player.Item{Path: path, Title: filepath.Base(path)}
+	t, ok := a.library.Track(path)
+	if !ok {
+		return item
+	}
+	item.Title = firstNonEmptyString(t.Title, item.Title)
+	item.Artist = t.Artist
+	item.Album = t.Album
+	item.AlbumArtist = t.AlbumArtist
+	item.DiscNumber = t.DiscNumber
+	item.TrackNumber = t.TrackNumber
+	return item
+}
+
+func (a *App) GetPlaybackSettings() (storage.PlaybackSettings, error) {
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return storage.PlaybackSettings{}, err
+	}
+	return set.Playback, nil
+}
+
+func (a *App) SetPlaybackSettings(patch json.RawMessage) error {
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return err
+	}
+	cfg := set.Playback
+	if err := json.Unmarshal(patch, &cfg); err != nil {
+		return apperror.Invalid("invalid playback settings: " + err.Error())
+	}
+	if cfg.SkipSilenceMinGap < 0 {
+		return apperror.Invalid("minimum silence gap must not be negative")
+	}
+	if len(cfg.EQ) > 0 && len(cfg.EQ) != audio.EQBandCount {
+		return apperror.Invalid(fmt.Sprintf("eq needs %d bands", audio.EQBandCount))
+	}
+	shuffle, err := player.NormalizeShuffle(cfg.Shuffle)
+	if err != nil {
+		return err
+	}
+	cfg.Shuffle = shuffle
+	repeat, err := player.NormalizeRepeat(cfg.Repeat)
+	if err != nil {
+		return apperror.Invalid(err.Error())
+	}
+	cfg.Repeat = repeat
+	endAction, err := player.NormalizeEndAction(cfg.EndAction)
+	if err != nil {
+		return apperror.Invalid(err.Error())
+	}
+	cfg.EndAction = endAction
+	rgMode, err := audio.NormalizeReplayGainMode(cfg.ReplayGain)
+	if err != nil {
+		return apperror.Invalid(err.Error())
+	}
+	cfg.ReplayGain = rgMode
+	resample, err := audio.NormalizeResampleQuality(cfg.ResampleQuality)
+	if err != nil {
+		return apperror.Invalid(err.Error())
+	}
+	cfg.ResampleQuality = resample
+	if cfg.Balance < -1 || cfg.Balance > 1 {
+		return apperror.Invalid("balance must be between -1 and 1")
+	}
+	if cfg.Preamp < -audio.MaxPreampDb || cfg.Preamp > audio.MaxPreampDb {
+		return apperror.Invalid(fmt.Sprintf("preamp must be between %.0f and %.0f dB", -audio.MaxPreampDb, audio.MaxPreampDb))
+	}
+	if cfg.FadeMs < 0 || time.Duration(cfg.FadeMs)*time.Millisecond > audio.MaxFadeDuration {
+		return apperror.Invalid(fmt.Sprintf("fade must be between 0 and %dms", audio.MaxFadeDuration.Milliseconds()))
+	}
+	if cfg.LoudnessTarget != 0 && (cfg.LoudnessTarget < audio.MinLoudnessTarget || cfg.LoudnessTarget > audio.MaxLoudnessTarget) {
+		return apperror.Invalid(fmt.Sprintf("loudness target must be between %.0f and %.0f LUFS", audio.MinLoudnessTarget, audio.MaxLoudnessTarget))
+	}
+	if cfg.CrossfeedLevel < 0 || cfg.CrossfeedLevel > 1 {
+		return apperror.Invalid("crossfeed level must be between 0 and 1")
+	}
+	set.Playback = cfg
+	if err := storage.SaveSettings(set); err != nil {
+		return err
+	}
+	a.player.SetSkipSilence(cfg.SkipSilence, cfg.SkipSilenceMinGap)
+	a.player.SetNightMode(nightModeFor(cfg, a.player.OutputDevice()))
+	a.player.SetMono(cfg.Mono)
+	if err := a.player.SetBitPerfect(cfg.BitPerfect); err != nil {
+		return err
+	}
+	if err := a.player.SetBalance(cfg.Balance); err != nil {
+		return apperror.Invalid(err.Error())
+	}
+	if err := a.player.SetCrossfeed(cfg.Crossfeed, crossfeedLevel(cfg)); err != nil {
+		return apperror.Invalid(err.Error())
+	}
+	if err := a.player.SetPreamp(cfg.Preamp); err != nil {
+		return apperror.Invalid(err.Error())
+	}
+	if err := a.player.SetFadeDuration(fadeDuration(cfg)); err != nil {
+		return apperror.Invalid(err.Error())
+	}
+	if err := a.player.SetReplayGainMode(cfg.ReplayGain); err != nil {
+		return apperror.Invalid(err.Error())
+	}
+	a.player.SetLoudnessNormalization(cfg.Normalize, loudnessTarget(cfg))
+	if err := a.player.SetResampleQuality(cfg.ResampleQuality); err != nil {
+		return apperror.Invalid(err.Error())
+	}
+	if len(cfg.EQ) > 0 {
+		if err := a.player.SetEQ(cfg.EQ); err != nil {
+			return apperror.Invalid(err.Error())
+		}
+	}
+	a.queue.SetShuffleHistory(a.recentPlays, cfg.ShuffleMemory, cfg.ArtistSpacing)
+	if _, err := a.queue.SetShuffle(cfg.Shuffle); err != nil {
+		return err
+	}
+	if _, err := a.queue.SetRepeat(cfg.Repeat); err != nil {
+		return err
+	}
+	if _, err := a.queue.SetEndAction(cfg.EndAction); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (a *App) GetOutputs() []audio.OutputInfo {
+	return a.player.Outputs()
+}
+
+func (a *App) StartStreamOutput(addr string) (audio.OutputInfo, error) {
+	if strings.TrimSpace(addr) == "" {
+		addr = "127.0.0.1:0"
+	}
+	info, err := a.player.StartStreamOutput(addr)
+	if err != nil {
+		return info, apperror.Wrap(err, apperror.CodeConflict, "could not start stream output")
+	}
+	return info, nil
+}
+
+func (a *App) StopStreamOutput() error {
+	return a.player.StopStreamOutput()
+}
+
+func (a *App) SetOutputVolume(output string, volume float64) error {
+	if err := a.player.SetOutputVolume(output, volume); err != nil {
+		return apperror.Invalid(err.Error())
+	}
+	return nil
+}
+
+func (a *App) GetNightMode() (bool, error) {
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return false, err
+	}
+	return nightModeFor(set.Playback, a.player.OutputDevice()), nil
+}
+
+func (a *App) SetNightMode(enabled bool) error {
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return err
+	}
+	if set.Playback.NightMode == nil {
+		set.Playback.NightMode = make(map[string]bool)
+	}
+	set.Playback.NightMode[a.player.OutputDevice()] = enabled
+	if err := storage.SaveSettings(set); err != nil {
+		return err
+	}
+	a.player.SetNightMode(enabled)
+	return nil
+}
+
+func nightModeFor(cfg storage.PlaybackSettings, device string) bool {
+	if enabled, ok := cfg.NightMode[device]; ok {
+		return enabled
+	}
+	return cfg.NightMode[audio.DefaultDevice]
+}
+
+func (a *App) GetMono() bool {
+	return a.player.Mono()
+}
+
+func (a *App) SetMono(enabled bool) error {
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return err
+	}
+	set.Playback.Mono = enabled
+	if err := storage.SaveSettings(set); err != nil {
+		return err
+	}
+	a.player.SetMono(enabled)
+	return nil
+}
+
+func (a *App) GetBalance() float64 {
+	return a.player.Balance()
+}
+
+func (a *App) SetBalance(balance float64) error {
+	if balance < -1 || balance > 1 {
+		return apperror.Invalid("balance must be between -1 and 1")
+	}
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return err
+	}
+	set.Playback.Balance = balance
+	if err := storage.SaveSettings(set); err != nil {
+		return err
+	}
+	return a.player.SetBalance(balance)
+}
+
+func (a *App) GetCrossfeed() audio.CrossfeedState {
+	return a.player.Crossfeed()
+}
+
+func (a *App) SetCrossfeed(enabled bool, level float64) (audio.CrossfeedState, error) {
+	if level < 0 || level > 1 {
+		return audio.CrossfeedState{}, apperror.Invalid("crossfeed level must be between 0 and 1")
+	}
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return audio.CrossfeedState{}, err
+	}
+	set.Playback.Crossfeed = enabled
+	set.Playback.CrossfeedLevel = level
+	if err := storage.SaveSettings(set); err != nil {
+		return audio.CrossfeedState{}, err
+	}
+	if err := a.player.SetCrossfeed(enabled, crossfeedLevel(set.Playback)); err != nil {
+		return audio.CrossfeedState{}, err
+	}
+	return a.player.Crossfeed(), nil
+}
+
+func (a *App) GetPreamp() float64 {
+	return a.player.Preamp()
+}
+
+func (a *App) SetPreamp(db float64) error {
+	if db < -audio.MaxPreampDb || db > audio.MaxPreampDb {
+		return apperror.Invalid(fmt.Sprintf("preamp must be between %.0f and %.0f dB", -audio.MaxPreampDb, audio.MaxPreampDb))
+	}
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return err
+	}
+	set.Playback.Preamp = db
+	if err := storage.SaveSettings(set); err != nil {
+		return err
+	}
+	return a.player.SetPreamp(db)
+}
+
+func (a *App) GetSpectrum() []float64 {
+	return a.player.Spectrum()
+}
+
+func (a *App) GetLevels() audio.Levels {
+	return a.player.Levels()
+}
+
+func (a *App) GetFollowDevice() bool {
+	set, err := storage.LoadSettings()
+	return err == nil && set.Playback.FollowDevice && audio.FollowsDefaultOutput()
+}
+
+func (a *App) SetFollowDevice(enabled bool) error {
+	if enabled && !audio.FollowsDefaultOutput() {
+		return apperror.Invalid("following the default output device is only supported on Windows")
+	}
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return err
+	}
+	set.Playback.FollowDevice = enabled
+	return storage.SaveSettings(set)
+}
+
+func (a *App) outputChanged(device string) {
+	change := events.OutputChange{Device: device, Previous: a.lastOutput}
+	change.Returned = a.prevOutput != "" && device == a.prevOutput
+	a.prevOutput, a.lastOutput = a.lastOutput, device
+	if set, err := storage.LoadSettings(); err == nil {
+		a.player.SetNightMode(nightModeFor(set.Playback, device))
+	}
+	change.Followed = audio.FollowsDefaultOutput()
+	a.emit(events.OutputChanged, change)
+}
+
+func (a *App) outputStalled(p audio.Position) {
+	device, _ := audio.DefaultOutputID()
+	change := events.OutputChange{Device: device, Stalled: true}
+	if err := a.player.RecoverOutput(); err != nil {
+		log.Printf("[app] recover stalled output failed: %v", err)
+		change.Error = err.Error()
+	}
+	a.emit(events.OutputChanged, change)
+}
+
+func (a *App) GetOutputMode() audio.OutputMode {
+	return a.player.OutputMode()
+}
+
+func (a *App) SetBitPerfect(enabled bool) (audio.OutputMode, error) {
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return audio.OutputMode{}, err
+	}
+	set.Playback.BitPerfect = enabled
+	if err := storage.SaveSettings(set); err != nil {
+		return audio.OutputMode{}, err
+	}
+	if err := a.player.SetBitPerfect(enabled); err != nil {
+		return audio.OutputMode{}, err
+	}
+	return a.player.OutputMode(), nil
+}
+
+func fadeDuration(cfg storage.PlaybackSettings) time.Duration {
+	if cfg.DisableFades {
+		return 0
+	}
+	if cfg.FadeMs <= 0 {
+		return audio.DefaultFadeDuration
+	}
+	return time.Duration(cfg.FadeMs) * time.Millisecond
+}
+
+func (a *App) GetFadeDuration() int {
+	return int(a.player.FadeDuration().Milliseconds())
+}
+
+func (a *App) SetFadeDuration(ms int) error {
+	d := time.Duration(ms) * time.Millisecond
+	if ms < 0 || d > audio.MaxFadeDuration {
+		return apperror.Invalid(fmt.Sprintf("fade must be between 0 and %dms", audio.MaxFadeDuration.Milliseconds()))
+	}
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return err
+	}
+	set.Playback.FadeMs = ms
+	set.Playback.DisableFades = ms == 0
+	if err := storage.SaveSettings(set); err != nil {
+		return err
+	}
+	return a.player.SetFadeDuration(d)
+}
+
+func (a *App) GetReplayGainMode() string {
+	return a.player.ReplayGainMode()
+}
+
+func (a *App) SetReplayGainMode(mode string) error {
+	mode, err := audio.NormalizeReplayGainMode(mode)
+	if err != nil {
+		return apperror.Invalid(err.Error())
+	}
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return err
+	}
+	set.Playback.ReplayGain = mode
+	if err := storage.SaveSettings(set); err != nil {
+		return err
+	}
+	return a.player.SetReplayGainMode(mode)
+}
+
+func (a *App) GetResampleQuality() string {
+	return a.player.ResampleQuality()
+}
+
+func (a *App) SetResampleQuality(quality string) error {
+	quality, err := audio.NormalizeResampleQuality(quality)
+	if err != nil {
+		return apperror.Invalid(err.Error())
+	}
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return err
+	}
+	set.Playback.ResampleQuality = quality
+	if err := storage.SaveSettings(set); err != nil {
+		return err
+	}
+	return a.player.SetResampleQuality(quality)
+}
+
+func crossfeedLevel(cfg storage.PlaybackSettings) float64 {
+	if cfg.CrossfeedLevel == 0 {
+		return audio.DefaultCrossfeedLevel
+	}
+	return cfg.CrossfeedLevel
+}
+
+func loudnessTarget(cfg storage.PlaybackSettings) float64 {
+	if cfg.LoudnessTarget == 0 {
+		return analysis.DefaultLoudnessTarget
+	}
+	return cfg.LoudnessTarget
+}
+
+func (a *App) applyLoudness(path string) {
+	l, cached := analysis.CachedLoudness(path)
+	if cached {
+		a.player.SetTrackLoudness(path, l.Integrated, l.Peak)
+	} else {
+		a.player.SetTrackLoudness("", 0, 0)
+	}
+	enabled, _ := a.player.LoudnessNormalization()
+	album := a.albumTracks(path)
+	if cached && a.applyAlbumLoudness(path, album, false) || !enabled {
+		return
+	}
+	go func() {
+		if err := a.power.Wait(a.ctx, nil); err != nil {
+			return
+		}
+		if !cached {
+			l, err := analysis.AnalyzeLoudness(a.ctx, path)
+			if err != nil {
+				log.Printf("[app] loudness analysis %s: %v", filepath.Base(path), err)
+				return
+			}
+			a.player.SetTrackLoudness(path, l.Integrated, l.Peak)
+		}
+		a.applyAlbumLoudness(path, album, true)
+	}()
+}
+
+func (a *App) albumTracks(path string) []string {
+	t, ok := a.library.Track(path)
+	if !ok {
+		return nil
+	}
+	key := albumGroupKey(t)
+	if key == "" {
+		return nil
+	}
+	paths := make([]string, 0)
+	for _, other := range a.library.Tracks() {
+		if albumGroupKey(other) == key {
+			paths = append(paths, other.FilePath)
+		}
+	}
+	if len(paths) < 2 || len(paths) > maxAlbumGainTracks {
+		return nil
+	}
+	return paths
+}
+
+func (a *App) applyAlbumLoudness(path string, album []string, analyze bool) bool {
+	if len(album) == 0 {
+		return false
+	}
+	tracks := make([]analysis.Loudness, 0, len(album))
+	for _, p := range album {
+		l, ok := analysis.CachedLoudness(p)
+		if !ok && analyze {
+			var err error
+			if l, err = analysis.AnalyzeLoudness(a.ctx, p); err != nil {
+				log.Printf("[app] album loudness %s: %v", filepath.Base(p), err)
+				return false
+			}
+			ok = true
+		}
+		if !ok {
+			return false
+		}
+		tracks = append(tracks, l)
+	}
+	albumLoudness, ok := analysis.AlbumLoudness(tracks)
+	if ok {
+		a.player.SetAlbumLoudness(path, albumLoudness.Integrated, albumLoudness.Peak)
+	}
+	return ok
+}
+
+func albumGroupKey(t metadata.TrackMetadata) string {
+	album := strings.ToLower(strings.TrimSpace(t.Album))
+	if album == "" || album == "unknown album" {
+		return ""
+	}
+	artist := strings.TrimSpace(t.AlbumArtist)
+	if artist == "" {
+		artist = t.Artist
+	}
+	return strings.ToLower(strings.TrimSpace(artist)) + "\x00" + album
+}
+
+type TrackGain struct {
+	Path       string  `json:"path"`
+	Integrated float64 `json:"integrated"`
+	Gain       float64 `json:"gain"`
+	Peak       float64 `json:"peak"`
+}
+
+type AlbumGain struct {
+	Album       string      `json:"album"`
+	AlbumArtist string      `json:"albumArtist"`
+	Integrated  float64     `json:"integrated"`
+	Gain        float64     `json:"gain"`
+	Peak        float64     `json:"peak"`
+	Tracks      []TrackGain `json:"tracks"`
+	Error       string      `json:"error,omitempty"`
+}
+
+func (a *App) StartAlbumGainScan(paths []string) ops.Operation {
+	return a.ops.Start(a.ctx, "album-gain", "Scanning album loudness", func(ctx context.Context, r *ops.Reporter) (interface{}, error) {
+		groups := map[string]*AlbumGain{}
+		order := make([]string, 0)
+		for _, p := range paths {
+			t, ok := a.library.Track(p)
+			if !ok {
+				if md, err := metadata.LoadMetadata(p); err == nil {
+					t = *md
+				}
+			}
+			key := albumGroupKey(t)
+			if key == "" {
+				key = "track:" + p
+			}
+			g := groups[key]
+			if g == nil {
+				g = &AlbumGain{Album: t.Album, AlbumArtist: firstNonEmptyString(t.AlbumArtist, t.Artist), Tracks: []TrackGain{}}
+				groups[key] = g
+				order = append(order, key)
+			}
+			g.Tracks = append(g.Tracks, TrackGain{Path: p})
+		}
+
+		results := make([]AlbumGain, 0, len(order))
+		done := 0
+		for _, key := range order {
+			g := groups[key]
+			measured := make([]analysis.Loudness, 0, len(g.Tracks))
+			for i := range g.Tracks {
+				if err := a.awaitPower(ctx, r); err != nil {
+					return results, err
+				}
+				r.Progress(done, len(paths), filepath.Base(g.Tracks[i].Path))
+				done++
+				l, err := analysis.AnalyzeLoudness(ctx, g.Tracks[i].Path)
+				if err != nil {
+					if ctx.Err() != nil {
+						return results, ctx.Err()
+					}
+					g.Error = fmt.Sprintf("%s: %v", filepath.Base(g.Tracks[i].Path), err)
+					continue
+				}
+				g.Tracks[i].Integrated = l.Integrated
+				g.Tracks[i].Gain = replayGainReference - l.Integrated
+				g.Tracks[i].Peak = l.Peak
+				measured = append(measured, l)
+			}
+			if album, ok := analysis.AlbumLoudness(measured); ok {
+				g.Integrated = album.Integrated
+				g.Gain = replayGainReference - album.Integrated
+				g.Peak = album.Peak
+			}
+			results = append(results, *g)
+		}
+		r.Progress(len(paths), len(paths), "")
+		return results, nil
+	})
+}
+
+func (a *App) SetLoudnessNormalization(enabled bool, target float64) error {
+	if target == 0 {
+		target = analysis.DefaultLoudnessTarget
+	}
+	if target < audio.MinLoudnessTarget || target > audio.MaxLoudnessTarget {
+		return apperror.Invalid(fmt.Sprintf("loudness target must be between %.0f and %.0f LUFS", audio.MinLoudnessTarget, audio.MaxLoudnessTarget))
+	}
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return err
+	}
+	set.Playback.Normalize = enabled
+	set.Playback.LoudnessTarget = target
+	if err := storage.SaveSettings(set); err != nil {
+		return err
+	}
+	a.player.SetLoudnessNormalization(enabled, target)
+	if path := a.player.CurrentPath(); enabled && path != "" {
+		a.applyLoudness(path)
+	}
+	return nil
+}
+
+func (a *App) GetLoudness(path string) (*analysis.Loudness, error) {
+	l, err := analysis.AnalyzeLoudness(a.ctx, a.trackPath(path))
+	if errors.Is(err, analysis.ErrLoudnessUnsupported) {
+		return nil, apperror.New(apperror.CodeUnsupported, err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (a *App) GetWaveform(path string, buckets int) (*analysis.Waveform, error) {
+	if buckets > analysis.MaxWaveformBuckets {
+		return nil, apperror.Invalid(fmt.Sprintf("waveform buckets must be at most %d", analysis.MaxWaveformBuckets))
+	}
+	w, err := analysis.GenerateWaveform(a.trackPath(path), buckets)
+	if errors.Is(err, analysis.ErrWaveformUnsupported) {
+		return nil, apperror.New(apperror.CodeUnsupported, err.Error())
+	}
+	return w, err
+}
+
+func (a *App) StartLoudnessAnalysis(paths []string) ops.Operation {
+	return a.ops.Start(a.ctx, "loudness", "Measuring loudness", func(ctx context.Context, r *ops.Reporter) (interface{}, error) {
+		results := map[string]analysis.Loudness{}
+		for i, path := range paths {
+			if err := a.awaitPower(ctx, r); err != nil {
+				return results, err
+			}
+			r.Progress(i, len(paths), filepath.Base(path))
+			l, err := analysis.AnalyzeLoudness(ctx, path)
+			if err != nil {
+				if ctx.Err() != nil {
+					return results, ctx.Err()
+				}
+				log.Printf("[app] loudness analysis %s: %v", filepath.Base(path), err)
+				continue
+			}
+			results[path] = l
+		}
+		r.Progress(len(paths), len(paths), "")
+		return results, nil
+	})
+}
+
+func (a *App) awaitPower(ctx context.Context, r *ops.Reporter) error {
+	return a.power.Wait(ctx, func(reason string) {
+		r.Note(fmt.Sprintf("Paused: %s", reason))
+	})
+}
+
+func powerPolicy(cfg storage.PowerSettings) power.Policy {
+	return power.Policy{Mode: cfg.Mode, BatteryThreshold: cfg.BatteryThreshold}
+}
+
+func (a *App) GetPowerState() power.State {
+	return a.power.State()
+}
+
+func (a *App) SetPowerPolicy(policy power.Policy) (power.State, error) {
+	policy, err := power.NormalizePolicy(policy)
+	if err != nil {
+		return power.State{}, apperror.Invalid(err.Error())
+	}
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return power.State{}, err
+	}
+	set.Power = storage.PowerSettings{Mode: policy.Mode, BatteryThreshold: policy.BatteryThreshold}
+	if err := storage.SaveSettings(set); err != nil {
+		return power.State{}, err
+	}
+	a.power.SetPolicy(policy)
+	st := a.power.State()
+	a.emit(events.PowerChanged, st)
+	return st, nil
+}
+
+func (a *App) GetShortcuts() []shortcuts.Shortcut {
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return shortcuts.Defaults()
+	}
+	return shortcuts.Resolve(set.Shortcuts)
+}
+
+func (a *App) SetShortcuts(overrides map[string]string) ([]shortcuts.Shortcut, error) {
+	clean, err := shortcuts.Validate(overrides)
+	if err != nil {
+		return nil, apperror.Invalid(err.Error())
+	}
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+	set.Shortcuts = clean
+	if len(clean) == 0 {
+		set.Shortcuts = nil
+	}
+	if err := storage.SaveSettings(set); err != nil {
+		return nil, err
+	}
+	list := shortcuts.Resolve(clean)
+	a.emit(events.ShortcutsChanged, list)
+	return list, nil
+}
+
+func (a *App) ResetShortcuts() ([]shortcuts.Shortcut, error) {
+	return a.SetShortcuts(nil)
+}
+
+func (a *App) GetEQ() (*EQState, error) {
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+	preset := set.Playback.EQPreset
+	if preset == "" {
+		preset = audio.EQPresetFlat
+	}
+	return &EQState{
+		Frequencies: audio.EQFrequencies[:],
+		Gains:       a.player.EQ(),
+		Preset:      preset,
+		Presets:     audio.EQPresetNames(),
+		MaxGainDb:   audio.MaxEQGainDb,
+	}, nil
+}
+
+func (a *App) SetEQBand(band int, gainDb float64) (*EQState, error) {
+	if err := a.player.SetEQBand(band, gainDb); err != nil {
+		return nil, apperror.Invalid(err.Error())
+	}
+	return a.saveEQ(audio.EQPresetCustom)
+}
+
+func (a *App) ApplyEQPreset(name string) (*EQState, error) {
+	gains, err := audio.EQPreset(name)
+	if err != nil {
+		return nil, apperror.Invalid(err.Error())
+	}
+	if err := a.player.SetEQ(gains); err != nil {
+		return nil, err
+	}
+	return a.saveEQ(strings.ToLower(strings.TrimSpace(name)))
+}
+
+func (a *App) saveEQ(preset string) (*EQState, error) {
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+	set.Playback.EQ = a.player.EQ()
+	set.Playback.EQPreset = preset
+	if err := storage.SaveSettings(set); err != nil {
+		return nil, err
+	}
+	return a.GetEQ()
+}
+
+func (a *App) GetAudioState() map[string]float64 {
+	return map[string]float64{
+		"duration": a.player.GetDuration(),
+		"position": a.player.GetPosition(),
+	}
+}
+
+func (a *App) LoadLibraryWithMetadata() (*library.BatchResult, error) {
+	return a.loadLibrary(a.ctx)
+}
+
+func (a *App) loadLibrary(ctx context.Context) (*library.BatchResult, error) {
+	var res *library.BatchResult
+	err := a.boot.Measure("library", func() error {
+		var err error
+		res, err = a.library.LoadStoredLibrary(ctx)
+		return err
+	})
+	if err == nil {
+		a.notifyLibrarySynced("load", res)
+	}
+	return res, err
+}
+
+func (a *App) AddFiles(paths []string) (*library.BatchResult, error) {
+	res, err := a.library.AddFiles(paths)
+	if err == nil {
+		a.notifyLibrarySynced("add", res)
+	}
+	return res, err
+}
+
+func (a *App) GetFailedLoads() ([]storage.FailedLoad, error) {
+	return a.library.FailedLoads()
+}
+
+func (a *App) RetryFailedLoads() ops.Operation {
+	return a.ops.Start(a.ctx, "retry-failed", "Retrying failed tracks", func(ctx context.Context, r *ops.Reporter) (interface{}, error) {
+		return a.retryFailedLoads(nil)
+	})
+}
+
+func (a *App) DismissFailedLoads(paths []string) ([]storage.FailedLoad, error) {
+	if err := a.library.DismissFailed(a.trackPaths(paths)); err != nil {
+		return nil, err
+	}
+	return a.library.FailedLoads()
+}
+
+func (a *App) failedRetryLoop(ctx context.Context) {
+	ticker := time.NewTicker(failedRetryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := a.power.Wait(ctx, nil); err != nil {
+			return
+		}
+		due := a.library.DueRetries(time.Now())
+		if len(due) == 0 {
+			continue
+		}
+		if _, err := a.retryFailedLoads(due); err != nil {
+			log.Printf("[app] retry failed loads: %v", err)
+		}
+	}
+}
+
+func (a *App) retryFailedLoads(paths []string) (*library.RetryResult, error) {
+	res, err := a.library.RetryFailed(paths)
+	if err != nil {
+		return nil, err
+	}
+	if res.Recovered > 0 {
+		a.notifyLibrarySynced("retry", &library.BatchResult{Tracks: res.Tracks})
+	}
+	a.emit(events.LibraryRetry, res)
+	return res, nil
+}
+
+func (a *App) StartImport(paths []string) ops.Operation {
+	return a.ops.Start(a.ctx, "import", "Importing files", func(ctx context.Context, r *ops.Reporter) (interface{}, error) {
+		return a.importPaths(ctx, paths, r)
+	})
+}
+
+func (a *App) importPaths(ctx context.Context, paths []string, r *ops.Reporter) (*library.BatchResult, error) {
+	total := &library.BatchResult{Tracks: []metadata.TrackMetadata{}, Errors: []string{}, Failures: []library.ImportFailure{}}
+	for start := 0; start < len(paths); start += importBatchSize {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		end := start + importBatchSize
+		if end > len(paths) {
+			end = len(paths)
+		}
+		r.Progress(start, len(paths), "")
+		res, err := a.library.AddFiles(paths[start:end])
+		if err != nil {
+			return total, err
+		}
+		total.Tracks = res.Tracks
+		total.Errors = append(total.Errors, res.Errors...)
+		total.Failures = append(total.Failures, res.Failures...)
+	}
+	a.notifyLibrarySynced("add", total)
+	return total, nil
+}
+
+func (a *App) StartFolderImport(dir string) (ops.Operation, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return ops.Operation{}, apperror.Invalid("folder is required")
+	}
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return ops.Operation{}, err
+	}
+	filter, err := library.NewFilter(set.Import)
+	if err != nil {
+		return ops.Operation{}, apperror.Invalid(err.Error())
+	}
+	a.rememberFolder(dir)
+	title := fmt.Sprintf("Importing %s", filepath.Base(dir))
+	return a.ops.Start(a.ctx, "import", title, func(ctx context.Context, r *ops.Reporter) (interface{}, error) {
+		r.Progress(0, 0, "Scanning folder")
+		scan, err := library.ScanFolder(ctx, dir, filter)
+		if err != nil {
+			return nil, folderAccessError(dir, err)
+		}
+		log.Printf("[app] folder import %s: %d files, %d skipped", dir, len(scan.Paths), len(scan.Skipped))
+		res, err := a.importPaths(ctx, scan.Paths, r)
+		return &FolderImportResult{BatchResult: res, Skipped: scan.Skipped}, err
+	}), nil
+}
+
+func (a *App) GetImportSettings() (storage.ImportSettings, error) {
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return storage.ImportSettings{}, err
+	}
+	return set.Import, nil
+}
+
+func (a *App) SetImportSettings(cfg storage.ImportSettings) error {
+	if _, err := library.NewFilter(cfg); err != nil {
+		return apperror.Invalid(err.Error())
+	}
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return err
+	}
+	set.Import = cfg
+	return storage.SaveSettings(set)
+}
+
+func (a *App) GetTrack(path string) (*metadata.TrackMetadata, error) {
+	t, ok := a.library.Full(a.trackPath(path))
+	if !ok {
+		return nil, apperror.NotFound("track not found in library")
+	}
+	return &t, nil
+}
+
+func (a *App) trackPath(ref string) string {
+	if path, ok := a.library.ResolveTrack(ref); ok {
+		return path
+	}
+	return ref
+}
+
+func (a *App) trackPaths(refs []string) []string {
+	paths := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		paths = append(paths, a.trackPath(ref))
+	}
+	return paths
+}
+
+func (a *App) GetTrackID(path string) (string, error) {
+	id, ok := a.library.IDForPath(path)
+	if !ok {
+		return "", apperror.NotFound("track not found in library")
+	}
+	return id, nil
+}
+
+func (a *App) RelocateTrack(id, newPath string) (*metadata.TrackMetadata, error) {
+	oldPath, ok := a.library.PathForID(id)
+	if !ok {
+		return nil, apperror.NotFound("track not found in library")
+	}
+	t, err := a.library.Relocate(id, newPath)
+	if errors.Is(err, library.ErrTrackExists) {
+		return nil, apperror.New(apperror.CodeAlreadyExists, err.Error())
+	}
+	if os.IsNotExist(err) {
+		return nil, apperror.NotFound(fmt.Sprintf("file not found: %s", newPath))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := a.playlists.ReplacePath(oldPath, t.FilePath); err != nil {
+		log.Printf("[app] update playlists after relocating %s: %v", filepath.Base(oldPath), err)
+	}
+	return &t, nil
+}
+
+func (a *App) GetMemoryStats() library.MemoryStats {
+	return a.library.MemoryStats()
+}
+
+func (a *App) SetMemoryLimits(cfg storage.CacheSettings) error {
+	if cfg.CoverMB < 0 || cfg.MetadataMB < 0 {
+		return apperror.Invalid("memory limits must not be negative")
+	}
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return err
+	}
+	set.Cache = cfg
+	if err := storage.SaveSettings(set); err != nil {
+		return err
+	}
+	library.SetMemoryLimits(cfg.CoverMB, cfg.MetadataMB)
+	a.library.EnforceMemoryLimits()
+	return nil
+}
+
+func (a *App) RescanTracks(paths []string) (*library.RescanResult, error) {
+	return a.library.Rescan(a.ctx, paths, func(done, total int, path string) {
+		a.emit(events.LibraryRescan, events.RescanProgress{Done: done, Total: total, Path: path})
+	})
+}
+
+func (a *App) StartRescan(paths []string) ops.Operation {
+	title := "Rescanning library"
+	if len(paths) > 0 {
+		title = fmt.Sprintf("Rescanning %d tracks", len(paths))
+	}
+	return a.ops.Start(a.ctx, "rescan", title, func(ctx context.Context, r *ops.Reporter) (interface{}, error) {
+		return a.library.Rescan(ctx, paths, func(done, total int, path string) {
+			r.Progress(done, total, filepath.Base(path))
+		})
+	})
+}
+
+func (a *App) StartLibrarySync() ops.Operation {
+	return a.ops.Start(a.ctx, "sync", "Loading library", func(ctx context.Context, r *ops.Reporter) (interface{}, error) {
+		return a.loadLibrary(ctx)
+	})
+}
+
+func (a *App) ListOperations() []ops.Operation {
+	return a.ops.List()
+}
+
+func (a *App) GetOperation(id string) (ops.Operation, error) {
+	return a.ops.Get(id)
+}
+
+func (a *App) CancelOperation(id string) error {
+	return a.ops.Cancel(id)
+}
+
+func (a *App) notifyLibrarySynced(reason string, res *library.BatchResult) {
+	if res == nil {
+		return
+	}
+	a.hooks.Notify(webhook.EventLibrarySynced, fmt.Sprintf("Kitty library synced: %d tracks", len(res.Tracks)), map[string]interface{}{
+		"reason": reason,
+		"tracks": len(res.Tracks),
+		"errors": len(res.Errors),
+	})
+}
+
+func (a *App) DownloaderStatus() downloader.Status {
+	return a.downloader.Status()
+}
+
+func (a *App) StartDownloader() error {
+	return a.downloader.Start(a.ctx)
+}
+
+func (a *App) StopDownloader() {
+	a.downloader.Stop()
+}
+
+func (a *App) GetNetworkTimeouts() map[string]storage.HTTPPolicy {
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return httpclient.Defaults()
+	}
+	return httpclient.Resolve(set.Network.Timeouts)
+}
+
+func (a *App) SetNetworkTimeouts(overrides map[string]storage.HTTPPolicy) (map[string]storage.HTTPPolicy, error) {
+	clean, err := httpclient.Validate(overrides)
+	if err != nil {
+		return nil, apperror.Invalid(err.Error())
+	}
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+	set.Network.Timeouts = clean
+	if err := storage.SaveSettings(set); err != nil {
+		return nil, err
+	}
+	httpclient.Configure(clean)
+	return httpclient.Resolve(clean), nil
+}
+
+func (a *App) ResetNetworkTimeouts() (map[string]storage.HTTPPolicy, error) {
+	return a.SetNetworkTimeouts(nil)
+}
+
+func (a *App) GetDownloaderSettings() (storage.DownloaderSettings, error) {
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return storage.DownloaderSettings{}, err
+	}
+	set.Downloader.PlaylistNumbering = downloader.NormalizeNumbering(set.Downloader.PlaylistNumbering)
+	if policy, err := downloader.NormalizeDuplicatePolicy(set.Downloader.Duplicates); err == nil {
+		set.Downloader.Duplicates = policy
+	}
+	return set.Downloader, nil
+}
+
+func (a *App) SetDownloaderSettings(patch json.RawMessage) error {
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return err
+	}
+	cfg := set.Downloader
+	if err := json.Unmarshal(patch, &cfg); err != nil {
+		return apperror.Invalid("invalid downloader settings: " + err.Error())
+	}
+	policy, err := downloader.NormalizeDuplicatePolicy(cfg.Duplicates)
+	if err != nil {
+		return apperror.Invalid(err.Error())
+	}
+	cfg.Duplicates = policy
+	if cfg.Scripts, err = downloader.NormalizeScripts(cfg.Scripts); err != nil {
+		return apperror.Invalid(err.Error())
+	}
+	cfg.PlaylistNumbering = downloader.NormalizeNumbering(cfg.PlaylistNumbering)
+	set.Downloader = cfg
+	return storage.SaveSettings(set)
+}
+
+func (a *App) filenameOptions() downloader.FilenameOptions {
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return downloader.FilenameOptions{}
+	}
+	return downloader.FilenameOptions{Transliterate: set.Downloader.Transliterate, Scripts: set.Downloader.Scripts}
+}
+
+func (a *App) GetDownloaderAutoStart() (bool, error) {
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return false, err
+	}
+	return set.Downloader.AutoStart, nil
+}
+
+func (a *App) SetDownloaderAutoStart(enabled bool) error {
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return err
+	}
+	set.Downloader.AutoStart = enabled
+	return storage.SaveSettings(set)
+}
+
+func (a *App) ResetAppData() error {
+	a.downloader.Stop()
+	a.player.Pause()
+
+	if err := storage.ClearLibrary(); err != nil {
+		return err
+	}
+	if err := library.ClearTrackCache(); err != nil {
+		return err
+	}
+	if err := storage.ClearSettings(); err != nil {
+		return err
+	}
+	if err := metadata.ClearSidecarCache(); err != nil {
+		return err
+	}
+	if err := a.media.ClearBackups(); err != nil {
+		return err
+	}
+	if err := a.playlists.Clear(); err != nil {
+		return err
+	}
+	if err := a.stats.Clear(); err != nil {
+		return err
+	}
+	if err := a.trackMarks.Clear(); err != nil {
+		return err
+	}
+	if err := a.downloads.Clear(); err != nil {
+		return err
+	}
+	if err := a.transfers.Clear(); err != nil {
+		return err
+	}
+	if err := a.secrets.Clear(); err != nil {
+		return err
+	}
+	if err := a.covers.Clear(); err != nil {
+		return err
+	}
+	if err := analysis.ClearWaveformCache(); err != nil {
+		return err
+	}
+	if err := soundcloud.ClearLikesSnapshot(); err != nil {
+		return err
+	}
+
+	a.library = library.NewManager()
+	return nil
+}
+
+func (a *App) GetSidecarLocation() string {
+	return metadata.SidecarLocation()
+}
+
+func (a *App) SetSidecarLocation(location string, migrate bool) (*ops.Operation, error) {
+	location, err := metadata.NormalizeSidecarLocation(location)
+	if err != nil {
+		return nil, apperror.Invalid(err.Error())
+	}
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+	set.Metadata.SidecarLocation = location
+	if err := storage.SaveSettings(set); err != nil {
+		return nil, err
+	}
+	if err := metadata.SetSidecarLocation(location); err != nil {
+		return nil, err
+	}
+	if !migrate {
+		return nil, nil
+	}
+	op := a.MigrateSidecars()
+	return &op, nil
+}
+
+func (a *App) MigrateSidecars() ops.Operation {
+	return a.ops.Start(a.ctx, "migrate-sidecars", "Moving metadata sidecars", func(ctx context.Context, r *ops.Reporter) (interface{}, error) {
+		tracks := a.library.Tracks()
+		paths := make([]string, 0, len(tracks))
+		for _, t := range tracks {
+			paths = append(paths, t.FilePath)
+		}
+		return metadata.MigrateSidecars(ctx, paths, func(done, total int) {
+			r.Progress(done, total, "")
+		})
+	})
+}
+
+func (a *App) ListSnapshots() ([]snapshot.Snapshot, error) {
+	return a.snapshots.List()
+}
+
+func (a *App) CreateSnapshot() (*snapshot.Snapshot, error) {
+	return a.snapshots.Create()
+}
+
+func (a *App) RestoreSnapshot(id string) (*library.BatchResult, error) {
+	if _, err := a.snapshots.Restore(id); err != nil {
+		if errors.Is(err, snapshot.ErrNotFound) {
+			return nil, apperror.NotFound(fmt.Sprintf("snapshot %s not found", id))
+		}
+		return nil, err
+	}
+	a.library = library.NewManager()
+	res, err := a.library.LoadStoredLibrary(a.ctx)
+	if err != nil {
+		return res, err
+	}
+	a.emit(events.SnapshotRestored, id)
+	return res, nil
+}
+
+func (a *App) GetSnapshotKeep() int {
+	return a.snapshots.Keep()
+}
+
+func (a *App) SetSnapshotKeep(keep int) error {
+	if keep < 1 || keep > snapshot.MaxKeep {
+		return apperror.Invalid(fmt.Sprintf("snapshot count must be between 1 and %d", snapshot.MaxKeep))
+	}
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return err
+	}
+	set.Snapshots.Keep = keep
+	if err := storage.SaveSettings(set); err != nil {
+		return err
+	}
+	return a.snapshots.SetKeep(keep)
+}
+
+func (a *App) snapshotDaily(ctx context.Context) {
+	if set, err := storage.LoadSettings(); err == nil {
+		if err := a.snapshots.SetKeep(set.Snapshots.Keep); err != nil {
+			log.Printf("[app] snapshot retention: %v", err)
+		}
+	}
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		if err := a.power.Wait(ctx, nil); err != nil {
+			return
+		}
+		if snap, err := a.snapshots.EnsureRecent(snapshot.DefaultInterval); err != nil {
+			log.Printf("[app] automatic snapshot failed: %v", err)
+		} else if snap != nil {
+			log.Printf("[app] created snapshot %s", snap.ID)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *App) watchVolumes(ctx context.Context) {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if a.library.RefreshVolumes() {
+			a.emit(events.LibraryVolumes, a.library.Volumes())
+		}
+	}
+}
+
+func (a *App) GetVolumes() []volumes.Volume {
+	return a.library.Volumes()
+}
+
+func (a *App) ChooseDownloadFolder() (string, error) {
+	if err := a.requireDesktop("folder picker"); err != nil {
+		return "", err
+	}
+	dir, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Select download folder",
+	})
+	if err == nil && dir != "" {
+		a.rememberFolder(dir)
+	}
+	return dir, err
+}
+
+func (a *App) rememberFolder(dir string) {
+	if err := a.bookmarks.Add(dir); err != nil && !errors.Is(err, access.ErrBookmarksUnsupported) {
+		log.Printf("[app] bookmark %s failed: %v", dir, err)
+	}
+}
+
+func folderAccessError(dir string, err error) error {
+	if !access.IsPermissionError(err) {
+		return err
+	}
+	return apperror.Wrap(err, apperror.CodePermissionDenied, fmt.Sprintf("Kitty is not allowed to read %s", dir))
+}
+
+func (a *App) CheckFolderAccess(paths []string) []access.FolderStatus {
+	if len(paths) == 0 {
+		paths = access.ProtectedFolders()
+	}
+	out := make([]access.FolderStatus, 0, len(paths))
+	for _, p := range paths {
+		st := access.CheckFolder(p)
+		st.Bookmarked = a.bookmarks.Has(st.Path)
+		out = append(out, st)
+	}
+	return out
+}
+
+func (a *App) OpenPrivacySettings() error {
+	if err := a.requireDesktop("privacy settings"); err != nil {
+		return err
+	}
+	if err := access.OpenSettings(a.ctx); err != nil {
+		if errors.Is(err, access.ErrUnsupported) {
+			return apperror.New(apperror.CodeUnsupported, err.Error())
+		}
+		return err
+	}
+	return nil
+}
+
+func (a *App) RequestFolderAccess(dir string) (*access.FolderStatus, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return nil, apperror.Invalid("folder is required")
+	}
+	st := access.CheckFolder(dir)
+	if st.Readable || !st.Exists {
+		st.Bookmarked = a.bookmarks.Has(st.Path)
+		return &st, nil
+	}
+	if err := a.requireDesktop("folder picker"); err != nil {
+		return nil, err
+	}
+	chosen, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:            fmt.Sprintf("Allow Kitty to access %s", filepath.Base(dir)),
+		DefaultDirectory: dir,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if chosen == "" {
+		return &st, nil
+	}
+	a.rememberFolder(chosen)
+	st = access.CheckFolder(chosen)
+	st.Bookmarked = a.bookmarks.Has(st.Path)
+	return &st, nil
+}
+
+func (a *App) SelectVideoFile() (string, error) {
+	if err := a.requireDesktop("file picker"); err != nil {
+		return "", err
+	}
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Select Video File",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Video Files", Pattern: "*.mp4;*.mkv;*.mov;*.webm;*.avi;*.m4v;*.flv"},
+		},
+	})
+	return path, err
 }
 
 func (a *App) ExtractAudioFromVideo(videoPath string, targetDir string, format string) (*ExtractAudioResult, error) {
 	videoPath = strings.TrimSpace(videoPath)
 	if videoPath == "" {
-		return nil, fmt.Errorf("video path is required")
+		return nil, apperror.Invalid("video path is required")
 	}
 	targetDir = strings.TrimSpace(targetDir)
 	if targetDir == "" {
-		return nil, fmt.Errorf("target directory is required")
+		return nil, apperror.Invalid("target directory is required")
+	}
+
+	outPath, err := a.media.ExtractAudio(a.ctx, videoPath, targetDir, format)
+	if err != nil {
+		return nil, err
+	}
+
+	res, addErr := a.library.AddFiles([]string{outPath})
+	if addErr != nil {
+		return &ExtractAudioResult{
+			SavedPath: outPath,
+			Errors:    []string{addErr.Error()},
+		}, nil
+	}
+
+	var updated *metadata.TrackMetadata
+	for i := range res.Tracks {
+		if res.Tracks[i].FilePath == outPath {
+			cp := res.Tracks[i]
+			updated = &cp
+			break
+		}
+	}
+
+	return &ExtractAudioResult{
+		SavedPath:    outPath,
+		UpdatedTrack: updated,
+		Errors:       res.Errors,
+	}, nil
+}
+
+func (a *App) DownloadMedia(link string, targetDir string, format string, bitrate string) (*downloader.DownloadResult, error) {
+	return a.downloadAndNotify(a.ctx, link, targetDir, format, bitrate, "", false)
+}
+
+func (a *App) DownloadMediaAnyway(link string, targetDir string, format string, bitrate string) (*downloader.DownloadResult, error) {
+	return a.downloadAndNotify(a.ctx, link, targetDir, format, bitrate, "", true)
+}
+
+func (a *App) DownloadMediaWithProfile(link string, targetDir string, format string, bitrate string, profileID string) (*downloader.DownloadResult, error) {
+	return a.downloadAndNotify(a.ctx, link, targetDir, format, bitrate, profileID, false)
+}
+
+func (a *App) downloadAndNotify(ctx context.Context, link string, targetDir string, format string, bitrate string, profileID string, force bool) (*downloader.DownloadResult, error) {
+	res, err := a.downloadMedia(ctx, link, targetDir, format, bitrate, profileID, force)
+	source := downloader.DetectSource(link)
+	if err != nil {
+		if !errors.Is(err, context.Canceled) && apperror.From(err).Code != apperror.CodeAlreadyExists {
+			if serr := a.transfers.RecordFailure(source); serr != nil {
+				log.Printf("[app] record download failure failed: %v", serr)
+			}
+		}
+		a.hooks.Notify(webhook.EventDownloadFailed, "Kitty download failed: "+err.Error(), map[string]interface{}{
+			"url":   link,
+			"error": err.Error(),
+		})
+		return nil, err
+	}
+	if res != nil && !res.Skipped {
+		data := map[string]interface{}{
+			"url":       link,
+			"savedPath": res.SavedPath,
+			"format":    res.Format,
+			"bitrate":   res.Bitrate,
+		}
+		summary := "Kitty downloaded " + filepath.Base(res.SavedPath)
+		entry := downloader.HistoryEntry{Path: res.SavedPath, URL: link, Source: source, Format: res.Format}
+		for _, t := range res.Tracks {
+			if t.FilePath == res.SavedPath {
+				data["title"] = t.Title
+				data["artist"] = t.Artist
+				entry.Title, entry.Artist = t.Title, t.Artist
+				summary = fmt.Sprintf("Kitty downloaded %s - %s", t.Artist, t.Title)
+				break
+			}
+		}
+		if err := a.downloads.Record(entry); err != nil {
+			log.Printf("[app] record download failed: %v", err)
+		}
+		if err := a.transfers.RecordSuccess(source, res.Bytes, res.Took); err != nil {
+			log.Printf("[app] record download stats failed: %v", err)
+		}
+		a.hooks.Notify(webhook.EventDownloadCompleted, summary, data)
+	}
+	return res, nil
+}
+
+func (a *App) GetDownloadStats(period string) (*downloader.DownloadStats, error) {
+	stats, err := a.transfers.Summary(period, time.Now())
+	if err != nil {
+		if _, perr := downloader.NormalizeStatsPeriod(period); perr != nil {
+			return nil, apperror.Invalid(perr.Error())
+		}
+		return nil, err
+	}
+	return stats, nil
+}
+
+func (a *App) GetCredentialServices() []string {
+	return downloader.CredentialServices
+}
+
+func (a *App) GetCredentialProfiles() ([]downloader.CredentialProfile, error) {
+	return a.secrets.List()
+}
+
+func (a *App) SaveCredentialProfile(p downloader.CredentialProfile) (*downloader.CredentialProfile, error) {
+	p, err := downloader.NormalizeCredentialProfile(p)
+	if err != nil {
+		return nil, apperror.Invalid(err.Error())
+	}
+	saved, err := a.secrets.Save(p)
+	switch {
+	case errors.Is(err, downloader.ErrCredentialNotFound):
+		return nil, apperror.NotFound(err.Error())
+	case errors.Is(err, downloader.ErrCredentialExists):
+		return nil, apperror.New(apperror.CodeAlreadyExists, err.Error())
+	case errors.Is(err, downloader.ErrCredentialSecret):
+		return nil, apperror.Invalid(err.Error())
+	case err != nil:
+		return nil, err
+	}
+	return &saved, nil
+}
+
+func (a *App) DeleteCredentialProfile(id string) error {
+	if err := a.secrets.Delete(id); err != nil {
+		if errors.Is(err, downloader.ErrCredentialNotFound) {
+			return apperror.NotFound(err.Error())
+		}
+		return err
+	}
+	a.downloader.StopProfile(id)
+	return nil
+}
+
+type RecentDownload struct {
+	Entry   downloader.HistoryEntry `json:"entry"`
+	Track   *metadata.TrackMetadata `json:"track,omitempty"`
+	Missing bool                    `json:"missing"`
+}
+
+type DownloadCleanup struct {
+	Removed      int               `json:"removed"`
+	DeletedFiles int               `json:"deletedFiles"`
+	Errors       []BulkUpdateError `json:"errors"`
+}
+
+func (a *App) GetRecentDownloads(days int) ([]RecentDownload, error) {
+	if days <= 0 {
+		days = downloader.DefaultRecentDays
+	}
+	entries, err := a.downloads.Since(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]RecentDownload, 0, len(entries))
+	for _, e := range entries {
+		item := RecentDownload{Entry: e}
+		if t, ok := a.library.Track(e.Path); ok {
+			item.Track = &t
+		}
+		if _, err := os.Stat(e.Path); err != nil {
+			item.Missing = true
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+func (a *App) CleanupDownloads(retentionDays int, deleteFiles bool) (*DownloadCleanup, error) {
+	if retentionDays <= 0 {
+		retentionDays = downloader.DefaultRetentionDays
+		if set, err := storage.LoadSettings(); err == nil && set.Downloader.RetentionDays > 0 {
+			retentionDays = set.Downloader.RetentionDays
+		}
+	}
+	removed, err := a.downloads.Prune(time.Now().AddDate(0, 0, -retentionDays))
+	if err != nil {
+		return nil, err
+	}
+	result := &DownloadCleanup{Removed: len(removed), Errors: []BulkUpdateError{}}
+	if !deleteFiles || len(removed) == 0 {
+		return result, nil
+	}
+	current := a.player.CurrentPath()
+	deleted := make([]string, 0, len(removed))
+	for _, e := range removed {
+		if e.Path == current {
+			result.Errors = append(result.Errors, BulkUpdateError{FilePath: e.Path, Error: "currently playing"})
+			continue
+		}
+		if err := os.Remove(e.Path); err != nil && !os.IsNotExist(err) {
+			result.Errors = append(result.Errors, BulkUpdateError{FilePath: e.Path, Error: err.Error()})
+			continue
+		}
+		deleted = append(deleted, e.Path)
+	}
+	result.DeletedFiles = len(deleted)
+	if _, err := a.library.Remove(deleted); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+func (a *App) DownloadPlaylist(req PlaylistDownloadRequest) (*PlaylistDownloadResult, error) {
+	return a.downloadPlaylist(a.ctx, req, nil)
+}
+
+func (a *App) StartPlaylistDownload(req PlaylistDownloadRequest) ops.Operation {
+	return a.ops.Start(a.ctx, "download", "Downloading playlist", func(ctx context.Context, r *ops.Reporter) (interface{}, error) {
+		return a.downloadPlaylist(ctx, req, r)
+	})
+}
+
+func (a *App) downloadPlaylist(ctx context.Context, req PlaylistDownloadRequest, r *ops.Reporter) (*PlaylistDownloadResult, error) {
+	targetDir := strings.TrimSpace(req.TargetDir)
+	if targetDir == "" {
+		return nil, apperror.Invalid("target directory is required for playlist downloads")
+	}
+	links := make([]string, 0, len(req.Links))
+	for _, l := range req.Links {
+		if l = strings.TrimSpace(l); l != "" {
+			links = append(links, l)
+		}
+	}
+	if len(links) == 0 {
+		return nil, apperror.Invalid("playlist has no links")
 	}
 
-	outPath, err := a.media.ExtractAudio(a.ctx, videoPath, targetDir, format)
+	numbering := req.Numbering
+	if strings.TrimSpace(numbering) == "" {
+		if set, err := storage.LoadSettings(); err == nil {
+			numbering = set.Downloader.PlaylistNumbering
+		}
+	}
+	numbering = downloader.NormalizeNumbering(numbering)
+
+	result := &PlaylistDownloadResult{
+		Total:  len(links),
+		Tracks: make([]metadata.TrackMetadata, 0, len(links)),
+		Errors: make([]BulkUpdateError, 0),
+	}
+	for i, link := range links {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if r != nil {
+			r.Progress(i, len(links), link)
+		}
+		res, err := a.downloadAndNotify(ctx, link, targetDir, req.Format, req.Bitrate, "", false)
+		if err != nil {
+			result.Errors = append(result.Errors, BulkUpdateError{FilePath: link, Error: err.Error()})
+			continue
+		}
+		if res == nil {
+			continue
+		}
+		if res.Skipped && res.Duplicate != nil {
+			result.Tracks = append(result.Tracks, *res.Duplicate)
+			continue
+		}
+
+		md, err := metadata.LoadMetadata(res.SavedPath)
+		if err != nil {
+			result.Errors = append(result.Errors, BulkUpdateError{FilePath: res.SavedPath, Error: err.Error()})
+			continue
+		}
+		build := applyPlaylistPosition(*md, i+1, len(links), req.Album, req.AlbumArtist, numbering)
+		updated, err := a.library.UpdateAndReload(build)
+		if err != nil {
+			result.Errors = append(result.Errors, BulkUpdateError{FilePath: res.SavedPath, Error: err.Error()})
+			continue
+		}
+		result.Tracks = append(result.Tracks, updated)
+	}
+
+	if ref := strings.TrimSpace(req.Playlist); ref != "" && len(result.Tracks) > 0 {
+		paths := make([]string, 0, len(result.Tracks))
+		for _, t := range result.Tracks {
+			paths = append(paths, t.FilePath)
+		}
+		pl, err := a.addToPlaylistRef(ref, paths)
+		if err != nil {
+			result.Errors = append(result.Errors, BulkUpdateError{FilePath: ref, Error: err.Error()})
+		} else {
+			result.Playlist = pl
+		}
+	}
+
+	result.Succeeded = len(result.Tracks)
+	result.Failed = len(result.Errors)
+	return result, nil
+}
+
+func (a *App) DownloadMediaToPlaylist(link string, targetDir string, format string, bitrate string, playlistRef string) (*downloader.DownloadResult, error) {
+	res, err := a.DownloadMedia(link, targetDir, format, bitrate)
+	if err != nil || res == nil {
+		return res, err
+	}
+	if strings.TrimSpace(playlistRef) == "" {
+		return res, nil
+	}
+	if _, err := a.addToPlaylistRef(playlistRef, []string{res.SavedPath}); err != nil {
+		res.Errors = append(res.Errors, fmt.Sprintf("add to playlist failed: %v", err))
+	}
+	return res, nil
+}
+
+func (a *App) addToPlaylistRef(ref string, paths []string) (*playlist.Playlist, error) {
+	pl, err := a.playlists.Resolve(ref)
 	if err != nil {
 		return nil, err
 	}
+	return a.playlists.AddTracks(pl.ID, paths)
+}
 
-	res, addErr := a.library.AddFiles([]string{outPath})
-	if addErr != nil {
-		return &ExtractAudioResult{
-			SavedPath: outPath,
-			Errors:    []string{addErr.Error()},
-		}, nil
+func (a *App) ImportYouTubePlaylist(playlistURL string) (*YouTubeImportResult, error) {
+	pl, err := a.yt.FetchPlaylist(a.ctx, playlistURL)
+	if err != nil {
+		return nil, err
 	}
 
-	var updated *metadata.TrackMetadata
-	for i := range res.Tracks {
-		if res.Tracks[i].FilePath == outPath {
-			cp := res.Tracks[i]
-			updated = &cp
-			break
+	result := &YouTubeImportResult{
+		PlaylistID:   pl.ID,
+		Title:        pl.Title,
+		Entries:      make([]YouTubeImportEntry, 0, len(pl.Entries)),
+		MissingLinks: make([]string, 0),
+		Truncated:    pl.Truncated,
+	}
+	for _, e := range pl.Entries {
+		entry := YouTubeImportEntry{Entry: e}
+		title, artist := splitArtistTitle(e.Title, e.Channel)
+		if t, ok := a.library.FindMatch(title, artist); ok {
+			entry.InLibrary = true
+			entry.MatchedPath = t.FilePath
+			result.Matched++
+		} else if t, ok := a.library.FindMatch(e.Title, ""); ok {
+			entry.InLibrary = true
+			entry.MatchedPath = t.FilePath
+			result.Matched++
+		} else {
+			result.MissingLinks = append(result.MissingLinks, e.URL)
 		}
+		result.Entries = append(result.Entries, entry)
 	}
+	return result, nil
+}
 
-	return &ExtractAudioResult{
-		SavedPath:    outPath,
-		UpdatedTrack: updated,
-		Errors:       res.Errors,
-	}, nil
+func splitArtistTitle(title, fallbackArtist string) (string, string) {
+	for _, sep := range []string{" - ", " – ", " — "} {
+		if parts := strings.SplitN(title, sep, 2); len(parts) == 2 {
+			if a, t := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]); a != "" && t != "" {
+				return t, a
+			}
+		}
+	}
+	return title, fallbackArtist
 }
 
-func (a *App) DownloadMedia(link string, targetDir string, format string, bitrate string) (*downloader.DownloadResult, error) {
-	if err := a.downloader.Start(a.ctx); err != nil {
+func (a *App) ListPlaylists() ([]playlist.Playlist, error) {
+	return a.playlists.List()
+}
+
+func (a *App) CreatePlaylist(name string) (*playlist.Playlist, error) {
+	return a.playlists.Create(name)
+}
+
+func (a *App) RenamePlaylist(id string, name string) (*playlist.Playlist, error) {
+	return a.playlists.Rename(id, name)
+}
+
+func (a *App) DeletePlaylist(id string) error {
+	return a.playlists.Delete(id)
+}
+
+func (a *App) AddTracksToPlaylist(id string, paths []string) (*playlist.Playlist, error) {
+	return a.playlists.AddTracks(id, a.trackPaths(paths))
+}
+
+func (a *App) RemoveTracksFromPlaylist(id string, paths []string) (*playlist.Playlist, error) {
+	return a.playlists.RemoveTracks(id, a.trackPaths(paths))
+}
+
+func (a *App) GetFolderPlaylistRoots() ([]string, error) {
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+	if set.Playlists.FolderRoots == nil {
+		return []string{}, nil
+	}
+	return set.Playlists.FolderRoots, nil
+}
+
+func (a *App) SetFolderPlaylistRoots(roots []string) ([]string, error) {
+	clean := make([]string, 0, len(roots))
+	seen := make(map[string]bool, len(roots))
+	for _, root := range roots {
+		root = strings.TrimSpace(root)
+		if root == "" {
+			continue
+		}
+		abs, err := filepath.Abs(root)
+		if err != nil {
+			return nil, apperror.Invalid(fmt.Sprintf("invalid folder: %s", root))
+		}
+		info, err := os.Stat(abs)
+		if err != nil || !info.IsDir() {
+			return nil, apperror.Invalid(fmt.Sprintf("not a folder: %s", root))
+		}
+		if seen[abs] {
+			continue
+		}
+		seen[abs] = true
+		clean = append(clean, abs)
+	}
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+	set.Playlists.FolderRoots = clean
+	if err := storage.SaveSettings(set); err != nil {
+		return nil, err
+	}
+	for _, root := range clean {
+		a.rememberFolder(root)
+	}
+	a.SyncFolderPlaylists()
+	return clean, nil
+}
+
+func (a *App) SyncFolderPlaylists() ops.Operation {
+	return a.ops.Start(a.ctx, "folder-playlists", "Syncing folder playlists", func(ctx context.Context, r *ops.Reporter) (interface{}, error) {
+		return a.syncFolderPlaylists(ctx, r)
+	})
+}
+
+func (a *App) folderPlaylistLoop(ctx context.Context) {
+	ticker := time.NewTicker(folderPlaylistInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := a.power.Wait(ctx, nil); err != nil {
+			return
+		}
+		set, err := storage.LoadSettings()
+		if err != nil || len(set.Playlists.FolderRoots) == 0 {
+			continue
+		}
+		a.SyncFolderPlaylists()
+	}
+}
+
+func (a *App) syncFolderPlaylists(ctx context.Context, r *ops.Reporter) (*playlist.FolderSync, error) {
+	if !a.folderSync.TryLock() {
+		return nil, apperror.New(apperror.CodeAlreadyExists, "folder playlists are already syncing")
+	}
+	defer a.folderSync.Unlock()
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+	filter, err := library.NewFilter(set.Import)
+	if err != nil {
+		return nil, apperror.Invalid(err.Error())
+	}
+
+	var (
+		lists   []playlist.FolderList
+		skipped []string
+	)
+	for i, root := range set.Playlists.FolderRoots {
+		r.Progress(i, len(set.Playlists.FolderRoots), filepath.Base(root))
+		scan, err := library.ScanFolder(ctx, root, filter)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Printf("[app] folder playlists: scan %s failed: %v", root, err)
+			skipped = append(skipped, root)
+			continue
+		}
+		known := make(map[string]bool)
+		for _, t := range a.library.Tracks() {
+			known[metadata.SourcePath(t.FilePath)] = true
+		}
+		var fresh []string
+		for _, p := range scan.Paths {
+			if !known[p] {
+				fresh = append(fresh, p)
+			}
+		}
+		if len(fresh) > 0 {
+			if _, err := a.importPaths(ctx, fresh, r); err != nil {
+				return nil, err
+			}
+		}
+
+		present := make(map[string]bool, len(scan.Paths))
+		for _, p := range scan.Paths {
+			present[p] = true
+		}
+		groups := make(map[string][]string)
+		for _, t := range a.library.Tracks() {
+			src := metadata.SourcePath(t.FilePath)
+			if present[src] {
+				dir := filepath.Dir(src)
+				groups[dir] = append(groups[dir], t.FilePath)
+			}
+		}
+		for dir, tracks := range groups {
+			sort.Strings(tracks)
+			lists = append(lists, playlist.FolderList{Folder: dir, Name: folderPlaylistName(root, dir), Tracks: tracks})
+		}
+	}
+	sort.Slice(lists, func(i, j int) bool { return lists[i].Folder < lists[j].Folder })
+
+	res, err := a.playlists.SyncFolders(lists, skipped)
+	if err != nil {
+		return nil, err
+	}
+	if res.Changed() {
+		log.Printf("[app] folder playlists: %d created, %d updated, %d removed", res.Created, res.Updated, res.Removed)
+		a.emit(events.FolderPlaylists, res)
+	}
+	return &res, nil
+}
+
+func folderPlaylistName(root, dir string) string {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." {
+		return filepath.Base(root)
+	}
+	return filepath.ToSlash(rel)
+}
+
+const (
+	providerSoundCloud = "soundcloud"
+	providerSpotify    = "spotify"
+)
+
+type UnmatchedTrack struct {
+	Path       string             `json:"path"`
+	Title      string             `json:"title"`
+	Artist     string             `json:"artist"`
+	Candidates []soundcloud.Track `json:"candidates"`
+}
+
+type PushResult struct {
+	Provider   string           `json:"provider"`
+	PlaylistID string           `json:"playlistId"`
+	RemoteID   string           `json:"remoteId"`
+	RemoteURL  string           `json:"remoteUrl"`
+	Total      int              `json:"total"`
+	Matched    int              `json:"matched"`
+	Unmatched  []UnmatchedTrack `json:"unmatched"`
+}
+
+func (a *App) requirePushProvider(provider string) (string, error) {
+	switch provider = strings.ToLower(strings.TrimSpace(provider)); provider {
+	case providerSoundCloud:
+		status, err := a.sc.Status()
+		if err != nil {
+			return "", err
+		}
+		if !status.Connected {
+			return "", apperror.New(apperror.CodeUnauthorized, "connect SoundCloud before pushing playlists")
+		}
+		return provider, nil
+	case providerSpotify:
+		return "", apperror.New(apperror.CodeUnsupported, "Spotify is not available yet")
+	}
+	return "", apperror.Invalid(fmt.Sprintf("unknown provider: %s", provider))
+}
+
+func (a *App) PushPlaylist(provider, playlistID string) (*PushResult, error) {
+	provider, err := a.requirePushProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+	pl, err := a.playlists.Get(playlistID)
+	if errors.Is(err, playlist.ErrNotFound) {
+		return nil, apperror.NotFound(err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+	remote := pl.Remote[provider]
+	result := &PushResult{Provider: provider, PlaylistID: pl.ID, Total: len(pl.Tracks), Unmatched: []UnmatchedTrack{}}
+	ids := make([]int64, 0, len(pl.Tracks))
+	found := map[string]string{}
+	for _, path := range pl.Tracks {
+		if err := a.ctx.Err(); err != nil {
+			return nil, err
+		}
+		if pinned, ok := remote.Tracks[path]; ok {
+			if id, err := strconv.ParseInt(pinned, 10, 64); err == nil && id > 0 {
+				ids = append(ids, id)
+				found[path] = pinned
+				continue
+			}
+		}
+		t, ok := a.library.Track(path)
+		if !ok {
+			if md, err := metadata.LoadMetadata(path); err == nil {
+				t = *md
+			} else {
+				t = metadata.TrackMetadata{FilePath: path, Title: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))}
+			}
+		}
+		artist := t.Artist
+		if artist == "Unknown Artist" {
+			artist = ""
+		}
+		candidates, err := a.sc.SearchTracks(a.ctx, strings.TrimSpace(artist+" "+t.Title))
+		if err != nil {
+			return nil, apperror.Wrap(err, apperror.CodeNetwork, "SoundCloud search failed")
+		}
+		if best, ok := soundcloud.BestMatch(candidates, t.Title, artist); ok {
+			ids = append(ids, best.ID)
+			found[path] = strconv.FormatInt(best.ID, 10)
+			continue
+		}
+		result.Unmatched = append(result.Unmatched, UnmatchedTrack{Path: path, Title: t.Title, Artist: artist, Candidates: candidates})
+	}
+	result.Matched = len(ids)
+
+	remoteID, _ := strconv.ParseInt(remote.ID, 10, 64)
+	saved, err := a.sc.SavePlaylist(a.ctx, remoteID, pl.Name, soundcloud.PrivacyPrivate, ids)
+	if errors.Is(err, soundcloud.ErrPlaylistGone) {
+		saved, err = a.sc.SavePlaylist(a.ctx, 0, pl.Name, soundcloud.PrivacyPrivate, ids)
+	}
+	if err != nil {
+		return nil, apperror.Wrap(err, apperror.CodeNetwork, "SoundCloud playlist could not be saved")
+	}
+	result.RemoteID = strconv.FormatInt(saved.ID, 10)
+	result.RemoteURL = saved.PermalinkURL
+	if _, err := a.playlists.SetRemote(pl.ID, provider, func(r *playlist.Remote) {
+		r.ID = result.RemoteID
+		r.URL = result.RemoteURL
+		r.PushedAt = time.Now().Unix()
+		for path, id := range found {
+			r.Tracks[path] = id
+		}
+	}); err != nil {
+		log.Printf("[app] remember pushed playlist failed: %v", err)
+	}
+	return result, nil
+}
+
+func (a *App) ResolvePushedTrack(provider, playlistID, path, link string) error {
+	provider, err := a.requirePushProvider(provider)
+	if err != nil {
+		return err
+	}
+	details, err := a.sc.ResolveTrack(a.ctx, link)
+	if err != nil {
+		return apperror.Invalid(err.Error())
+	}
+	if details.ID == 0 {
+		return apperror.Invalid("link does not point to a SoundCloud track")
+	}
+	_, err = a.playlists.SetRemote(playlistID, provider, func(r *playlist.Remote) {
+		r.Tracks[path] = strconv.FormatInt(details.ID, 10)
+	})
+	if errors.Is(err, playlist.ErrNotFound) {
+		return apperror.NotFound(err.Error())
+	}
+	return err
+}
+
+func applyPlaylistPosition(md metadata.TrackMetadata, position, total int, album, albumArtist, numbering string) metadata.TrackMetadata {
+	album = strings.TrimSpace(album)
+	albumArtist = strings.TrimSpace(albumArtist)
+	if numbering == downloader.NumberingKeepOriginal {
+		if md.TrackNumber <= 0 {
+			md.TrackNumber = position
+		}
+		if album != "" && (strings.TrimSpace(md.Album) == "" || md.Album == "Unknown Album") {
+			md.Album = album
+		}
+		if albumArtist != "" && strings.TrimSpace(md.AlbumArtist) == "" {
+			md.AlbumArtist = albumArtist
+		}
+		return md
+	}
+
+	md.TrackNumber = position
+	if album != "" {
+		md.Album = album
+	}
+	if albumArtist != "" {
+		md.AlbumArtist = albumArtist
+	}
+	if md.DiscNumber <= 0 && total > 0 {
+		md.DiscNumber = 1
+	}
+	return md
+}
+
+func (a *App) downloadMedia(ctx context.Context, link string, targetDir string, format string, bitrate string, profileID string, force bool) (*downloader.DownloadResult, error) {
+	profile, err := a.secrets.ForLink(link, profileID)
+	if errors.Is(err, downloader.ErrCredentialNotFound) {
+		return nil, apperror.NotFound(err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+	api, err := a.downloader.UseProfile(a.ctx, profile)
+	if err != nil {
 		return nil, err
 	}
 	if format == "" {
@@ -295,10 +3979,15 @@ func (a *App) DownloadMedia(link string, targetDir string, format string, bitrat
 	if bitrate == "" {
 		bitrate = "320"
 	}
-	info, err := a.downloader.RequestDownload(a.ctx, link, format, bitrate)
+	info, err := a.downloader.RequestDownload(ctx, api, link, format, bitrate)
 	if err != nil {
 		return nil, err
 	}
+	if !force {
+		if res, err := a.checkDuplicate(info); res != nil || err != nil {
+			return res, err
+		}
+	}
 
 	filename := info.Filename
 	if filename == "" {
@@ -307,10 +3996,16 @@ func (a *App) DownloadMedia(link string, targetDir string, format string, bitrat
 		filename = ensureExtension(filename, info.MimeType, "mp3")
 	}
 
+	nameOpts := a.filenameOptions()
+	filename = downloader.SanitizeFilename(filename, nameOpts)
+
 	var savePath string
 	if targetDir != "" {
-		savePath = filepath.Join(targetDir, filename)
+		savePath = downloader.SafePath(targetDir, filename, nameOpts)
 	} else {
+		if err := a.requireDesktop("save dialog"); err != nil {
+			return nil, err
+		}
 		savePath, err = runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
 			Title:           "Save downloaded audio",
 			DefaultFilename: filename,
@@ -323,24 +4018,95 @@ func (a *App) DownloadMedia(link string, targetDir string, format string, bitrat
 		}
 	}
 
-	if _, err := a.downloader.Fetch(a.ctx, info.URL, savePath); err != nil {
-		return nil, err
+	fetchStart := time.Now()
+	if _, err := a.downloader.Fetch(ctx, info.URL, savePath); err != nil {
+		return nil, err
+	}
+	took := time.Since(fetchStart)
+	var size int64
+	if st, err := os.Stat(savePath); err == nil {
+		size = st.Size()
+	}
+
+	res, err := a.library.AddFiles([]string{savePath})
+	if err != nil {
+		return nil, err
+	}
+
+	merged := mergeAndPersistMetadata(savePath, info, res.Tracks, a.library, a.downloader)
+	if downloader.DetectSource(link) == downloader.SourceSoundCloud {
+		merged = a.enrichFromSoundCloud(savePath, link, merged)
+	}
+
+	return &downloader.DownloadResult{
+		SavedPath: savePath,
+		Tracks:    merged,
+		Errors:    res.Errors,
+		Format:    info.RequestedFormat,
+		Bitrate:   info.RequestedBitrate,
+		Bytes:     size,
+		Took:      took,
+	}, nil
+}
+
+func (a *App) checkDuplicate(info *downloader.DownloadInfo) (*downloader.DownloadResult, error) {
+	policy := downloader.DuplicatesAsk
+	if set, err := storage.LoadSettings(); err == nil {
+		if p, err := downloader.NormalizeDuplicatePolicy(set.Downloader.Duplicates); err == nil {
+			policy = p
+		}
+	}
+	if policy == downloader.DuplicatesDownload {
+		return nil, nil
+	}
+	title := downloader.HintString(info.MetaHints, "title")
+	if title == "" {
+		return nil, nil
+	}
+	existing, ok := a.library.FindDuplicate(title, downloader.HintString(info.MetaHints, "artist"), downloader.HintDuration(info.MetaHints), downloader.DuplicateDurationTolerance)
+	if !ok {
+		return nil, nil
+	}
+	if policy == downloader.DuplicatesAsk {
+		err := apperror.New(apperror.CodeAlreadyExists, fmt.Sprintf("%s is already in your library", title))
+		err.Details = existing.FilePath
+		return nil, err
+	}
+	log.Printf("[app] skipping download of %q, already have %s", title, existing.FilePath)
+	return &downloader.DownloadResult{
+		SavedPath: existing.FilePath,
+		Tracks:    []metadata.TrackMetadata{existing},
+		Errors:    []string{},
+		Format:    info.RequestedFormat,
+		Bitrate:   info.RequestedBitrate,
+		Skipped:   true,
+		Duplicate: &existing,
+	}, nil
+}
+
+func (a *App) GetWebhookSettings() (storage.WebhookSettings, error) {
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return storage.WebhookSettings{}, err
 	}
+	return set.Webhooks, nil
+}
 
-	res, err := a.library.AddFiles([]string{savePath})
+func (a *App) SetWebhookSettings(cfg storage.WebhookSettings) error {
+	cfg.URL = strings.TrimSpace(cfg.URL)
+	if err := webhook.ValidateURL(cfg.URL); err != nil {
+		return err
+	}
+	set, err := storage.LoadSettings()
 	if err != nil {
-		return nil, err
+		return err
 	}
+	set.Webhooks = cfg
+	return storage.SaveSettings(set)
+}
 
-	merged := mergeAndPersistMetadata(savePath, info, res.Tracks, a.library, a.downloader)
-
-	return &downloader.DownloadResult{
-		SavedPath: savePath,
-		Tracks:    merged,
-		Errors:    res.Errors,
-		Format:    info.RequestedFormat,
-		Bitrate:   info.RequestedBitrate,
-	}, nil
+func (a *App) TestWebhook(cfg storage.WebhookSettings) error {
+	return a.hooks.Test(a.ctx, cfg)
 }
 
 func (a *App) SoundCloudStatus() (soundcloud.AuthStatus, error) {
@@ -360,10 +4126,14 @@ func (a *App) SoundCloudBeginAuth() (string, error) {
 	if err != nil {
 		return "", err
 	}
-	runtime.BrowserOpenURL(a.ctx, authURL)
+	a.openURL(authURL)
 	return authURL, nil
 }
 
+func (a *App) SoundCloudCheckToken() soundcloud.TokenHealth {
+	return a.sc.CheckToken(a.ctx)
+}
+
 func (a *App) SoundCloudLogout() error {
 	return a.sc.Logout()
 }
@@ -372,7 +4142,198 @@ func (a *App) SoundCloudListLikes(nextHref string) (*soundcloud.LikesPage, error
 	return a.sc.ListLikes(a.ctx, nextHref)
 }
 
+func (a *App) SoundCloudListReposts(nextHref string) (*soundcloud.LikesPage, error) {
+	return a.sc.ListReposts(a.ctx, nextHref)
+}
+
+func (a *App) SoundCloudListPlayHistory(nextHref string) (*soundcloud.LikesPage, error) {
+	return a.sc.ListPlayHistory(a.ctx, nextHref)
+}
+
+func (a *App) UploadTrack(path string, md metadata.TrackMetadata, privacy string) (ops.Operation, error) {
+	if strings.TrimSpace(path) == "" {
+		path = md.FilePath
+	}
+	if strings.TrimSpace(path) == "" {
+		return ops.Operation{}, apperror.Invalid("track path is required")
+	}
+	path = a.trackPath(path)
+	privacy, err := soundcloud.NormalizePrivacy(privacy)
+	if err != nil {
+		return ops.Operation{}, apperror.Invalid(err.Error())
+	}
+	if err := a.ensureLocal(path); err != nil {
+		return ops.Operation{}, err
+	}
+	status, err := a.sc.Status()
+	if err != nil {
+		return ops.Operation{}, err
+	}
+	if !status.Connected {
+		return ops.Operation{}, apperror.New(apperror.CodeUnauthorized, "connect SoundCloud before uploading")
+	}
+	req := soundcloud.UploadRequest{
+		Path:        path,
+		Title:       md.Title,
+		Genre:       md.Genre,
+		Description: md.Comment,
+		Label:       md.Label,
+		ReleaseYear: md.Year,
+		Tags:        []string{md.Artist, md.Album},
+		Privacy:     privacy,
+	}
+	if md.HasCover || strings.TrimSpace(md.CoverImage) != "" {
+		if head, data, ok := strings.Cut(md.CoverImage, ","); ok {
+			if raw, err := base64.StdEncoding.DecodeString(data); err == nil {
+				req.Artwork = raw
+				req.ArtworkMime = strings.TrimSuffix(strings.TrimPrefix(head, "data:"), ";base64")
+			}
+		}
+	}
+	title := "Uploading " + filepath.Base(path)
+	return a.ops.Start(a.ctx, "upload", title, func(ctx context.Context, r *ops.Reporter) (interface{}, error) {
+		return a.sc.UploadTrack(ctx, req, func(p soundcloud.UploadProgress) {
+			switch p.Stage {
+			case soundcloud.UploadStageSending:
+				if p.Total > 0 {
+					r.Fraction(0.9*float64(p.Sent)/float64(p.Total), "Uploading")
+				}
+			case soundcloud.UploadStageTranscoding:
+				r.Fraction(0.95, "SoundCloud is transcoding")
+			case soundcloud.UploadStageFinished:
+				r.Fraction(1, "Uploaded")
+			}
+		})
+	}), nil
+}
+
+func (a *App) SoundCloudLikesCount() (int, error) {
+	return a.sc.LikesCount(a.ctx)
+}
+
+func (a *App) SoundCloudPrefetchLikes() (int, error) {
+	total, err := a.sc.LikesCount(a.ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithCancel(a.ctx)
+	a.mu.Lock()
+	if a.likesCancel != nil {
+		a.likesCancel()
+	}
+	a.likesCancel = cancel
+	a.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		loaded, err := a.sc.PrefetchLikes(ctx, func(p soundcloud.LikesProgress) {
+			a.emit(events.SoundCloudLikesPage, p)
+		})
+		done := events.LikesDone{Loaded: loaded, Total: total}
+		if err != nil {
+			done.Error = err.Error()
+			log.Printf("[app] soundcloud likes prefetch stopped: %v", err)
+		}
+		a.emit(events.SoundCloudLikesDone, done)
+	}()
+	return total, nil
+}
+
+func (a *App) SoundCloudCancelPrefetch() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.likesCancel != nil {
+		a.likesCancel()
+		a.likesCancel = nil
+	}
+}
+
+func (a *App) SoundCloudLikesDiff() ops.Operation {
+	return a.ops.Start(a.ctx, "likes-diff", "Comparing SoundCloud likes", func(ctx context.Context, r *ops.Reporter) (interface{}, error) {
+		diff, err := a.sc.LikesDiff(ctx, func(p soundcloud.LikesProgress) {
+			r.Progress(p.Loaded, p.Total, "")
+		})
+		if err != nil {
+			return nil, err
+		}
+		a.markLikesInLibrary(diff)
+		log.Printf("[app] soundcloud likes diff: %d added, %d unliked, %d removed", len(diff.Added), len(diff.Unliked), len(diff.Removed))
+		return diff, nil
+	})
+}
+
+func (a *App) SoundCloudLastLikesDiff() (*soundcloud.LikesDiff, error) {
+	diff, err := soundcloud.LastLikesDiff()
+	if err != nil {
+		return nil, err
+	}
+	if diff == nil {
+		return nil, apperror.NotFound("SoundCloud likes have not been compared yet")
+	}
+	a.markLikesInLibrary(diff)
+	return diff, nil
+}
+
+func (a *App) markLikesInLibrary(diff *soundcloud.LikesDiff) {
+	sources := make(map[string]bool)
+	for _, t := range a.library.Tracks() {
+		if t.SourceURL != "" {
+			sources[t.SourceURL] = true
+		}
+	}
+	diff.InLibrary = []int64{}
+	for _, list := range [][]soundcloud.Track{diff.Added, diff.Unliked, diff.Removed} {
+		for _, t := range list {
+			if sources[t.PermalinkURL] {
+				diff.InLibrary = append(diff.InLibrary, t.ID)
+			} else if _, ok := a.library.FindMatch(t.Title, t.Artist); ok {
+				diff.InLibrary = append(diff.InLibrary, t.ID)
+			}
+		}
+	}
+}
+
+func (a *App) GetAPIInfo() events.APIInfo {
+	return events.Info()
+}
+
+func (a *App) emit(event string, data ...interface{}) {
+	if a.onEvent != nil {
+		a.onEvent(event, data...)
+	}
+	if a.ctx == nil || a.headless {
+		return
+	}
+	runtime.EventsEmit(a.ctx, event, data...)
+}
+
+func (a *App) requireDesktop(feature string) error {
+	if a.headless {
+		return apperror.New(apperror.CodeUnsupported, fmt.Sprintf("%s is not available in headless mode", feature))
+	}
+	return nil
+}
+
+func (a *App) openURL(link string) {
+	if a.headless {
+		log.Printf("[app] headless, not opening %s", link)
+		return
+	}
+	runtime.BrowserOpenURL(a.ctx, link)
+}
+
 func (a *App) BulkUpdateMetadata(paths []string, patch BulkMetadataPatch) (*BulkUpdateResult, error) {
+	return a.bulkUpdateMetadata(a.ctx, a.trackPaths(paths), patch, nil)
+}
+
+func (a *App) StartBulkUpdate(paths []string, patch BulkMetadataPatch) ops.Operation {
+	return a.ops.Start(a.ctx, "batch-edit", "Updating metadata", func(ctx context.Context, r *ops.Reporter) (interface{}, error) {
+		return a.bulkUpdateMetadata(ctx, a.trackPaths(paths), patch, r)
+	})
+}
+
+func (a *App) bulkUpdateMetadata(ctx context.Context, paths []string, patch BulkMetadataPatch, r *ops.Reporter) (*BulkUpdateResult, error) {
 	unique := make([]string, 0, len(paths))
 	seen := make(map[string]struct{}, len(paths))
 	for _, p := range paths {
@@ -396,7 +4357,13 @@ func (a *App) BulkUpdateMetadata(paths []string, patch BulkMetadataPatch) (*Bulk
 		return result, nil
 	}
 
-	for _, path := range unique {
+	for i, path := range unique {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if r != nil {
+			r.Progress(i, len(unique), filepath.Base(path))
+		}
 		md, err := metadata.LoadMetadata(path)
 		if err != nil {
 			result.Errors = append(result.Errors, BulkUpdateError{
@@ -641,9 +4608,77 @@ func mergeAndPersistMetadata(
 		}
 	}
 
+	if info != nil && strings.TrimSpace(info.SourceURL) != "" {
+		overlay := metadata.TrackMetadata{
+			FilePath:  path,
+			FileName:  filepath.Base(path),
+			SourceURL: info.SourceURL,
+			Source:    downloader.DetectSource(info.SourceURL),
+		}
+		merged := lib.ApplyMetadata(path, overlay)
+		if err := metadata.SaveMetadata(merged); err != nil {
+			log.Printf("[app] persist source url failed: %v", err)
+		}
+		for i := range mergedList {
+			if mergedList[i].FilePath == path {
+				mergedList[i] = merged
+				break
+			}
+		}
+	}
+
 	return mergedList
 }
 
+func (a *App) enrichFromSoundCloud(path, link string, tracks []metadata.TrackMetadata) []metadata.TrackMetadata {
+	status, err := a.sc.Status()
+	if err != nil || !status.Connected {
+		return tracks
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	details, err := a.sc.ResolveTrack(ctx, link)
+	if err != nil {
+		log.Printf("[app] soundcloud enrichment skipped for %s: %v", filepath.Base(path), err)
+		return tracks
+	}
+
+	overlay := metadata.TrackMetadata{
+		FilePath: path,
+		FileName: filepath.Base(path),
+		Title:    details.Title,
+		Artist:   details.Artist,
+		Genre:    details.Genre,
+		Label:    details.Label,
+		Comment:  details.Description,
+		Year:     details.ReleaseYear,
+		Source:   downloader.SourceSoundCloud,
+	}
+	if details.PermalinkURL != "" {
+		overlay.SourceURL = details.PermalinkURL
+	}
+	if details.ArtworkURL != "" {
+		if dataURL, err := a.downloader.FetchDataURL(ctx, details.ArtworkURL); err == nil && dataURL != "" {
+			overlay.CoverImage = dataURL
+			overlay.HasCover = true
+		}
+	}
+
+	merged := a.library.ApplyMetadata(path, overlay)
+	if err := metadata.SaveMetadata(merged); err != nil {
+		log.Printf("[app] persist soundcloud tags failed: %v", err)
+	}
+	for i := range tracks {
+		if tracks[i].FilePath == path {
+			tracks[i] = merged
+			break
+		}
+	}
+	return tracks
+}
+
 func parseBitrate(s string) int {
 	s = strings.TrimSpace(s)
 	if s == "" {
@@ -662,7 +4697,34 @@ func (a *App) GetTrimWaveform(path string, points int) (*media.WaveformResult, e
 	return a.media.GetWaveform(a.ctx, path, points)
 }
 
+func (a *App) GetPreviewClip(path string) (*media.PreviewClip, error) {
+	path = a.trackPath(path)
+	if err := a.ensureLocal(path); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(a.ctx)
+	a.mu.Lock()
+	if a.previewCancel != nil {
+		a.previewCancel()
+	}
+	a.previewCancel = cancel
+	a.mu.Unlock()
+	defer cancel()
+	return a.media.PreviewClip(ctx, path)
+}
+
+func (a *App) CancelPreviewClip() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.previewCancel != nil {
+		a.previewCancel()
+		a.previewCancel = nil
+	}
+}
+
 func (a *App) TrimTrack(path string, startMs int64, endMs int64, mode string) (*TrimResult, error) {
+	path = a.trackPath(path)
 	backup, err := a.media.TrimTrack(a.ctx, path, startMs, endMs, mode)
 	if err != nil {
 		return nil, err
@@ -682,8 +4744,303 @@ func (a *App) TrimTrack(path string, startMs int64, endMs int64, mode string) (*
 	}, nil
 }
 
+func (a *App) SplitMix(req SplitMixRequest) (*SplitMixResult, error) {
+	return a.splitMix(a.ctx, req)
+}
+
+func (a *App) splitMix(ctx context.Context, req SplitMixRequest) (*SplitMixResult, error) {
+	path := strings.TrimSpace(req.Path)
+	if path == "" {
+		return nil, apperror.Invalid("track path is required")
+	}
+
+	var segments []media.Segment
+	if strings.TrimSpace(req.Tracklist) != "" && !req.UseSilence {
+		parsed, err := media.ParseTracklist(req.Tracklist)
+		if err != nil {
+			return nil, err
+		}
+		segments = parsed
+	} else {
+		gaps, durationMs, err := a.media.DetectSilence(ctx, path, req.ThresholdDb, req.MinSilenceMs)
+		if err != nil {
+			return nil, err
+		}
+		segments = media.SegmentsFromSilence(gaps, durationMs)
+	}
+	return a.splitIntoAlbum(ctx, path, req.OutputDir, req.Album, req.AlbumArtist, segments)
+}
+
+func (a *App) StartSplitMix(req SplitMixRequest) ops.Operation {
+	return a.ops.Start(a.ctx, "split", "Splitting mix", func(ctx context.Context, r *ops.Reporter) (interface{}, error) {
+		return a.splitMix(ctx, req)
+	})
+}
+
+func (a *App) PreviewVinylSplit(req VinylSplitRequest) (*media.SplitPreview, error) {
+	path := strings.TrimSpace(req.Path)
+	if path == "" {
+		return nil, apperror.Invalid("track path is required")
+	}
+	return a.media.PreviewSilenceSplit(a.ctx, path, req.ThresholdDb, req.MinSilenceMs, req.SideGapMs)
+}
+
+func (a *App) ExportSplit(req ExportSplitRequest) (*SplitMixResult, error) {
+	path := strings.TrimSpace(req.Path)
+	if path == "" {
+		return nil, apperror.Invalid("track path is required")
+	}
+	segments := make([]media.Segment, 0, len(req.Segments))
+	for _, seg := range req.Segments {
+		if seg.EndMs > 0 && seg.EndMs <= seg.StartMs {
+			return nil, apperror.Invalid(fmt.Sprintf("invalid segment %q: end must be after start", seg.Title))
+		}
+		segments = append(segments, seg)
+	}
+	sort.SliceStable(segments, func(i, j int) bool {
+		return segments[i].StartMs < segments[j].StartMs
+	})
+	return a.splitIntoAlbum(a.ctx, path, req.OutputDir, req.Album, req.AlbumArtist, segments)
+}
+
+func (a *App) splitIntoAlbum(ctx context.Context, path, outputDir, album, albumArtist string, segments []media.Segment) (*SplitMixResult, error) {
+	outputs, err := a.media.SplitTrack(ctx, path, outputDir, segments)
+	if err != nil && len(outputs) == 0 {
+		return nil, err
+	}
+
+	result := &SplitMixResult{
+		Tracks: make([]metadata.TrackMetadata, 0, len(outputs)),
+		Errors: make([]string, 0),
+	}
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	}
+
+	source, _ := metadata.LoadMetadata(path)
+	album = strings.TrimSpace(album)
+	if album == "" && source != nil {
+		album = source.Title
+	}
+	if strings.TrimSpace(albumArtist) == "" && source != nil {
+		albumArtist = source.Artist
+	}
+
+	paths := make([]string, 0, len(outputs))
+	for _, o := range outputs {
+		paths = append(paths, o.Path)
+	}
+	if _, err := a.library.AddFiles(paths); err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	}
+
+	for _, o := range outputs {
+		md, err := metadata.LoadMetadata(o.Path)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", o.Path, err))
+			continue
+		}
+		if t := strings.TrimSpace(o.Segment.Title); t != "" {
+			md.Title = t
+		}
+		if ar := strings.TrimSpace(o.Segment.Artist); ar != "" {
+			md.Artist = ar
+		} else if albumArtist != "" {
+			md.Artist = albumArtist
+		}
+		md.Album = album
+		md.AlbumArtist = albumArtist
+		md.TrackNumber = o.Index
+		md.DiscNumber = 1
+		if source != nil {
+			md.Genre = firstNonEmptyString(md.Genre, source.Genre)
+			if md.Year == 0 {
+				md.Year = source.Year
+			}
+			if !md.HasCover && source.HasCover {
+				md.CoverImage = source.CoverImage
+				md.HasCover = true
+			}
+		}
+		updated, err := a.library.UpdateAndReload(*md)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", o.Path, err))
+			continue
+		}
+		result.Tracks = append(result.Tracks, updated)
+	}
+	return result, nil
+}
+
+func (a *App) StemSeparationAvailable() bool {
+	_, err := a.media.StemToolAvailable()
+	return err == nil
+}
+
+func (a *App) SeparateStems(path, outputDir, model string) (ops.Operation, error) {
+	if _, err := a.media.StemToolAvailable(); err != nil {
+		return ops.Operation{}, apperror.Wrap(err, apperror.CodeDependency, "stem separation requires demucs (pip install demucs)")
+	}
+	title := "Separating stems of " + filepath.Base(path)
+	op := a.ops.Start(a.ctx, "stems", title, func(ctx context.Context, r *ops.Reporter) (interface{}, error) {
+		finished := make(chan media.StemJob, 1)
+		_, err := a.media.StartStemSeparation(ctx, path, outputDir, model, func(job media.StemJob) {
+			if job.Status == media.StemStatusRunning {
+				r.Fraction(job.Progress, "")
+				a.emit(events.StemsProgress, job)
+				return
+			}
+			finished <- job
+		})
+		if err != nil {
+			return nil, err
+		}
+		job := <-finished
+		switch job.Status {
+		case media.StemStatusCompleted:
+			r.Note("Importing stems")
+			a.importStems(job)
+			a.emit(events.StemsDone, job)
+			return job, nil
+		case media.StemStatusCancelled:
+			a.emit(events.StemsDone, job)
+			return job, context.Canceled
+		default:
+			a.emit(events.StemsDone, job)
+			return job, errors.New(job.Error)
+		}
+	})
+	return op, nil
+}
+
+func (a *App) CancelStemSeparation(id string) error {
+	return a.media.CancelStemJob(id)
+}
+
+func (a *App) ListStemJobs() []media.StemJob {
+	return a.media.ListStemJobs()
+}
+
+func (a *App) importStems(job media.StemJob) {
+	if _, err := a.library.AddFiles(job.Outputs); err != nil {
+		log.Printf("[app] stems import failed: %v", err)
+		return
+	}
+	source, _ := metadata.LoadMetadata(job.Path)
+	labels := map[string]string{job.Instrumental: "Instrumental", job.Vocals: "Vocals"}
+	for path, label := range labels {
+		md, err := metadata.LoadMetadata(path)
+		if err != nil {
+			continue
+		}
+		if source != nil && strings.TrimSpace(source.Title) != "" {
+			md.Title = fmt.Sprintf("%s (%s)", strings.TrimSpace(source.Title), label)
+			md.Artist = source.Artist
+			md.Album = source.Album
+			md.AlbumArtist = source.AlbumArtist
+			md.Genre = source.Genre
+			md.Year = source.Year
+			if source.HasCover {
+				md.CoverImage = source.CoverImage
+				md.HasCover = true
+			}
+		}
+		if _, err := a.library.UpdateAndReload(*md); err != nil {
+			log.Printf("[app] stems tagging failed for %s: %v", filepath.Base(path), err)
+		}
+	}
+}
+
+func (a *App) ListRecordingDevices() ([]media.InputDevice, error) {
+	return a.media.ListInputDevices(a.ctx)
+}
+
+type recordingResult struct {
+	track *metadata.TrackMetadata
+	err   error
+}
+
+func (a *App) StartRecording(device, format, outputDir string) (*media.Recording, error) {
+	rec, err := a.media.StartRecording(a.ctx, device, format, outputDir)
+	if err != nil {
+		return nil, err
+	}
+	done := make(chan recordingResult, 1)
+	a.mu.Lock()
+	a.recordDone = done
+	a.mu.Unlock()
+	a.ops.Start(a.ctx, "record", "Recording from "+rec.Device, func(ctx context.Context, r *ops.Reporter) (interface{}, error) {
+		r.Note(filepath.Base(rec.Path))
+		select {
+		case res := <-done:
+			return res.track, res.err
+		case <-ctx.Done():
+			if _, err := a.StopRecording(); err != nil {
+				log.Printf("[app] stop cancelled recording failed: %v", err)
+			}
+			return nil, ctx.Err()
+		}
+	})
+	a.emit(events.RecordingStarted, rec)
+	return rec, nil
+}
+
+func (a *App) ActiveRecording() *media.Recording {
+	return a.media.ActiveRecording()
+}
+
+func (a *App) StopRecording() (*metadata.TrackMetadata, error) {
+	md, err := a.stopRecording()
+	a.mu.Lock()
+	done := a.recordDone
+	a.recordDone = nil
+	a.mu.Unlock()
+	if done != nil {
+		done <- recordingResult{track: md, err: err}
+	}
+	return md, err
+}
+
+func (a *App) stopRecording() (*metadata.TrackMetadata, error) {
+	rec, err := a.media.StopRecording()
+	if err != nil {
+		a.emit(events.RecordingStopped, nil)
+		return nil, err
+	}
+	a.emit(events.RecordingStopped, rec)
+
+	if _, err := a.library.AddFiles([]string{rec.Path}); err != nil {
+		return nil, err
+	}
+	md, err := metadata.LoadMetadata(rec.Path)
+	if err != nil {
+		return nil, err
+	}
+	started := time.Unix(rec.StartedAt, 0)
+	md.Title = "Recording " + started.Format("2006-01-02 15:04")
+	md.Artist = firstNonEmptyString(md.Artist, "Kitty Recorder")
+	md.Album = firstNonEmptyString(md.Album, "Recordings")
+	md.Genre = firstNonEmptyString(md.Genre, "Recording")
+	md.Year = started.Year()
+	md.Comment = fmt.Sprintf("Recorded from %s on %s", rec.Device, started.Format(time.RFC1123))
+	updated, err := a.library.UpdateAndReload(*md)
+	if err != nil {
+		return md, err
+	}
+	return &updated, nil
+}
+
+func firstNonEmptyString(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
+
 func (a *App) ListTrimBackups(path string) ([]media.TrimBackup, error) {
-	return a.media.ListBackups(path)
+	return a.media.ListBackups(a.trackPath(path))
 }
 
 func (a *App) RestoreTrimBackup(backupID string) (*TrimResult, error) {
@@ -0,0 +1,218 @@
+package access
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrUnsupported          = errors.New("privacy settings are only available on macOS")
+	ErrBookmarksUnsupported = errors.New("security-scoped bookmarks are not supported on this platform")
+)
+
+type FolderStatus struct {
+	Path       string `json:"path"`
+	Exists     bool   `json:"exists"`
+	Readable   bool   `json:"readable"`
+	Protected  bool   `json:"protected"`
+	Bookmarked bool   `json:"bookmarked"`
+	Error      string `json:"error,omitempty"`
+}
+
+func IsPermissionError(err error) bool {
+	return err != nil && errors.Is(err, fs.ErrPermission)
+}
+
+func ProtectedFolders() []string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return []string{}
+	}
+	out := make([]string, 0, len(protectedDirs))
+	for _, name := range protectedDirs {
+		out = append(out, filepath.Join(home, name))
+	}
+	return out
+}
+
+func IsProtected(path string) bool {
+	path = filepath.Clean(path)
+	for _, dir := range ProtectedFolders() {
+		if path == dir || strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+func CheckFolder(path string) FolderStatus {
+	path = filepath.Clean(path)
+	st := FolderStatus{Path: path, Protected: IsProtected(path)}
+	info, err := os.Stat(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			st.Exists = true
+			st.Error = err.Error()
+		}
+		return st
+	}
+	st.Exists = true
+	if !info.IsDir() {
+		f, err := os.Open(path)
+		if err != nil {
+			st.Error = err.Error()
+			return st
+		}
+		f.Close()
+		st.Readable = true
+		return st
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		st.Error = err.Error()
+		return st
+	}
+	defer f.Close()
+	if _, err := f.Readdirnames(1); err != nil && !errors.Is(err, io.EOF) {
+		st.Error = err.Error()
+		return st
+	}
+	st.Readable = true
+	return st
+}
+
+type Bookmarks struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]string
+	loaded  bool
+}
+
+func NewBookmarks() *Bookmarks {
+	return &Bookmarks{path: bookmarksPath(), entries: map[string]string{}}
+}
+
+func bookmarksPath() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil || configDir == "" {
+		return "kitty_bookmarks.json"
+	}
+	return filepath.Join(configDir, "Kitty", "bookmarks.json")
+}
+
+func (b *Bookmarks) Add(path string) error {
+	path = filepath.Clean(path)
+	data, err := createBookmark(path)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.loadLocked()
+	b.entries[path] = base64.StdEncoding.EncodeToString(data)
+	return b.saveLocked()
+}
+
+func (b *Bookmarks) Has(path string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.loadLocked()
+	_, ok := b.entries[filepath.Clean(path)]
+	return ok
+}
+
+func (b *Bookmarks) Paths() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.loadLocked()
+	out := make([]string, 0, len(b.entries))
+	for p := range b.entries {
+		out = append(out, p)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (b *Bookmarks) Remove(path string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.loadLocked()
+	delete(b.entries, filepath.Clean(path))
+	return b.saveLocked()
+}
+
+func (b *Bookmarks) Restore() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.loadLocked()
+	restored := make([]string, 0, len(b.entries))
+	changed := false
+	for path, encoded := range b.entries {
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			delete(b.entries, path)
+			changed = true
+			continue
+		}
+		resolved, stale, err := resolveBookmark(data)
+		if err != nil {
+			log.Printf("[access] restore bookmark %s: %v", path, err)
+			continue
+		}
+		if stale || resolved != path {
+			if fresh, err := createBookmark(resolved); err == nil {
+				delete(b.entries, path)
+				b.entries[resolved] = base64.StdEncoding.EncodeToString(fresh)
+				changed = true
+			}
+		}
+		restored = append(restored, resolved)
+	}
+	if changed {
+		if err := b.saveLocked(); err != nil {
+			log.Printf("[access] save bookmarks failed: %v", err)
+		}
+	}
+	sort.Strings(restored)
+	return restored
+}
+
+func (b *Bookmarks) loadLocked() {
+	if b.loaded {
+		return
+	}
+	b.loaded = true
+	data, err := os.ReadFile(b.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("[access] read bookmarks failed: %v", err)
+		}
+		return
+	}
+	if err := json.Unmarshal(data, &b.entries); err != nil {
+		log.Printf("[access] decode bookmarks failed: %v", err)
+	}
+	if b.entries == nil {
+		b.entries = map[string]string{}
+	}
+}
+
+func (b *Bookmarks) saveLocked() error {
+	data, err := json.Marshal(b.entries)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(b.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(b.path, data, 0o600)
+}
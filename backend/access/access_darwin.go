@@ -0,0 +1,24 @@
+package access
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const privacySettingsURL = "x-apple.systempreferences:com.apple.preference.security?Privacy_FilesAndFolders"
+
+var protectedDirs = []string{"Desktop", "Documents", "Downloads", "Music"}
+
+func OpenSettings(ctx context.Context) error {
+	out, err := exec.CommandContext(ctx, "open", privacySettingsURL).CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return fmt.Errorf("open privacy settings failed: %s", msg)
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+//go:build !darwin
+
+package access
+
+import "context"
+
+var protectedDirs = []string{}
+
+func OpenSettings(ctx context.Context) error {
+	return ErrUnsupported
+}
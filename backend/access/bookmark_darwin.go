@@ -0,0 +1,90 @@
+//go:build darwin && cgo
+
+package access
+
+/*
+#cgo CFLAGS: -x objective-c
+#cgo LDFLAGS: -framework Foundation
+#import <Foundation/Foundation.h>
+#include <stdlib.h>
+#include <string.h>
+
+static char *kittyErrorString(NSError *error) {
+	NSString *msg = error != nil ? [error localizedDescription] : @"unknown error";
+	return strdup([msg UTF8String]);
+}
+
+static void *kittyCreateBookmark(const char *path, int *length, char **errOut) {
+	@autoreleasepool {
+		NSURL *url = [NSURL fileURLWithPath:[NSString stringWithUTF8String:path]];
+		NSError *error = nil;
+		NSData *data = [url bookmarkDataWithOptions:NSURLBookmarkCreationWithSecurityScope
+			includingResourceValuesForKeys:nil
+			relativeToURL:nil
+			error:&error];
+		if (data == nil) {
+			*errOut = kittyErrorString(error);
+			return NULL;
+		}
+		*length = (int)[data length];
+		void *buf = malloc(*length);
+		memcpy(buf, [data bytes], *length);
+		return buf;
+	}
+}
+
+static char *kittyResolveBookmark(const void *bytes, int length, int *stale, char **errOut) {
+	@autoreleasepool {
+		NSData *data = [NSData dataWithBytes:bytes length:length];
+		BOOL isStale = NO;
+		NSError *error = nil;
+		NSURL *url = [NSURL URLByResolvingBookmarkData:data
+			options:NSURLBookmarkResolutionWithSecurityScope
+			relativeToURL:nil
+			bookmarkDataIsStale:&isStale
+			error:&error];
+		if (url == nil) {
+			*errOut = kittyErrorString(error);
+			return NULL;
+		}
+		[url startAccessingSecurityScopedResource];
+		*stale = isStale ? 1 : 0;
+		return strdup([[url path] UTF8String]);
+	}
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"unsafe"
+)
+
+func createBookmark(path string) ([]byte, error) {
+	cpath := C.CString(path)
+	defer C.free(unsafe.Pointer(cpath))
+	var length C.int
+	var cerr *C.char
+	buf := C.kittyCreateBookmark(cpath, &length, &cerr)
+	if buf == nil {
+		defer C.free(unsafe.Pointer(cerr))
+		return nil, errors.New(C.GoString(cerr))
+	}
+	defer C.free(buf)
+	return C.GoBytes(buf, length), nil
+}
+
+func resolveBookmark(data []byte) (string, bool, error) {
+	if len(data) == 0 {
+		return "", false, errors.New("empty bookmark")
+	}
+	var stale C.int
+	var cerr *C.char
+	cpath := C.kittyResolveBookmark(unsafe.Pointer(&data[0]), C.int(len(data)), &stale, &cerr)
+	if cpath == nil {
+		defer C.free(unsafe.Pointer(cerr))
+		return "", false, errors.New(C.GoString(cerr))
+	}
+	defer C.free(unsafe.Pointer(cpath))
+	return C.GoString(cpath), stale != 0, nil
+}
@@ -0,0 +1,11 @@
+//go:build !darwin || !cgo
+
+package access
+
+func createBookmark(path string) ([]byte, error) {
+	return nil, ErrBookmarksUnsupported
+}
+
+func resolveBookmark(data []byte) (string, bool, error) {
+	return "", false, ErrBookmarksUnsupported
+}
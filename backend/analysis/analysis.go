@@ -14,8 +14,9 @@ import (
 )
 
 type AudioProperties struct {
-	Bitrate    int `json:"bitrate"`
-	SampleRate int `json:"sampleRate"`
+	Bitrate    int     `json:"bitrate"`
+	SampleRate int     `json:"sampleRate"`
+	Duration   float64 `json:"duration"`
 }
 
 func GetAudioProperties(path string) (AudioProperties, error) {
@@ -76,6 +77,7 @@ func mp3Props(path string) (AudioProperties, error) {
 		return AudioProperties{
 			Bitrate:    bestBitrate,
 			SampleRate: sampleRate,
+			Duration:   totalDur.Seconds(),
 		}, nil
 	}
 
@@ -87,6 +89,7 @@ func mp3Props(path string) (AudioProperties, error) {
 				return AudioProperties{
 					Bitrate:    br,
 					SampleRate: sampleRate,
+					Duration:   seconds,
 				}, nil
 			}
 		}
@@ -127,12 +130,13 @@ func decodeProps(path string, decoder func(io.ReadCloser) (beep.StreamSeekCloser
 
 	fi, err := os.Stat(path)
 	if err != nil {
-		return AudioProperties{SampleRate: int(format.SampleRate)}, nil
+		return AudioProperties{SampleRate: int(format.SampleRate), Duration: duration}, nil
 	}
 
 	bitrate := int((float64(fi.Size()*8) / duration) / 1000)
 	return AudioProperties{
 		Bitrate:    bitrate,
 		SampleRate: int(format.SampleRate),
+		Duration:   duration,
 	}, nil
 }
@@ -0,0 +1,298 @@
+package analysis
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"kitty/backend/decode"
+)
+
+const (
+	DefaultLoudnessTarget = -14.0
+
+	absoluteGate   = -70.0
+	relativeGate   = -10.0
+	histogramScale = 10.0
+)
+
+var ErrLoudnessUnsupported = errors.New("loudness analysis is not supported for this format")
+
+type Loudness struct {
+	Integrated float64     `json:"integrated"`
+	Peak       float64     `json:"peak"`
+	Duration   float64     `json:"duration"`
+	AnalyzedAt int64       `json:"analyzedAt"`
+	Histogram  map[int]int `json:"histogram,omitempty"`
+}
+
+type loudnessEntry struct {
+	Stamp    string   `json:"stamp"`
+	Loudness Loudness `json:"loudness"`
+}
+
+var (
+	loudnessMu    sync.Mutex
+	loudnessCache map[string]loudnessEntry
+)
+
+func MeasureLoudness(ctx context.Context, path string) (Loudness, error) {
+	if !decode.CanDecode(path) {
+		return Loudness{}, ErrLoudnessUnsupported
+	}
+	streamer, format, err := decode.Open(path)
+	if err != nil {
+		return Loudness{}, err
+	}
+	defer streamer.Close()
+
+	m := newLoudnessMeter(float64(format.SampleRate), format.NumChannels)
+	buf := make([][2]float64, 8192)
+	for {
+		if err := ctx.Err(); err != nil {
+			return Loudness{}, err
+		}
+		n, ok := streamer.Stream(buf)
+		m.add(buf[:n])
+		if !ok {
+			break
+		}
+	}
+	if err := streamer.Err(); err != nil {
+		return Loudness{}, err
+	}
+	hist := m.histogram()
+	integrated := integratedLoudness(hist)
+	if math.IsInf(integrated, -1) {
+		return Loudness{}, errors.New("track is too quiet or too short to measure")
+	}
+	return Loudness{
+		Integrated: integrated,
+		Peak:       m.peak,
+		Histogram:  hist,
+		Duration:   float64(m.samples) / float64(format.SampleRate),
+		AnalyzedAt: time.Now().Unix(),
+	}, nil
+}
+
+func CachedLoudness(path string) (Loudness, bool) {
+	stamp, err := fileStamp(path)
+	if err != nil {
+		return Loudness{}, false
+	}
+	loudnessMu.Lock()
+	defer loudnessMu.Unlock()
+	loadLoudnessLocked()
+	e, ok := loudnessCache[path]
+	if !ok || e.Stamp != stamp || len(e.Loudness.Histogram) == 0 {
+		return Loudness{}, false
+	}
+	return e.Loudness, true
+}
+
+func AnalyzeLoudness(ctx context.Context, path string) (Loudness, error) {
+	if l, ok := CachedLoudness(path); ok {
+		return l, nil
+	}
+	stamp, err := fileStamp(path)
+	if err != nil {
+		return Loudness{}, err
+	}
+	l, err := MeasureLoudness(ctx, path)
+	if err != nil {
+		return Loudness{}, err
+	}
+	loudnessMu.Lock()
+	defer loudnessMu.Unlock()
+	loadLoudnessLocked()
+	loudnessCache[path] = loudnessEntry{Stamp: stamp, Loudness: l}
+	if err := saveLoudnessLocked(); err != nil {
+		return l, fmt.Errorf("save loudness cache: %w", err)
+	}
+	return l, nil
+}
+
+func AlbumLoudness(tracks []Loudness) (Loudness, bool) {
+	album := Loudness{Histogram: map[int]int{}, AnalyzedAt: time.Now().Unix()}
+	for _, t := range tracks {
+		if len(t.Histogram) == 0 {
+			return Loudness{}, false
+		}
+		for bin, count := range t.Histogram {
+			album.Histogram[bin] += count
+		}
+		album.Peak = math.Max(album.Peak, t.Peak)
+		album.Duration += t.Duration
+	}
+	album.Integrated = integratedLoudness(album.Histogram)
+	if math.IsInf(album.Integrated, -1) {
+		return Loudness{}, false
+	}
+	return album, true
+}
+
+func fileStamp(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha1.Sum([]byte(fmt.Sprintf("%d|%d", info.Size(), info.ModTime().UnixNano())))
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+func loudnessCachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil || dir == "" {
+		return filepath.Join(os.TempDir(), "kitty-loudness.json")
+	}
+	return filepath.Join(dir, "Kitty", "loudness.json")
+}
+
+func loadLoudnessLocked() {
+	if loudnessCache != nil {
+		return
+	}
+	loudnessCache = map[string]loudnessEntry{}
+	data, err := os.ReadFile(loudnessCachePath())
+	if err != nil {
+		return
+	}
+	_ = json.Unmarshal(data, &loudnessCache)
+}
+
+func saveLoudnessLocked() error {
+	data, err := json.Marshal(loudnessCache)
+	if err != nil {
+		return err
+	}
+	path := loudnessCachePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+type kFilter struct {
+	b0, b1, b2, a1, a2 float64
+	z1, z2             [2]float64
+}
+
+func (f *kFilter) process(x float64, ch int) float64 {
+	y := f.b0*x + f.z1[ch]
+	f.z1[ch] = f.b1*x - f.a1*y + f.z2[ch]
+	f.z2[ch] = f.b2*x - f.a2*y
+	return y
+}
+
+type loudnessMeter struct {
+	shelf, highpass kFilter
+	channels        int
+	step            int
+	pos             int
+	energy          float64
+	steps           []float64
+	peak            float64
+	samples         int64
+}
+
+func newLoudnessMeter(sr float64, channels int) *loudnessMeter {
+	m := &loudnessMeter{channels: channels, step: int(sr / 10)}
+	if m.channels < 1 || m.channels > 2 {
+		m.channels = 2
+	}
+	if m.step < 1 {
+		m.step = 1
+	}
+
+	k := math.Tan(math.Pi * 1681.974450955533 / sr)
+	vh := math.Pow(10, 3.999843853973347/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	q := 0.7071752369554196
+	a0 := 1 + k/q + k*k
+	m.shelf = kFilter{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	k = math.Tan(math.Pi * 38.13547087602444 / sr)
+	q = 0.5003270373238773
+	a0 = 1 + k/q + k*k
+	m.highpass = kFilter{b0: 1, b1: -2, b2: 1, a1: 2 * (k*k - 1) / a0, a2: (1 - k/q + k*k) / a0}
+	return m
+}
+
+func (m *loudnessMeter) add(samples [][2]float64) {
+	for _, s := range samples {
+		for ch := 0; ch < m.channels; ch++ {
+			x := s[ch]
+			if a := math.Abs(x); a > m.peak {
+				m.peak = a
+			}
+			y := m.highpass.process(m.shelf.process(x, ch), ch)
+			m.energy += y * y
+		}
+		m.samples++
+		m.pos++
+		if m.pos == m.step {
+			m.steps = append(m.steps, m.energy/float64(m.step))
+			m.pos, m.energy = 0, 0
+		}
+	}
+}
+
+func (m *loudnessMeter) histogram() map[int]int {
+	hist := map[int]int{}
+	for i := 0; i+4 <= len(m.steps); i++ {
+		e := (m.steps[i] + m.steps[i+1] + m.steps[i+2] + m.steps[i+3]) / 4
+		if l := energyToLUFS(e); l > absoluteGate {
+			hist[int(math.Round(l*histogramScale))]++
+		}
+	}
+	return hist
+}
+
+func integratedLoudness(hist map[int]int) float64 {
+	var sum float64
+	var n int
+	for bin, count := range hist {
+		sum += lufsToEnergy(float64(bin)/histogramScale) * float64(count)
+		n += count
+	}
+	if n == 0 {
+		return math.Inf(-1)
+	}
+	gate := energyToLUFS(sum/float64(n)) + relativeGate
+	sum, n = 0, 0
+	for bin, count := range hist {
+		if l := float64(bin) / histogramScale; l > gate {
+			sum += lufsToEnergy(l) * float64(count)
+			n += count
+		}
+	}
+	if n == 0 {
+		return math.Inf(-1)
+	}
+	return energyToLUFS(sum / float64(n))
+}
+
+func lufsToEnergy(l float64) float64 {
+	return math.Pow(10, (l+0.691)/10)
+}
+
+func energyToLUFS(e float64) float64 {
+	if e <= 0 {
+		return math.Inf(-1)
+	}
+	return -0.691 + 10*math.Log10(e)
+}
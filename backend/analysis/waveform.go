@@ -0,0 +1,132 @@
+package analysis
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+
+	"kitty/backend/decode"
+)
+
+const (
+	DefaultWaveformBuckets = 1000
+	MaxWaveformBuckets     = 10000
+)
+
+var ErrWaveformUnsupported = errors.New("waveform generation is not supported for this format")
+
+type Waveform struct {
+	Buckets  int       `json:"buckets"`
+	Duration float64   `json:"duration"`
+	Min      []float64 `json:"min"`
+	Max      []float64 `json:"max"`
+}
+
+func GenerateWaveform(path string, buckets int) (*Waveform, error) {
+	if buckets <= 0 {
+		buckets = DefaultWaveformBuckets
+	}
+	if buckets > MaxWaveformBuckets {
+		return nil, fmt.Errorf("waveform buckets must be at most %d", MaxWaveformBuckets)
+	}
+	if !decode.CanDecode(path) {
+		return nil, ErrWaveformUnsupported
+	}
+	hash, err := contentHash(path)
+	if err != nil {
+		return nil, err
+	}
+	cachePath := filepath.Join(waveformCacheDir(), fmt.Sprintf("%s-%d.json", hash, buckets))
+	if data, err := os.ReadFile(cachePath); err == nil {
+		var w Waveform
+		if err := json.Unmarshal(data, &w); err == nil && len(w.Min) == buckets && len(w.Max) == buckets {
+			return &w, nil
+		}
+		log.Printf("[analysis] waveform cache for %s invalid, regenerating", filepath.Base(path))
+	}
+
+	streamer, format, err := decode.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer streamer.Close()
+
+	total := streamer.Len()
+	if total <= 0 || format.SampleRate <= 0 {
+		return nil, errors.New("track has no decodable samples")
+	}
+	per := int(math.Ceil(float64(total) / float64(buckets)))
+	w := &Waveform{
+		Buckets:  buckets,
+		Duration: float64(total) / float64(format.SampleRate),
+		Min:      make([]float64, buckets),
+		Max:      make([]float64, buckets),
+	}
+	buf := make([][2]float64, 8192)
+	pos := 0
+	for {
+		n, ok := streamer.Stream(buf)
+		for _, s := range buf[:n] {
+			b := pos / per
+			if b >= buckets {
+				b = buckets - 1
+			}
+			for _, v := range s {
+				w.Min[b] = math.Min(w.Min[b], v)
+				w.Max[b] = math.Max(w.Max[b], v)
+			}
+			pos++
+		}
+		if !ok {
+			break
+		}
+	}
+	if err := streamer.Err(); err != nil {
+		return nil, err
+	}
+
+	if data, err := json.Marshal(w); err == nil {
+		if err := os.MkdirAll(filepath.Dir(cachePath), 0o755); err == nil {
+			err = os.WriteFile(cachePath, data, 0o644)
+		}
+		if err != nil {
+			log.Printf("[analysis] save waveform cache failed: %v", err)
+		}
+	}
+	return w, nil
+}
+
+func ClearWaveformCache() error {
+	if err := os.RemoveAll(waveformCacheDir()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func contentHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func waveformCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil || dir == "" {
+		return filepath.Join(os.TempDir(), "kitty-waveforms")
+	}
+	return filepath.Join(dir, "Kitty", "waveforms")
+}
@@ -0,0 +1,141 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net"
+	"strings"
+)
+
+const (
+	CodeInvalidInput     = "invalid_input"
+	CodeNotFound         = "not_found"
+	CodePermissionDenied = "permission_denied"
+	CodeAlreadyExists    = "already_exists"
+	CodeUnsupported      = "unsupported"
+	CodeDependency       = "dependency_missing"
+	CodeNetwork          = "network"
+	CodeTimeout          = "timeout"
+	CodeCancelled        = "cancelled"
+	CodeUnauthorized     = "unauthorized"
+	CodeConflict         = "conflict"
+	CodeInternal         = "internal"
+)
+
+type AppError struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Details   string `json:"details,omitempty"`
+	Retryable bool   `json:"retryable"`
+
+	cause error
+}
+
+func (e *AppError) Error() string {
+	if e.Details != "" {
+		return e.Message + ": " + e.Details
+	}
+	return e.Message
+}
+
+func (e *AppError) Unwrap() error {
+	return e.cause
+}
+
+func New(code, message string) *AppError {
+	return &AppError{Code: code, Message: message, Retryable: retryable(code)}
+}
+
+func Wrap(err error, code, message string) error {
+	if err == nil {
+		return nil
+	}
+	return &AppError{
+		Code:      code,
+		Message:   message,
+		Details:   err.Error(),
+		Retryable: retryable(code),
+		cause:     err,
+	}
+}
+
+func Invalid(message string) *AppError {
+	return New(CodeInvalidInput, message)
+}
+
+func NotFound(message string) *AppError {
+	return New(CodeNotFound, message)
+}
+
+func From(err error) *AppError {
+	if err == nil {
+		return nil
+	}
+	var ae *AppError
+	if errors.As(err, &ae) {
+		return ae
+	}
+
+	code := classify(err)
+	return &AppError{
+		Code:      code,
+		Message:   err.Error(),
+		Retryable: retryable(code),
+		cause:     err,
+	}
+}
+
+func Format(err error) any {
+	return From(err)
+}
+
+func classify(err error) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return CodeCancelled
+	case errors.Is(err, context.DeadlineExceeded):
+		return CodeTimeout
+	case errors.Is(err, fs.ErrNotExist):
+		return CodeNotFound
+	case errors.Is(err, fs.ErrPermission):
+		return CodePermissionDenied
+	case errors.Is(err, fs.ErrExist):
+		return CodeAlreadyExists
+	case errors.Is(err, fs.ErrInvalid):
+		return CodeUnsupported
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return CodeTimeout
+		}
+		return CodeNetwork
+	}
+
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "not found in path"), strings.Contains(msg, "is set but not executable"), strings.Contains(msg, "requires demucs"):
+		return CodeDependency
+	case strings.Contains(msg, "not found"):
+		return CodeNotFound
+	case strings.Contains(msg, "not connected"), strings.Contains(msg, "unauthorized"), strings.Contains(msg, "reconnect"):
+		return CodeUnauthorized
+	case strings.Contains(msg, "already in progress"), strings.Contains(msg, "already running"):
+		return CodeConflict
+	case strings.Contains(msg, "is required"), strings.Contains(msg, "is empty"), strings.Contains(msg, "invalid"), strings.Contains(msg, "unsupported"), strings.Contains(msg, "unknown"):
+		return CodeInvalidInput
+	case strings.Contains(msg, "connection refused"), strings.Contains(msg, "no such host"), strings.Contains(msg, "429"), strings.Contains(msg, "rate limit"):
+		return CodeNetwork
+	}
+	return CodeInternal
+}
+
+func retryable(code string) bool {
+	switch code {
+	case CodeNetwork, CodeTimeout, CodeConflict:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,218 @@
+package artwork
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	maxSize      = 2048
+	paletteSize  = 5
+	sampleTarget = 96
+)
+
+type Image struct {
+	Size    int    `json:"size"`
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+	DataURL string `json:"dataUrl"`
+}
+
+type Swatch struct {
+	Hex        string  `json:"hex"`
+	Population float64 `json:"population"`
+	Luminance  float64 `json:"luminance"`
+}
+
+func DecodeDataURL(dataURL string) (image.Image, error) {
+	idx := strings.Index(dataURL, ";base64,")
+	if !strings.HasPrefix(dataURL, "data:") || idx < 0 {
+		return nil, errors.New("cover is not a base64 data url")
+	}
+	raw, err := base64.StdEncoding.DecodeString(dataURL[idx+len(";base64,"):])
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("decode cover: %w", err)
+	}
+	return img, nil
+}
+
+func Resize(src image.Image, size int) (image.Image, error) {
+	if size <= 0 || size > maxSize {
+		return nil, fmt.Errorf("invalid art size: %d", size)
+	}
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	if sw == 0 || sh == 0 {
+		return nil, errors.New("cover is empty")
+	}
+	dw, dh := size, size
+	if sw > sh {
+		dh = int(math.Max(1, math.Round(float64(size)*float64(sh)/float64(sw))))
+	} else if sh > sw {
+		dw = int(math.Max(1, math.Round(float64(size)*float64(sw)/float64(sh))))
+	}
+	if dw >= sw && dh >= sh {
+		return src, nil
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	xRatio := float64(sw) / float64(dw)
+	yRatio := float64(sh) / float64(dh)
+	for y := 0; y < dh; y++ {
+		y0 := b.Min.Y + int(float64(y)*yRatio)
+		y1 := b.Min.Y + int(math.Ceil(float64(y+1)*yRatio))
+		if y1 > b.Max.Y {
+			y1 = b.Max.Y
+		}
+		for x := 0; x < dw; x++ {
+			x0 := b.Min.X + int(float64(x)*xRatio)
+			x1 := b.Min.X + int(math.Ceil(float64(x+1)*xRatio))
+			if x1 > b.Max.X {
+				x1 = b.Max.X
+			}
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					bl += uint64(cb)
+					a += uint64(ca)
+					n++
+				}
+			}
+			if n == 0 {
+				continue
+			}
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(bl / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+	return dst, nil
+}
+
+func EncodeJPEG(img image.Image, size int) (Image, error) {
+	data, err := encodeJPEG(img)
+	if err != nil {
+		return Image{}, err
+	}
+	b := img.Bounds()
+	return Image{
+		Size:    size,
+		Width:   b.Dx(),
+		Height:  b.Dy(),
+		DataURL: "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(data),
+	}, nil
+}
+
+func encodeJPEG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 88}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func Palette(img image.Image) []Swatch {
+	small, err := Resize(img, sampleTarget)
+	if err != nil {
+		small = img
+	}
+
+	type bucket struct {
+		r, g, b, n float64
+	}
+	buckets := make(map[uint32]*bucket)
+	var total float64
+	bounds := small.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			cr, cg, cb, ca := small.At(x, y).RGBA()
+			if ca < 0x8000 {
+				continue
+			}
+			r8, g8, b8 := cr>>8, cg>>8, cb>>8
+			key := (r8>>3)<<10 | (g8>>3)<<5 | (b8 >> 3)
+			bk := buckets[key]
+			if bk == nil {
+				bk = &bucket{}
+				buckets[key] = bk
+			}
+			bk.r += float64(r8)
+			bk.g += float64(g8)
+			bk.b += float64(b8)
+			bk.n++
+			total++
+		}
+	}
+	if total == 0 {
+		return []Swatch{}
+	}
+
+	ranked := make([]*bucket, 0, len(buckets))
+	for _, bk := range buckets {
+		ranked = append(ranked, bk)
+	}
+	sort.Slice(ranked, func(i, j int) bool { return ranked[i].n > ranked[j].n })
+
+	var picked []Swatch
+	var pickedRGB [][3]float64
+	for _, bk := range ranked {
+		rgb := [3]float64{bk.r / bk.n, bk.g / bk.n, bk.b / bk.n}
+		distinct := true
+		for i, p := range pickedRGB {
+			if colorDistance(rgb, p) < 48 {
+				picked[i].Population += bk.n / total
+				distinct = false
+				break
+			}
+		}
+		if !distinct {
+			continue
+		}
+		if len(picked) >= paletteSize {
+			continue
+		}
+		pickedRGB = append(pickedRGB, rgb)
+		picked = append(picked, Swatch{
+			Hex:        fmt.Sprintf("#%02x%02x%02x", uint8(rgb[0]), uint8(rgb[1]), uint8(rgb[2])),
+			Population: bk.n / total,
+			Luminance:  luminance(rgb),
+		})
+	}
+	sort.SliceStable(picked, func(i, j int) bool { return picked[i].Population > picked[j].Population })
+	return picked
+}
+
+func colorDistance(a, b [3]float64) float64 {
+	dr, dg, db := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+	return math.Sqrt(dr*dr + dg*dg + db*db)
+}
+
+func luminance(rgb [3]float64) float64 {
+	lin := func(c float64) float64 {
+		c /= 255
+		if c <= 0.03928 {
+			return c / 12.92
+		}
+		return math.Pow((c+0.055)/1.055, 2.4)
+	}
+	return 0.2126*lin(rgb[0]) + 0.7152*lin(rgb[1]) + 0.0722*lin(rgb[2])
+}
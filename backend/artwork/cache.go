@@ -0,0 +1,256 @@
+package artwork
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"kitty/backend/metadata"
+)
+
+const (
+	DefaultThumbnailSize = 256
+	cacheIndexName       = "index.json"
+)
+
+var ErrNoCover = errors.New("track has no cover art")
+
+type CoverLoader func(path string) (string, error)
+
+type CacheReport struct {
+	Checked int      `json:"checked"`
+	Rebuilt int      `json:"rebuilt"`
+	NoCover int      `json:"noCover"`
+	Errors  []string `json:"errors"`
+}
+
+type thumbEntry struct {
+	Stamp  string `json:"stamp"`
+	Hash   string `json:"hash"`
+	File   string `json:"file"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+type Cache struct {
+	mu    sync.Mutex
+	dir   string
+	index map[string]thumbEntry
+}
+
+func NewCache() *Cache {
+	return &Cache{dir: coverCacheDir()}
+}
+
+func coverCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil || dir == "" {
+		return filepath.Join(os.TempDir(), "kitty-covers")
+	}
+	return filepath.Join(dir, "Kitty", "covers")
+}
+
+func (c *Cache) Thumbnail(path string, size int, load CoverLoader) (Image, error) {
+	if size <= 0 {
+		size = DefaultThumbnailSize
+	}
+	stamp, err := sourceStamp(path)
+	if err != nil {
+		return Image{}, err
+	}
+	key := cacheKey(path, size)
+
+	c.mu.Lock()
+	c.loadLocked()
+	entry, ok := c.index[key]
+	c.mu.Unlock()
+	if ok && entry.Stamp == stamp {
+		img, err := c.read(entry, size)
+		if err == nil {
+			return img, nil
+		}
+		log.Printf("[artwork] cover cache entry for %s invalid, rebuilding: %v", filepath.Base(path), err)
+	}
+	return c.build(path, size, stamp, load)
+}
+
+func (c *Cache) read(entry thumbEntry, size int) (Image, error) {
+	data, err := os.ReadFile(filepath.Join(c.dir, entry.File))
+	if err != nil {
+		return Image{}, err
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != entry.Hash {
+		return Image{}, errors.New("checksum mismatch")
+	}
+	return Image{
+		Size:    size,
+		Width:   entry.Width,
+		Height:  entry.Height,
+		DataURL: "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(data),
+	}, nil
+}
+
+func (c *Cache) build(path string, size int, stamp string, load CoverLoader) (Image, error) {
+	dataURL, err := load(path)
+	if err != nil {
+		return Image{}, err
+	}
+	if dataURL == "" {
+		c.forget(path, size)
+		return Image{}, ErrNoCover
+	}
+	src, err := DecodeDataURL(dataURL)
+	if err != nil {
+		return Image{}, err
+	}
+	scaled, err := Resize(src, size)
+	if err != nil {
+		return Image{}, err
+	}
+	data, err := encodeJPEG(scaled)
+	if err != nil {
+		return Image{}, err
+	}
+
+	key := cacheKey(path, size)
+	sum := sha256.Sum256(data)
+	b := scaled.Bounds()
+	entry := thumbEntry{
+		Stamp:  stamp,
+		Hash:   hex.EncodeToString(sum[:]),
+		File:   key + ".jpg",
+		Width:  b.Dx(),
+		Height: b.Dy(),
+	}
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return Image{}, err
+	}
+	if err := os.WriteFile(filepath.Join(c.dir, entry.File), data, 0o644); err != nil {
+		return Image{}, err
+	}
+
+	c.mu.Lock()
+	c.loadLocked()
+	c.index[key] = entry
+	err = c.saveLocked()
+	c.mu.Unlock()
+	if err != nil {
+		log.Printf("[artwork] save cover cache index failed: %v", err)
+	}
+	return Image{
+		Size:    size,
+		Width:   entry.Width,
+		Height:  entry.Height,
+		DataURL: "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(data),
+	}, nil
+}
+
+func (c *Cache) forget(path string, size int) {
+	key := cacheKey(path, size)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loadLocked()
+	entry, ok := c.index[key]
+	if !ok {
+		return
+	}
+	delete(c.index, key)
+	_ = os.Remove(filepath.Join(c.dir, entry.File))
+	if err := c.saveLocked(); err != nil {
+		log.Printf("[artwork] save cover cache index failed: %v", err)
+	}
+}
+
+func (c *Cache) Rebuild(ctx context.Context, paths []string, load CoverLoader, progress func(done, total int, path string)) (*CacheReport, error) {
+	report := &CacheReport{Errors: []string{}}
+	if err := c.Clear(); err != nil {
+		return report, err
+	}
+	for i, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		if progress != nil {
+			progress(i, len(paths), path)
+		}
+		report.Checked++
+		stamp, err := sourceStamp(path)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", path, err))
+			continue
+		}
+		if _, err := c.build(path, DefaultThumbnailSize, stamp, load); err != nil {
+			if errors.Is(err, ErrNoCover) {
+				report.NoCover++
+				continue
+			}
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", path, err))
+			continue
+		}
+		report.Rebuilt++
+	}
+	if progress != nil {
+		progress(len(paths), len(paths), "")
+	}
+	log.Printf("[artwork] rebuilt covers checked=%d rebuilt=%d errors=%d", report.Checked, report.Rebuilt, len(report.Errors))
+	return report, nil
+}
+
+func (c *Cache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.index = map[string]thumbEntry{}
+	if err := os.RemoveAll(c.dir); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (c *Cache) loadLocked() {
+	if c.index != nil {
+		return
+	}
+	c.index = map[string]thumbEntry{}
+	data, err := os.ReadFile(filepath.Join(c.dir, cacheIndexName))
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &c.index); err != nil {
+		log.Printf("[artwork] cover cache index unreadable, starting fresh: %v", err)
+		c.index = map[string]thumbEntry{}
+	}
+}
+
+func (c *Cache) saveLocked() error {
+	data, err := json.Marshal(c.index)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(c.dir, cacheIndexName), data, 0o644)
+}
+
+func cacheKey(path string, size int) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s|%d", filepath.Clean(path), size)))
+	return hex.EncodeToString(sum[:12])
+}
+
+func sourceStamp(path string) (string, error) {
+	info, err := os.Stat(metadata.SourcePath(path))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d|%d", info.Size(), info.ModTime().UnixNano()), nil
+}
@@ -0,0 +1,199 @@
+package artwork
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strconv"
+	"strings"
+)
+
+const (
+	cardW      = 1200
+	cardH      = 630
+	cardMargin = 60
+	cardCover  = cardH - 2*cardMargin
+)
+
+type ShareCard struct {
+	Title  string
+	Artist string
+	Album  string
+	Cover  image.Image
+}
+
+func RenderShareCard(card ShareCard) (Image, error) {
+	bg := color.RGBA{0x1c, 0x1b, 0x22, 0xff}
+	if card.Cover != nil {
+		if sw := Palette(card.Cover); len(sw) > 0 {
+			if c, ok := parseHex(sw[0].Hex); ok {
+				bg = shade(c, 0.35)
+			}
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, cardW, cardH))
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{bg}, image.Point{}, draw.Src)
+
+	coverRect := image.Rect(cardMargin, cardMargin, cardMargin+cardCover, cardMargin+cardCover)
+	if card.Cover != nil {
+		scaled, err := Resize(card.Cover, cardCover)
+		if err != nil {
+			return Image{}, err
+		}
+		scaled = upscale(scaled, cardCover)
+		sb := scaled.Bounds()
+		at := coverRect.Min.Add(image.Pt((cardCover-sb.Dx())/2, (cardCover-sb.Dy())/2))
+		draw.Draw(dst, image.Rectangle{Min: at, Max: at.Add(sb.Size())}, scaled, sb.Min, draw.Src)
+	} else {
+		draw.Draw(dst, coverRect, &image.Uniform{shade(bg, 1.6)}, image.Point{}, draw.Src)
+		drawText(dst, "?", coverRect.Min.X+cardCover/2-30, coverRect.Min.Y+cardCover/2-42, 12, color.NRGBA{0xff, 0xff, 0xff, 0x60})
+	}
+
+	white := color.NRGBA{0xff, 0xff, 0xff, 0xff}
+	muted := color.NRGBA{0xd8, 0xd8, 0xe0, 0xff}
+	faint := color.NRGBA{0xff, 0xff, 0xff, 0x99}
+	x := coverRect.Max.X + cardMargin
+	width := cardW - x - cardMargin
+	y := cardMargin + 10
+
+	drawText(dst, "NOW LISTENING", x, y, 3, faint)
+	y += 3*glyphH + 40
+	for _, line := range wrapText(cardText(card.Title), width, 6, 3) {
+		drawText(dst, line, x, y, 6, white)
+		y += 6*glyphH + 18
+	}
+	y += 12
+	for _, line := range wrapText(cardText(card.Artist), width, 4, 2) {
+		drawText(dst, line, x, y, 4, muted)
+		y += 4*glyphH + 14
+	}
+	if album := cardText(card.Album); album != "" {
+		y += 8
+		for _, line := range wrapText(album, width, 3, 2) {
+			drawText(dst, line, x, y, 3, faint)
+			y += 3*glyphH + 10
+		}
+	}
+	brand := "KITTY"
+	drawText(dst, brand, cardW-cardMargin-textWidth(brand, 4), cardH-cardMargin-4*glyphH, 4, white)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dst); err != nil {
+		return Image{}, err
+	}
+	return Image{
+		Size:    cardW,
+		Width:   cardW,
+		Height:  cardH,
+		DataURL: "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}, nil
+}
+
+func upscale(src image.Image, size int) image.Image {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	if sw >= size || sh >= size {
+		return src
+	}
+	dw, dh := size, size
+	if sw > sh {
+		dh = size * sh / sw
+	} else if sh > sw {
+		dw = size * sw / sh
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			dst.Set(x, y, src.At(b.Min.X+x*sw/dw, b.Min.Y+y*sh/dh))
+		}
+	}
+	return dst
+}
+
+func drawText(dst *image.RGBA, s string, x, y, scale int, c color.Color) {
+	src := &image.Uniform{c}
+	for _, r := range s {
+		g := glyphs[r]
+		for row := 0; row < glyphH; row++ {
+			for col := 0; col < glyphW; col++ {
+				if g[row]&(1<<(glyphW-1-col)) == 0 {
+					continue
+				}
+				px := image.Rect(x+col*scale, y+row*scale, x+(col+1)*scale, y+(row+1)*scale)
+				draw.Draw(dst, px, src, image.Point{}, draw.Over)
+			}
+		}
+		x += (glyphW + 1) * scale
+	}
+}
+
+func textWidth(s string, scale int) int {
+	n := len([]rune(s))
+	if n == 0 {
+		return 0
+	}
+	return n*(glyphW+1)*scale - scale
+}
+
+func wrapText(s string, width, scale, maxLines int) []string {
+	if s == "" {
+		return nil
+	}
+	perLine := (width + scale) / ((glyphW + 1) * scale)
+	var lines []string
+	var cur string
+	for _, word := range strings.Fields(s) {
+		for len([]rune(word)) > perLine {
+			r := []rune(word)
+			if cur != "" {
+				lines = append(lines, cur)
+				cur = ""
+			}
+			lines = append(lines, string(r[:perLine]))
+			word = string(r[perLine:])
+		}
+		switch {
+		case cur == "":
+			cur = word
+		case len([]rune(cur))+1+len([]rune(word)) <= perLine:
+			cur += " " + word
+		default:
+			lines = append(lines, cur)
+			cur = word
+		}
+	}
+	if cur != "" {
+		lines = append(lines, cur)
+	}
+	if len(lines) > maxLines {
+		lines = lines[:maxLines]
+		last := []rune(lines[maxLines-1])
+		if len(last) > perLine-3 {
+			last = last[:perLine-3]
+		}
+		lines[maxLines-1] = strings.TrimSpace(string(last)) + "..."
+	}
+	return lines
+}
+
+func parseHex(hex string) (color.RGBA, bool) {
+	v, err := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32)
+	if err != nil || len(hex) != 7 {
+		return color.RGBA{}, false
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xff}, true
+}
+
+func shade(c color.RGBA, f float64) color.RGBA {
+	scale := func(v uint8) uint8 {
+		x := float64(v) * f
+		if x > 255 {
+			x = 255
+		}
+		return uint8(x)
+	}
+	return color.RGBA{scale(c.R), scale(c.G), scale(c.B), 0xff}
+}
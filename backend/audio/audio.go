@@ -1,19 +1,15 @@
 package audio
 
 import (
+	"fmt"
 	"log"
 	"math"
-	"os"
-	"strings"
 	"sync"
 	"time"
 
 	"github.com/gopxl/beep"
 	"github.com/gopxl/beep/effects"
-	"github.com/gopxl/beep/mp3"
 	"github.com/gopxl/beep/speaker"
-	"github.com/gopxl/beep/vorbis"
-	"github.com/gopxl/beep/wav"
 )
 
 type AudioPlayer struct {
@@ -21,42 +17,91 @@ type AudioPlayer struct {
 	streamer  beep.StreamSeekCloser
 	format    beep.Format
 	ctrl      *beep.Ctrl
+	fader     *fader
+	gain      *effects.Volume
 	volume    *effects.Volume
 	isPlaying bool
 	filePath  string
+
+	envelope *envelope
+	fades    trackFades
+
+	skipper     *silenceSkipper
+	skipSilence bool
+	skipMinGap  float64
+
+	compressor *compressor
+	nightMode  bool
+
+	channels    *channelMixer
+	monoEnabled bool
+	balance     float64
+
+	crossfeed      *crossfeed
+	crossfeedOn    bool
+	crossfeedLevel float64
+
+	eq      *equalizer
+	eqGains [EQBandCount]float64
+
+	userVolume float64
+	preampDb   float64
+	rgMode     string
+	rg         ReplayGain
+	normalize  bool
+	loudTarget float64
+	loudness   trackLoudness
+
+	albumContext bool
+
+	resample   string
+	fadeDur    time.Duration
+	bitPerfect bool
+
+	generation uint64
+	onFinished func(path string)
+
+	tee    *tee
+	meter  *meter
+	stream *streamOutput
+
+	preload *preloaded
+
+	spectrum *analyzer
 }
 
 func NewAudioPlayer() *AudioPlayer {
-	return &AudioPlayer{}
+	return &AudioPlayer{fadeDur: DefaultFadeDuration, spectrum: &analyzer{}}
 }
 
 func (ap *AudioPlayer) Load(path string) error {
+	return ap.open(path, 0, false)
+}
+
+func (ap *AudioPlayer) LoadAt(path string, at float64, paused bool) error {
+	return ap.open(path, at, paused)
+}
+
+func (ap *AudioPlayer) open(path string, at float64, paused bool) error {
 	log.Printf("[audio] load %s", path)
-	f, err := os.Open(path)
-	if err != nil {
-		log.Printf("[audio] open failed: %v", err)
-		return err
+	streamer, format, ok := ap.takePreloaded(path)
+	if !ok {
+		var err error
+		if streamer, format, err = openStream(path); err != nil {
+			return err
+		}
 	}
 
-	var streamer beep.StreamSeekCloser
-	var format beep.Format
-
-	lower := strings.ToLower(path)
-	switch {
-	case strings.HasSuffix(lower, ".mp3"):
-		streamer, format, err = mp3.Decode(f)
-	case strings.HasSuffix(lower, ".wav"):
-		streamer, format, err = wav.Decode(f)
-	case strings.HasSuffix(lower, ".ogg"):
-		streamer, format, err = vorbis.Decode(f)
-	default:
-		f.Close()
-		log.Printf("[audio] unsupported format for playback: %s", path)
-		return os.ErrInvalid
+	ap.mu.Lock()
+	var native beep.SampleRate
+	if ap.bitPerfect {
+		native = format.SampleRate
 	}
+	ap.mu.Unlock()
+	outRate, err := ensureSpeaker(native)
 	if err != nil {
-		f.Close()
-		log.Printf("[audio] decode failed: %v", err)
+		_ = streamer.Close()
+		log.Printf("[audio] speaker init failed: %v", err)
 		return err
 	}
 
@@ -66,17 +111,40 @@ func (ap *AudioPlayer) Load(path string) error {
 	ap.format = format
 	ap.filePath = path
 
-	speaker.Init(format.SampleRate, format.SampleRate.N(time.Second/10))
-
-	ap.ctrl = &beep.Ctrl{Streamer: ap.streamer, Paused: false}
+	ap.envelope = newEnvelope(ap.streamer, format.SampleRate, ap.fadesFor(path))
+	ap.skipper = newSilenceSkipper(ap.envelope, format.SampleRate, ap.skipSilence, ap.skipMinGap)
+	ap.eq = newEqualizer(ap.skipper, format.SampleRate, ap.eqGains)
+	ap.compressor = newCompressor(ap.eq, format.SampleRate, ap.nightMode)
+	ap.channels = newChannelMixer(ap.compressor, ap.monoEnabled, ap.balance)
+	ap.crossfeed = newCrossfeed(ap.channels, format.SampleRate, ap.crossfeedOn, ap.crossfeedLevel)
+	ap.ctrl = &beep.Ctrl{Streamer: resampleTo(ap.sourceLocked(), format.SampleRate, outRate, ap.resample), Paused: false}
+	ap.fader = newFader(ap.ctrl)
+	ap.spectrum.reset()
+	ap.gain = &effects.Volume{
+		Streamer: ap.fader,
+		Base:     2,
+		Volume:   ap.targetGainLocked(),
+		Silent:   false,
+	}
+	ap.tee = &tee{src: ap.gain, sink: ap.stream, tap: ap.spectrum}
+	if ap.stream != nil {
+		ap.stream.setSampleRate(outRate)
+	}
 	ap.volume = &effects.Volume{
-		Streamer: ap.ctrl,
+		Streamer: ap.tee,
 		Base:     2,
-		Volume:   0,
+		Volume:   ap.targetVolumeLocked(),
 		Silent:   false,
 	}
+	ap.meter = &meter{src: ap.volume}
+	if at > 0 {
+		ap.seekLocked(format.SampleRate.N(time.Duration(at * float64(time.Second))))
+	}
+	ap.ctrl.Paused = paused
 
-	ap.isPlaying = true
+	ap.isPlaying = !paused
+	ap.generation++
+	gen := ap.generation
 	ap.mu.Unlock()
 
 	if prev != nil {
@@ -84,20 +152,42 @@ func (ap *AudioPlayer) Load(path string) error {
 	}
 
 	speaker.Clear()
-	speaker.Play(ap.volume)
+	speaker.Play(beep.Seq(ap.meter, beep.Callback(func() {
+		go ap.finished(gen)
+	})))
 
-	log.Printf("[audio] playback started sr=%d", format.SampleRate)
+	log.Printf("[audio] playback started sr=%d out=%d", format.SampleRate, outRate)
 	return nil
 }
 
+func (ap *AudioPlayer) SetOnFinished(fn func(path string)) {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	ap.onFinished = fn
+}
+
+func (ap *AudioPlayer) finished(gen uint64) {
+	ap.mu.Lock()
+	if gen != ap.generation {
+		ap.mu.Unlock()
+		return
+	}
+	ap.isPlaying = false
+	path := ap.filePath
+	fn := ap.onFinished
+	ap.mu.Unlock()
+
+	log.Printf("[audio] finished %s", path)
+	if fn != nil {
+		fn(path)
+	}
+}
+
 func (ap *AudioPlayer) Play() {
 	ap.mu.Lock()
 	defer ap.mu.Unlock()
 	if ap.ctrl != nil {
-		speaker.Lock()
-		ap.ctrl.Paused = false
-		speaker.Unlock()
-		ap.isPlaying = true
+		ap.playLocked()
 		log.Printf("[audio] play")
 	}
 }
@@ -106,10 +196,7 @@ func (ap *AudioPlayer) Pause() {
 	ap.mu.Lock()
 	defer ap.mu.Unlock()
 	if ap.ctrl != nil {
-		speaker.Lock()
-		ap.ctrl.Paused = true
-		speaker.Unlock()
-		ap.isPlaying = false
+		ap.pauseLocked()
 		log.Printf("[audio] pause")
 	}
 }
@@ -118,23 +205,29 @@ func (ap *AudioPlayer) TogglePlay() bool {
 	ap.mu.Lock()
 	defer ap.mu.Unlock()
 	if ap.ctrl != nil {
-		speaker.Lock()
-		ap.ctrl.Paused = !ap.ctrl.Paused
-		speaker.Unlock()
-		ap.isPlaying = !ap.ctrl.Paused
+		if ap.isPlaying {
+			ap.pauseLocked()
+		} else {
+			ap.playLocked()
+		}
 		log.Printf("[audio] toggle play -> %v", ap.isPlaying)
 		return ap.isPlaying
 	}
 	return false
 }
 
+func (ap *AudioPlayer) IsPlaying() bool {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	return ap.isPlaying
+}
+
 func (ap *AudioPlayer) SetVolume(vol float64) {
 	ap.mu.Lock()
 	defer ap.mu.Unlock()
+	ap.userVolume = vol
 	if ap.volume != nil {
-		speaker.Lock()
-		ap.volume.Volume = vol
-		speaker.Unlock()
+		ap.applyVolumeLocked()
 		log.Printf("[audio] volume %.2f", vol)
 	}
 }
@@ -157,8 +250,26 @@ func (ap *AudioPlayer) Seek(percentage float64) {
 	if length <= 0 {
 		return
 	}
+	ap.seekLocked(int(math.Round(float64(length-1) * percentage)))
+}
 
-	pos := int(math.Round(float64(length-1) * percentage))
+func (ap *AudioPlayer) SeekTo(seconds float64) {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	if ap.streamer == nil || ap.format.SampleRate <= 0 {
+		return
+	}
+	if seconds < 0 {
+		seconds = 0
+	}
+	ap.seekLocked(ap.format.SampleRate.N(time.Duration(seconds * float64(time.Second))))
+}
+
+func (ap *AudioPlayer) seekLocked(pos int) {
+	length := ap.streamer.Len()
+	if length <= 0 {
+		return
+	}
 	if pos < 0 {
 		pos = 0
 	} else if pos >= length {
@@ -169,9 +280,98 @@ func (ap *AudioPlayer) Seek(percentage float64) {
 	if err := ap.streamer.Seek(pos); err != nil {
 		log.Printf("[audio] seek failed: %v", err)
 	}
+	if ap.skipper != nil {
+		ap.skipper.reset()
+	}
+	if ap.eq != nil {
+		ap.eq.reset()
+	}
+	if ap.compressor != nil {
+		ap.compressor.reset()
+	}
+	if ap.crossfeed != nil {
+		ap.crossfeed.reset()
+	}
 	speaker.Unlock()
 }
 
+func (ap *AudioPlayer) SetSkipSilence(enabled bool, minGapSec float64) {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	ap.skipSilence = enabled
+	ap.skipMinGap = minGapSec
+	if ap.skipper != nil {
+		speaker.Lock()
+		ap.skipper.enabled = enabled
+		ap.skipper.configure(ap.format.SampleRate, minGapSec)
+		ap.skipper.reset()
+		speaker.Unlock()
+	}
+	log.Printf("[audio] skip silence %v (min gap %.1fs)", enabled, minGapSec)
+}
+
+func (ap *AudioPlayer) SetNightMode(enabled bool) {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	ap.nightMode = enabled
+	if ap.compressor != nil {
+		speaker.Lock()
+		ap.compressor.enabled = enabled
+		ap.compressor.reset()
+		speaker.Unlock()
+	}
+	log.Printf("[audio] night mode %v", enabled)
+}
+
+func (ap *AudioPlayer) SetEQBand(band int, gainDb float64) error {
+	if band < 0 || band >= EQBandCount {
+		return fmt.Errorf("eq band must be between 0 and %d", EQBandCount-1)
+	}
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	next := ap.eqGains
+	next[band] = clampEQGain(gainDb)
+	ap.applyEQLocked(next)
+	return nil
+}
+
+func (ap *AudioPlayer) SetEQ(gains []float64) error {
+	if len(gains) != EQBandCount {
+		return fmt.Errorf("eq needs %d bands, got %d", EQBandCount, len(gains))
+	}
+	var next [EQBandCount]float64
+	for i, g := range gains {
+		next[i] = clampEQGain(g)
+	}
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	ap.applyEQLocked(next)
+	return nil
+}
+
+func (ap *AudioPlayer) applyEQLocked(next [EQBandCount]float64) {
+	ap.eqGains = next
+	if ap.eq != nil {
+		speaker.Lock()
+		ap.eq.setGains(next)
+		speaker.Unlock()
+	}
+}
+
+func (ap *AudioPlayer) EQ() []float64 {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	gains := ap.eqGains
+	return gains[:]
+}
+
+func (ap *AudioPlayer) OutputDevice() string {
+	if id, err := DefaultOutputID(); err == nil && id != "" {
+		return id
+	}
+	return DefaultDevice
+}
+
 func (ap *AudioPlayer) GetDuration() float64 {
 	ap.mu.Lock()
 	defer ap.mu.Unlock()
@@ -189,3 +389,9 @@ func (ap *AudioPlayer) GetPosition() float64 {
 	}
 	return 0
 }
+
+func (ap *AudioPlayer) CurrentPath() string {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	return ap.filePath
+}
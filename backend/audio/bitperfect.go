@@ -0,0 +1,63 @@
+package audio
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/gopxl/beep"
+	"github.com/gopxl/beep/speaker"
+)
+
+type OutputMode struct {
+	BitPerfect bool   `json:"bitPerfect"`
+	Active     bool   `json:"active"`
+	SourceRate int    `json:"sourceRate"`
+	OutputRate int    `json:"outputRate"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+func (ap *AudioPlayer) SetBitPerfect(enabled bool) error {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	if ap.bitPerfect == enabled {
+		return nil
+	}
+	ap.bitPerfect = enabled
+	ap.applyVolumeLocked()
+	if ap.ctrl != nil && ap.crossfeed != nil {
+		if out := outputRate(); out != 0 {
+			speaker.Lock()
+			ap.ctrl.Streamer = resampleTo(ap.sourceLocked(), ap.format.SampleRate, out, ap.resample)
+			speaker.Unlock()
+		}
+	}
+	log.Printf("[audio] bit-perfect output %v", enabled)
+	return nil
+}
+
+func (ap *AudioPlayer) sourceLocked() beep.Streamer {
+	if ap.bitPerfect {
+		return ap.streamer
+	}
+	return ap.crossfeed
+}
+
+func (ap *AudioPlayer) OutputMode() OutputMode {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	m := OutputMode{BitPerfect: ap.bitPerfect, OutputRate: int(outputRate())}
+	if ap.streamer != nil {
+		m.SourceRate = int(ap.format.SampleRate)
+	}
+	switch {
+	case !ap.bitPerfect:
+	case ap.streamer == nil:
+		m.Reason = "nothing is playing"
+	case m.OutputRate != 0 && m.SourceRate != m.OutputRate:
+		m.Reason = fmt.Sprintf("resampling %d Hz to the %d Hz output; restart Kitty to reopen the device at the track's rate", m.SourceRate, m.OutputRate)
+	default:
+		m.Active = true
+		m.Reason = "Kitty applies no processing, but output still goes through the shared system mixer"
+	}
+	return m
+}
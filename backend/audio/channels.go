@@ -0,0 +1,88 @@
+package audio
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/gopxl/beep"
+	"github.com/gopxl/beep/speaker"
+)
+
+type channelMixer struct {
+	src   beep.Streamer
+	mono  bool
+	left  float64
+	right float64
+}
+
+func newChannelMixer(src beep.Streamer, mono bool, balance float64) *channelMixer {
+	c := &channelMixer{src: src, mono: mono}
+	c.setBalance(balance)
+	return c
+}
+
+func (c *channelMixer) setBalance(balance float64) {
+	c.left = math.Min(1, 1-balance)
+	c.right = math.Min(1, 1+balance)
+}
+
+func (c *channelMixer) Stream(samples [][2]float64) (int, bool) {
+	n, ok := c.src.Stream(samples)
+	if !c.mono && c.left == 1 && c.right == 1 {
+		return n, ok
+	}
+	for i := 0; i < n; i++ {
+		if c.mono {
+			v := (samples[i][0] + samples[i][1]) / 2
+			samples[i][0], samples[i][1] = v, v
+		}
+		samples[i][0] *= c.left
+		samples[i][1] *= c.right
+	}
+	return n, ok
+}
+
+func (c *channelMixer) Err() error {
+	return c.src.Err()
+}
+
+func (ap *AudioPlayer) SetMono(enabled bool) {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	ap.monoEnabled = enabled
+	if ap.channels != nil {
+		speaker.Lock()
+		ap.channels.mono = enabled
+		speaker.Unlock()
+	}
+	log.Printf("[audio] mono %v", enabled)
+}
+
+func (ap *AudioPlayer) Mono() bool {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	return ap.monoEnabled
+}
+
+func (ap *AudioPlayer) SetBalance(balance float64) error {
+	if math.IsNaN(balance) || balance < -1 || balance > 1 {
+		return fmt.Errorf("balance must be between -1 and 1")
+	}
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	ap.balance = balance
+	if ap.channels != nil {
+		speaker.Lock()
+		ap.channels.setBalance(balance)
+		speaker.Unlock()
+	}
+	log.Printf("[audio] balance %.2f", balance)
+	return nil
+}
+
+func (ap *AudioPlayer) Balance() float64 {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	return ap.balance
+}
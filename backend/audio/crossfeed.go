@@ -0,0 +1,117 @@
+package audio
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/gopxl/beep"
+	"github.com/gopxl/beep/speaker"
+)
+
+const (
+	DefaultCrossfeedLevel = 0.25
+
+	crossfeedCutoffHz = 700.0
+	crossfeedMinDb    = 1.0
+	crossfeedMaxDb    = 15.0
+)
+
+type CrossfeedState struct {
+	Enabled bool    `json:"enabled"`
+	Level   float64 `json:"level"`
+}
+
+type crossfeed struct {
+	src     beep.Streamer
+	enabled bool
+	rate    float64
+
+	a0Lo, b1Lo       float64
+	a0Hi, a1Hi, b1Hi float64
+	gain             float64
+
+	lo, hi, prev [2]float64
+}
+
+func newCrossfeed(src beep.Streamer, sr beep.SampleRate, enabled bool, level float64) *crossfeed {
+	c := &crossfeed{src: src, enabled: enabled, rate: float64(sr)}
+	if c.rate <= 0 {
+		c.rate = 44100
+	}
+	c.configure(level)
+	return c
+}
+
+func (c *crossfeed) configure(level float64) {
+	feedDb := crossfeedMinDb + level*(crossfeedMaxDb-crossfeedMinDb)
+	loDb := feedDb*-5/6 - 3
+	hiDb := feedDb/6 - 3
+	gLo := math.Pow(10, loDb/20)
+	gHi := 1 - math.Pow(10, hiDb/20)
+	hiCutoff := crossfeedCutoffHz * math.Pow(2, (loDb-20*math.Log10(gHi))/12)
+
+	x := math.Exp(-2 * math.Pi * crossfeedCutoffHz / c.rate)
+	c.b1Lo = x
+	c.a0Lo = gLo * (1 - x)
+
+	x = math.Exp(-2 * math.Pi * hiCutoff / c.rate)
+	c.b1Hi = x
+	c.a0Hi = 1 - gHi*(1-x)
+	c.a1Hi = -x
+
+	c.gain = 1 / (1 - gHi + gLo)
+}
+
+func (c *crossfeed) reset() {
+	c.lo, c.hi, c.prev = [2]float64{}, [2]float64{}, [2]float64{}
+}
+
+func (c *crossfeed) Stream(samples [][2]float64) (int, bool) {
+	n, ok := c.src.Stream(samples)
+	if !c.enabled {
+		return n, ok
+	}
+	for i := 0; i < n; i++ {
+		in := samples[i]
+		for ch := 0; ch < 2; ch++ {
+			c.lo[ch] = c.a0Lo*in[ch] + c.b1Lo*c.lo[ch]
+			c.hi[ch] = c.a0Hi*in[ch] + c.a1Hi*c.prev[ch] + c.b1Hi*c.hi[ch]
+			c.prev[ch] = in[ch]
+		}
+		samples[i][0] = (c.hi[0] + c.lo[1]) * c.gain
+		samples[i][1] = (c.hi[1] + c.lo[0]) * c.gain
+	}
+	return n, ok
+}
+
+func (c *crossfeed) Err() error {
+	return c.src.Err()
+}
+
+func (ap *AudioPlayer) SetCrossfeed(enabled bool, level float64) error {
+	if math.IsNaN(level) || level < 0 || level > 1 {
+		return fmt.Errorf("crossfeed level must be between 0 and 1")
+	}
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	ap.crossfeedOn = enabled
+	ap.crossfeedLevel = level
+	if ap.crossfeed != nil {
+		speaker.Lock()
+		if enabled && !ap.crossfeed.enabled {
+			ap.crossfeed.reset()
+		}
+		ap.crossfeed.enabled = enabled
+		ap.crossfeed.configure(level)
+		speaker.Unlock()
+	}
+	log.Printf("[audio] crossfeed %v level=%.2f", enabled, level)
+	return nil
+}
+
+func (ap *AudioPlayer) Crossfeed() CrossfeedState {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	return CrossfeedState{Enabled: ap.crossfeedOn, Level: ap.crossfeedLevel}
+}
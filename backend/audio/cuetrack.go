@@ -0,0 +1,73 @@
+package audio
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"kitty/backend/metadata"
+
+	"github.com/gopxl/beep"
+)
+
+type segment struct {
+	beep.StreamSeekCloser
+	start int
+	end   int
+}
+
+func openCueTrack(path string) (beep.StreamSeekCloser, beep.Format, error) {
+	_, track, err := metadata.ResolveCueTrack(path)
+	if err != nil {
+		return nil, beep.Format{}, err
+	}
+	streamer, format, err := openStream(track.File)
+	if err != nil {
+		return nil, beep.Format{}, err
+	}
+	start := format.SampleRate.N(time.Duration(track.Start * float64(time.Second)))
+	end := streamer.Len()
+	if track.End > track.Start {
+		if n := format.SampleRate.N(time.Duration(track.End * float64(time.Second))); n < end {
+			end = n
+		}
+	}
+	if start >= end {
+		_ = streamer.Close()
+		return nil, beep.Format{}, fmt.Errorf("cue track %d starts past the end of %s", track.Number, filepath.Base(track.File))
+	}
+	if err := streamer.Seek(start); err != nil {
+		_ = streamer.Close()
+		return nil, beep.Format{}, err
+	}
+	return &segment{StreamSeekCloser: streamer, start: start, end: end}, format, nil
+}
+
+func (s *segment) Stream(samples [][2]float64) (int, bool) {
+	remaining := s.end - s.StreamSeekCloser.Position()
+	if remaining <= 0 {
+		return 0, false
+	}
+	if len(samples) > remaining {
+		samples = samples[:remaining]
+	}
+	return s.StreamSeekCloser.Stream(samples)
+}
+
+func (s *segment) Len() int {
+	return s.end - s.start
+}
+
+func (s *segment) Position() int {
+	return s.StreamSeekCloser.Position() - s.start
+}
+
+func (s *segment) Seek(p int) error {
+	if p < 0 {
+		p = 0
+	}
+	if p > s.Len() {
+		p = s.Len()
+	}
+	return s.StreamSeekCloser.Seek(s.start + p)
+}
@@ -0,0 +1,100 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"log"
+	"runtime"
+	"time"
+)
+
+const (
+	DeviceFollowInterval = 2 * time.Second
+	StallTimeout         = 3 * time.Second
+)
+
+var ErrDeviceWatchUnsupported = errors.New("default output detection is not supported on this platform")
+
+func DefaultOutputID() (string, error) {
+	return defaultOutputID()
+}
+
+func FollowsDefaultOutput() bool {
+	return runtime.GOOS == "windows"
+}
+
+func WatchDefaultOutput(ctx context.Context, interval time.Duration, fn func(device string)) {
+	if interval <= 0 {
+		interval = DeviceFollowInterval
+	}
+	last, err := defaultOutputID()
+	if err != nil {
+		log.Printf("[audio] default output watch disabled: %v", err)
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		device, err := defaultOutputID()
+		if err != nil || device == "" || device == last {
+			continue
+		}
+		log.Printf("[audio] default output changed %s -> %s", last, device)
+		last = device
+		fn(device)
+	}
+}
+
+func (ap *AudioPlayer) Reopen() error {
+	ap.mu.Lock()
+	path, paused := ap.filePath, !ap.isPlaying
+	var at float64
+	if ap.streamer != nil && ap.format.SampleRate > 0 {
+		at = float64(ap.streamer.Position()) / float64(ap.format.SampleRate)
+	}
+	ap.mu.Unlock()
+	if path == "" {
+		return nil
+	}
+	log.Printf("[audio] reopening %s at %.1fs", path, at)
+	return ap.open(path, at, paused)
+}
+
+func (ap *AudioPlayer) WatchStall(ctx context.Context, timeout time.Duration, fn func(Position)) {
+	if timeout <= 0 {
+		timeout = StallTimeout
+	}
+	ticker := time.NewTicker(timeout / 3)
+	defer ticker.Stop()
+	var (
+		last  Position
+		since time.Time
+	)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		p := ap.Position()
+		if !p.Playing || p.Path != last.Path || p.Position != last.Position || p.Duration-p.Position < 0.5 {
+			last, since = p, time.Now()
+			continue
+		}
+		if time.Since(since) < timeout {
+			continue
+		}
+		log.Printf("[audio] output stalled at %.1fs of %s", p.Position, p.Path)
+		since = time.Now()
+		fn(p)
+	}
+}
+
+func (ap *AudioPlayer) RecoverOutput() error {
+	return ap.Reopen()
+}
@@ -0,0 +1,35 @@
+//go:build darwin && cgo
+
+package audio
+
+/*
+#cgo LDFLAGS: -framework CoreAudio
+#include <CoreAudio/CoreAudio.h>
+
+static OSStatus kittyDefaultOutput(AudioObjectID *device) {
+	AudioObjectPropertyAddress addr = {
+		kAudioHardwarePropertyDefaultOutputDevice,
+		kAudioObjectPropertyScopeGlobal,
+		kAudioObjectPropertyElementMain
+	};
+	UInt32 size = sizeof(AudioObjectID);
+	return AudioObjectGetPropertyData(kAudioObjectSystemObject, &addr, 0, NULL, &size, device);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"strconv"
+)
+
+func defaultOutputID() (string, error) {
+	var device C.AudioObjectID
+	if status := C.kittyDefaultOutput(&device); status != 0 {
+		return "", fmt.Errorf("query default output device: OSStatus %d", int32(status))
+	}
+	if device == 0 {
+		return "", nil
+	}
+	return strconv.FormatUint(uint64(device), 10), nil
+}
@@ -0,0 +1,20 @@
+//go:build !windows && !(darwin && cgo)
+
+package audio
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+func defaultOutputID() (string, error) {
+	if runtime.GOOS == "darwin" {
+		return "", ErrDeviceWatchUnsupported
+	}
+	out, err := exec.Command("pactl", "get-default-sink").Output()
+	if err != nil {
+		return "", ErrDeviceWatchUnsupported
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,92 @@
+package audio
+
+import (
+	"fmt"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	coinitMultithreaded = 0
+	clsctxAll           = 0x17
+	rpcEChangedMode     = 0x80010106
+
+	enumeratorGetDefaultEndpoint = 4
+	deviceGetID                  = 5
+)
+
+type guid struct {
+	Data1 uint32
+	Data2 uint16
+	Data3 uint16
+	Data4 [8]byte
+}
+
+var (
+	clsidDeviceEnumerator = guid{0xbcde0395, 0xe52f, 0x467c, [8]byte{0x8e, 0x3d, 0xc4, 0x57, 0x92, 0x91, 0x69, 0x2e}}
+	iidDeviceEnumerator   = guid{0xa95664d2, 0x9614, 0x4f35, [8]byte{0xa7, 0x46, 0xde, 0x8d, 0xb6, 0x36, 0x17, 0xe6}}
+
+	ole32                = syscall.NewLazyDLL("ole32.dll")
+	procCoInitializeEx   = ole32.NewProc("CoInitializeEx")
+	procCoUninitialize   = ole32.NewProc("CoUninitialize")
+	procCoCreateInstance = ole32.NewProc("CoCreateInstance")
+	procCoTaskMemFree    = ole32.NewProc("CoTaskMemFree")
+)
+
+type comObject struct {
+	vtbl *[8]uintptr
+}
+
+func (o *comObject) call(slot int, args ...uintptr) error {
+	all := append([]uintptr{uintptr(unsafe.Pointer(o))}, args...)
+	hr, _, _ := syscall.SyscallN(o.vtbl[slot], all...)
+	if int32(hr) < 0 {
+		return fmt.Errorf("HRESULT 0x%08x", uint32(hr))
+	}
+	return nil
+}
+
+func (o *comObject) release() {
+	if o != nil {
+		_ = o.call(2)
+	}
+}
+
+func defaultOutputID() (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	hr, _, _ := procCoInitializeEx.Call(0, coinitMultithreaded)
+	if int32(hr) < 0 && uint32(hr) != rpcEChangedMode {
+		return "", fmt.Errorf("CoInitializeEx: HRESULT 0x%08x", uint32(hr))
+	}
+	if int32(hr) >= 0 {
+		defer procCoUninitialize.Call()
+	}
+
+	var enumerator *comObject
+	hr, _, _ = procCoCreateInstance.Call(
+		uintptr(unsafe.Pointer(&clsidDeviceEnumerator)), 0, clsctxAll,
+		uintptr(unsafe.Pointer(&iidDeviceEnumerator)), uintptr(unsafe.Pointer(&enumerator)))
+	if int32(hr) < 0 {
+		return "", fmt.Errorf("create device enumerator: HRESULT 0x%08x", uint32(hr))
+	}
+	defer enumerator.release()
+
+	var device *comObject
+	if err := enumerator.call(enumeratorGetDefaultEndpoint, 0, 0, uintptr(unsafe.Pointer(&device))); err != nil {
+		return "", fmt.Errorf("default audio endpoint: %w", err)
+	}
+	defer device.release()
+
+	var id *uint16
+	if err := device.call(deviceGetID, uintptr(unsafe.Pointer(&id))); err != nil {
+		return "", fmt.Errorf("audio endpoint id: %w", err)
+	}
+	defer procCoTaskMemFree.Call(uintptr(unsafe.Pointer(id)))
+	var buf []uint16
+	for p := unsafe.Pointer(id); *(*uint16)(p) != 0; p = unsafe.Add(p, 2) {
+		buf = append(buf, *(*uint16)(p))
+	}
+	return syscall.UTF16ToString(buf), nil
+}
@@ -0,0 +1,77 @@
+package audio
+
+import (
+	"math"
+	"time"
+
+	"github.com/gopxl/beep"
+	"github.com/gopxl/beep/speaker"
+)
+
+type trackFades struct {
+	path string
+	in   time.Duration
+	out  time.Duration
+}
+
+type envelope struct {
+	src beep.StreamSeeker
+	in  int
+	out int
+}
+
+func newEnvelope(src beep.StreamSeeker, sr beep.SampleRate, fades trackFades) *envelope {
+	e := &envelope{src: src}
+	e.configure(sr, fades)
+	return e
+}
+
+func (e *envelope) configure(sr beep.SampleRate, fades trackFades) {
+	e.in, e.out = sr.N(fades.in), sr.N(fades.out)
+}
+
+func (e *envelope) Stream(samples [][2]float64) (int, bool) {
+	start := e.src.Position()
+	n, ok := e.src.Stream(samples)
+	if e.in <= 0 && e.out <= 0 {
+		return n, ok
+	}
+	length := e.src.Len()
+	for i := 0; i < n; i++ {
+		pos := start + i
+		gain := 1.0
+		if e.in > 0 && pos < e.in {
+			gain = float64(pos) / float64(e.in)
+		}
+		if left := length - pos; e.out > 0 && left < e.out {
+			gain = math.Min(gain, float64(left)/float64(e.out))
+		}
+		if gain < 1 {
+			samples[i][0] *= gain
+			samples[i][1] *= gain
+		}
+	}
+	return n, ok
+}
+
+func (e *envelope) Err() error {
+	return e.src.Err()
+}
+
+func (ap *AudioPlayer) SetTrackFades(path string, in, out time.Duration) {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	ap.fades = trackFades{path: path, in: in, out: out}
+	if ap.envelope != nil && path == ap.filePath {
+		speaker.Lock()
+		ap.envelope.configure(ap.format.SampleRate, ap.fades)
+		speaker.Unlock()
+	}
+}
+
+func (ap *AudioPlayer) fadesFor(path string) trackFades {
+	if ap.fades.path != path {
+		return trackFades{}
+	}
+	return ap.fades
+}
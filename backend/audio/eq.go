@@ -0,0 +1,142 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/gopxl/beep"
+)
+
+const (
+	EQBandCount    = 10
+	MaxEQGainDb    = 12.0
+	EQPresetFlat   = "flat"
+	EQPresetCustom = "custom"
+
+	eqQ = 1.41
+)
+
+var EQFrequencies = [EQBandCount]float64{31, 62, 125, 250, 500, 1000, 2000, 4000, 8000, 16000}
+
+var eqPresets = map[string][EQBandCount]float64{
+	EQPresetFlat:   {},
+	"bass-boost":   {6, 5, 4, 2, 0, 0, 0, 0, 0, 0},
+	"treble-boost": {0, 0, 0, 0, 0, 1, 2, 4, 5, 6},
+	"vocal":        {-2, -2, -1, 1, 3, 4, 3, 1, 0, -1},
+	"rock":         {4, 3, 2, 0, -1, -1, 1, 3, 4, 4},
+	"pop":          {-1, 1, 3, 4, 3, 0, -1, -1, 1, 2},
+	"jazz":         {3, 2, 1, 2, -1, -1, 0, 1, 2, 3},
+	"classical":    {4, 3, 2, 1, -1, -1, 0, 2, 3, 4},
+	"electronic":   {5, 4, 1, 0, -2, 1, 0, 1, 4, 5},
+	"loudness":     {6, 4, 0, 0, -2, 0, -1, -4, 5, 1},
+}
+
+func EQPresetNames() []string {
+	names := make([]string, 0, len(eqPresets))
+	for name := range eqPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func EQPreset(name string) ([]float64, error) {
+	gains, ok := eqPresets[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return nil, fmt.Errorf("unknown eq preset: %s", name)
+	}
+	return gains[:], nil
+}
+
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	z1, z2             [2]float64
+}
+
+func (q *biquad) peaking(sr, f0, gainDb float64) {
+	if gainDb == 0 || f0 >= sr*0.49 {
+		*q = biquad{b0: 1}
+		return
+	}
+	a := math.Pow(10, gainDb/40)
+	w0 := 2 * math.Pi * f0 / sr
+	alpha := math.Sin(w0) / (2 * eqQ)
+	cos := math.Cos(w0)
+	a0 := 1 + alpha/a
+	q.b0 = (1 + alpha*a) / a0
+	q.b1 = -2 * cos / a0
+	q.b2 = (1 - alpha*a) / a0
+	q.a1 = -2 * cos / a0
+	q.a2 = (1 - alpha/a) / a0
+}
+
+func (q *biquad) process(x float64, ch int) float64 {
+	y := q.b0*x + q.z1[ch]
+	q.z1[ch] = q.b1*x - q.a1*y + q.z2[ch]
+	q.z2[ch] = q.b2*x - q.a2*y
+	return y
+}
+
+type equalizer struct {
+	src    beep.Streamer
+	sr     float64
+	gains  [EQBandCount]float64
+	bands  [EQBandCount]biquad
+	active bool
+}
+
+func newEqualizer(src beep.Streamer, sr beep.SampleRate, gains [EQBandCount]float64) *equalizer {
+	e := &equalizer{src: src, sr: float64(sr)}
+	e.setGains(gains)
+	return e
+}
+
+func (e *equalizer) setGains(gains [EQBandCount]float64) {
+	e.gains = gains
+	e.active = false
+	for i, g := range gains {
+		e.bands[i].peaking(e.sr, EQFrequencies[i], g)
+		if g != 0 {
+			e.active = true
+		}
+	}
+}
+
+func (e *equalizer) reset() {
+	for i := range e.bands {
+		e.bands[i].z1 = [2]float64{}
+		e.bands[i].z2 = [2]float64{}
+	}
+}
+
+func (e *equalizer) Stream(samples [][2]float64) (int, bool) {
+	n, ok := e.src.Stream(samples)
+	if !e.active {
+		return n, ok
+	}
+	for i := 0; i < n; i++ {
+		l, r := samples[i][0], samples[i][1]
+		for b := range e.bands {
+			if e.gains[b] == 0 {
+				continue
+			}
+			l = e.bands[b].process(l, 0)
+			r = e.bands[b].process(r, 1)
+		}
+		samples[i][0], samples[i][1] = l, r
+	}
+	return n, ok
+}
+
+func (e *equalizer) Err() error {
+	return e.src.Err()
+}
+
+func clampEQGain(db float64) float64 {
+	if math.IsNaN(db) {
+		return 0
+	}
+	return math.Max(-MaxEQGainDb, math.Min(MaxEQGainDb, db))
+}
@@ -0,0 +1,135 @@
+package audio
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/gopxl/beep"
+	"github.com/gopxl/beep/speaker"
+)
+
+const (
+	DefaultFadeDuration = 150 * time.Millisecond
+	MaxFadeDuration     = 2 * time.Second
+)
+
+type fader struct {
+	ctrl   *beep.Ctrl
+	gain   float64
+	target float64
+	step   float64
+	pause  bool
+}
+
+func newFader(ctrl *beep.Ctrl) *fader {
+	return &fader{ctrl: ctrl, gain: 1, target: 1}
+}
+
+func (f *fader) fadeTo(target float64, samples int, pause bool) {
+	f.target = target
+	f.pause = pause
+	if samples <= 0 || f.gain == target {
+		f.gain = target
+		f.step = 0
+		f.settle()
+		return
+	}
+	f.step = math.Abs(target-f.gain) / float64(samples)
+}
+
+func (f *fader) settle() {
+	if f.pause && f.gain == f.target {
+		f.ctrl.Paused = true
+		f.pause = false
+	}
+}
+
+func (f *fader) Stream(samples [][2]float64) (int, bool) {
+	n, ok := f.ctrl.Stream(samples)
+	if f.gain == 1 && f.target == 1 {
+		return n, ok
+	}
+	for i := 0; i < n; i++ {
+		if f.gain < f.target {
+			f.gain = math.Min(f.target, f.gain+f.step)
+		} else if f.gain > f.target {
+			f.gain = math.Max(f.target, f.gain-f.step)
+		}
+		samples[i][0] *= f.gain
+		samples[i][1] *= f.gain
+	}
+	f.settle()
+	return n, ok
+}
+
+func (f *fader) Err() error {
+	return f.ctrl.Err()
+}
+
+func (ap *AudioPlayer) SetFadeDuration(d time.Duration) error {
+	if d < 0 || d > MaxFadeDuration {
+		return fmt.Errorf("fade must be between 0 and %dms", MaxFadeDuration.Milliseconds())
+	}
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	ap.fadeDur = d
+	log.Printf("[audio] transport fade %s", d)
+	return nil
+}
+
+func (ap *AudioPlayer) FadeDuration() time.Duration {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	return ap.fadeDur
+}
+
+func (ap *AudioPlayer) fadeSamplesLocked() int {
+	rate := outputRate()
+	if rate <= 0 {
+		return 0
+	}
+	return rate.N(ap.fadeDur)
+}
+
+func (ap *AudioPlayer) playLocked() {
+	speaker.Lock()
+	if ap.ctrl.Paused {
+		ap.ctrl.Paused = false
+		ap.fader.gain = 0
+	}
+	ap.fader.fadeTo(1, ap.fadeSamplesLocked(), false)
+	speaker.Unlock()
+	ap.isPlaying = true
+}
+
+func (ap *AudioPlayer) pauseLocked() {
+	speaker.Lock()
+	if !ap.ctrl.Paused {
+		ap.fader.fadeTo(0, ap.fadeSamplesLocked(), true)
+	}
+	speaker.Unlock()
+	ap.isPlaying = false
+}
+
+func (ap *AudioPlayer) Stop() {
+	ap.mu.Lock()
+	if ap.ctrl == nil {
+		ap.mu.Unlock()
+		return
+	}
+	ap.pauseLocked()
+	gen, wait := ap.generation, ap.fadeDur
+	ap.mu.Unlock()
+	log.Printf("[audio] stop")
+
+	time.AfterFunc(wait, func() {
+		ap.mu.Lock()
+		defer ap.mu.Unlock()
+		if ap.generation != gen || ap.isPlaying || ap.streamer == nil {
+			return
+		}
+		ap.seekLocked(0)
+	})
+}
@@ -0,0 +1,73 @@
+package audio
+
+import (
+	"log"
+	"math"
+)
+
+const (
+	MinLoudnessTarget = -31.0
+	MaxLoudnessTarget = -5.0
+)
+
+type trackLoudness struct {
+	path       string
+	integrated float64
+	peak       float64
+
+	hasAlbum        bool
+	albumIntegrated float64
+	albumPeak       float64
+}
+
+func (ap *AudioPlayer) SetLoudnessNormalization(enabled bool, targetLUFS float64) {
+	targetLUFS = math.Max(MinLoudnessTarget, math.Min(MaxLoudnessTarget, targetLUFS))
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	ap.normalize = enabled
+	ap.loudTarget = targetLUFS
+	ap.applyVolumeLocked()
+	log.Printf("[audio] loudness normalization %v (target %.1f LUFS)", enabled, targetLUFS)
+}
+
+func (ap *AudioPlayer) SetTrackLoudness(path string, integrated, peak float64) {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	ap.loudness = trackLoudness{path: path, integrated: integrated, peak: peak}
+	ap.applyVolumeLocked()
+}
+
+func (ap *AudioPlayer) SetAlbumLoudness(path string, integrated, peak float64) {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	if ap.loudness.path != path {
+		return
+	}
+	ap.loudness.hasAlbum = true
+	ap.loudness.albumIntegrated = integrated
+	ap.loudness.albumPeak = peak
+	ap.applyVolumeLocked()
+}
+
+func (ap *AudioPlayer) normalizationGainLocked() (float64, bool) {
+	if !ap.normalize || ap.loudness.path == "" || ap.loudness.path != ap.filePath {
+		return 0, false
+	}
+	integrated, peak := ap.loudness.integrated, ap.loudness.peak
+	if ap.albumContext && ap.loudness.hasAlbum {
+		integrated, peak = ap.loudness.albumIntegrated, ap.loudness.albumPeak
+	}
+	gain := ap.loudTarget - integrated
+	if peak > 0 {
+		if limit := -20 * math.Log10(peak); gain > limit {
+			gain = limit
+		}
+	}
+	return gain, true
+}
+
+func (ap *AudioPlayer) LoudnessNormalization() (bool, float64) {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	return ap.normalize, ap.loudTarget
+}
@@ -0,0 +1,69 @@
+package audio
+
+import (
+	"math"
+	"sync"
+
+	"github.com/gopxl/beep"
+)
+
+type Levels struct {
+	PeakL   float64 `json:"peakL"`
+	PeakR   float64 `json:"peakR"`
+	RMSL    float64 `json:"rmsL"`
+	RMSR    float64 `json:"rmsR"`
+	Clipped bool    `json:"clipped"`
+}
+
+type meter struct {
+	src    beep.Streamer
+	mu     sync.Mutex
+	levels Levels
+}
+
+func (m *meter) Stream(samples [][2]float64) (int, bool) {
+	n, ok := m.src.Stream(samples)
+	if n == 0 {
+		return n, ok
+	}
+	var lv Levels
+	var sumL, sumR float64
+	for _, s := range samples[:n] {
+		l, r := math.Abs(s[0]), math.Abs(s[1])
+		lv.PeakL = math.Max(lv.PeakL, l)
+		lv.PeakR = math.Max(lv.PeakR, r)
+		sumL += s[0] * s[0]
+		sumR += s[1] * s[1]
+	}
+	lv.RMSL = math.Sqrt(sumL / float64(n))
+	lv.RMSR = math.Sqrt(sumR / float64(n))
+	lv.Clipped = lv.PeakL >= 1 || lv.PeakR >= 1
+
+	m.mu.Lock()
+	lv.Clipped = lv.Clipped || m.levels.Clipped
+	m.levels = lv
+	m.mu.Unlock()
+	return n, ok
+}
+
+func (m *meter) Err() error {
+	return m.src.Err()
+}
+
+func (m *meter) read() Levels {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	lv := m.levels
+	m.levels.Clipped = false
+	return lv
+}
+
+func (ap *AudioPlayer) Levels() Levels {
+	ap.mu.Lock()
+	m, playing := ap.meter, ap.isPlaying
+	ap.mu.Unlock()
+	if m == nil || !playing {
+		return Levels{}
+	}
+	return m.read()
+}
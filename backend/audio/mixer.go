@@ -0,0 +1,110 @@
+package audio
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gopxl/beep"
+	"github.com/gopxl/beep/speaker"
+)
+
+const (
+	OutputSampleRate = beep.SampleRate(44100)
+
+	ResampleFast   = "fast"
+	ResampleMedium = "medium"
+	ResampleHigh   = "high"
+)
+
+var resampleQualities = map[string]int{
+	ResampleFast:   1,
+	ResampleMedium: 4,
+	ResampleHigh:   16,
+}
+
+var (
+	speakerMu   sync.Mutex
+	speakerRate beep.SampleRate
+)
+
+func ensureSpeaker(preferred beep.SampleRate) (beep.SampleRate, error) {
+	speakerMu.Lock()
+	defer speakerMu.Unlock()
+	if speakerRate != 0 {
+		return speakerRate, nil
+	}
+	rate := OutputSampleRate
+	if preferred > 0 {
+		rate = preferred
+	}
+	if err := speaker.Init(rate, rate.N(time.Second/10)); err != nil {
+		return 0, err
+	}
+	speakerRate = rate
+	log.Printf("[audio] speaker initialized sr=%d", speakerRate)
+	return speakerRate, nil
+}
+
+func OutputReady() (int, bool) {
+	sr := outputRate()
+	return int(sr), sr != 0
+}
+
+func outputRate() beep.SampleRate {
+	speakerMu.Lock()
+	defer speakerMu.Unlock()
+	return speakerRate
+}
+
+func NormalizeResampleQuality(quality string) (string, error) {
+	quality = strings.ToLower(strings.TrimSpace(quality))
+	if quality == "" {
+		return ResampleMedium, nil
+	}
+	if _, ok := resampleQualities[quality]; !ok {
+		return "", fmt.Errorf("unknown resample quality: %s", quality)
+	}
+	return quality, nil
+}
+
+func resampleTo(s beep.Streamer, from, to beep.SampleRate, quality string) beep.Streamer {
+	if from == to {
+		return s
+	}
+	q, ok := resampleQualities[quality]
+	if !ok {
+		q = resampleQualities[ResampleMedium]
+	}
+	return beep.Resample(q, from, to, s)
+}
+
+func (ap *AudioPlayer) SetResampleQuality(quality string) error {
+	quality, err := NormalizeResampleQuality(quality)
+	if err != nil {
+		return err
+	}
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	ap.resample = quality
+	if ap.ctrl != nil && ap.crossfeed != nil {
+		if out := outputRate(); out != 0 && out != ap.format.SampleRate {
+			speaker.Lock()
+			ap.ctrl.Streamer = resampleTo(ap.sourceLocked(), ap.format.SampleRate, out, quality)
+			speaker.Unlock()
+		}
+	}
+	log.Printf("[audio] resample quality %s", quality)
+	return nil
+}
+
+func (ap *AudioPlayer) ResampleQuality() string {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	if ap.resample == "" {
+		return ResampleMedium
+	}
+	return ap.resample
+}
@@ -0,0 +1,73 @@
+package audio
+
+import (
+	"math"
+
+	"github.com/gopxl/beep"
+)
+
+const (
+	DefaultDevice = "default"
+
+	nightThresholdDb = -24.0
+	nightRatio       = 4.0
+	nightMakeupDb    = 9.0
+	nightAttackSec   = 0.01
+	nightReleaseSec  = 0.25
+	nightCeiling     = 0.97
+)
+
+type compressor struct {
+	src     beep.Streamer
+	enabled bool
+	attack  float64
+	release float64
+	env     float64
+}
+
+func newCompressor(src beep.Streamer, sr beep.SampleRate, enabled bool) *compressor {
+	c := &compressor{src: src, enabled: enabled}
+	c.configure(sr)
+	return c
+}
+
+func (c *compressor) configure(sr beep.SampleRate) {
+	rate := float64(sr)
+	if rate <= 0 {
+		rate = 44100
+	}
+	c.attack = math.Exp(-1 / (nightAttackSec * rate))
+	c.release = math.Exp(-1 / (nightReleaseSec * rate))
+}
+
+func (c *compressor) reset() {
+	c.env = 0
+}
+
+func (c *compressor) Stream(samples [][2]float64) (int, bool) {
+	n, ok := c.src.Stream(samples)
+	if !c.enabled {
+		return n, ok
+	}
+	for i := 0; i < n; i++ {
+		peak := math.Max(math.Abs(samples[i][0]), math.Abs(samples[i][1]))
+		coef := c.release
+		if peak > c.env {
+			coef = c.attack
+		}
+		c.env = peak + coef*(c.env-peak)
+
+		gainDb := nightMakeupDb
+		if levelDb := 20 * math.Log10(c.env+1e-9); levelDb > nightThresholdDb {
+			gainDb -= (levelDb - nightThresholdDb) * (1 - 1/nightRatio)
+		}
+		gain := math.Pow(10, gainDb/20)
+		samples[i][0] = nightCeiling * math.Tanh(samples[i][0]*gain/nightCeiling)
+		samples[i][1] = nightCeiling * math.Tanh(samples[i][1]*gain/nightCeiling)
+	}
+	return n, ok
+}
+
+func (c *compressor) Err() error {
+	return c.src.Err()
+}
@@ -0,0 +1,272 @@
+package audio
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gopxl/beep"
+	"github.com/gopxl/beep/speaker"
+)
+
+const (
+	OutputDeviceID = "device"
+	OutputStreamID = "stream"
+
+	streamClientBuffer = 64
+)
+
+type OutputInfo struct {
+	ID      string  `json:"id"`
+	Address string  `json:"address,omitempty"`
+	Volume  float64 `json:"volume"`
+	Clients int     `json:"clients"`
+	Active  bool    `json:"active"`
+}
+
+type streamOutput struct {
+	mu      sync.Mutex
+	srv     *http.Server
+	addr    string
+	volume  float64
+	sr      beep.SampleRate
+	clients map[chan []byte]struct{}
+}
+
+func newStreamOutput(addr string) (*streamOutput, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	o := &streamOutput{addr: ln.Addr().String(), clients: make(map[chan []byte]struct{})}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stream.wav", o.serve)
+	o.srv = &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := o.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("[audio] stream output stopped: %v", err)
+		}
+	}()
+	log.Printf("[audio] stream output listening on http://%s/stream.wav", o.addr)
+	return o, nil
+}
+
+func (o *streamOutput) serve(w http.ResponseWriter, r *http.Request) {
+	o.mu.Lock()
+	sr := o.sr
+	o.mu.Unlock()
+	if sr <= 0 {
+		http.Error(w, "nothing is playing", http.StatusServiceUnavailable)
+		return
+	}
+
+	ch := make(chan []byte, streamClientBuffer)
+	o.mu.Lock()
+	o.clients[ch] = struct{}{}
+	o.mu.Unlock()
+	defer o.drop(ch)
+
+	w.Header().Set("Content-Type", "audio/wav")
+	w.Header().Set("Cache-Control", "no-store")
+	if _, err := w.Write(wavStreamHeader(sr)); err != nil {
+		return
+	}
+	flusher, _ := w.(http.Flusher)
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case buf, ok := <-ch:
+			if !ok {
+				return
+			}
+			if _, err := w.Write(buf); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+func (o *streamOutput) drop(ch chan []byte) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if _, ok := o.clients[ch]; ok {
+		delete(o.clients, ch)
+		close(ch)
+	}
+}
+
+func (o *streamOutput) setSampleRate(sr beep.SampleRate) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.sr == sr {
+		return
+	}
+	o.sr = sr
+	for ch := range o.clients {
+		delete(o.clients, ch)
+		close(ch)
+	}
+}
+
+func (o *streamOutput) write(samples [][2]float64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.clients) == 0 {
+		return
+	}
+	gain := math.Pow(2, o.volume)
+	buf := make([]byte, len(samples)*4)
+	for i, s := range samples {
+		binary.LittleEndian.PutUint16(buf[i*4:], uint16(pcm16(s[0]*gain)))
+		binary.LittleEndian.PutUint16(buf[i*4+2:], uint16(pcm16(s[1]*gain)))
+	}
+	for ch := range o.clients {
+		select {
+		case ch <- buf:
+		default:
+		}
+	}
+}
+
+func (o *streamOutput) info() OutputInfo {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return OutputInfo{ID: OutputStreamID, Address: "http://" + o.addr + "/stream.wav", Volume: o.volume, Clients: len(o.clients), Active: true}
+}
+
+func (o *streamOutput) close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	o.mu.Lock()
+	for ch := range o.clients {
+		delete(o.clients, ch)
+		close(ch)
+	}
+	o.mu.Unlock()
+	return o.srv.Shutdown(ctx)
+}
+
+func pcm16(v float64) int16 {
+	v = math.Max(-1, math.Min(1, v))
+	return int16(v * math.MaxInt16)
+}
+
+func wavStreamHeader(sr beep.SampleRate) []byte {
+	const channels, bits = 2, 16
+	h := make([]byte, 44)
+	copy(h[0:], "RIFF")
+	binary.LittleEndian.PutUint32(h[4:], 0xFFFFFFFF)
+	copy(h[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(h[16:], 16)
+	binary.LittleEndian.PutUint16(h[20:], 1)
+	binary.LittleEndian.PutUint16(h[22:], channels)
+	binary.LittleEndian.PutUint32(h[24:], uint32(sr))
+	binary.LittleEndian.PutUint32(h[28:], uint32(sr)*channels*bits/8)
+	binary.LittleEndian.PutUint16(h[32:], channels*bits/8)
+	binary.LittleEndian.PutUint16(h[34:], bits)
+	copy(h[36:], "data")
+	binary.LittleEndian.PutUint32(h[40:], 0xFFFFFFFF)
+	return h
+}
+
+type tee struct {
+	src  beep.Streamer
+	sink *streamOutput
+	tap  *analyzer
+}
+
+func (t *tee) Stream(samples [][2]float64) (int, bool) {
+	n, ok := t.src.Stream(samples)
+	if t.sink != nil && n > 0 {
+		t.sink.write(samples[:n])
+	}
+	if t.tap != nil && n > 0 {
+		t.tap.write(samples[:n])
+	}
+	return n, ok
+}
+
+func (t *tee) Err() error {
+	return t.src.Err()
+}
+
+func (ap *AudioPlayer) StartStreamOutput(addr string) (OutputInfo, error) {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	if ap.stream != nil {
+		return OutputInfo{}, fmt.Errorf("stream output already running at %s", ap.stream.addr)
+	}
+	out, err := newStreamOutput(addr)
+	if err != nil {
+		return OutputInfo{}, err
+	}
+	out.sr = outputRate()
+	ap.stream = out
+	if ap.tee != nil {
+		speaker.Lock()
+		ap.tee.sink = out
+		speaker.Unlock()
+	}
+	return out.info(), nil
+}
+
+func (ap *AudioPlayer) StopStreamOutput() error {
+	ap.mu.Lock()
+	out := ap.stream
+	ap.stream = nil
+	if ap.tee != nil {
+		speaker.Lock()
+		ap.tee.sink = nil
+		speaker.Unlock()
+	}
+	ap.mu.Unlock()
+	if out == nil {
+		return nil
+	}
+	return out.close()
+}
+
+func (ap *AudioPlayer) SetOutputVolume(id string, vol float64) error {
+	switch id {
+	case OutputDeviceID:
+		ap.SetVolume(vol)
+		return nil
+	case OutputStreamID:
+		ap.mu.Lock()
+		out := ap.stream
+		ap.mu.Unlock()
+		if out == nil {
+			return fmt.Errorf("stream output is not running")
+		}
+		out.mu.Lock()
+		out.volume = vol
+		out.mu.Unlock()
+		return nil
+	default:
+		return fmt.Errorf("unknown output: %s", id)
+	}
+}
+
+func (ap *AudioPlayer) Outputs() []OutputInfo {
+	address := ap.OutputDevice()
+	ap.mu.Lock()
+	device := OutputInfo{ID: OutputDeviceID, Address: address, Volume: ap.userVolume, Active: ap.ctrl != nil}
+	out := ap.stream
+	ap.mu.Unlock()
+	outputs := []OutputInfo{device}
+	if out != nil {
+		outputs = append(outputs, out.info())
+	}
+	return outputs
+}
@@ -0,0 +1,49 @@
+package audio
+
+import (
+	"context"
+	"time"
+)
+
+const PositionInterval = 250 * time.Millisecond
+
+type Position struct {
+	Path     string  `json:"path"`
+	Position float64 `json:"position"`
+	Duration float64 `json:"duration"`
+	Playing  bool    `json:"playing"`
+}
+
+func (ap *AudioPlayer) Position() Position {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	p := Position{Path: ap.filePath, Playing: ap.isPlaying}
+	if ap.streamer != nil && ap.format.SampleRate > 0 {
+		sr := float64(ap.format.SampleRate)
+		p.Position = float64(ap.streamer.Position()) / sr
+		p.Duration = float64(ap.streamer.Len()) / sr
+	}
+	return p
+}
+
+func (ap *AudioPlayer) WatchPosition(ctx context.Context, interval time.Duration, fn func(Position)) {
+	if interval <= 0 {
+		interval = PositionInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var last Position
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		p := ap.Position()
+		if p.Path == "" || p == last {
+			continue
+		}
+		last = p
+		fn(p)
+	}
+}
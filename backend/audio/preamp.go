@@ -0,0 +1,27 @@
+package audio
+
+import (
+	"fmt"
+	"log"
+	"math"
+)
+
+const MaxPreampDb = 12.0
+
+func (ap *AudioPlayer) SetPreamp(db float64) error {
+	if math.IsNaN(db) || db < -MaxPreampDb || db > MaxPreampDb {
+		return fmt.Errorf("preamp must be between %.0f and %.0f dB", -MaxPreampDb, MaxPreampDb)
+	}
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	ap.preampDb = db
+	ap.applyVolumeLocked()
+	log.Printf("[audio] preamp %.1f dB", db)
+	return nil
+}
+
+func (ap *AudioPlayer) Preamp() float64 {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	return ap.preampDb
+}
@@ -0,0 +1,80 @@
+package audio
+
+import (
+	"log"
+	"path/filepath"
+	"time"
+
+	"kitty/backend/decode"
+	"kitty/backend/metadata"
+
+	"github.com/gopxl/beep"
+)
+
+type preloaded struct {
+	path     string
+	streamer beep.StreamSeekCloser
+	format   beep.Format
+}
+
+func CanDecode(path string) bool {
+	return decode.CanDecode(path)
+}
+
+func openStream(path string) (beep.StreamSeekCloser, beep.Format, error) {
+	if _, _, ok := metadata.SplitCueTrackPath(path); ok {
+		return openCueTrack(path)
+	}
+	streamer, format, err := decode.Open(path)
+	if err != nil {
+		log.Printf("[audio] open %s failed: %v", filepath.Base(path), err)
+		return nil, beep.Format{}, err
+	}
+	return streamer, format, nil
+}
+
+func (ap *AudioPlayer) Preload(path string) error {
+	ap.mu.Lock()
+	if (ap.preload != nil && ap.preload.path == path) || ap.filePath == path {
+		ap.mu.Unlock()
+		return nil
+	}
+	ap.mu.Unlock()
+
+	started := time.Now()
+	streamer, format, err := openStream(path)
+	if err != nil {
+		return err
+	}
+	buf := make([][2]float64, 512)
+	streamer.Stream(buf)
+	if err := streamer.Seek(0); err != nil {
+		streamer.Close()
+		return err
+	}
+
+	ap.mu.Lock()
+	prev := ap.preload
+	ap.preload = &preloaded{path: path, streamer: streamer, format: format}
+	ap.mu.Unlock()
+	if prev != nil {
+		_ = prev.streamer.Close()
+	}
+	log.Printf("[audio] preloaded %s in %s", path, time.Since(started).Round(time.Millisecond))
+	return nil
+}
+
+func (ap *AudioPlayer) takePreloaded(path string) (beep.StreamSeekCloser, beep.Format, bool) {
+	ap.mu.Lock()
+	p := ap.preload
+	ap.preload = nil
+	ap.mu.Unlock()
+	if p == nil {
+		return nil, beep.Format{}, false
+	}
+	if p.path != path {
+		_ = p.streamer.Close()
+		return nil, beep.Format{}, false
+	}
+	return p.streamer, p.format, true
+}
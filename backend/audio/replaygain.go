@@ -0,0 +1,139 @@
+package audio
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+
+	"github.com/gopxl/beep/speaker"
+)
+
+const (
+	ReplayGainOff   = "off"
+	ReplayGainTrack = "track"
+	ReplayGainAlbum = "album"
+	ReplayGainAuto  = "auto"
+)
+
+type ReplayGain struct {
+	TrackGain float64
+	TrackPeak float64
+	AlbumGain float64
+	AlbumPeak float64
+	HasTrack  bool
+	HasAlbum  bool
+}
+
+func NormalizeReplayGainMode(mode string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", ReplayGainOff:
+		return ReplayGainOff, nil
+	case ReplayGainTrack:
+		return ReplayGainTrack, nil
+	case ReplayGainAlbum:
+		return ReplayGainAlbum, nil
+	case ReplayGainAuto:
+		return ReplayGainAuto, nil
+	default:
+		return "", fmt.Errorf("unknown replaygain mode: %s", mode)
+	}
+}
+
+func (rg ReplayGain) gainDb(mode string) float64 {
+	var gain, peak float64
+	switch {
+	case mode == ReplayGainAlbum && rg.HasAlbum:
+		gain, peak = rg.AlbumGain, rg.AlbumPeak
+	case mode != ReplayGainOff && rg.HasTrack:
+		gain, peak = rg.TrackGain, rg.TrackPeak
+	case mode == ReplayGainTrack && rg.HasAlbum:
+		gain, peak = rg.AlbumGain, rg.AlbumPeak
+	default:
+		return 0
+	}
+	if peak > 0 {
+		if limit := -20 * math.Log10(peak); gain > limit {
+			gain = limit
+		}
+	}
+	return gain
+}
+
+func dbToVolume(db float64) float64 {
+	return db / 20 * math.Log2(10)
+}
+
+func (ap *AudioPlayer) SetReplayGainMode(mode string) error {
+	mode, err := NormalizeReplayGainMode(mode)
+	if err != nil {
+		return err
+	}
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	ap.rgMode = mode
+	ap.applyVolumeLocked()
+	log.Printf("[audio] replaygain mode %s (%.2f dB)", mode, ap.rg.gainDb(ap.effectiveRGModeLocked()))
+	return nil
+}
+
+func (ap *AudioPlayer) SetReplayGain(rg ReplayGain) {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	ap.rg = rg
+	ap.applyVolumeLocked()
+}
+
+func (ap *AudioPlayer) ReplayGainMode() string {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	if ap.rgMode == "" {
+		return ReplayGainOff
+	}
+	return ap.rgMode
+}
+
+func (ap *AudioPlayer) targetVolumeLocked() float64 {
+	if ap.bitPerfect {
+		return 0
+	}
+	return ap.userVolume
+}
+
+func (ap *AudioPlayer) targetGainLocked() float64 {
+	if ap.bitPerfect {
+		return 0
+	}
+	base := dbToVolume(ap.preampDb)
+	if gain, ok := ap.normalizationGainLocked(); ok {
+		return base + dbToVolume(gain)
+	}
+	return base + dbToVolume(ap.rg.gainDb(ap.effectiveRGModeLocked()))
+}
+
+func (ap *AudioPlayer) SetAlbumContext(album bool) {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	ap.albumContext = album
+	ap.applyVolumeLocked()
+}
+
+func (ap *AudioPlayer) effectiveRGModeLocked() string {
+	if ap.rgMode != ReplayGainAuto {
+		return ap.rgMode
+	}
+	if ap.albumContext {
+		return ReplayGainAlbum
+	}
+	return ReplayGainTrack
+}
+
+func (ap *AudioPlayer) applyVolumeLocked() {
+	if ap.volume == nil {
+		return
+	}
+	speaker.Lock()
+	ap.gain.Volume = ap.targetGainLocked()
+	ap.volume.Volume = ap.targetVolumeLocked()
+	speaker.Unlock()
+}
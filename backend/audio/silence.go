@@ -0,0 +1,79 @@
+package audio
+
+import (
+	"math"
+	"time"
+
+	"github.com/gopxl/beep"
+)
+
+const (
+	silenceThreshold      = 0.00316
+	defaultSkipSilenceGap = 3.0
+	skipScanFactor        = 32
+)
+
+type silenceSkipper struct {
+	src     beep.Streamer
+	enabled bool
+	minGap  int
+	run     int
+}
+
+func newSilenceSkipper(src beep.Streamer, sr beep.SampleRate, enabled bool, minGapSec float64) *silenceSkipper {
+	s := &silenceSkipper{src: src, enabled: enabled}
+	s.configure(sr, minGapSec)
+	return s
+}
+
+func (s *silenceSkipper) configure(sr beep.SampleRate, minGapSec float64) {
+	if minGapSec <= 0 {
+		minGapSec = defaultSkipSilenceGap
+	}
+	s.minGap = sr.N(time.Duration(minGapSec * float64(time.Second)))
+}
+
+func (s *silenceSkipper) reset() {
+	s.run = 0
+}
+
+func (s *silenceSkipper) Stream(samples [][2]float64) (int, bool) {
+	if !s.enabled {
+		return s.src.Stream(samples)
+	}
+
+	filled := 0
+	budget := len(samples) * skipScanFactor
+	for filled < len(samples) {
+		n, ok := s.src.Stream(samples[filled:])
+		kept := filled
+		for i := filled; i < filled+n; i++ {
+			if math.Abs(samples[i][0]) < silenceThreshold && math.Abs(samples[i][1]) < silenceThreshold {
+				s.run++
+			} else {
+				s.run = 0
+			}
+			if s.run > s.minGap {
+				continue
+			}
+			samples[kept] = samples[i]
+			kept++
+		}
+		filled = kept
+		budget -= n
+		if !ok {
+			return filled, filled > 0
+		}
+		if budget <= 0 || n == 0 {
+			for i := filled; i < len(samples); i++ {
+				samples[i] = [2]float64{}
+			}
+			return len(samples), true
+		}
+	}
+	return filled, true
+}
+
+func (s *silenceSkipper) Err() error {
+	return s.src.Err()
+}
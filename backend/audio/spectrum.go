@@ -0,0 +1,119 @@
+package audio
+
+import (
+	"math"
+	"math/cmplx"
+	"sync"
+
+	"github.com/gopxl/beep"
+)
+
+const (
+	SpectrumBands = 64
+
+	spectrumSize    = 2048
+	spectrumFloorDb = -80.0
+	spectrumLowHz   = 20.0
+	spectrumHighHz  = 20000.0
+)
+
+type analyzer struct {
+	mu   sync.Mutex
+	ring [spectrumSize]float64
+	pos  int
+}
+
+func (a *analyzer) write(samples [][2]float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, s := range samples {
+		a.ring[a.pos] = (s[0] + s[1]) / 2
+		a.pos = (a.pos + 1) % spectrumSize
+	}
+}
+
+func (a *analyzer) reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.ring = [spectrumSize]float64{}
+	a.pos = 0
+}
+
+func (a *analyzer) bands(sr beep.SampleRate) []float64 {
+	out := make([]float64, SpectrumBands)
+	if sr <= 0 {
+		return out
+	}
+	buf := make([]complex128, spectrumSize)
+	a.mu.Lock()
+	for i := range buf {
+		w := 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(spectrumSize-1))
+		buf[i] = complex(a.ring[(a.pos+i)%spectrumSize]*w, 0)
+	}
+	a.mu.Unlock()
+	fft(buf)
+
+	binHz := float64(sr) / spectrumSize
+	high := math.Min(spectrumHighHz, float64(sr)/2)
+	ratio := math.Pow(high/spectrumLowHz, 1/float64(SpectrumBands))
+	for b := range out {
+		lo := int(spectrumLowHz * math.Pow(ratio, float64(b)) / binHz)
+		hi := int(spectrumLowHz * math.Pow(ratio, float64(b+1)) / binHz)
+		if lo < 1 {
+			lo = 1
+		}
+		if hi < lo {
+			hi = lo
+		}
+		if hi > spectrumSize/2 {
+			hi = spectrumSize / 2
+		}
+		peak := 0.0
+		for k := lo; k <= hi; k++ {
+			peak = math.Max(peak, cmplx.Abs(buf[k]))
+		}
+		amp := 4 * peak / spectrumSize
+		if amp <= 0 {
+			continue
+		}
+		db := 20 * math.Log10(amp)
+		out[b] = math.Max(0, math.Min(1, (db-spectrumFloorDb)/-spectrumFloorDb))
+	}
+	return out
+}
+
+func fft(x []complex128) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j |= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				even, odd := x[start+k], x[start+k+size/2]*w
+				x[start+k] = even + odd
+				x[start+k+size/2] = even - odd
+				w *= step
+			}
+		}
+	}
+}
+
+func (ap *AudioPlayer) Spectrum() []float64 {
+	ap.mu.Lock()
+	playing := ap.isPlaying && ap.ctrl != nil
+	ap.mu.Unlock()
+	if !playing {
+		return make([]float64, SpectrumBands)
+	}
+	return ap.spectrum.bands(outputRate())
+}
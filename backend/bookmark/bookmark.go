@@ -0,0 +1,248 @@
+package bookmark
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const MaxLabelLength = 200
+
+var ErrNotFound = errors.New("bookmark not found")
+
+type Bookmark struct {
+	ID        string  `json:"id"`
+	Path      string  `json:"path"`
+	Position  float64 `json:"position"`
+	Label     string  `json:"label"`
+	Auto      bool    `json:"auto,omitempty"`
+	CreatedAt int64   `json:"createdAt"`
+	UpdatedAt int64   `json:"updatedAt"`
+}
+
+type data struct {
+	Tracks map[string][]Bookmark `json:"tracks"`
+}
+
+type Store struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewStore() *Store {
+	return &Store{path: storePath()}
+}
+
+func storePath() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil || configDir == "" {
+		return "kitty_track_bookmarks.json"
+	}
+	return filepath.Join(configDir, "Kitty", "track_bookmarks.json")
+}
+
+func (s *Store) Path() string {
+	return s.path
+}
+
+func (s *Store) Add(path string, position float64, label string) (Bookmark, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return Bookmark{}, fmt.Errorf("path is required")
+	}
+	if position < 0 {
+		return Bookmark{}, fmt.Errorf("position must not be negative")
+	}
+	label = strings.TrimSpace(label)
+	if len(label) > MaxLabelLength {
+		return Bookmark{}, fmt.Errorf("label is longer than %d characters", MaxLabelLength)
+	}
+	if label == "" {
+		label = "Bookmark at " + FormatPosition(position)
+	}
+	id, err := newID()
+	if err != nil {
+		return Bookmark{}, err
+	}
+	now := time.Now().Unix()
+	b := Bookmark{ID: id, Path: path, Position: position, Label: label, CreatedAt: now, UpdatedAt: now}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, err := s.loadLocked()
+	if err != nil {
+		return Bookmark{}, err
+	}
+	d.Tracks[path] = append(d.Tracks[path], b)
+	return b, s.saveLocked(d)
+}
+
+func (s *Store) List(path string) ([]Bookmark, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, err := s.loadLocked()
+	if err != nil {
+		return nil, err
+	}
+	out := append([]Bookmark{}, d.Tracks[path]...)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Position < out[j].Position })
+	return out, nil
+}
+
+func (s *Store) Delete(path, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, err := s.loadLocked()
+	if err != nil {
+		return err
+	}
+	marks := d.Tracks[path]
+	for i, b := range marks {
+		if b.ID != id {
+			continue
+		}
+		marks = append(marks[:i], marks[i+1:]...)
+		if len(marks) == 0 {
+			delete(d.Tracks, path)
+		} else {
+			d.Tracks[path] = marks
+		}
+		return s.saveLocked(d)
+	}
+	return ErrNotFound
+}
+
+func (s *Store) SetResume(path string, position float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, err := s.loadLocked()
+	if err != nil {
+		return err
+	}
+	now := time.Now().Unix()
+	marks := d.Tracks[path]
+	for i, b := range marks {
+		if b.Auto {
+			marks[i].Position = position
+			marks[i].Label = "Last position"
+			marks[i].UpdatedAt = now
+			return s.saveLocked(d)
+		}
+	}
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+	d.Tracks[path] = append(marks, Bookmark{ID: id, Path: path, Position: position, Label: "Last position", Auto: true, CreatedAt: now, UpdatedAt: now})
+	return s.saveLocked(d)
+}
+
+func (s *Store) ClearResume(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, err := s.loadLocked()
+	if err != nil {
+		return err
+	}
+	marks := d.Tracks[path]
+	kept := marks[:0]
+	for _, b := range marks {
+		if !b.Auto {
+			kept = append(kept, b)
+		}
+	}
+	if len(kept) == len(marks) {
+		return nil
+	}
+	if len(kept) == 0 {
+		delete(d.Tracks, path)
+	} else {
+		d.Tracks[path] = kept
+	}
+	return s.saveLocked(d)
+}
+
+func (s *Store) Latest(path string) (Bookmark, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, err := s.loadLocked()
+	if err != nil {
+		return Bookmark{}, false, err
+	}
+	var (
+		latest Bookmark
+		found  bool
+	)
+	for _, b := range d.Tracks[path] {
+		if !found || b.UpdatedAt > latest.UpdatedAt || (b.UpdatedAt == latest.UpdatedAt && b.Auto) {
+			latest, found = b, true
+		}
+	}
+	return latest, found, nil
+}
+
+func (s *Store) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func FormatPosition(sec float64) string {
+	total := int(sec)
+	h, m, ss := total/3600, total/60%60, total%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, ss)
+	}
+	return fmt.Sprintf("%d:%02d", m, ss)
+}
+
+func newID() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func (s *Store) loadLocked() (*data, error) {
+	d := &data{Tracks: make(map[string][]Bookmark)}
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return d, nil
+		}
+		return nil, err
+	}
+	if strings.TrimSpace(string(raw)) == "" {
+		return d, nil
+	}
+	if err := json.Unmarshal(raw, d); err != nil {
+		return nil, err
+	}
+	if d.Tracks == nil {
+		d.Tracks = make(map[string][]Bookmark)
+	}
+	return d, nil
+}
+
+func (s *Store) saveLocked(d *data) error {
+	raw, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, raw, 0o644)
+}
@@ -0,0 +1,91 @@
+package cloudfile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	hydrateChunk   = 1 << 20
+	hydrateTimeout = 10 * time.Minute
+	pollInterval   = 500 * time.Millisecond
+)
+
+func IsPlaceholder(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return os.IsNotExist(err) && hasStub(path)
+	}
+	return !info.IsDir() && placeholderAttrs(info)
+}
+
+func Hydrate(ctx context.Context, path string, progress func(done, total int64)) error {
+	if !IsPlaceholder(path) {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, hydrateTimeout)
+	defer cancel()
+	started := time.Now()
+	log.Printf("[cloud] hydrating %s", filepath.Base(path))
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := requestDownload(ctx, path); err != nil {
+			return err
+		}
+		if err := waitForFile(ctx, path); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	total := info.Size()
+	buf := make([]byte, hydrateChunk)
+	var done int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("download of %s did not finish: %w", filepath.Base(path), err)
+		}
+		n, err := f.Read(buf)
+		done += int64(n)
+		if progress != nil && n > 0 {
+			progress(done, total)
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	log.Printf("[cloud] hydrated %s (%d bytes) in %s", filepath.Base(path), done, time.Since(started).Round(time.Millisecond))
+	return nil
+}
+
+func waitForFile(ctx context.Context, path string) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("download of %s did not finish: %w", filepath.Base(path), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
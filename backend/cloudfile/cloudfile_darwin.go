@@ -0,0 +1,39 @@
+package cloudfile
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+const sfDataless = 0x40000000
+
+func placeholderAttrs(info os.FileInfo) bool {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	return ok && st.Flags&sfDataless != 0
+}
+
+func stubPath(path string) string {
+	return filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".icloud")
+}
+
+func hasStub(path string) bool {
+	_, err := os.Stat(stubPath(path))
+	return err == nil
+}
+
+func requestDownload(ctx context.Context, path string) error {
+	out, err := exec.CommandContext(ctx, "brctl", "download", path).CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return fmt.Errorf("request iCloud download failed: %s", msg)
+	}
+	return nil
+}
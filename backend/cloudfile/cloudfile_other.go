@@ -0,0 +1,21 @@
+//go:build !darwin && !windows
+
+package cloudfile
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+func placeholderAttrs(info os.FileInfo) bool {
+	return false
+}
+
+func hasStub(path string) bool {
+	return false
+}
+
+func requestDownload(ctx context.Context, path string) error {
+	return fmt.Errorf("file not found: %s", path)
+}
@@ -0,0 +1,30 @@
+package cloudfile
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+const (
+	fileAttributeOffline            = 0x00001000
+	fileAttributeRecallOnOpen       = 0x00040000
+	fileAttributeRecallOnDataAccess = 0x00400000
+)
+
+func placeholderAttrs(info os.FileInfo) bool {
+	data, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return false
+	}
+	return data.FileAttributes&(fileAttributeOffline|fileAttributeRecallOnOpen|fileAttributeRecallOnDataAccess) != 0
+}
+
+func hasStub(path string) bool {
+	return false
+}
+
+func requestDownload(ctx context.Context, path string) error {
+	return fmt.Errorf("file not found: %s", path)
+}
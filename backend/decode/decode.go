@@ -0,0 +1,52 @@
+package decode
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gopxl/beep"
+	"github.com/gopxl/beep/mp3"
+	"github.com/gopxl/beep/vorbis"
+	"github.com/gopxl/beep/wav"
+)
+
+var nativeExts = map[string]bool{".mp3": true, ".wav": true, ".ogg": true, ".flac": true}
+
+func CanDecode(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return nativeExts[ext] || transcodeExts[ext]
+}
+
+func Open(path string) (beep.StreamSeekCloser, beep.Format, error) {
+	if needsTranscode(path) {
+		return decodeFFmpeg(path)
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	if !nativeExts[ext] {
+		return nil, beep.Format{}, fmt.Errorf("unsupported audio format %s: %w", ext, os.ErrInvalid)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, beep.Format{}, err
+	}
+
+	var streamer beep.StreamSeekCloser
+	var format beep.Format
+	switch ext {
+	case ".mp3":
+		streamer, format, err = mp3.Decode(f)
+	case ".wav":
+		streamer, format, err = wav.Decode(f)
+	case ".ogg":
+		streamer, format, err = vorbis.Decode(f)
+	case ".flac":
+		streamer, format, err = decodeFLAC(f)
+	}
+	if err != nil {
+		f.Close()
+		return nil, beep.Format{}, err
+	}
+	return streamer, format, nil
+}
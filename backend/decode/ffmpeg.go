@@ -0,0 +1,182 @@
+package decode
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"kitty/backend/media"
+
+	"github.com/gopxl/beep"
+)
+
+const (
+	transcodeFrameBytes = 8
+	probeTimeout        = 15 * time.Second
+)
+
+var transcodeExts = map[string]bool{".m4a": true, ".m4b": true, ".aac": true, ".alac": true, ".mp4": true}
+
+func needsTranscode(path string) bool {
+	return transcodeExts[strings.ToLower(filepath.Ext(path))]
+}
+
+type ffmpegStream struct {
+	ffmpeg string
+	path   string
+	rate   int
+	length int
+	pos    int
+
+	cmd    *exec.Cmd
+	out    io.ReadCloser
+	reader *bufio.Reader
+	stderr bytes.Buffer
+	frame  [transcodeFrameBytes]byte
+	atEnd  bool
+	err    error
+}
+
+func decodeFFmpeg(path string) (beep.StreamSeekCloser, beep.Format, error) {
+	ffmpeg, err := media.ResolveFFmpeg()
+	if err != nil {
+		return nil, beep.Format{}, fmt.Errorf("playing %s requires ffmpeg: %w", filepath.Ext(path), err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	info, err := media.ProbeAudio(ctx, path)
+	if err != nil {
+		return nil, beep.Format{}, fmt.Errorf("probe %s failed: %w", filepath.Base(path), err)
+	}
+	s := &ffmpegStream{
+		ffmpeg: ffmpeg,
+		path:   path,
+		rate:   info.SampleRate,
+		length: int(math.Round(info.Duration * float64(info.SampleRate))),
+	}
+	if err := s.start(); err != nil {
+		return nil, beep.Format{}, err
+	}
+	format := beep.Format{SampleRate: beep.SampleRate(info.SampleRate), NumChannels: 2, Precision: 4}
+	return s, format, nil
+}
+
+func (s *ffmpegStream) start() error {
+	args := []string{"-v", "error", "-nostdin"}
+	if s.pos > 0 {
+		args = append(args, "-ss", strconv.FormatFloat(float64(s.pos)/float64(s.rate), 'f', 6, 64))
+	}
+	args = append(args, "-i", s.path, "-vn", "-map", "0:a:0", "-ac", "2", "-ar", strconv.Itoa(s.rate), "-f", "f32le", "-")
+	cmd := exec.Command(s.ffmpeg, args...)
+	s.stderr.Reset()
+	cmd.Stderr = &limitedBuffer{buf: &s.stderr, limit: 4096}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("decode %s failed: %w", filepath.Base(s.path), err)
+	}
+	s.cmd, s.out = cmd, out
+	s.reader = bufio.NewReaderSize(out, 64*1024)
+	return nil
+}
+
+func (s *ffmpegStream) stop() error {
+	if s.cmd == nil {
+		return nil
+	}
+	_ = s.cmd.Process.Kill()
+	_ = s.out.Close()
+	err := s.cmd.Wait()
+	s.cmd, s.out, s.reader = nil, nil, nil
+	return err
+}
+
+func (s *ffmpegStream) Stream(samples [][2]float64) (int, bool) {
+	if s.err != nil || s.atEnd || s.reader == nil {
+		return 0, false
+	}
+	n := 0
+	for n < len(samples) {
+		if _, err := io.ReadFull(s.reader, s.frame[:]); err != nil {
+			s.finish(err)
+			break
+		}
+		l := math.Float32frombits(binary.LittleEndian.Uint32(s.frame[0:4]))
+		r := math.Float32frombits(binary.LittleEndian.Uint32(s.frame[4:8]))
+		samples[n] = [2]float64{float64(l), float64(r)}
+		n++
+	}
+	s.pos += n
+	return n, n > 0
+}
+
+func (s *ffmpegStream) finish(readErr error) {
+	s.atEnd = true
+	cmd := s.cmd
+	s.cmd, s.out, s.reader = nil, nil, nil
+	waitErr := cmd.Wait()
+	if readErr != io.EOF && readErr != io.ErrUnexpectedEOF {
+		s.err = readErr
+		return
+	}
+	if waitErr != nil {
+		msg := strings.TrimSpace(s.stderr.String())
+		if msg == "" {
+			msg = waitErr.Error()
+		}
+		s.err = fmt.Errorf("decode %s failed: %s", filepath.Base(s.path), msg)
+	}
+}
+
+func (s *ffmpegStream) Err() error {
+	return s.err
+}
+
+func (s *ffmpegStream) Len() int {
+	return max(s.length, s.pos)
+}
+
+func (s *ffmpegStream) Position() int {
+	return s.pos
+}
+
+func (s *ffmpegStream) Seek(p int) error {
+	if p < 0 {
+		return fmt.Errorf("seek position %d out of range", p)
+	}
+	_ = s.stop()
+	s.pos, s.atEnd, s.err = p, false, nil
+	if err := s.start(); err != nil {
+		s.err = err
+		return err
+	}
+	return nil
+}
+
+func (s *ffmpegStream) Close() error {
+	_ = s.stop()
+	return nil
+}
+
+type limitedBuffer struct {
+	buf   *bytes.Buffer
+	limit int
+}
+
+func (w *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := w.limit - w.buf.Len(); remaining > 0 {
+		w.buf.Write(p[:min(len(p), remaining)])
+	}
+	return len(p), nil
+}
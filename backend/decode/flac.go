@@ -0,0 +1,125 @@
+package decode
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gopxl/beep"
+	"github.com/mewkiz/flac"
+)
+
+type flacDecoder struct {
+	f      *os.File
+	stream *flac.Stream
+	block  [][2]float64
+	buf    [][2]float64
+	pos    int
+	scale  float64
+	atEnd  bool
+	err    error
+}
+
+func decodeFLAC(f *os.File) (beep.StreamSeekCloser, beep.Format, error) {
+	stream, err := flac.NewSeek(f)
+	if err != nil {
+		return nil, beep.Format{}, fmt.Errorf("flac: %w", err)
+	}
+	info := stream.Info
+	if info.BitsPerSample == 0 || info.BitsPerSample > 32 || info.NChannels == 0 {
+		return nil, beep.Format{}, fmt.Errorf("flac: unsupported stream (%d-bit, %d channels)", info.BitsPerSample, info.NChannels)
+	}
+	d := &flacDecoder{f: f, stream: stream, scale: 1 / float64(int64(1)<<(info.BitsPerSample-1))}
+	format := beep.Format{
+		SampleRate:  beep.SampleRate(info.SampleRate),
+		NumChannels: int(info.NChannels),
+		Precision:   (int(info.BitsPerSample) + 7) / 8,
+	}
+	return d, format, nil
+}
+
+func (d *flacDecoder) Stream(samples [][2]float64) (int, bool) {
+	if d.err != nil || d.atEnd {
+		return 0, false
+	}
+	n := 0
+	for n < len(samples) {
+		if len(d.buf) == 0 {
+			if err := d.refill(); err != nil {
+				if err != io.EOF {
+					d.err = err
+				}
+				d.atEnd = true
+				break
+			}
+		}
+		c := copy(samples[n:], d.buf)
+		d.buf = d.buf[c:]
+		n += c
+	}
+	d.pos += n
+	return n, n > 0
+}
+
+func (d *flacDecoder) refill() error {
+	frame, err := d.stream.ParseNext()
+	if err != nil {
+		return err
+	}
+	left := frame.Subframes[0].Samples
+	right := left
+	if len(frame.Subframes) > 1 {
+		right = frame.Subframes[1].Samples
+	}
+	n := len(left)
+	if cap(d.block) < n {
+		d.block = make([][2]float64, n)
+	}
+	d.buf = d.block[:n]
+	for i := range d.buf {
+		d.buf[i] = [2]float64{float64(left[i]) * d.scale, float64(right[i]) * d.scale}
+	}
+	return nil
+}
+
+func (d *flacDecoder) Err() error {
+	return d.err
+}
+
+func (d *flacDecoder) Len() int {
+	return int(d.stream.Info.NSamples)
+}
+
+func (d *flacDecoder) Position() int {
+	return d.pos
+}
+
+func (d *flacDecoder) Seek(p int) error {
+	if p < 0 || p > d.Len() {
+		return fmt.Errorf("flac: seek position %d out of range [0, %d]", p, d.Len())
+	}
+	d.buf, d.err, d.atEnd = nil, nil, false
+	start, err := d.stream.Seek(uint64(p))
+	if err == io.EOF {
+		d.pos, d.atEnd = p, true
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("flac: %w", err)
+	}
+	d.pos = int(start)
+	for d.pos < p {
+		if err := d.refill(); err != nil {
+			d.pos, d.atEnd = p, true
+			return nil
+		}
+		skip := min(p-d.pos, len(d.buf))
+		d.buf = d.buf[skip:]
+		d.pos += skip
+	}
+	return nil
+}
+
+func (d *flacDecoder) Close() error {
+	return d.f.Close()
+}
@@ -0,0 +1,388 @@
+package downloader
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"kitty/backend/storage"
+)
+
+const MaxCredentialNameLength = 100
+
+var CredentialServices = []string{"youtube", "instagram", "instagram_bearer", "twitter", "reddit", "vimeo_bearer"}
+
+var (
+	ErrCredentialNotFound = errors.New("credential profile not found")
+	ErrCredentialExists   = errors.New("a credential profile with this name already exists")
+	ErrCredentialSecret   = errors.New("cookie or token is required")
+)
+
+type CredentialProfile struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Service   string   `json:"service"`
+	Domains   []string `json:"domains"`
+	Cookie    string   `json:"cookie,omitempty"`
+	HasCookie bool     `json:"hasCookie"`
+	CreatedAt int64    `json:"createdAt"`
+	UpdatedAt int64    `json:"updatedAt"`
+}
+
+type Credentials struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewCredentials() *Credentials {
+	return &Credentials{path: credentialsPath()}
+}
+
+func credentialsPath() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil || configDir == "" {
+		return "kitty_credentials.json"
+	}
+	return filepath.Join(configDir, "Kitty", "credentials.json")
+}
+
+func cookiesPath() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil || configDir == "" {
+		return "kitty_cobalt_cookies.json"
+	}
+	return filepath.Join(configDir, "Kitty", "cobalt_cookies.json")
+}
+
+func profileCookiesPath(id string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil || dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "Kitty", "cobalt", "cookies_"+id+".json")
+}
+
+func (c *Credentials) Path() string {
+	return c.path
+}
+
+func (c *Credentials) List() ([]CredentialProfile, error) {
+	c.mu.Lock()
+	profiles, err := c.loadLocked()
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]CredentialProfile, 0, len(profiles))
+	for _, p := range profiles {
+		out = append(out, p.redacted())
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return strings.ToLower(out[i].Name) < strings.ToLower(out[j].Name)
+	})
+	return out, nil
+}
+
+func NormalizeCredentialProfile(p CredentialProfile) (CredentialProfile, error) {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return CredentialProfile{}, fmt.Errorf("profile name is required")
+	}
+	if len([]rune(p.Name)) > MaxCredentialNameLength {
+		return CredentialProfile{}, fmt.Errorf("profile name must be at most %d characters", MaxCredentialNameLength)
+	}
+	service, err := normalizeCredentialService(p.Service)
+	if err != nil {
+		return CredentialProfile{}, err
+	}
+	p.Service = service
+	if p.Domains, err = normalizeDomains(p.Domains); err != nil {
+		return CredentialProfile{}, err
+	}
+	p.Cookie = strings.TrimSpace(p.Cookie)
+	p.HasCookie = false
+	return p, nil
+}
+
+func (c *Credentials) Save(p CredentialProfile) (CredentialProfile, error) {
+	p, err := NormalizeCredentialProfile(p)
+	if err != nil {
+		return CredentialProfile{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	profiles, err := c.loadLocked()
+	if err != nil {
+		return CredentialProfile{}, err
+	}
+	now := time.Now().Unix()
+	idx := -1
+	for i, existing := range profiles {
+		if p.ID != "" && existing.ID == p.ID {
+			idx = i
+			continue
+		}
+		if strings.EqualFold(existing.Name, p.Name) {
+			return CredentialProfile{}, ErrCredentialExists
+		}
+	}
+	if p.ID != "" && idx < 0 {
+		return CredentialProfile{}, ErrCredentialNotFound
+	}
+	if idx >= 0 {
+		if p.Cookie == "" {
+			p.Cookie = profiles[idx].Cookie
+		}
+		p.CreatedAt = profiles[idx].CreatedAt
+	}
+	if p.Cookie == "" {
+		return CredentialProfile{}, ErrCredentialSecret
+	}
+	p.UpdatedAt = now
+	if idx >= 0 {
+		profiles[idx] = p
+	} else {
+		buf := make([]byte, 8)
+		if _, err := rand.Read(buf); err != nil {
+			return CredentialProfile{}, err
+		}
+		p.ID = hex.EncodeToString(buf)
+		p.CreatedAt = now
+		profiles = append(profiles, p)
+	}
+	if err := c.saveLocked(profiles); err != nil {
+		return CredentialProfile{}, err
+	}
+	return p.redacted(), nil
+}
+
+func (c *Credentials) Delete(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	profiles, err := c.loadLocked()
+	if err != nil {
+		return err
+	}
+	kept := profiles[:0]
+	for _, p := range profiles {
+		if p.ID != id {
+			kept = append(kept, p)
+		}
+	}
+	if len(kept) == len(profiles) {
+		return ErrCredentialNotFound
+	}
+	return c.saveLocked(kept)
+}
+
+func (c *Credentials) ForLink(link, profileID string) (*CredentialProfile, error) {
+	c.mu.Lock()
+	profiles, err := c.loadLocked()
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if profileID != "" {
+		for _, p := range profiles {
+			if p.ID == profileID {
+				return &p, nil
+			}
+		}
+		return nil, ErrCredentialNotFound
+	}
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.Host == "" {
+		return nil, nil
+	}
+	host := strings.ToLower(strings.TrimPrefix(u.Hostname(), "www."))
+	var best *CredentialProfile
+	bestLen := 0
+	for i, p := range profiles {
+		for _, d := range p.Domains {
+			if (host == d || strings.HasSuffix(host, "."+d)) && len(d) > bestLen {
+				best, bestLen = &profiles[i], len(d)
+			}
+		}
+	}
+	return best, nil
+}
+
+func (c *Credentials) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (p CredentialProfile) redacted() CredentialProfile {
+	p.HasCookie = p.Cookie != ""
+	p.Cookie = ""
+	return p
+}
+
+func (p *CredentialProfile) Cookies() map[string][]string {
+	if p == nil || p.Cookie == "" {
+		return nil
+	}
+	return map[string][]string{p.Service: {p.Cookie}}
+}
+
+func normalizeCredentialService(service string) (string, error) {
+	s := strings.ToLower(strings.TrimSpace(service))
+	for _, known := range CredentialServices {
+		if s == known {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported credential service: %s", service)
+}
+
+func normalizeDomains(domains []string) ([]string, error) {
+	seen := make(map[string]struct{}, len(domains))
+	out := make([]string, 0, len(domains))
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if d == "" {
+			continue
+		}
+		if strings.Contains(d, "://") {
+			u, err := url.Parse(d)
+			if err != nil || u.Hostname() == "" {
+				return nil, fmt.Errorf("invalid domain: %s", d)
+			}
+			d = u.Hostname()
+		}
+		d = strings.TrimPrefix(strings.Trim(d, "./"), "www.")
+		if d == "" || strings.ContainsAny(d, "/ ") {
+			return nil, fmt.Errorf("invalid domain: %s", d)
+		}
+		if _, ok := seen[d]; ok {
+			continue
+		}
+		seen[d] = struct{}{}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+func (c *Credentials) loadLocked() ([]CredentialProfile, error) {
+	raw, err := os.ReadFile(c.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []CredentialProfile{}, nil
+		}
+		return nil, err
+	}
+	var profiles []CredentialProfile
+	if err := json.Unmarshal(raw, &profiles); err != nil {
+		return nil, err
+	}
+	plain := false
+	for i := range profiles {
+		if profiles[i].Cookie == "" {
+			continue
+		}
+		plain = plain || !storage.IsSealed(profiles[i].Cookie)
+		if profiles[i].Cookie, err = storage.Unseal(profiles[i].Cookie); err != nil {
+			return nil, fmt.Errorf("credential profile %q: %w", profiles[i].Name, err)
+		}
+	}
+	if plain {
+		if err := c.saveLocked(profiles); err != nil {
+			log.Printf("[downloader] encrypt stored credentials failed: %v", err)
+		}
+	}
+	return profiles, nil
+}
+
+func (c *Credentials) saveLocked(profiles []CredentialProfile) error {
+	sealed := make([]CredentialProfile, len(profiles))
+	for i, p := range profiles {
+		var err error
+		if p.Cookie, err = storage.Seal(p.Cookie); err != nil {
+			return err
+		}
+		sealed[i] = p
+	}
+	raw, err := json.Marshal(sealed)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, raw, 0o600)
+}
+
+func (c *Client) UseProfile(ctx context.Context, profile *CredentialProfile) (string, error) {
+	cookies := profile.Cookies()
+	if len(cookies) == 0 {
+		return "", c.Start(ctx)
+	}
+	raw, err := json.Marshal(cookies)
+	if err != nil {
+		return "", err
+	}
+	sig := string(raw)
+
+	c.mu.Lock()
+	api, ok := c.profiles[profile.ID]
+	if !ok {
+		api = newCobaltAPI(c.nextPort, profileCookiesPath(profile.ID))
+		c.nextPort++
+		c.profiles[profile.ID] = api
+	}
+	c.mu.Unlock()
+
+	api.startMu.Lock()
+	defer api.startMu.Unlock()
+	c.mu.Lock()
+	changed := api.cookieSig != sig
+	if changed && api.running {
+		log.Printf("[downloader] credentials for %s changed, restarting its cobalt api", profile.Name)
+		c.stopAPILocked(api)
+	}
+	api.cookieSig = sig
+	c.mu.Unlock()
+	if changed {
+		if err := os.MkdirAll(filepath.Dir(api.cookiePath), 0o700); err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(api.cookiePath, raw, 0o600); err != nil {
+			return "", err
+		}
+	}
+	return profile.ID, c.startAPI(ctx, api)
+}
+
+func (c *Client) StopProfile(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	api, ok := c.profiles[id]
+	if !ok {
+		return
+	}
+	c.stopAPILocked(api)
+	removeCookieFile(api.cookiePath)
+	delete(c.profiles, id)
+}
+
+func removeCookieFile(path string) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		log.Printf("[downloader] remove cookie file failed: %v", err)
+	}
+}
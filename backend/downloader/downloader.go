@@ -20,22 +20,38 @@ import (
 	"sync"
 	"time"
 
+	"kitty/backend/httpclient"
 	"kitty/backend/metadata"
 )
 
+const cobaltPort = 8787
+
 type Client struct {
 	apiDir    string
-	baseURL   string
 	mu        sync.Mutex
-	cmd       *exec.Cmd
-	running   bool
+	api       *cobaltAPI
 	installed bool
 	http      *http.Client
+	fetch     *http.Client
+	covers    *http.Client
 	pm        *pkgManager
 	nodePath  string
 
 	updateOnce   sync.Once
 	updateCancel context.CancelFunc
+
+	profiles map[string]*cobaltAPI
+	nextPort int
+}
+
+type cobaltAPI struct {
+	startMu    sync.Mutex
+	baseURL    string
+	port       int
+	cookiePath string
+	cookieSig  string
+	cmd        *exec.Cmd
+	running    bool
 }
 
 type pkgManager struct {
@@ -98,6 +114,10 @@ type DownloadResult struct {
 	Errors    []string                 `json:"errors"`
 	Format    string                   `json:"format"`
 	Bitrate   string                   `json:"bitrate"`
+	Skipped   bool                     `json:"skipped,omitempty"`
+	Duplicate *metadata.TrackMetadata  `json:"duplicate,omitempty"`
+	Bytes     int64                    `json:"bytes,omitempty"`
+	Took      time.Duration            `json:"-"`
 }
 
 type DownloadInfo struct {
@@ -106,6 +126,7 @@ type DownloadInfo struct {
 	MimeType  string
 	CoverURL  string
 	MetaHints map[string]interface{}
+	SourceURL string
 
 	RequestedFormat  string
 	RequestedBitrate string
@@ -113,18 +134,28 @@ type DownloadInfo struct {
 
 func New(apiDir string) *Client {
 	return &Client{
-		apiDir:  apiDir,
-		baseURL: "http://127.0.0.1:8787",
-		http: &http.Client{
-			Timeout: 60 * time.Second,
-		},
+		apiDir:   apiDir,
+		api:      newCobaltAPI(cobaltPort, ""),
+		profiles: make(map[string]*cobaltAPI),
+		nextPort: cobaltPort + 1,
+		http:     httpclient.New(httpclient.KindAPI),
+		fetch:    httpclient.New(httpclient.KindDownload),
+		covers:   httpclient.New(httpclient.KindCover),
+	}
+}
+
+func newCobaltAPI(port int, cookiePath string) *cobaltAPI {
+	return &cobaltAPI{
+		baseURL:    "http://127.0.0.1:" + strconv.Itoa(port),
+		port:       port,
+		cookiePath: cookiePath,
 	}
 }
 
 func (c *Client) Status() Status {
 	c.mu.Lock()
-	running := c.running
-	cmd := c.cmd
+	running := c.api.running
+	cmd := c.api.cmd
 	c.mu.Unlock()
 
 	if !running {
@@ -134,20 +165,16 @@ func (c *Client) Status() Status {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/", nil)
-	resp, err := c.http.Do(req)
-	if err == nil {
-		io.Copy(io.Discard, resp.Body)
-		resp.Body.Close()
-		if resp.StatusCode >= 200 && resp.StatusCode < 400 {
-			return Status{Running: true}
-		}
+	if c.ping(ctx, c.api) == nil {
+		return Status{Running: true}
 	}
 
 	if cmd != nil && cmd.ProcessState != nil && cmd.ProcessState.Exited() {
 		c.mu.Lock()
-		c.running = false
-		c.cmd = nil
+		if c.api.cmd == cmd {
+			c.api.running = false
+			c.api.cmd = nil
+		}
 		c.mu.Unlock()
 		return Status{Running: false}
 	}
@@ -156,12 +183,18 @@ func (c *Client) Status() Status {
 }
 
 func (c *Client) Start(ctx context.Context) error {
+	c.api.startMu.Lock()
+	defer c.api.startMu.Unlock()
+	return c.startAPI(ctx, c.api)
+}
+
+func (c *Client) startAPI(ctx context.Context, api *cobaltAPI) error {
 	if err := c.resolveAPIDir(); err != nil {
 		return err
 	}
 
 	c.mu.Lock()
-	if c.running {
+	if api.running {
 		c.mu.Unlock()
 		return nil
 	}
@@ -177,8 +210,8 @@ func (c *Client) Start(ctx context.Context) error {
 	}
 
 	c.mu.Lock()
-	defer c.mu.Unlock()
-	if c.running {
+	if api.running {
+		c.mu.Unlock()
 		return nil
 	}
 
@@ -186,43 +219,56 @@ func (c *Client) Start(ctx context.Context) error {
 	cmd.Dir = c.apiDir
 	configureCmd(cmd)
 	cmd.Env = append(os.Environ(),
-		"API_URL="+c.baseURL,
-		"API_PORT=8787",
+		"API_URL="+api.baseURL,
+		"API_PORT="+strconv.Itoa(api.port),
 		"API_LISTEN_ADDRESS=127.0.0.1",
 		"CORS_WILDCARD=1",
 	)
+	if api.cookieSig != "" {
+		cmd.Env = append(cmd.Env, "COOKIE_PATH="+api.cookiePath)
+	}
 	stdout, _ := cmd.StdoutPipe()
 	stderr, _ := cmd.StderrPipe()
 
 	if err := cmd.Start(); err != nil {
+		c.mu.Unlock()
 		return err
 	}
 
-	c.cmd = cmd
-	c.running = true
+	api.cmd = cmd
+	api.running = true
+	c.mu.Unlock()
 
+	prefix := "[cobalt]"
+	if api.port != cobaltPort {
+		prefix = fmt.Sprintf("[cobalt:%d]", api.port)
+	}
 	startupLog := &limitedBuffer{limit: 64 * 1024}
-	go streamLogs(io.TeeReader(stdout, startupLog), "[cobalt]")
-	go streamLogs(io.TeeReader(stderr, startupLog), "[cobalt]")
+	go streamLogs(io.TeeReader(stdout, startupLog), prefix)
+	go streamLogs(io.TeeReader(stderr, startupLog), prefix)
 
 	waitCh := make(chan error, 1)
 	go func() {
 		if err := cmd.Wait(); err != nil {
-			log.Printf("[downloader] cobalt api exited: %v", err)
+			log.Printf("[downloader] cobalt api on port %d exited: %v", api.port, err)
 			waitCh <- err
 		} else {
 			waitCh <- nil
 		}
 		c.mu.Lock()
-		c.running = false
-		c.cmd = nil
+		if api.cmd == cmd {
+			api.running = false
+			api.cmd = nil
+		}
 		c.mu.Unlock()
 	}()
 
 	readyCtx, cancel := context.WithTimeout(ctx, 8*time.Second)
 	defer cancel()
-	if err := c.waitForReady(readyCtx, waitCh); err != nil {
-		c.Stop()
+	if err := c.waitForReady(readyCtx, api, waitCh); err != nil {
+		c.mu.Lock()
+		c.stopAPILocked(api)
+		c.mu.Unlock()
 		out := strings.TrimSpace(startupLog.String())
 		if out != "" {
 			return fmt.Errorf("%w: %s", err, out)
@@ -233,12 +279,12 @@ func (c *Client) Start(ctx context.Context) error {
 	return nil
 }
 
-func (c *Client) waitForReady(ctx context.Context, waitCh <-chan error) error {
+func (c *Client) waitForReady(ctx context.Context, api *cobaltAPI, waitCh <-chan error) error {
 	ticker := time.NewTicker(250 * time.Millisecond)
 	defer ticker.Stop()
 
 	for {
-		if c.ping(ctx) == nil {
+		if c.ping(ctx, api) == nil {
 			return nil
 		}
 
@@ -255,8 +301,8 @@ func (c *Client) waitForReady(ctx context.Context, waitCh <-chan error) error {
 	}
 }
 
-func (c *Client) ping(ctx context.Context) error {
-	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/", nil)
+func (c *Client) ping(ctx context.Context, api *cobaltAPI) error {
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, api.baseURL+"/", nil)
 	resp, err := c.http.Do(req)
 	if err != nil {
 		return err
@@ -670,15 +716,31 @@ func (c *Client) Stop() {
 		c.updateCancel()
 		c.updateCancel = nil
 	}
-	killProcessTree(c.cmd)
-	c.cmd = nil
-	c.running = false
+	c.stopAPILocked(c.api)
+	removeCookieFile(cookiesPath())
+	for id, api := range c.profiles {
+		c.stopAPILocked(api)
+		removeCookieFile(api.cookiePath)
+		delete(c.profiles, id)
+	}
+}
+
+func (c *Client) stopAPILocked(api *cobaltAPI) {
+	killProcessTree(api.cmd)
+	api.cmd = nil
+	api.running = false
 }
 
-func (c *Client) RequestDownload(ctx context.Context, link string, format string, bitrate string) (*DownloadInfo, error) {
+func (c *Client) RequestDownload(ctx context.Context, profileID string, link string, format string, bitrate string) (*DownloadInfo, error) {
 	if link == "" {
 		return nil, errors.New("missing link")
 	}
+	c.mu.Lock()
+	api := c.api
+	if p, ok := c.profiles[profileID]; ok && profileID != "" {
+		api = p
+	}
+	c.mu.Unlock()
 	payload := downloadRequest{
 		URL:             link,
 		AudioBitrate:    bitrate,
@@ -689,7 +751,7 @@ func (c *Client) RequestDownload(ctx context.Context, link string, format string
 		DisableMetadata: false,
 	}
 	body, _ := json.Marshal(payload)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/", bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, api.baseURL+"/", bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
@@ -733,6 +795,7 @@ func (c *Client) RequestDownload(ctx context.Context, link string, format string
 			Filename:         parsed.Filename,
 			MimeType:         parsed.Output.Type,
 			MetaHints:        parsed.Output.Metadata,
+			SourceURL:        link,
 			RequestedFormat:  payload.AudioFormat,
 			RequestedBitrate: payload.AudioBitrate,
 		}, nil
@@ -750,6 +813,7 @@ func (c *Client) RequestDownload(ctx context.Context, link string, format string
 			MimeType:         parsed.Output.Type,
 			CoverURL:         coverURL,
 			MetaHints:        parsed.Output.Metadata,
+			SourceURL:        link,
 			RequestedFormat:  payload.AudioFormat,
 			RequestedBitrate: payload.AudioBitrate,
 		}, nil
@@ -773,7 +837,7 @@ func (c *Client) Fetch(ctx context.Context, downloadURL, destinationPath string)
 		return "", err
 	}
 
-	resp, err := c.http.Do(req)
+	resp, err := c.fetch.Do(req)
 	if err != nil {
 		return "", err
 	}
@@ -787,14 +851,36 @@ func (c *Client) Fetch(ctx context.Context, downloadURL, destinationPath string)
 		return "", err
 	}
 
-	out, err := os.Create(destinationPath)
+	partPath := destinationPath + partSuffix
+	c.trackPart(partPath)
+	defer c.untrackPart(partPath)
+
+	out, err := os.Create(partPath)
 	if err != nil {
 		return "", err
 	}
-	defer out.Close()
 
-	if _, err := io.Copy(out, resp.Body); err != nil {
-		return "", err
+	written, copyErr := io.Copy(out, resp.Body)
+	if copyErr == nil {
+		copyErr = out.Sync()
+	}
+	if closeErr := out.Close(); copyErr == nil {
+		copyErr = closeErr
+	}
+	if copyErr == nil {
+		copyErr = verifyDownload(written, resp.ContentLength)
+	}
+	if copyErr != nil {
+		_ = os.Remove(partPath)
+		return "", copyErr
+	}
+
+	if err := os.Rename(partPath, destinationPath); err != nil {
+		_ = os.Remove(destinationPath)
+		if retryErr := os.Rename(partPath, destinationPath); retryErr != nil {
+			_ = os.Remove(partPath)
+			return "", retryErr
+		}
 	}
 
 	return destinationPath, nil
@@ -809,7 +895,7 @@ func (c *Client) FetchDataURL(ctx context.Context, fileURL string) (string, erro
 	if err != nil {
 		return "", err
 	}
-	resp, err := c.http.Do(req)
+	resp, err := c.covers.Do(req)
 	if err != nil {
 		return "", err
 	}
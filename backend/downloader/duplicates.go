@@ -0,0 +1,43 @@
+package downloader
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	DuplicatesAsk      = "ask"
+	DuplicatesSkip     = "skip"
+	DuplicatesDownload = "download"
+
+	DuplicateDurationTolerance = 3.0
+)
+
+func NormalizeDuplicatePolicy(policy string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(policy)) {
+	case "", DuplicatesAsk:
+		return DuplicatesAsk, nil
+	case DuplicatesSkip:
+		return DuplicatesSkip, nil
+	case DuplicatesDownload:
+		return DuplicatesDownload, nil
+	}
+	return "", fmt.Errorf("unknown duplicate policy: %s", policy)
+}
+
+func HintString(hints map[string]interface{}, key string) string {
+	if s, ok := hints[key].(string); ok {
+		return strings.TrimSpace(s)
+	}
+	return ""
+}
+
+func HintDuration(hints map[string]interface{}) float64 {
+	switch v := hints["duration"].(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	}
+	return 0
+}
@@ -0,0 +1,157 @@
+package downloader
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	maxNameBytes    = 240
+	windowsMaxPath  = 260
+	longPathPrefix  = `\\?\`
+	defaultBaseName = "download"
+)
+
+type FilenameOptions struct {
+	Transliterate bool
+	Scripts       []string
+	GOOS          string
+}
+
+var windowsReserved = map[string]struct{}{
+	"CON": {}, "PRN": {}, "AUX": {}, "NUL": {},
+	"COM1": {}, "COM2": {}, "COM3": {}, "COM4": {}, "COM5": {}, "COM6": {}, "COM7": {}, "COM8": {}, "COM9": {},
+	"LPT1": {}, "LPT2": {}, "LPT3": {}, "LPT4": {}, "LPT5": {}, "LPT6": {}, "LPT7": {}, "LPT8": {}, "LPT9": {},
+}
+
+var latinFallbacks = map[rune]string{
+	'ß': "ss", 'Æ': "AE", 'æ': "ae", 'Ø': "O", 'ø': "o", 'Œ': "OE", 'œ': "oe",
+	'Ł': "L", 'ł': "l", 'Đ': "D", 'đ': "d", 'Þ': "Th", 'þ': "th", 'Ð': "D", 'ð': "d",
+	'‘': "'", '’': "'", '“': "'", '”': "'", '–': "-", '—': "-", '…': "...",
+}
+
+func SanitizeFilename(name string, opts FilenameOptions) string {
+	goos := opts.GOOS
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+
+	name = strings.ToValidUTF8(name, "")
+
+	ext := filepath.Ext(name)
+	if len(ext) > 10 || strings.ContainsAny(ext, " ") {
+		ext = ""
+	}
+	base := strings.TrimSuffix(name, ext)
+
+	if opts.Transliterate {
+		base = transliterate(base, opts.Scripts)
+		ext = transliterate(ext, opts.Scripts)
+	}
+	base = cleanComponent(base, goos)
+	ext = cleanComponent(ext, goos)
+	if ext == "." {
+		ext = ""
+	}
+
+	if strings.Trim(base, ". ") == "" {
+		base = defaultBaseName
+	}
+	if goos == "windows" {
+		stem := strings.ToUpper(base)
+		if i := strings.IndexByte(stem, '.'); i >= 0 {
+			stem = stem[:i]
+		}
+		if _, reserved := windowsReserved[stem]; reserved {
+			base = "_" + base
+		}
+	}
+
+	return truncateBytes(base, maxNameBytes-len(ext)) + ext
+}
+
+func SafePath(dir, name string, opts FilenameOptions) string {
+	goos := opts.GOOS
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	name = SanitizeFilename(name, opts)
+	full := filepath.Join(dir, name)
+	if goos != "windows" || len(full) < windowsMaxPath {
+		return full
+	}
+
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	budget := windowsMaxPath - 1 - len(filepath.Join(dir, ext)) - 1
+	if budget >= 16 {
+		return filepath.Join(dir, strings.TrimRight(truncateBytes(base, budget), " .")+ext)
+	}
+	if abs, err := filepath.Abs(full); err == nil && !strings.HasPrefix(abs, longPathPrefix) {
+		return longPathPrefix + abs
+	}
+	return full
+}
+
+func cleanComponent(s, goos string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		switch {
+		case r == utf8.RuneError:
+			continue
+		case unicode.IsControl(r), unicode.Is(unicode.Cf, r):
+			continue
+		case r >= 0xFE00 && r <= 0xFE0F, r >= 0xE0100 && r <= 0xE01EF:
+			continue
+		case r == '/' || r == '\\':
+			b.WriteRune('_')
+		case goos == "windows" && strings.ContainsRune(`<>:"\|?*`, r):
+			b.WriteRune(windowsReplacement(r))
+		case goos == "darwin" && r == ':':
+			b.WriteRune('-')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	out := strings.Join(strings.Fields(b.String()), " ")
+	if goos == "windows" {
+		out = strings.TrimRight(out, " .")
+	}
+	return out
+}
+
+func windowsReplacement(r rune) rune {
+	switch r {
+	case ':':
+		return '-'
+	case '"':
+		return '\''
+	case '<':
+		return '('
+	case '>':
+		return ')'
+	default:
+		return '_'
+	}
+}
+
+func truncateBytes(s string, limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+	if len(s) <= limit {
+		return s
+	}
+	cut := 0
+	for i := range s {
+		if i > limit {
+			break
+		}
+		cut = i
+	}
+	return strings.TrimSpace(s[:cut])
+}
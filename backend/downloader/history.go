@@ -0,0 +1,149 @@
+package downloader
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxHistoryEntries    = 2000
+	DefaultRecentDays    = 14
+	DefaultRetentionDays = 90
+)
+
+type HistoryEntry struct {
+	Path         string `json:"path"`
+	URL          string `json:"url"`
+	Title        string `json:"title"`
+	Artist       string `json:"artist"`
+	Source       string `json:"source"`
+	Format       string `json:"format"`
+	DownloadedAt int64  `json:"downloadedAt"`
+}
+
+type History struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewHistory() *History {
+	return &History{path: historyPath()}
+}
+
+func historyPath() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil || configDir == "" {
+		return "kitty_downloads.json"
+	}
+	return filepath.Join(configDir, "Kitty", "downloads.json")
+}
+
+func (h *History) Path() string {
+	return h.path
+}
+
+func (h *History) Record(e HistoryEntry) error {
+	e.Path = strings.TrimSpace(e.Path)
+	if e.Path == "" {
+		return nil
+	}
+	if e.DownloadedAt == 0 {
+		e.DownloadedAt = time.Now().Unix()
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	entries, err := h.loadLocked()
+	if err != nil {
+		return err
+	}
+	out := entries[:0]
+	for _, old := range entries {
+		if old.Path != e.Path {
+			out = append(out, old)
+		}
+	}
+	out = append(out, e)
+	if len(out) > maxHistoryEntries {
+		out = out[len(out)-maxHistoryEntries:]
+	}
+	return h.saveLocked(out)
+}
+
+func (h *History) Since(t time.Time) ([]HistoryEntry, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	entries, err := h.loadLocked()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]HistoryEntry, 0)
+	for _, e := range entries {
+		if e.DownloadedAt >= t.Unix() {
+			out = append(out, e)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].DownloadedAt > out[j].DownloadedAt })
+	return out, nil
+}
+
+func (h *History) Prune(before time.Time) ([]HistoryEntry, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	entries, err := h.loadLocked()
+	if err != nil {
+		return nil, err
+	}
+	kept := make([]HistoryEntry, 0, len(entries))
+	removed := make([]HistoryEntry, 0)
+	for _, e := range entries {
+		if e.DownloadedAt < before.Unix() {
+			removed = append(removed, e)
+		} else {
+			kept = append(kept, e)
+		}
+	}
+	if len(removed) == 0 {
+		return removed, nil
+	}
+	return removed, h.saveLocked(kept)
+}
+
+func (h *History) Clear() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := os.Remove(h.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (h *History) loadLocked() ([]HistoryEntry, error) {
+	raw, err := os.ReadFile(h.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []HistoryEntry{}, nil
+		}
+		return nil, err
+	}
+	var entries []HistoryEntry
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (h *History) saveLocked(entries []HistoryEntry) error {
+	raw, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(h.path, raw, 0o644)
+}
@@ -0,0 +1,159 @@
+package downloader
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	LinkKindTrack    = "track"
+	LinkKindPlaylist = "playlist"
+	LinkKindAlbum    = "album"
+	LinkKindArtist   = "artist"
+	LinkKindVideo    = "video"
+)
+
+type MediaLink struct {
+	URL    string `json:"url"`
+	Source string `json:"source"`
+	Kind   string `json:"kind"`
+}
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://)?(?:[a-z0-9-]+\.)*(?:soundcloud\.com|snd\.sc|youtube\.com|youtu\.be|bandcamp\.com)(?:/[^\s<>"'\x60\]\[{}|\\^]*)?`)
+
+func ExtractLinks(text string) []MediaLink {
+	links := make([]MediaLink, 0)
+	seen := make(map[string]bool)
+	for _, raw := range linkPattern.FindAllString(text, -1) {
+		raw = strings.TrimRight(raw, ".,;:!?)'\"")
+		link, ok := NormalizeMediaLink(raw)
+		if !ok || seen[link.URL] {
+			continue
+		}
+		seen[link.URL] = true
+		links = append(links, link)
+	}
+	return links
+}
+
+func NormalizeMediaLink(raw string) (MediaLink, bool) {
+	raw = strings.TrimSpace(raw)
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return MediaLink{}, false
+	}
+	u.Scheme = "https"
+	u.Fragment = ""
+	host := strings.ToLower(u.Hostname())
+	u.Host = host
+
+	switch DetectSource(u.String()) {
+	case SourceYouTube:
+		return normalizeYouTube(u)
+	case SourceSoundCloud:
+		return normalizeSoundCloud(u)
+	case SourceBandcamp:
+		return normalizeBandcamp(u)
+	}
+	return MediaLink{}, false
+}
+
+func normalizeYouTube(u *url.URL) (MediaLink, bool) {
+	q := u.Query()
+	segments := pathSegments(u.Path)
+	id := ""
+	switch {
+	case u.Host == "youtu.be" && len(segments) > 0:
+		id = segments[0]
+	case len(segments) >= 2 && (segments[0] == "shorts" || segments[0] == "live" || segments[0] == "embed"):
+		id = segments[1]
+	case len(segments) > 0 && segments[0] == "watch":
+		id = q.Get("v")
+	}
+	if id != "" {
+		return MediaLink{URL: "https://www.youtube.com/watch?v=" + url.QueryEscape(id), Source: SourceYouTube, Kind: LinkKindVideo}, true
+	}
+	if list := q.Get("list"); list != "" {
+		return MediaLink{URL: "https://www.youtube.com/playlist?list=" + url.QueryEscape(list), Source: SourceYouTube, Kind: LinkKindPlaylist}, true
+	}
+	if len(segments) > 0 && (strings.HasPrefix(segments[0], "@") || segments[0] == "channel" || segments[0] == "c") {
+		return MediaLink{URL: "https://www.youtube.com/" + strings.Join(segments, "/"), Source: SourceYouTube, Kind: LinkKindArtist}, true
+	}
+	return MediaLink{}, false
+}
+
+func normalizeSoundCloud(u *url.URL) (MediaLink, bool) {
+	segments := pathSegments(u.Path)
+	if len(segments) == 0 {
+		return MediaLink{}, false
+	}
+	if u.Host == "on.soundcloud.com" || u.Host == "snd.sc" {
+		return MediaLink{URL: "https://" + u.Host + "/" + strings.Join(segments, "/"), Source: SourceSoundCloud, Kind: LinkKindTrack}, true
+	}
+	if u.Host == "api.soundcloud.com" || u.Host == "api-v2.soundcloud.com" {
+		return MediaLink{}, false
+	}
+
+	clean := &url.URL{Scheme: "https", Host: "soundcloud.com", Path: "/" + strings.Join(segments, "/")}
+	kind := LinkKindTrack
+	switch {
+	case len(segments) == 1:
+		kind = LinkKindArtist
+	case len(segments) >= 3 && segments[1] == "sets":
+		kind = LinkKindPlaylist
+	case len(segments) == 2 && isSoundCloudProfileTab(segments[1]):
+		kind = LinkKindArtist
+	}
+	if secret := secretToken(u); secret != "" {
+		clean.RawQuery = url.Values{"secret_token": {secret}}.Encode()
+	}
+	return MediaLink{URL: clean.String(), Source: SourceSoundCloud, Kind: kind}, true
+}
+
+func normalizeBandcamp(u *url.URL) (MediaLink, bool) {
+	if u.Host == "bandcamp.com" || u.Host == "www.bandcamp.com" {
+		return MediaLink{}, false
+	}
+	segments := pathSegments(u.Path)
+	clean := &url.URL{Scheme: "https", Host: u.Host, Path: "/" + strings.Join(segments, "/")}
+	kind := LinkKindArtist
+	if len(segments) >= 2 {
+		switch segments[0] {
+		case "track":
+			kind = LinkKindTrack
+		case "album":
+			kind = LinkKindAlbum
+		}
+	}
+	if kind == LinkKindArtist {
+		clean.Path = ""
+	}
+	return MediaLink{URL: clean.String(), Source: SourceBandcamp, Kind: kind}, true
+}
+
+func pathSegments(p string) []string {
+	parts := strings.Split(p, "/")
+	out := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+func isSoundCloudProfileTab(tab string) bool {
+	switch tab {
+	case "tracks", "albums", "sets", "reposts", "likes", "popular-tracks", "followers", "following":
+		return true
+	}
+	return false
+}
+
+func secretToken(u *url.URL) string {
+	return strings.TrimSpace(u.Query().Get("secret_token"))
+}
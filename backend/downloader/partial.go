@@ -0,0 +1,113 @@
+package downloader
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const partSuffix = ".part"
+
+var partsMu sync.Mutex
+
+func partsJournalPath() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil || configDir == "" {
+		return "kitty_downloads_inflight.json"
+	}
+	return filepath.Join(configDir, "Kitty", "downloads_inflight.json")
+}
+
+func loadPartsLocked() []string {
+	data, err := os.ReadFile(partsJournalPath())
+	if err != nil {
+		return nil
+	}
+	var parts []string
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return nil
+	}
+	return parts
+}
+
+func savePartsLocked(parts []string) error {
+	path := partsJournalPath()
+	if len(parts) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.Marshal(parts)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+func (c *Client) trackPart(path string) {
+	partsMu.Lock()
+	defer partsMu.Unlock()
+	parts := loadPartsLocked()
+	for _, p := range parts {
+		if p == path {
+			return
+		}
+	}
+	if err := savePartsLocked(append(parts, path)); err != nil {
+		log.Printf("[downloader] journal write failed: %v", err)
+	}
+}
+
+func (c *Client) untrackPart(path string) {
+	partsMu.Lock()
+	defer partsMu.Unlock()
+	parts := loadPartsLocked()
+	out := parts[:0]
+	for _, p := range parts {
+		if p != path {
+			out = append(out, p)
+		}
+	}
+	if err := savePartsLocked(out); err != nil {
+		log.Printf("[downloader] journal write failed: %v", err)
+	}
+}
+
+func (c *Client) CleanupStaleParts() (int, error) {
+	partsMu.Lock()
+	defer partsMu.Unlock()
+
+	removed := 0
+	for _, p := range loadPartsLocked() {
+		if !strings.HasSuffix(p, partSuffix) {
+			continue
+		}
+		if err := os.Remove(p); err == nil {
+			removed++
+		} else if !os.IsNotExist(err) {
+			log.Printf("[downloader] remove stale part %s failed: %v", p, err)
+		}
+	}
+	if removed > 0 {
+		log.Printf("[downloader] removed %d stale partial downloads", removed)
+	}
+	return removed, savePartsLocked(nil)
+}
+
+func verifyDownload(written, expected int64) error {
+	if written <= 0 {
+		return fmt.Errorf("download produced an empty file")
+	}
+	if expected > 0 && written != expected {
+		return fmt.Errorf("download incomplete: got %d of %d bytes", written, expected)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package downloader
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+type Readiness struct {
+	APIDir      string `json:"apiDir"`
+	NodePath    string `json:"nodePath"`
+	NodeVersion string `json:"nodeVersion"`
+	Installed   bool   `json:"installed"`
+	Running     bool   `json:"running"`
+}
+
+func (c *Client) Readiness(ctx context.Context) (Readiness, error) {
+	var r Readiness
+	if err := c.resolveAPIDir(); err != nil {
+		return r, err
+	}
+	c.mu.Lock()
+	r.APIDir = c.apiDir
+	c.mu.Unlock()
+	if _, err := os.Stat(filepath.Join(r.APIDir, "node_modules")); err == nil {
+		r.Installed = true
+	}
+
+	nodePath, err := c.getNodePath()
+	if err != nil {
+		return r, err
+	}
+	r.NodePath = nodePath
+	cmd := exec.CommandContext(ctx, nodePath, "--version")
+	configureCmd(cmd)
+	out, err := cmd.Output()
+	if err != nil {
+		return r, err
+	}
+	r.NodeVersion = strings.TrimSpace(string(out))
+	r.Running = c.Status().Running
+	return r, nil
+}
@@ -0,0 +1,45 @@
+package downloader
+
+import (
+	"net/url"
+	"strings"
+)
+
+const (
+	SourceSoundCloud = "soundcloud"
+	SourceYouTube    = "youtube"
+	SourceBandcamp   = "bandcamp"
+	SourceOther      = "web"
+)
+
+const (
+	NumberingSourceOrder  = "source"
+	NumberingKeepOriginal = "keep"
+)
+
+func NormalizeNumbering(mode string) string {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case NumberingKeepOriginal:
+		return NumberingKeepOriginal
+	default:
+		return NumberingSourceOrder
+	}
+}
+
+func DetectSource(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	host := strings.ToLower(strings.TrimPrefix(u.Hostname(), "www."))
+	switch {
+	case host == "soundcloud.com" || strings.HasSuffix(host, ".soundcloud.com") || host == "on.soundcloud.com" || host == "snd.sc":
+		return SourceSoundCloud
+	case host == "youtube.com" || strings.HasSuffix(host, ".youtube.com") || host == "youtu.be":
+		return SourceYouTube
+	case host == "bandcamp.com" || strings.HasSuffix(host, ".bandcamp.com"):
+		return SourceBandcamp
+	default:
+		return SourceOther
+	}
+}
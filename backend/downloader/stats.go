@@ -0,0 +1,236 @@
+package downloader
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	StatsDay   = "day"
+	StatsWeek  = "week"
+	StatsMonth = "month"
+	StatsYear  = "year"
+	StatsAll   = "all"
+
+	statsDayLayout = "2006-01-02"
+	maxStatsDays   = 3 * 366
+)
+
+type statsBucket struct {
+	Day       string `json:"day"`
+	Source    string `json:"source"`
+	Downloads int    `json:"downloads"`
+	Failures  int    `json:"failures"`
+	Bytes     int64  `json:"bytes"`
+	Millis    int64  `json:"millis"`
+}
+
+type SourceStats struct {
+	Source         string  `json:"source"`
+	Downloads      int     `json:"downloads"`
+	Failures       int     `json:"failures"`
+	FailureRate    float64 `json:"failureRate"`
+	Bytes          int64   `json:"bytes"`
+	AvgBytesPerSec float64 `json:"avgBytesPerSec"`
+
+	millis int64
+}
+
+type DownloadStats struct {
+	Period  string        `json:"period"`
+	Since   int64         `json:"since,omitempty"`
+	Total   SourceStats   `json:"total"`
+	Sources []SourceStats `json:"sources"`
+}
+
+type Stats struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewStats() *Stats {
+	return &Stats{path: statsPath()}
+}
+
+func statsPath() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil || configDir == "" {
+		return "kitty_download_stats.json"
+	}
+	return filepath.Join(configDir, "Kitty", "download_stats.json")
+}
+
+func (s *Stats) Path() string {
+	return s.path
+}
+
+func NormalizeStatsPeriod(period string) (string, error) {
+	switch p := strings.ToLower(strings.TrimSpace(period)); p {
+	case "":
+		return StatsAll, nil
+	case StatsDay, StatsWeek, StatsMonth, StatsYear, StatsAll:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown stats period: %s", period)
+}
+
+func periodStart(period string, now time.Time) time.Time {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch period {
+	case StatsDay:
+		return day
+	case StatsWeek:
+		return day.AddDate(0, 0, -6)
+	case StatsMonth:
+		return day.AddDate(0, 0, -29)
+	case StatsYear:
+		return day.AddDate(0, 0, -364)
+	}
+	return time.Time{}
+}
+
+func (s *Stats) RecordSuccess(source string, bytes int64, took time.Duration) error {
+	return s.record(source, func(b *statsBucket) {
+		b.Downloads++
+		b.Bytes += bytes
+		b.Millis += took.Milliseconds()
+	})
+}
+
+func (s *Stats) RecordFailure(source string) error {
+	return s.record(source, func(b *statsBucket) {
+		b.Failures++
+	})
+}
+
+func (s *Stats) record(source string, fn func(b *statsBucket)) error {
+	if source == "" {
+		source = SourceOther
+	}
+	day := time.Now().Format(statsDayLayout)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	buckets, err := s.loadLocked()
+	if err != nil {
+		return err
+	}
+	idx := -1
+	for i, b := range buckets {
+		if b.Day == day && b.Source == source {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		buckets = append(buckets, statsBucket{Day: day, Source: source})
+		idx = len(buckets) - 1
+	}
+	fn(&buckets[idx])
+	cutoff := time.Now().AddDate(0, 0, -maxStatsDays).Format(statsDayLayout)
+	kept := buckets[:0]
+	for _, b := range buckets {
+		if b.Day >= cutoff {
+			kept = append(kept, b)
+		}
+	}
+	return s.saveLocked(kept)
+}
+
+func (s *Stats) Summary(period string, now time.Time) (*DownloadStats, error) {
+	period, err := NormalizeStatsPeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	buckets, err := s.loadLocked()
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	out := &DownloadStats{Period: period, Total: SourceStats{Source: "all"}, Sources: []SourceStats{}}
+	var from string
+	if start := periodStart(period, now); !start.IsZero() {
+		out.Since = start.Unix()
+		from = start.Format(statsDayLayout)
+	}
+	bySource := map[string]*SourceStats{}
+	for _, b := range buckets {
+		if b.Day < from {
+			continue
+		}
+		st := bySource[b.Source]
+		if st == nil {
+			st = &SourceStats{Source: b.Source}
+			bySource[b.Source] = st
+		}
+		for _, t := range []*SourceStats{st, &out.Total} {
+			t.Downloads += b.Downloads
+			t.Failures += b.Failures
+			t.Bytes += b.Bytes
+			t.millis += b.Millis
+		}
+	}
+	for _, st := range bySource {
+		out.Sources = append(out.Sources, st.finish())
+	}
+	out.Total = out.Total.finish()
+	sort.Slice(out.Sources, func(i, j int) bool {
+		if out.Sources[i].Bytes != out.Sources[j].Bytes {
+			return out.Sources[i].Bytes > out.Sources[j].Bytes
+		}
+		return out.Sources[i].Source < out.Sources[j].Source
+	})
+	return out, nil
+}
+
+func (st SourceStats) finish() SourceStats {
+	if attempts := st.Downloads + st.Failures; attempts > 0 {
+		st.FailureRate = float64(st.Failures) / float64(attempts)
+	}
+	if st.millis > 0 {
+		st.AvgBytesPerSec = float64(st.Bytes) / (float64(st.millis) / 1000)
+	}
+	return st
+}
+
+func (s *Stats) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *Stats) loadLocked() ([]statsBucket, error) {
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []statsBucket{}, nil
+		}
+		return nil, err
+	}
+	var buckets []statsBucket
+	if err := json.Unmarshal(raw, &buckets); err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
+
+func (s *Stats) saveLocked(buckets []statsBucket) error {
+	raw, err := json.Marshal(buckets)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, raw, 0o644)
+}
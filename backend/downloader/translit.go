@@ -0,0 +1,219 @@
+package downloader
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	ScriptLatin    = "latin"
+	ScriptCyrillic = "cyrillic"
+	ScriptGreek    = "greek"
+	ScriptJapanese = "japanese"
+	ScriptKorean   = "korean"
+)
+
+var allScripts = []string{ScriptLatin, ScriptCyrillic, ScriptGreek, ScriptJapanese, ScriptKorean}
+
+var cyrillicLetters = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya", 'є': "ye", 'і': "i", 'ї': "yi", 'ґ': "g", 'ў': "u", 'ђ': "dj", 'ј': "j",
+	'љ': "lj", 'њ': "nj", 'ћ': "c", 'џ': "dz", 'ѓ': "gj", 'ќ': "kj", 'ѕ': "dz",
+}
+
+var greekLetters = map[rune]string{
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th",
+	'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p",
+	'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps",
+	'ω': "o",
+}
+
+var kana = map[rune]string{
+	'あ': "a", 'い': "i", 'う': "u", 'え': "e", 'お': "o",
+	'か': "ka", 'き': "ki", 'く': "ku", 'け': "ke", 'こ': "ko",
+	'が': "ga", 'ぎ': "gi", 'ぐ': "gu", 'げ': "ge", 'ご': "go",
+	'さ': "sa", 'し': "shi", 'す': "su", 'せ': "se", 'そ': "so",
+	'ざ': "za", 'じ': "ji", 'ず': "zu", 'ぜ': "ze", 'ぞ': "zo",
+	'た': "ta", 'ち': "chi", 'つ': "tsu", 'て': "te", 'と': "to",
+	'だ': "da", 'ぢ': "ji", 'づ': "zu", 'で': "de", 'ど': "do",
+	'な': "na", 'に': "ni", 'ぬ': "nu", 'ね': "ne", 'の': "no",
+	'は': "ha", 'ひ': "hi", 'ふ': "fu", 'へ': "he", 'ほ': "ho",
+	'ば': "ba", 'び': "bi", 'ぶ': "bu", 'べ': "be", 'ぼ': "bo",
+	'ぱ': "pa", 'ぴ': "pi", 'ぷ': "pu", 'ぺ': "pe", 'ぽ': "po",
+	'ま': "ma", 'み': "mi", 'む': "mu", 'め': "me", 'も': "mo",
+	'や': "ya", 'ゆ': "yu", 'よ': "yo",
+	'ら': "ra", 'り': "ri", 'る': "ru", 'れ': "re", 'ろ': "ro",
+	'わ': "wa", 'ゐ': "i", 'ゑ': "e", 'を': "o", 'ん': "n", 'ゔ': "vu",
+	'ぁ': "a", 'ぃ': "i", 'ぅ': "u", 'ぇ': "e", 'ぉ': "o", 'ゎ': "wa",
+	'ゃ': "ya", 'ゅ': "yu", 'ょ': "yo",
+}
+
+var (
+	hangulInitials = []string{"g", "kk", "n", "d", "tt", "r", "m", "b", "pp", "s", "ss", "", "j", "jj", "ch", "k", "t", "p", "h"}
+	hangulVowels   = []string{"a", "ae", "ya", "yae", "eo", "e", "yeo", "ye", "o", "wa", "wae", "oe", "yo", "u", "wo", "we", "wi", "yu", "eu", "ui", "i"}
+	hangulFinals   = []string{"", "k", "k", "k", "n", "n", "n", "t", "l", "k", "m", "l", "l", "l", "p", "l", "m", "p", "p", "t", "t", "ng", "t", "t", "k", "t", "p", "t"}
+)
+
+func Scripts() []string {
+	return append([]string{}, allScripts...)
+}
+
+func NormalizeScripts(scripts []string) ([]string, error) {
+	seen := make(map[string]bool, len(scripts))
+	for _, s := range scripts {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if s == "" {
+			continue
+		}
+		if !containsString(allScripts, s) {
+			return nil, fmt.Errorf("unknown script: %s", s)
+		}
+		seen[s] = true
+	}
+	out := make([]string, 0, len(seen))
+	for _, s := range allScripts {
+		if seen[s] {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func transliterate(s string, scripts []string) string {
+	enabled := func(script string) bool {
+		return len(scripts) == 0 || containsString(scripts, script)
+	}
+	s = norm.NFC.String(s)
+	if enabled(ScriptJapanese) {
+		s = romanizeKana(s)
+	}
+
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case enabled(ScriptCyrillic) && unicode.Is(unicode.Cyrillic, r):
+			b.WriteString(letterCase(r, cyrillicLetters))
+		case enabled(ScriptGreek) && unicode.Is(unicode.Greek, r):
+			b.WriteString(letterCase(r, greekLetters))
+		case enabled(ScriptKorean) && r >= 0xAC00 && r <= 0xD7A3:
+			idx := int(r - 0xAC00)
+			b.WriteString(hangulInitials[idx/588] + hangulVowels[idx%588/28] + hangulFinals[idx%28])
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if !enabled(ScriptLatin) {
+		return b.String()
+	}
+
+	s = b.String()
+	b.Reset()
+	latinBase := false
+	for _, r := range norm.NFD.String(s) {
+		if unicode.Is(unicode.Mn, r) {
+			if !latinBase {
+				b.WriteRune(r)
+			}
+			continue
+		}
+		latinBase = unicode.Is(unicode.Latin, r)
+		if r < utf8.RuneSelf {
+			b.WriteRune(r)
+			continue
+		}
+		if rep, ok := latinFallbacks[r]; ok {
+			b.WriteString(rep)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return norm.NFC.String(b.String())
+}
+
+func letterCase(r rune, table map[rune]string) string {
+	lower := unicode.ToLower(r)
+	rep, ok := table[lower]
+	if !ok {
+		base, _ := utf8.DecodeRuneInString(norm.NFD.String(string(lower)))
+		if rep, ok = table[base]; !ok {
+			return string(r)
+		}
+	}
+	if lower == r || rep == "" {
+		return rep
+	}
+	first, size := utf8.DecodeRuneInString(rep)
+	return string(unicode.ToUpper(first)) + rep[size:]
+}
+
+func romanizeKana(s string) string {
+	runes := []rune(s)
+	var (
+		b       strings.Builder
+		double  bool
+		lastVow byte
+	)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r >= 0x30A1 && r <= 0x30F6 {
+			r -= 0x60
+		}
+		switch r {
+		case 'っ':
+			double = true
+			continue
+		case 'ー':
+			if lastVow != 0 {
+				b.WriteByte(lastVow)
+			}
+			continue
+		}
+		rom, ok := kana[r]
+		if !ok {
+			double = false
+			lastVow = 0
+			b.WriteRune(runes[i])
+			continue
+		}
+		if i+1 < len(runes) {
+			next := runes[i+1]
+			if next >= 0x30A1 && next <= 0x30F6 {
+				next -= 0x60
+			}
+			switch next {
+			case 'ゃ', 'ゅ', 'ょ':
+				if strings.HasSuffix(rom, "i") && len(rom) > 1 {
+					stem := strings.TrimSuffix(rom, "i")
+					if !strings.HasSuffix(stem, "sh") && !strings.HasSuffix(stem, "ch") && stem != "j" {
+						stem += "y"
+					}
+					rom = stem + kana[next][1:]
+					i++
+				}
+			case 'ぁ', 'ぃ', 'ぅ', 'ぇ', 'ぉ':
+				if len(rom) > 1 {
+					rom = rom[:len(rom)-1] + kana[next]
+					i++
+				}
+			}
+		}
+		if double {
+			if strings.HasPrefix(rom, "ch") {
+				b.WriteByte('t')
+			} else if rom[0] != 'a' && rom[0] != 'i' && rom[0] != 'u' && rom[0] != 'e' && rom[0] != 'o' && rom[0] != 'n' {
+				b.WriteByte(rom[0])
+			}
+			double = false
+		}
+		b.WriteString(rom)
+		lastVow = rom[len(rom)-1]
+	}
+	return b.String()
+}
@@ -0,0 +1,225 @@
+package downloader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const linkResolveTimeout = 8 * time.Second
+
+var shortLinkHosts = map[string]bool{
+	"on.soundcloud.com": true,
+	"snd.sc":            true,
+	"sc.link":           true,
+	"bit.ly":            true,
+	"t.co":              true,
+	"tinyurl.com":       true,
+	"spoti.fi":          true,
+	"vm.tiktok.com":     true,
+	"vt.tiktok.com":     true,
+	"pin.it":            true,
+}
+
+var cobaltServiceHosts = map[string]string{
+	"youtube.com":     "youtube",
+	"youtu.be":        "youtube",
+	"soundcloud.com":  "soundcloud",
+	"vimeo.com":       "vimeo",
+	"twitter.com":     "twitter",
+	"x.com":           "twitter",
+	"instagram.com":   "instagram",
+	"tiktok.com":      "tiktok",
+	"reddit.com":      "reddit",
+	"redd.it":         "reddit",
+	"twitch.tv":       "twitch",
+	"dailymotion.com": "dailymotion",
+	"pinterest.com":   "pinterest",
+	"tumblr.com":      "tumblr",
+	"vk.com":          "vk",
+	"ok.ru":           "ok",
+	"rutube.ru":       "rutube",
+	"streamable.com":  "streamable",
+	"bilibili.com":    "bilibili",
+	"loom.com":        "loom",
+	"facebook.com":    "facebook",
+	"snapchat.com":    "snapchat",
+	"newgrounds.com":  "newgrounds",
+	"bsky.app":        "bsky",
+	"xiaohongshu.com": "xiaohongshu",
+}
+
+type LinkCheck struct {
+	Input     string `json:"input"`
+	URL       string `json:"url"`
+	Resolved  bool   `json:"resolved"`
+	Valid     bool   `json:"valid"`
+	Source    string `json:"source"`
+	Kind      string `json:"kind,omitempty"`
+	Service   string `json:"service,omitempty"`
+	Supported bool   `json:"supported"`
+	Backend   string `json:"backend"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+func (c *Client) ValidateLink(ctx context.Context, raw string) LinkCheck {
+	check := LinkCheck{Input: raw, Backend: "cobalt"}
+	u, err := parseLink(raw)
+	if err != nil {
+		check.Reason = err.Error()
+		return check
+	}
+	check.Valid = true
+	check.URL = u.String()
+
+	if shortLinkHosts[strings.TrimPrefix(u.Hostname(), "www.")] {
+		resolved, err := c.resolveRedirects(ctx, u.String())
+		if err != nil {
+			check.Reason = fmt.Sprintf("could not resolve short link: %v", err)
+			check.Source = DetectSource(check.URL)
+			return check
+		}
+		if resolved != check.URL {
+			check.URL, check.Resolved = resolved, true
+		}
+	}
+
+	check.Source = DetectSource(check.URL)
+	if link, ok := NormalizeMediaLink(check.URL); ok {
+		check.URL, check.Kind = link.URL, link.Kind
+	}
+	check.Service = cobaltService(check.URL)
+	if check.Service == "" {
+		check.Reason = "this site is not supported by the downloader"
+		return check
+	}
+
+	services, err := c.Services(ctx)
+	switch {
+	case err != nil:
+		check.Supported = true
+		check.Reason = "downloader is not running; support is based on the built-in service list"
+	case !containsString(services, check.Service):
+		check.Reason = fmt.Sprintf("%s is disabled in the current downloader", check.Service)
+	default:
+		check.Supported = true
+	}
+	return check
+}
+
+func (c *Client) Services(ctx context.Context) ([]string, error) {
+	c.mu.Lock()
+	running := c.api.running
+	baseURL := c.api.baseURL
+	c.mu.Unlock()
+	if !running {
+		return nil, fmt.Errorf("downloader is not running")
+	}
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	res, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return nil, fmt.Errorf("api responded with %s", res.Status)
+	}
+	var info struct {
+		Cobalt struct {
+			Services []string `json:"services"`
+		} `json:"cobalt"`
+	}
+	if err := json.NewDecoder(io.LimitReader(res.Body, 64*1024)).Decode(&info); err != nil {
+		return nil, err
+	}
+	return info.Cobalt.Services, nil
+}
+
+func (c *Client) resolveRedirects(ctx context.Context, link string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, linkResolveTimeout)
+	defer cancel()
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(ctx, method, link, nil)
+		if err != nil {
+			return "", err
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			if method == http.MethodGet {
+				return "", err
+			}
+			continue
+		}
+		res.Body.Close()
+		if res.StatusCode < 400 {
+			return res.Request.URL.String(), nil
+		}
+		if method == http.MethodGet {
+			return "", fmt.Errorf("%s", res.Status)
+		}
+	}
+	return link, nil
+}
+
+func parseLink(raw string) (*url.URL, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, fmt.Errorf("link is empty")
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid link: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("only http and https links can be downloaded")
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "" || !strings.Contains(host, ".") || strings.HasSuffix(host, ".") {
+		return nil, fmt.Errorf("link has no valid host")
+	}
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	return u, nil
+}
+
+func cobaltService(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+	for host != "" {
+		if service, ok := cobaltServiceHosts[host]; ok {
+			return service
+		}
+		_, rest, found := strings.Cut(host, ".")
+		if !found {
+			break
+		}
+		host = rest
+	}
+	return ""
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
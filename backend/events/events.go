@@ -0,0 +1,140 @@
+package events
+
+import (
+	"kitty/backend/library"
+	"kitty/backend/media"
+	"kitty/backend/metadata"
+	"kitty/backend/ops"
+	"kitty/backend/player"
+	"kitty/backend/playlist"
+	"kitty/backend/power"
+	"kitty/backend/shortcuts"
+	"kitty/backend/soundcloud"
+	"kitty/backend/startup"
+	"kitty/backend/volumes"
+)
+
+const Version = 1
+
+const (
+	OperationUpdate     = "operation:update"
+	SoundCloudReconnect = "soundcloud:reconnect-needed"
+	SoundCloudLikesPage = "soundcloud:likes:page"
+	SoundCloudLikesDone = "soundcloud:likes:done"
+	LibraryRescan       = "library:rescan"
+	SnapshotRestored    = "snapshot:restored"
+	StemsProgress       = "stems:progress"
+	StemsDone           = "stems:done"
+	RecordingStarted    = "recording:started"
+	RecordingStopped    = "recording:stopped"
+	CloudHydrate        = "cloud:hydrate"
+	LibraryVolumes      = "library:volumes"
+	ServiceReady        = "startup:service-ready"
+	QueueAdvanced       = "queue:advanced"
+	QueueEnded          = "queue:ended"
+	PlaybackPosition    = "playback:position"
+	PlaybackEnded       = "playback:ended"
+	PlaybackChapter     = "playback:chapter"
+	PowerChanged        = "power:changed"
+	MediaCommand        = "media:command"
+	OutputChanged       = "audio:output-changed"
+	ShortcutsChanged    = "shortcuts:changed"
+	FolderPlaylists     = "playlists:folder-sync"
+	LibraryRetry        = "library:retry"
+)
+
+type RescanProgress struct {
+	Done  int    `json:"done"`
+	Total int    `json:"total"`
+	Path  string `json:"path"`
+}
+
+type Position struct {
+	Path     string  `json:"path"`
+	Position float64 `json:"position"`
+	Duration float64 `json:"duration"`
+	Playing  bool    `json:"playing"`
+}
+
+type TrackEnded struct {
+	Path string `json:"path"`
+}
+
+type MediaKey struct {
+	Command string `json:"command"`
+	Error   string `json:"error,omitempty"`
+}
+
+type OutputChange struct {
+	Device   string `json:"device"`
+	Previous string `json:"previous,omitempty"`
+	Followed bool   `json:"followed"`
+	Returned bool   `json:"returned"`
+	Stalled  bool   `json:"stalled"`
+	Error    string `json:"error,omitempty"`
+}
+
+type ChapterChange struct {
+	Path    string           `json:"path"`
+	Chapter metadata.Chapter `json:"chapter"`
+}
+
+type LikesDone struct {
+	Loaded int    `json:"loaded"`
+	Total  int    `json:"total"`
+	Error  string `json:"error,omitempty"`
+}
+
+type HydrateProgress struct {
+	Path     string `json:"path"`
+	Done     int64  `json:"done"`
+	Total    int64  `json:"total"`
+	Finished bool   `json:"finished"`
+	Error    string `json:"error,omitempty"`
+}
+
+type Spec struct {
+	Name     string      `json:"name"`
+	Payload  interface{} `json:"-"`
+	Nullable bool        `json:"nullable,omitempty"`
+}
+
+type APIInfo struct {
+	Version int      `json:"version"`
+	Events  []string `json:"events"`
+}
+
+var Catalog = []Spec{
+	{Name: OperationUpdate, Payload: ops.Operation{}},
+	{Name: SoundCloudReconnect, Payload: soundcloud.TokenHealth{}},
+	{Name: SoundCloudLikesPage, Payload: soundcloud.LikesProgress{}},
+	{Name: SoundCloudLikesDone, Payload: LikesDone{}},
+	{Name: LibraryRescan, Payload: RescanProgress{}},
+	{Name: SnapshotRestored, Payload: ""},
+	{Name: StemsProgress, Payload: media.StemJob{}},
+	{Name: StemsDone, Payload: media.StemJob{}},
+	{Name: RecordingStarted, Payload: media.Recording{}},
+	{Name: RecordingStopped, Payload: media.Recording{}, Nullable: true},
+	{Name: CloudHydrate, Payload: HydrateProgress{}},
+	{Name: LibraryVolumes, Payload: []volumes.Volume{}},
+	{Name: ServiceReady, Payload: startup.Timing{}},
+	{Name: QueueAdvanced, Payload: player.State{}},
+	{Name: QueueEnded, Payload: player.State{}},
+	{Name: PlaybackPosition, Payload: Position{}},
+	{Name: PlaybackEnded, Payload: TrackEnded{}},
+	{Name: PlaybackChapter, Payload: ChapterChange{}},
+	{Name: PowerChanged, Payload: power.State{}},
+	{Name: MediaCommand, Payload: MediaKey{}},
+	{Name: OutputChanged, Payload: OutputChange{}},
+	{Name: ShortcutsChanged, Payload: []shortcuts.Shortcut{}},
+	{Name: FolderPlaylists, Payload: playlist.FolderSync{}},
+	{Name: LibraryRetry, Payload: library.RetryResult{}},
+}
+
+func Info() APIInfo {
+	names := make([]string, 0, len(Catalog))
+	for _, spec := range Catalog {
+		names = append(names, spec.Name)
+	}
+	return APIInfo{Version: Version, Events: names}
+}
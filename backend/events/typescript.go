@@ -0,0 +1,165 @@
+package events
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DefaultTSOutput = "frontend/src/events.gen.ts"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+type tsWriter struct {
+	names map[reflect.Type]string
+	taken map[string]reflect.Type
+	order []reflect.Type
+}
+
+func WriteTypeScript(w io.Writer) error {
+	g := &tsWriter{names: make(map[reflect.Type]string), taken: make(map[string]reflect.Type)}
+
+	payloads := make([]string, 0, len(Catalog))
+	for _, spec := range Catalog {
+		ts := g.typeOf(reflect.TypeOf(spec.Payload))
+		if spec.Nullable {
+			ts += " | null"
+		}
+		payloads = append(payloads, fmt.Sprintf("  %s: %s;", strconv.Quote(spec.Name), ts))
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by cmd/eventgen. DO NOT EDIT.\n\n")
+	buf.WriteString("import { EventsOn } from '../wailsjs/runtime/runtime';\n\n")
+	fmt.Fprintf(&buf, "export const API_VERSION = %d;\n\n", Version)
+
+	for i := 0; i < len(g.order); i++ {
+		t := g.order[i]
+		fmt.Fprintf(&buf, "export interface %s {\n", g.names[t])
+		for _, line := range g.fields(t) {
+			fmt.Fprintf(&buf, "  %s\n", line)
+		}
+		buf.WriteString("}\n\n")
+	}
+
+	buf.WriteString("export interface EventPayloads {\n")
+	for _, line := range payloads {
+		buf.WriteString(line + "\n")
+	}
+	buf.WriteString("}\n\n")
+
+	buf.WriteString("export type EventName = keyof EventPayloads;\n\n")
+	buf.WriteString("export const EVENT_NAMES: EventName[] = [\n")
+	for _, spec := range Catalog {
+		fmt.Fprintf(&buf, "  %s,\n", strconv.Quote(spec.Name))
+	}
+	buf.WriteString("];\n\n")
+	buf.WriteString("export function onEvent<K extends EventName>(name: K, cb: (payload: EventPayloads[K]) => void): () => void {\n")
+	buf.WriteString("  return EventsOn(name, (payload: EventPayloads[K]) => cb(payload));\n")
+	buf.WriteString("}\n")
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func (g *tsWriter) typeOf(t reflect.Type) string {
+	if t == nil {
+		return "any"
+	}
+	if t == timeType {
+		return "string"
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Pointer:
+		return g.typeOf(t.Elem()) + " | null"
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "string"
+		}
+		elem := g.typeOf(t.Elem())
+		if strings.Contains(elem, " ") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case reflect.Map:
+		return fmt.Sprintf("Record<string, %s>", g.typeOf(t.Elem()))
+	case reflect.Struct:
+		return g.named(t)
+	}
+	return "any"
+}
+
+func (g *tsWriter) named(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if name == "" {
+		name = "Anonymous"
+	}
+	if other, ok := g.taken[name]; ok && other != t {
+		pkg := path.Base(t.PkgPath())
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	g.names[t] = name
+	g.taken[name] = t
+	g.order = append(g.order, t)
+	return name
+}
+
+func (g *tsWriter) fields(t reflect.Type) []string {
+	var lines []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				lines = append(lines, g.fields(ft)...)
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+		optional := ""
+		if strings.Contains(opts, "omitempty") {
+			optional = "?"
+		}
+		lines = append(lines, fmt.Sprintf("%s%s: %s;", quoteKey(name), optional, g.typeOf(f.Type)))
+	}
+	return lines
+}
+
+func quoteKey(name string) string {
+	for i, r := range name {
+		if r == '_' || r == '$' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (i > 0 && r >= '0' && r <= '9') {
+			continue
+		}
+		return strconv.Quote(name)
+	}
+	return name
+}
@@ -0,0 +1,230 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"kitty/backend/storage"
+)
+
+const (
+	KindAPI        = "api"
+	KindDownload   = "download"
+	KindCover      = "cover"
+	KindSoundCloud = "soundcloud"
+	KindYouTube    = "youtube"
+	KindWebhook    = "webhook"
+
+	MaxRetries = 5
+
+	retryBaseDelay = 500 * time.Millisecond
+)
+
+var ErrStalled = errors.New("transfer stalled")
+
+var defaults = map[string]storage.HTTPPolicy{
+	KindAPI:        {ConnectSec: 5, ReadSec: 60, TotalSec: 60, Retries: 0},
+	KindDownload:   {ConnectSec: 10, ReadSec: 60, StallSec: 30, Retries: 2},
+	KindCover:      {ConnectSec: 5, ReadSec: 15, TotalSec: 30, Retries: 1},
+	KindSoundCloud: {ConnectSec: 10, ReadSec: 20, TotalSec: 20, Retries: 1},
+	KindYouTube:    {ConnectSec: 10, ReadSec: 30, TotalSec: 30, Retries: 1},
+	KindWebhook:    {ConnectSec: 5, ReadSec: 10, TotalSec: 10, Retries: 0},
+}
+
+var (
+	mu         sync.Mutex
+	policies   = Resolve(nil)
+	transports = map[string]*http.Transport{}
+)
+
+func Kinds() []string {
+	kinds := make([]string, 0, len(defaults))
+	for k := range defaults {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+func Defaults() map[string]storage.HTTPPolicy {
+	return Resolve(nil)
+}
+
+func Resolve(overrides map[string]storage.HTTPPolicy) map[string]storage.HTTPPolicy {
+	out := make(map[string]storage.HTTPPolicy, len(defaults))
+	for k, p := range defaults {
+		if o, ok := overrides[k]; ok {
+			p = o
+		}
+		out[k] = p
+	}
+	return out
+}
+
+func Validate(overrides map[string]storage.HTTPPolicy) (map[string]storage.HTTPPolicy, error) {
+	clean := make(map[string]storage.HTTPPolicy, len(overrides))
+	for k, p := range overrides {
+		def, ok := defaults[k]
+		if !ok {
+			return nil, fmt.Errorf("unknown request type: %s", k)
+		}
+		switch {
+		case p.ConnectSec <= 0:
+			return nil, fmt.Errorf("%s: connect timeout must be positive", k)
+		case p.ReadSec < 0 || p.TotalSec < 0 || p.StallSec < 0:
+			return nil, fmt.Errorf("%s: timeouts must not be negative", k)
+		case p.Retries < 0 || p.Retries > MaxRetries:
+			return nil, fmt.Errorf("%s: retries must be between 0 and %d", k, MaxRetries)
+		case p.TotalSec > 0 && p.TotalSec < p.ConnectSec:
+			return nil, fmt.Errorf("%s: total timeout is shorter than the connect timeout", k)
+		}
+		if p != def {
+			clean[k] = p
+		}
+	}
+	return clean, nil
+}
+
+func Configure(overrides map[string]storage.HTTPPolicy) {
+	mu.Lock()
+	defer mu.Unlock()
+	policies = Resolve(overrides)
+	for k, t := range transports {
+		t.CloseIdleConnections()
+		delete(transports, k)
+	}
+}
+
+func Policy(kind string) storage.HTTPPolicy {
+	mu.Lock()
+	defer mu.Unlock()
+	return policies[kind]
+}
+
+func New(kind string) *http.Client {
+	return &http.Client{Transport: &roundTripper{kind: kind}}
+}
+
+func seconds(v float64) time.Duration {
+	return time.Duration(v * float64(time.Second))
+}
+
+func transport(kind string) (*http.Transport, storage.HTTPPolicy) {
+	mu.Lock()
+	defer mu.Unlock()
+	p := policies[kind]
+	if t, ok := transports[kind]; ok {
+		return t, p
+	}
+	dialer := &net.Dialer{Timeout: seconds(p.ConnectSec), KeepAlive: 30 * time.Second}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialContext = dialer.DialContext
+	t.TLSHandshakeTimeout = seconds(p.ConnectSec)
+	t.ResponseHeaderTimeout = seconds(p.ReadSec)
+	transports[kind] = t
+	return t, p
+}
+
+type roundTripper struct {
+	kind string
+}
+
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	t, p := transport(rt.kind)
+	ctx, cancel := req.Context(), context.CancelFunc(func() {})
+	if p.TotalSec > 0 {
+		ctx, cancel = context.WithTimeout(ctx, seconds(p.TotalSec))
+	} else if p.StallSec > 0 {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	req = req.WithContext(ctx)
+
+	retries := p.Retries
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		retries = 0
+	}
+	var (
+		res *http.Response
+		err error
+	)
+	for attempt := 0; ; attempt++ {
+		res, err = t.RoundTrip(req)
+		if attempt >= retries || ctx.Err() != nil || !retryable(res, err) {
+			break
+		}
+		if res != nil {
+			io.Copy(io.Discard, io.LimitReader(res.Body, 64*1024))
+			res.Body.Close()
+		}
+		select {
+		case <-ctx.Done():
+			cancel()
+			return nil, ctx.Err()
+		case <-time.After(retryBaseDelay << attempt):
+		}
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	res.Body = newBody(res.Body, cancel, seconds(p.StallSec))
+	return res, nil
+}
+
+func retryable(res *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch res.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+type body struct {
+	io.ReadCloser
+	cancel  context.CancelFunc
+	stall   time.Duration
+	timer   *time.Timer
+	stalled atomic.Bool
+}
+
+func newBody(rc io.ReadCloser, cancel context.CancelFunc, stall time.Duration) io.ReadCloser {
+	b := &body{ReadCloser: rc, cancel: cancel, stall: stall}
+	if stall > 0 {
+		b.timer = time.AfterFunc(stall, func() {
+			b.stalled.Store(true)
+			cancel()
+		})
+	}
+	return b
+}
+
+func (b *body) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 && b.timer != nil {
+		b.timer.Reset(b.stall)
+	}
+	if err != nil && err != io.EOF && b.stalled.Load() {
+		err = fmt.Errorf("%w: no data received for %s", ErrStalled, b.stall)
+	}
+	return n, err
+}
+
+func (b *body) Close() error {
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
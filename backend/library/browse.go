@@ -0,0 +1,139 @@
+package library
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"kitty/backend/metadata"
+)
+
+const (
+	BrowseArtist    = "artist"
+	BrowseClassical = "classical"
+
+	unknownComposer = "Unknown Composer"
+	otherWorks      = "Other"
+)
+
+type BrowseTrack struct {
+	Path   string `json:"path"`
+	Title  string `json:"title"`
+	Number int    `json:"number"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type BrowseNode struct {
+	Name     string        `json:"name"`
+	Count    int           `json:"count"`
+	Children []*BrowseNode `json:"children,omitempty"`
+	Tracks   []BrowseTrack `json:"tracks,omitempty"`
+}
+
+func NormalizeBrowseMode(mode string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", BrowseArtist:
+		return BrowseArtist, nil
+	case BrowseClassical:
+		return BrowseClassical, nil
+	}
+	return "", fmt.Errorf("unknown browse mode: %s", mode)
+}
+
+func (m *Manager) Browse(mode string) ([]*BrowseNode, error) {
+	mode, err := NormalizeBrowseMode(mode)
+	if err != nil {
+		return nil, err
+	}
+	roots := map[string]*BrowseNode{}
+	groups := map[*BrowseNode]map[string]*BrowseNode{}
+	for _, t := range m.Tracks() {
+		top, group, item := artistPath(t)
+		if mode == BrowseClassical {
+			top, group, item = classicalPath(t)
+		}
+		root := roots[top]
+		if root == nil {
+			root = &BrowseNode{Name: top}
+			roots[top] = root
+			groups[root] = map[string]*BrowseNode{}
+		}
+		node := groups[root][group]
+		if node == nil {
+			node = &BrowseNode{Name: group}
+			groups[root][group] = node
+			root.Children = append(root.Children, node)
+		}
+		node.Tracks = append(node.Tracks, item)
+		node.Count++
+		root.Count++
+	}
+
+	out := make([]*BrowseNode, 0, len(roots))
+	for _, root := range roots {
+		sortNodes(root.Children)
+		for _, child := range root.Children {
+			sort.SliceStable(child.Tracks, func(i, j int) bool {
+				a, b := child.Tracks[i], child.Tracks[j]
+				if a.Number != b.Number {
+					return a.Number < b.Number
+				}
+				return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+			})
+		}
+		out = append(out, root)
+	}
+	sortNodes(out)
+	return out, nil
+}
+
+func artistPath(t metadata.TrackMetadata) (string, string, BrowseTrack) {
+	artist := firstValue(t.AlbumArtist, t.Artist, "Unknown Artist")
+	album := firstValue(t.Album, "Unknown Album")
+	return artist, album, BrowseTrack{Path: t.FilePath, Title: t.Title, Number: t.DiscNumber*1000 + t.TrackNumber}
+}
+
+func classicalPath(t metadata.TrackMetadata) (string, string, BrowseTrack) {
+	c := metadata.Classical{}
+	if t.Classical != nil {
+		c = *t.Classical
+	}
+	composer := firstValue(t.Composer, unknownComposer)
+	work := firstValue(c.Work, otherWorks)
+	item := BrowseTrack{
+		Path:   t.FilePath,
+		Title:  firstValue(c.Movement, t.Title),
+		Number: c.MovementNumber,
+	}
+	if item.Number == 0 {
+		item.Number = t.DiscNumber*1000 + t.TrackNumber
+	}
+	var performers []string
+	for _, p := range []string{c.Conductor, c.Orchestra} {
+		if p != "" {
+			performers = append(performers, p)
+		}
+	}
+	performers = append(performers, c.Soloists...)
+	item.Detail = strings.Join(performers, ", ")
+	return composer, work, item
+}
+
+func sortNodes(nodes []*BrowseNode) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		a, b := nodes[i].Name, nodes[j].Name
+		if (a == otherWorks) != (b == otherWorks) {
+			return b == otherWorks
+		}
+		return strings.ToLower(a) < strings.ToLower(b)
+	})
+}
+
+func firstValue(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
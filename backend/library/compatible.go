@@ -0,0 +1,106 @@
+package library
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"kitty/backend/metadata"
+)
+
+const (
+	RelationSameKey     = "same-key"
+	RelationAdjacent    = "adjacent"
+	RelationRelative    = "relative"
+	RelationEnergyBoost = "energy-boost"
+	RelationTempoOnly   = "tempo-only"
+
+	DefaultTempoTolerance = 0.06
+	maxCompatibleResults  = 50
+)
+
+type CompatibleTrack struct {
+	Track    metadata.TrackMetadata `json:"track"`
+	Relation string                 `json:"relation"`
+	BPMDelta float64                `json:"bpmDelta"`
+	Score    float64                `json:"score"`
+}
+
+func (m *Manager) CompatibleTracks(path string, tolerance float64) ([]CompatibleTrack, error) {
+	seed, ok := m.Track(path)
+	if !ok {
+		return nil, fmt.Errorf("track not found in library: %s", path)
+	}
+	if seed.BPM <= 0 && seed.Key == "" {
+		return nil, fmt.Errorf("%s has no bpm or key tags", seed.FileName)
+	}
+	if tolerance <= 0 {
+		tolerance = DefaultTempoTolerance
+	}
+
+	results := make([]CompatibleTrack, 0)
+	for _, t := range m.Tracks() {
+		if t.FilePath == seed.FilePath {
+			continue
+		}
+		relation, keyScore := keyRelation(seed.Key, t.Key)
+		delta, tempoOK := tempoDelta(seed.BPM, t.BPM, tolerance)
+		if seed.BPM > 0 && t.BPM > 0 && !tempoOK {
+			continue
+		}
+		if relation == "" {
+			if !tempoOK || seed.Key != "" {
+				continue
+			}
+			relation = RelationTempoOnly
+		}
+
+		score := keyScore
+		if tempoOK {
+			score += 1 - math.Abs(delta)/(seed.BPM*tolerance)
+		}
+		results = append(results, CompatibleTrack{Track: t, Relation: relation, BPMDelta: delta, Score: score})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if len(results) > maxCompatibleResults {
+		results = results[:maxCompatibleResults]
+	}
+	return results, nil
+}
+
+func keyRelation(from, to string) (string, float64) {
+	fn, fl, ok := metadata.ParseCamelot(from)
+	if !ok {
+		return "", 0
+	}
+	tn, tl, ok := metadata.ParseCamelot(to)
+	if !ok {
+		return "", 0
+	}
+	step := (tn - fn + 12) % 12
+	switch {
+	case step == 0 && fl == tl:
+		return RelationSameKey, 3
+	case (step == 1 || step == 11) && fl == tl:
+		return RelationAdjacent, 2
+	case step == 0:
+		return RelationRelative, 2
+	case step == 2 && fl == tl:
+		return RelationEnergyBoost, 1
+	}
+	return "", 0
+}
+
+func tempoDelta(from, to, tolerance float64) (float64, bool) {
+	if from <= 0 || to <= 0 {
+		return 0, false
+	}
+	best := to - from
+	for _, factor := range []float64{0.5, 2} {
+		if d := to*factor - from; math.Abs(d) < math.Abs(best) {
+			best = d
+		}
+	}
+	return best, math.Abs(best) <= from*tolerance
+}
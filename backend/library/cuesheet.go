@@ -0,0 +1,73 @@
+package library
+
+import (
+	"log"
+	"path/filepath"
+
+	"kitty/backend/metadata"
+)
+
+func expandCueSheets(paths []string) ([]string, []ImportFailure) {
+	sheets := make(map[string]*metadata.CueSheet)
+	covered := make(map[string]bool)
+	var failures []ImportFailure
+	for _, p := range paths {
+		if !metadata.IsCueSheet(p) {
+			continue
+		}
+		sheet, err := metadata.ReadCueSheet(p)
+		if err != nil {
+			log.Printf("[library] cue sheet %s: %v", filepath.Base(p), err)
+			failures = append(failures, newFailure(p, StageMetadata, err))
+			continue
+		}
+		sheets[p] = sheet
+		for _, t := range sheet.Tracks {
+			covered[filepath.Clean(t.File)] = true
+		}
+	}
+	if len(sheets) == 0 && len(failures) == 0 {
+		return paths, nil
+	}
+
+	out := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if metadata.IsCueSheet(p) {
+			if sheet, ok := sheets[p]; ok {
+				for _, t := range sheet.Tracks {
+					out = append(out, t.Path)
+				}
+			}
+			continue
+		}
+		if covered[filepath.Clean(p)] {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out, failures
+}
+
+func withoutCueSources(paths []string) []string {
+	covered := make(map[string]bool)
+	for _, p := range paths {
+		if !metadata.IsCueSheet(p) {
+			continue
+		}
+		if sheet, err := metadata.ReadCueSheet(p); err == nil {
+			for _, t := range sheet.Tracks {
+				covered[filepath.Clean(t.File)] = true
+			}
+		}
+	}
+	if len(covered) == 0 {
+		return paths
+	}
+	out := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if !covered[filepath.Clean(p)] {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,144 @@
+package library
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"kitty/backend/analysis"
+	"kitty/backend/metadata"
+	"kitty/backend/storage"
+)
+
+var audioExts = map[string]bool{
+	".mp3": true, ".flac": true, ".wav": true, ".ogg": true, ".opus": true,
+	".m4a": true, ".m4b": true, ".aac": true, ".aiff": true, ".aif": true, ".wma": true,
+}
+
+type Filter struct {
+	folders     []string
+	globs       []string
+	minSize     int64
+	minDuration float64
+}
+
+type Skipped struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+type FolderScan struct {
+	Paths   []string  `json:"paths"`
+	Skipped []Skipped `json:"skipped"`
+}
+
+func NewFilter(cfg storage.ImportSettings) (*Filter, error) {
+	f := &Filter{minSize: cfg.MinFileSizeKB * 1024, minDuration: cfg.MinDurationSec}
+	if f.minSize < 0 || f.minDuration < 0 {
+		return nil, fmt.Errorf("minimum size and duration must not be negative")
+	}
+	for _, folder := range cfg.IgnoreFolders {
+		folder = strings.ToLower(strings.Trim(filepath.ToSlash(strings.TrimSpace(folder)), "/"))
+		if folder != "" {
+			f.folders = append(f.folders, folder)
+		}
+	}
+	for _, glob := range cfg.IgnoreGlobs {
+		glob = strings.ToLower(strings.TrimSpace(glob))
+		if glob == "" {
+			continue
+		}
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid ignore pattern %q: %w", glob, err)
+		}
+		f.globs = append(f.globs, glob)
+	}
+	return f, nil
+}
+
+func IsAudioFile(p string) bool {
+	return audioExts[strings.ToLower(filepath.Ext(p))]
+}
+
+func (f *Filter) SkipDir(dir string) bool {
+	name := strings.ToLower(filepath.Base(dir))
+	if strings.HasPrefix(name, ".") {
+		return true
+	}
+	slashed := strings.ToLower(filepath.ToSlash(dir))
+	for _, folder := range f.folders {
+		if strings.Contains(folder, "/") {
+			if strings.HasSuffix(slashed, "/"+folder) || slashed == folder {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(folder, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Filter) Exclude(p string, size int64) string {
+	name := strings.ToLower(filepath.Base(p))
+	for _, glob := range f.globs {
+		if ok, _ := path.Match(glob, name); ok {
+			return "matches " + glob
+		}
+	}
+	if f.minSize > 0 && size >= 0 && size < f.minSize {
+		return fmt.Sprintf("smaller than %d KB", f.minSize/1024)
+	}
+	if f.minDuration > 0 {
+		if props, err := analysis.GetAudioProperties(p); err == nil && props.Duration > 0 && props.Duration < f.minDuration {
+			return fmt.Sprintf("shorter than %.0fs", f.minDuration)
+		}
+	}
+	return ""
+}
+
+func ScanFolder(ctx context.Context, root string, filter *Filter) (*FolderScan, error) {
+	scan := &FolderScan{Paths: []string{}, Skipped: []Skipped{}}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			scan.Skipped = append(scan.Skipped, Skipped{Path: p, Reason: err.Error()})
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != root && filter.SkipDir(p) {
+				scan.Skipped = append(scan.Skipped, Skipped{Path: p, Reason: "ignored folder"})
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if metadata.IsCueSheet(p) {
+			scan.Paths = append(scan.Paths, p)
+			return nil
+		}
+		if !IsAudioFile(p) {
+			return nil
+		}
+		var size int64 = -1
+		if info, err := d.Info(); err == nil {
+			size = info.Size()
+		}
+		if reason := filter.Exclude(p, size); reason != "" {
+			scan.Skipped = append(scan.Skipped, Skipped{Path: p, Reason: reason})
+			return nil
+		}
+		scan.Paths = append(scan.Paths, p)
+		return nil
+	})
+	scan.Paths = withoutCueSources(scan.Paths)
+	return scan, err
+}
@@ -0,0 +1,171 @@
+package library
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"kitty/backend/metadata"
+	"kitty/backend/storage"
+)
+
+var (
+	ErrUnknownTrack = errors.New("track is not in the library")
+	ErrTrackExists  = errors.New("a track with that path is already in the library")
+)
+
+func TrackID(path string) string {
+	sum := sha1.Sum([]byte(filepath.Clean(path)))
+	return hex.EncodeToString(sum[:10])
+}
+
+func (m *Manager) PathForID(id string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	path, ok := m.ids[id]
+	return path, ok
+}
+
+func (m *Manager) IDForPath(path string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id, ok := m.pathIDs[path]
+	return id, ok
+}
+
+func (m *Manager) ResolveTrack(ref string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if path, ok := m.ids[ref]; ok {
+		return path, true
+	}
+	if _, ok := m.tracks[ref]; ok {
+		return ref, true
+	}
+	return "", false
+}
+
+func (m *Manager) loadIDsLocked() {
+	if m.idsLoaded {
+		return
+	}
+	m.idsLoaded = true
+	stored, err := storage.LoadTrackIDs()
+	if err != nil {
+		log.Printf("[library] load track ids failed: %v", err)
+		return
+	}
+	for path, id := range stored {
+		if _, taken := m.ids[id]; taken {
+			continue
+		}
+		m.ids[id] = path
+		m.pathIDs[path] = id
+	}
+}
+
+func (m *Manager) assignIDLocked(path string) string {
+	m.loadIDsLocked()
+	if id, ok := m.pathIDs[path]; ok {
+		return id
+	}
+	id := TrackID(path)
+	for n := 1; ; n++ {
+		if _, taken := m.ids[id]; !taken {
+			break
+		}
+		id = TrackID(fmt.Sprintf("%s#%d", path, n))
+	}
+	m.ids[id] = path
+	m.pathIDs[path] = id
+	m.idsDirty = true
+	return id
+}
+
+func (m *Manager) forgetIDLocked(path string) {
+	if id, ok := m.pathIDs[path]; ok {
+		delete(m.ids, id)
+		delete(m.pathIDs, path)
+		m.idsDirty = true
+	}
+}
+
+func (m *Manager) saveIDs() {
+	m.mu.Lock()
+	if !m.idsDirty {
+		m.mu.Unlock()
+		return
+	}
+	ids := make(map[string]string, len(m.pathIDs))
+	for path, id := range m.pathIDs {
+		ids[path] = id
+	}
+	m.idsDirty = false
+	m.mu.Unlock()
+	if err := storage.SaveTrackIDs(ids); err != nil {
+		log.Printf("[library] save track ids failed: %v", err)
+	}
+}
+
+func (m *Manager) Relocate(id, newPath string) (metadata.TrackMetadata, error) {
+	newPath = filepath.Clean(newPath)
+	if _, err := os.Stat(newPath); err != nil {
+		return metadata.TrackMetadata{}, err
+	}
+	m.mu.Lock()
+	oldPath, ok := m.ids[id]
+	_, taken := m.tracks[newPath]
+	m.mu.Unlock()
+	if !ok {
+		return metadata.TrackMetadata{}, ErrUnknownTrack
+	}
+	if taken {
+		return metadata.TrackMetadata{}, ErrTrackExists
+	}
+
+	md, err := metadata.LoadMetadata(newPath)
+	if err != nil {
+		return metadata.TrackMetadata{}, err
+	}
+	stamp, _ := statStamp(newPath)
+
+	m.mu.Lock()
+	m.dropMemLocked(oldPath)
+	delete(m.tracks, oldPath)
+	delete(m.stamps, oldPath)
+	delete(m.pathIDs, oldPath)
+	m.ids[id] = newPath
+	m.pathIDs[newPath] = id
+	m.idsDirty = true
+	replaced := false
+	for i, p := range m.order {
+		if p == oldPath {
+			m.order[i] = newPath
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		m.order = append(m.order, newPath)
+	}
+	m.putLocked(newPath, *md)
+	m.stamps[newPath] = stamp
+	t := m.tracks[newPath]
+	files := append([]string{}, m.order...)
+	stamps := m.stampsLocked()
+	m.mu.Unlock()
+
+	if err := storage.SaveLibrary(files); err != nil {
+		return t, err
+	}
+	if err := storage.SaveFileStamps(stamps); err != nil {
+		log.Printf("[library] save file stamps failed: %v", err)
+	}
+	m.saveIDs()
+	log.Printf("[library] relocated %s -> %s", filepath.Base(oldPath), newPath)
+	return t, nil
+}
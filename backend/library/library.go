@@ -1,46 +1,105 @@
 package library
 
 import (
+	"container/list"
+	"context"
 	"fmt"
+	"kitty/backend/cloudfile"
 	"kitty/backend/metadata"
 	"kitty/backend/storage"
 	"log"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"sync"
 )
 
 type BatchResult struct {
-	Tracks []metadata.TrackMetadata `json:"tracks"`
-	Errors []string                 `json:"errors"`
+	Tracks   []metadata.TrackMetadata `json:"tracks"`
+	Errors   []string                 `json:"errors"`
+	Failures []ImportFailure          `json:"failures"`
 }
 
 type Manager struct {
-	mu     sync.Mutex
-	tracks map[string]metadata.TrackMetadata
-	order  []string
+	mu        sync.Mutex
+	tracks    map[string]metadata.TrackMetadata
+	order     []string
+	stamps    map[string]storage.FileStamp
+	ids       map[string]string
+	pathIDs   map[string]string
+	idsLoaded bool
+	idsDirty  bool
+	durations map[string]float64
+
+	mem        map[string]*memEntry
+	lru        *list.List
+	metaBytes  int64
+	coverBytes int64
+
+	failedMu sync.Mutex
 }
 
 func NewManager() *Manager {
 	return &Manager{
-		tracks: make(map[string]metadata.TrackMetadata),
-		order:  make([]string, 0),
+		tracks:  make(map[string]metadata.TrackMetadata),
+		order:   make([]string, 0),
+		stamps:  make(map[string]storage.FileStamp),
+		ids:     make(map[string]string),
+		pathIDs: make(map[string]string),
+		mem:     make(map[string]*memEntry),
+		lru:     list.New(),
 	}
 }
 
-func (m *Manager) LoadStoredLibrary() (*BatchResult, error) {
+func (m *Manager) LoadStoredLibrary(ctx context.Context) (*BatchResult, error) {
 	paths, err := storage.LoadLibrary()
 	if err != nil {
 		return &BatchResult{}, err
 	}
-	return m.loadAndMerge(paths, false)
+	res, err := m.loadAndMerge(ctx, m.restoreCached(paths), false)
+	if err != nil {
+		return res, err
+	}
+	rank := make(map[string]int, len(paths))
+	for i, p := range paths {
+		if _, ok := rank[p]; !ok {
+			rank[p] = i
+		}
+	}
+	pos := func(p string) int {
+		if i, ok := rank[p]; ok {
+			return i
+		}
+		return len(paths)
+	}
+	m.mu.Lock()
+	sort.SliceStable(m.order, func(i, j int) bool { return pos(m.order[i]) < pos(m.order[j]) })
+	m.mu.Unlock()
+	res.Tracks = m.snapshot()
+	m.saveTrackCache()
+	return res, nil
 }
 
 func (m *Manager) AddFiles(paths []string) (*BatchResult, error) {
-	return m.loadAndMerge(paths, true)
+	paths, failures := expandCueSheets(paths)
+	res, err := m.loadAndMerge(context.Background(), paths, true)
+	if res != nil && len(failures) > 0 {
+		for _, f := range failures {
+			res.Errors = append(res.Errors, fmt.Sprintf("%s: %s", f.Path, f.Error))
+		}
+		res.Failures = append(failures, res.Failures...)
+	}
+	return res, err
 }
 
 func (m *Manager) UpdateAndReload(md metadata.TrackMetadata) (metadata.TrackMetadata, error) {
+	if err := cloudfile.Hydrate(context.Background(), md.FilePath, nil); err != nil {
+		return metadata.TrackMetadata{}, err
+	}
+	md, err := m.withEvicted(md)
+	if err != nil {
+		return metadata.TrackMetadata{}, err
+	}
 	if err := metadata.SaveMetadata(md); err != nil {
 		return metadata.TrackMetadata{}, err
 	}
@@ -49,8 +108,11 @@ func (m *Manager) UpdateAndReload(md metadata.TrackMetadata) (metadata.TrackMeta
 		return metadata.TrackMetadata{}, err
 	}
 
+	stamp, _ := statStamp(refreshed.FilePath)
+
 	m.mu.Lock()
-	m.tracks[refreshed.FilePath] = *refreshed
+	m.putLocked(refreshed.FilePath, *refreshed)
+	m.stamps[refreshed.FilePath] = stamp
 	if !m.hasPath(refreshed.FilePath) {
 		m.order = append(m.order, refreshed.FilePath)
 	}
@@ -61,10 +123,30 @@ func (m *Manager) UpdateAndReload(md metadata.TrackMetadata) (metadata.TrackMeta
 	return *refreshed, nil
 }
 
-func (m *Manager) loadAndMerge(paths []string, persist bool) (*BatchResult, error) {
-	unique := m.filterNew(paths)
+func (m *Manager) Reload(path string) (metadata.TrackMetadata, error) {
+	refreshed, err := metadata.LoadMetadata(path)
+	if err != nil {
+		return metadata.TrackMetadata{}, err
+	}
+	stamp, _ := statStamp(path)
+
+	m.mu.Lock()
+	if _, ok := m.tracks[path]; ok {
+		m.putLocked(path, *refreshed)
+		m.stamps[path] = stamp
+	}
+	m.mu.Unlock()
+	return *refreshed, nil
+}
+
+func (m *Manager) loadAndMerge(ctx context.Context, paths []string, persist bool) (*BatchResult, error) {
+	unique, dupes := m.filterNew(paths)
+	failures := make([]ImportFailure, 0, len(dupes))
+	for _, p := range dupes {
+		failures = append(failures, duplicateFailure(p))
+	}
 	if len(unique) == 0 {
-		return &BatchResult{Tracks: m.snapshot()}, nil
+		return &BatchResult{Tracks: m.snapshot(), Failures: failures}, nil
 	}
 
 	workerCount := runtime.NumCPU() * 8
@@ -74,7 +156,9 @@ func (m *Manager) loadAndMerge(paths []string, persist bool) (*BatchResult, erro
 
 	type res struct {
 		track metadata.TrackMetadata
+		stamp storage.FileStamp
 		err   error
+		stage string
 		path  string
 	}
 
@@ -87,18 +171,34 @@ func (m *Manager) loadAndMerge(paths []string, persist bool) (*BatchResult, erro
 		go func() {
 			defer wg.Done()
 			for path := range jobs {
-				md, err := metadata.LoadMetadata(path)
+				var md *metadata.TrackMetadata
+				stage := StageRead
+				err := checkImportable(path)
+				if err == nil {
+					stage = StageMetadata
+					md, err = loadWithTimeout(path)
+				}
 				if err != nil {
-					results <- res{err: err, path: path}
+					if offline(path) {
+						results <- res{track: *metadata.OfflineMetadata(path), path: path}
+						continue
+					}
+					results <- res{err: err, stage: stage, path: path}
 					continue
 				}
-				results <- res{track: *md, path: path}
+				stamp, _ := statStamp(path)
+				results <- res{track: *md, stamp: stamp, path: path}
 			}
 		}()
 	}
 
+feed:
 	for _, p := range unique {
-		jobs <- p
+		select {
+		case jobs <- p:
+		case <-ctx.Done():
+			break feed
+		}
 	}
 	close(jobs)
 	wg.Wait()
@@ -108,14 +208,18 @@ func (m *Manager) loadAndMerge(paths []string, persist bool) (*BatchResult, erro
 		newTracks []metadata.TrackMetadata
 		errs      []string
 	)
+	newStamps := make(map[string]storage.FileStamp, len(unique))
 
 	for r := range results {
 		if r.err != nil {
 			errs = append(errs, fmt.Sprintf("%s: %v", r.path, r.err))
+			failures = append(failures, newFailure(r.path, r.stage, r.err))
 			continue
 		}
 		newTracks = append(newTracks, r.track)
+		newStamps[r.path] = r.stamp
 	}
+	m.recordLoads(failures, newStamps)
 
 	loadedByPath := make(map[string]metadata.TrackMetadata, len(newTracks))
 	for _, t := range newTracks {
@@ -134,36 +238,55 @@ func (m *Manager) loadAndMerge(paths []string, persist bool) (*BatchResult, erro
 			if _, exists := m.tracks[t.FilePath]; !exists {
 				m.order = append(m.order, t.FilePath)
 			}
-			m.tracks[t.FilePath] = t
+			m.putLocked(t.FilePath, t)
+		}
+		for p, st := range newStamps {
+			m.stamps[p] = st
 		}
 		snapshot := m.snapshotLocked()
+		stamps := m.stampsLocked()
 		m.mu.Unlock()
 
 		if persist {
 			if err := storage.SaveLibrary(m.order); err != nil {
 				errs = append(errs, fmt.Sprintf("save library failed: %v", err))
+				failures = append(failures, ImportFailure{Stage: StageSave, Code: FailureUnknown, Error: err.Error(), Suggestion: "Check that the Kitty settings folder is writable."})
 			}
 		}
+		if err := storage.SaveFileStamps(stamps); err != nil {
+			log.Printf("[library] save file stamps failed: %v", err)
+		}
+		m.saveIDs()
 
 		log.Printf("[library] added %d tracks (errors: %d); total=%d", len(orderedNewTracks), len(errs), len(snapshot))
 	}
 
 	return &BatchResult{
-		Tracks: m.snapshot(),
-		Errors: errs,
-	}, nil
+		Tracks:   m.snapshot(),
+		Errors:   errs,
+		Failures: failures,
+	}, ctx.Err()
 }
 
 func (m *Manager) ApplyMetadata(path string, overlay metadata.TrackMetadata) metadata.TrackMetadata {
+	full, _ := m.Full(path)
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	existing, ok := m.tracks[path]
 	if !ok {
-		m.tracks[path] = overlay
+		m.putLocked(path, overlay)
 		m.order = append(m.order, path)
 		return overlay
 	}
+	if e := m.mem[path]; e != nil {
+		if e.coverEvicted {
+			existing.CoverImage = full.CoverImage
+		}
+		if e.lyricsEvicted {
+			existing.Lyrics = full.Lyrics
+		}
+	}
 
 	if overlay.Title != "" {
 		existing.Title = overlay.Title
@@ -186,9 +309,18 @@ func (m *Manager) ApplyMetadata(path string, overlay metadata.TrackMetadata) met
 	if overlay.Composer != "" {
 		existing.Composer = overlay.Composer
 	}
+	if overlay.Label != "" {
+		existing.Label = overlay.Label
+	}
 	if overlay.Lyrics != "" {
 		existing.Lyrics = overlay.Lyrics
 	}
+	if overlay.SourceURL != "" {
+		existing.SourceURL = overlay.SourceURL
+	}
+	if overlay.Source != "" {
+		existing.Source = overlay.Source
+	}
 	if overlay.CoverImage != "" {
 		existing.CoverImage = overlay.CoverImage
 		existing.HasCover = true
@@ -204,7 +336,7 @@ func (m *Manager) ApplyMetadata(path string, overlay metadata.TrackMetadata) met
 		existing.Year = overlay.Year
 	}
 
-	m.tracks[path] = existing
+	m.putLocked(path, existing)
 	return existing
 }
 
@@ -233,20 +365,22 @@ func (m *Manager) hasPath(path string) bool {
 	return false
 }
 
-func (m *Manager) filterNew(paths []string) []string {
+func (m *Manager) filterNew(paths []string) ([]string, []string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	seen := make(map[string]struct{}, len(paths))
-	var unique []string
+	var unique, dupes []string
 	for _, p := range paths {
 		if _, ok := seen[p]; ok {
 			continue
 		}
 		seen[p] = struct{}{}
-		if _, exists := m.tracks[p]; !exists {
-			unique = append(unique, p)
+		if _, exists := m.tracks[p]; exists {
+			dupes = append(dupes, p)
+			continue
 		}
+		unique = append(unique, p)
 	}
-	return unique
+	return unique, dupes
 }
@@ -0,0 +1,156 @@
+package library
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"kitty/backend/analysis"
+	"kitty/backend/metadata"
+)
+
+var titleNoise = []string{
+	"official music video", "official video", "official audio", "official lyric video",
+	"lyric video", "lyrics", "audio", "music video", "visualizer", "hd", "hq", "4k",
+}
+
+func (m *Manager) Tracks() []metadata.TrackMetadata {
+	return m.snapshot()
+}
+
+func (m *Manager) Track(path string) (metadata.TrackMetadata, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.tracks[path]
+	return t, ok
+}
+
+func (m *Manager) FindMatch(title, artist string) (metadata.TrackMetadata, bool) {
+	wantTitle := NormalizeTitle(title)
+	wantArtist := normalizeKey(artist)
+	if wantTitle == "" {
+		return metadata.TrackMetadata{}, false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, path := range m.order {
+		t, ok := m.tracks[path]
+		if !ok {
+			continue
+		}
+		if matchesTrack(t, wantTitle, wantArtist) {
+			return t, true
+		}
+	}
+	return metadata.TrackMetadata{}, false
+}
+
+func (m *Manager) FindDuplicate(title, artist string, duration, tolerance float64) (metadata.TrackMetadata, bool) {
+	wantTitle := NormalizeTitle(title)
+	wantArtist := normalizeKey(artist)
+	if wantTitle == "" {
+		return metadata.TrackMetadata{}, false
+	}
+
+	m.mu.Lock()
+	candidates := make([]metadata.TrackMetadata, 0)
+	for _, path := range m.order {
+		if t, ok := m.tracks[path]; ok && !t.Offline && matchesTrack(t, wantTitle, wantArtist) {
+			candidates = append(candidates, t)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, t := range candidates {
+		if duration <= 0 {
+			return t, true
+		}
+		props, err := analysis.GetAudioProperties(t.FilePath)
+		if err != nil || props.Duration <= 0 || math.Abs(props.Duration-duration) <= tolerance {
+			return t, true
+		}
+	}
+	return metadata.TrackMetadata{}, false
+}
+
+func matchesTrack(t metadata.TrackMetadata, wantTitle, wantArtist string) bool {
+	title := NormalizeTitle(t.Title)
+	if title == "" {
+		return false
+	}
+	artist := normalizeKey(t.Artist)
+	if artist == normalizeKey("Unknown Artist") {
+		artist = ""
+	}
+
+	if wantArtist != "" && artist != "" {
+		if title == wantTitle && (artist == wantArtist || strings.Contains(wantArtist, artist) || strings.Contains(artist, wantArtist)) {
+			return true
+		}
+	} else if title == wantTitle {
+		return true
+	}
+
+	if artist != "" {
+		combined := artist + " " + title
+		if combined == wantTitle || strings.Contains(wantTitle, combined) {
+			return true
+		}
+	}
+	return false
+}
+
+func NormalizeTitle(s string) string {
+	s = strings.ToLower(s)
+	var b strings.Builder
+	for s != "" {
+		i := strings.IndexAny(s, "([")
+		if i < 0 {
+			b.WriteString(s)
+			break
+		}
+		b.WriteString(s[:i])
+		closer := byte(')')
+		if s[i] == '[' {
+			closer = ']'
+		}
+		j := strings.IndexByte(s[i+1:], closer)
+		if j < 0 {
+			b.WriteString(s[i+1:])
+			break
+		}
+		if inner := strings.TrimSpace(s[i+1 : i+1+j]); !isNoise(inner) {
+			b.WriteString(" " + inner + " ")
+		}
+		s = s[i+2+j:]
+	}
+	return normalizeKey(b.String())
+}
+
+func isNoise(s string) bool {
+	for _, n := range titleNoise {
+		if s == n {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeKey(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			space = false
+			continue
+		}
+		if !space && b.Len() > 0 {
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,260 @@
+package library
+
+import (
+	"container/list"
+	"fmt"
+	"log"
+	"runtime"
+	"strings"
+	"sync"
+
+	"kitty/backend/metadata"
+)
+
+const (
+	DefaultCoverBudgetMB    = 128
+	DefaultMetadataBudgetMB = 384
+
+	trackOverhead = 512
+	evictTarget   = 0.9
+)
+
+var (
+	limitsMu sync.Mutex
+	coverCap int64 = DefaultCoverBudgetMB << 20
+	metaCap  int64 = DefaultMetadataBudgetMB << 20
+)
+
+type MemoryStats struct {
+	Tracks         int    `json:"tracks"`
+	MetadataBytes  int64  `json:"metadataBytes"`
+	CoverBytes     int64  `json:"coverBytes"`
+	MetadataLimit  int64  `json:"metadataLimit"`
+	CoverLimit     int64  `json:"coverLimit"`
+	EvictedCovers  int    `json:"evictedCovers"`
+	EvictedLyrics  int    `json:"evictedLyrics"`
+	HeapAllocBytes uint64 `json:"heapAllocBytes"`
+	HeapSysBytes   uint64 `json:"heapSysBytes"`
+	NumGC          uint32 `json:"numGc"`
+}
+
+type memEntry struct {
+	size          int64
+	cover         int64
+	elem          *list.Element
+	coverEvicted  bool
+	lyricsEvicted bool
+}
+
+func SetMemoryLimits(coverMB, metadataMB int64) {
+	if coverMB <= 0 {
+		coverMB = DefaultCoverBudgetMB
+	}
+	if metadataMB <= 0 {
+		metadataMB = DefaultMetadataBudgetMB
+	}
+	limitsMu.Lock()
+	coverCap, metaCap = coverMB<<20, metadataMB<<20
+	limitsMu.Unlock()
+}
+
+func memoryLimits() (int64, int64) {
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	return coverCap, metaCap
+}
+
+func trackSize(t metadata.TrackMetadata) int64 {
+	n := trackOverhead + len(t.ID) + len(t.FilePath) + len(t.FileName) + len(t.Title) + len(t.Artist) + len(t.Album) +
+		len(t.AlbumArtist) + len(t.Genre) + len(t.Comment) + len(t.Composer) + len(t.Label) + len(t.Lyrics) + len(t.SyncedLyrics) +
+		len(t.CoverImage) + len(t.Format) + len(t.Key) + len(t.SourceURL) + len(t.Source)
+	for _, l := range t.Links {
+		n += 32 + len(l.URL)
+	}
+	n += len(t.CuePoints) * 64
+	if c := t.Classical; c != nil {
+		n += 64 + len(c.Work) + len(c.Movement) + len(c.Conductor) + len(c.Orchestra)
+		for _, s := range c.Soloists {
+			n += 16 + len(s)
+		}
+	}
+	return int64(n)
+}
+
+func (m *Manager) putLocked(path string, t metadata.TrackMetadata) {
+	prev := m.mem[path]
+	if prev != nil {
+		m.metaBytes -= prev.size
+		m.coverBytes -= prev.cover
+	}
+	t.ID = m.assignIDLocked(path)
+	e := &memEntry{size: trackSize(t), cover: int64(len(t.CoverImage))}
+	if prev != nil {
+		e.coverEvicted = prev.coverEvicted && t.CoverImage == "" && t.HasCover
+		e.lyricsEvicted = prev.lyricsEvicted && t.Lyrics == ""
+		e.elem = prev.elem
+		m.lru.MoveToBack(e.elem)
+	} else {
+		e.elem = m.lru.PushBack(path)
+	}
+	m.mem[path] = e
+	m.tracks[path] = t
+	delete(m.durations, path)
+	m.metaBytes += e.size
+	m.coverBytes += e.cover
+
+	coverLimit, metaLimit := memoryLimits()
+	if m.coverBytes > coverLimit || m.metaBytes > metaLimit {
+		m.enforceLocked(coverLimit, metaLimit)
+	}
+}
+
+func (m *Manager) dropMemLocked(path string) {
+	e := m.mem[path]
+	if e == nil {
+		return
+	}
+	m.metaBytes -= e.size
+	m.coverBytes -= e.cover
+	m.lru.Remove(e.elem)
+	delete(m.mem, path)
+}
+
+func (m *Manager) EnforceMemoryLimits() {
+	coverLimit, metaLimit := memoryLimits()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.enforceLocked(coverLimit, metaLimit)
+}
+
+func (m *Manager) enforceLocked(coverLimit, metaLimit int64) {
+	if m.coverBytes <= coverLimit && m.metaBytes <= metaLimit {
+		return
+	}
+	coverTarget := int64(float64(coverLimit) * evictTarget)
+	metaTarget := int64(float64(metaLimit) * evictTarget)
+	covers, lyrics := 0, 0
+	for el := m.lru.Front(); el != nil; el = el.Next() {
+		if m.coverBytes <= coverTarget && m.metaBytes <= metaTarget {
+			break
+		}
+		p := el.Value.(string)
+		e := m.mem[p]
+		if e.cover == 0 {
+			continue
+		}
+		t := m.tracks[p]
+		t.CoverImage = ""
+		m.tracks[p] = t
+		m.coverBytes -= e.cover
+		m.metaBytes -= e.cover
+		e.size -= e.cover
+		e.cover = 0
+		e.coverEvicted = true
+		covers++
+	}
+	for el := m.lru.Front(); el != nil; el = el.Next() {
+		if m.metaBytes <= metaTarget {
+			break
+		}
+		p := el.Value.(string)
+		t := m.tracks[p]
+		if t.Lyrics == "" {
+			continue
+		}
+		e := m.mem[p]
+		n := int64(len(t.Lyrics))
+		t.Lyrics = ""
+		m.tracks[p] = t
+		m.metaBytes -= n
+		e.size -= n
+		e.lyricsEvicted = true
+		lyrics++
+	}
+	if covers > 0 || lyrics > 0 {
+		log.Printf("[library] memory budget: evicted %d covers, %d lyrics (covers=%dKB total=%dKB)", covers, lyrics, m.coverBytes>>10, m.metaBytes>>10)
+	}
+}
+
+func (m *Manager) Full(path string) (metadata.TrackMetadata, bool) {
+	m.mu.Lock()
+	t, ok := m.tracks[path]
+	e := m.mem[path]
+	if ok && e != nil {
+		m.lru.MoveToBack(e.elem)
+	}
+	m.mu.Unlock()
+	if !ok || e == nil || (!e.coverEvicted && !e.lyricsEvicted) {
+		return t, ok
+	}
+
+	md, err := metadata.LoadMetadata(path)
+	if err != nil {
+		log.Printf("[library] reload evicted %s failed: %v", path, err)
+		return t, true
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cur, ok := m.tracks[path]
+	if !ok {
+		return *md, true
+	}
+	if e.coverEvicted {
+		cur.CoverImage = md.CoverImage
+	}
+	if e.lyricsEvicted {
+		cur.Lyrics = md.Lyrics
+	}
+	m.putLocked(path, cur)
+	return cur, true
+}
+
+func (m *Manager) withEvicted(md metadata.TrackMetadata) (metadata.TrackMetadata, error) {
+	m.mu.Lock()
+	e := m.mem[md.FilePath]
+	cover := e != nil && e.coverEvicted && md.HasCover && strings.TrimSpace(md.CoverImage) == ""
+	lyrics := e != nil && e.lyricsEvicted && strings.TrimSpace(md.Lyrics) == ""
+	m.mu.Unlock()
+	if !cover && !lyrics {
+		return md, nil
+	}
+	stored, err := metadata.LoadMetadata(md.FilePath)
+	if err != nil {
+		return md, fmt.Errorf("reload evicted cover and lyrics: %w", err)
+	}
+	if cover {
+		md.CoverImage = stored.CoverImage
+	}
+	if lyrics {
+		md.Lyrics = stored.Lyrics
+	}
+	return md, nil
+}
+
+func (m *Manager) MemoryStats() MemoryStats {
+	coverLimit, metaLimit := memoryLimits()
+	var rt runtime.MemStats
+	runtime.ReadMemStats(&rt)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := MemoryStats{
+		Tracks:         len(m.tracks),
+		MetadataBytes:  m.metaBytes,
+		CoverBytes:     m.coverBytes,
+		MetadataLimit:  metaLimit,
+		CoverLimit:     coverLimit,
+		HeapAllocBytes: rt.HeapAlloc,
+		HeapSysBytes:   rt.HeapSys,
+		NumGC:          rt.NumGC,
+	}
+	for _, e := range m.mem {
+		if e.coverEvicted {
+			stats.EvictedCovers++
+		}
+		if e.lyricsEvicted {
+			stats.EvictedLyrics++
+		}
+	}
+	return stats
+}
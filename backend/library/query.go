@@ -0,0 +1,179 @@
+package library
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"kitty/backend/metadata"
+	"kitty/backend/storage"
+)
+
+const (
+	SortTitle      = "title"
+	SortArtist     = "artist"
+	SortAlbum      = "album"
+	SortYear       = "year"
+	SortBPM        = "bpm"
+	SortColorLabel = "colorLabel"
+
+	customSortPrefix = "custom:"
+)
+
+func NormalizeQuery(q storage.TrackQuery) (storage.TrackQuery, error) {
+	labels := make([]string, 0, len(q.ColorLabels))
+	for _, l := range q.ColorLabels {
+		norm, err := metadata.NormalizeColorLabel(l)
+		if err != nil {
+			return storage.TrackQuery{}, err
+		}
+		labels = append(labels, norm)
+	}
+	q.ColorLabels = labels
+	q.Search = strings.TrimSpace(q.Search)
+	q.Column = strings.TrimSpace(q.Column)
+	q.Value = strings.TrimSpace(q.Value)
+	if q.Column == "" && (q.Value != "" || q.Min != nil || q.Max != nil) {
+		return storage.TrackQuery{}, fmt.Errorf("a column is required to filter by value")
+	}
+	q.SortBy = strings.TrimSpace(q.SortBy)
+	if !validSortField(q.SortBy) {
+		return storage.TrackQuery{}, fmt.Errorf("unknown sort field: %s", q.SortBy)
+	}
+	return q, nil
+}
+
+func validSortField(field string) bool {
+	switch field {
+	case "", SortTitle, SortArtist, SortAlbum, SortYear, SortBPM, SortColorLabel:
+		return true
+	}
+	return strings.HasPrefix(field, customSortPrefix) && len(field) > len(customSortPrefix)
+}
+
+func (m *Manager) Query(q storage.TrackQuery) ([]metadata.TrackMetadata, error) {
+	q, err := NormalizeQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]metadata.TrackMetadata, 0)
+	for _, t := range m.Tracks() {
+		if matchesQuery(t, q) {
+			out = append(out, t)
+		}
+	}
+	if q.SortBy != "" {
+		sort.SliceStable(out, func(i, j int) bool {
+			c := compareField(out[i], out[j], q.SortBy)
+			if q.Descending {
+				return c > 0
+			}
+			return c < 0
+		})
+	}
+	return out, nil
+}
+
+func matchesQuery(t metadata.TrackMetadata, q storage.TrackQuery) bool {
+	if q.Search != "" && !matchesSearch(t, q.Search) {
+		return false
+	}
+	if len(q.ColorLabels) > 0 {
+		found := false
+		for _, l := range q.ColorLabels {
+			if l == t.ColorLabel {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if q.Column == "" {
+		return true
+	}
+	value, ok := t.Custom[q.Column]
+	if q.Value == "" && q.Min == nil && q.Max == nil {
+		return ok
+	}
+	if q.Value != "" && !strings.Contains(strings.ToLower(value), strings.ToLower(q.Value)) {
+		return false
+	}
+	if q.Min != nil || q.Max != nil {
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return false
+		}
+		if q.Min != nil && n < *q.Min {
+			return false
+		}
+		if q.Max != nil && n > *q.Max {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesSearch(t metadata.TrackMetadata, search string) bool {
+	haystack := strings.ToLower(strings.Join([]string{
+		t.Title, t.Artist, t.Album, t.AlbumArtist, t.Genre, t.Composer, t.Label, t.FileName,
+	}, "\n"))
+	for _, term := range strings.Fields(strings.ToLower(search)) {
+		if !strings.Contains(haystack, term) {
+			return false
+		}
+	}
+	return true
+}
+
+func compareField(a, b metadata.TrackMetadata, field string) int {
+	switch field {
+	case SortTitle:
+		return compareText(a.Title, b.Title)
+	case SortArtist:
+		return compareText(a.Artist, b.Artist)
+	case SortAlbum:
+		return compareText(a.Album, b.Album)
+	case SortYear:
+		return compareNumber(float64(a.Year), float64(b.Year))
+	case SortBPM:
+		return compareNumber(a.BPM, b.BPM)
+	case SortColorLabel:
+		return metadata.ColorLabelRank(a.ColorLabel) - metadata.ColorLabelRank(b.ColorLabel)
+	}
+	key := strings.TrimPrefix(field, customSortPrefix)
+	return compareValues(a.Custom[key], b.Custom[key])
+}
+
+func compareValues(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+	na, errA := strconv.ParseFloat(a, 64)
+	nb, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		return compareNumber(na, nb)
+	}
+	return compareText(a, b)
+}
+
+func compareText(a, b string) int {
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+func compareNumber(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,45 @@
+package library
+
+import (
+	"log"
+
+	"kitty/backend/storage"
+)
+
+func (m *Manager) Remove(paths []string) (int, error) {
+	drop := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		drop[p] = true
+	}
+	m.mu.Lock()
+	removed := 0
+	order := m.order[:0]
+	for _, p := range m.order {
+		if !drop[p] {
+			order = append(order, p)
+			continue
+		}
+		m.dropMemLocked(p)
+		delete(m.tracks, p)
+		delete(m.stamps, p)
+		delete(m.durations, p)
+		m.forgetIDLocked(p)
+		removed++
+	}
+	m.order = order
+	files := append([]string{}, m.order...)
+	stamps := m.stampsLocked()
+	m.mu.Unlock()
+
+	if removed == 0 {
+		return 0, nil
+	}
+	if err := storage.SaveFileStamps(stamps); err != nil {
+		log.Printf("[library] save file stamps failed: %v", err)
+	}
+	if err := storage.SaveLibrary(files); err != nil {
+		return removed, err
+	}
+	m.saveIDs()
+	return removed, nil
+}
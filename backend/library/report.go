@@ -0,0 +1,115 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"kitty/backend/metadata"
+)
+
+const (
+	StageScan     = "scan"
+	StageRead     = "read"
+	StageMetadata = "metadata"
+	StageSave     = "save"
+
+	FailureUnsupported = "unsupported"
+	FailurePermission  = "permission_denied"
+	FailureNotFound    = "not_found"
+	FailureCorrupt     = "corrupt"
+	FailureDuplicate   = "duplicate"
+	FailureTimeout     = "timeout"
+	FailureUnknown     = "unknown"
+
+	importFileTimeout = 30 * time.Second
+)
+
+var (
+	errImportTimeout     = errors.New("reading the file timed out")
+	errUnsupportedFormat = errors.New("unsupported file format")
+	errEmptyFile         = errors.New("file is empty")
+)
+
+type ImportFailure struct {
+	Path       string `json:"path"`
+	Stage      string `json:"stage"`
+	Code       string `json:"code"`
+	Error      string `json:"error"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+func newFailure(path, stage string, err error) ImportFailure {
+	code, suggestion := classifyFailure(err)
+	return ImportFailure{Path: path, Stage: stage, Code: code, Error: err.Error(), Suggestion: suggestion}
+}
+
+func duplicateFailure(path string) ImportFailure {
+	return ImportFailure{
+		Path:       path,
+		Stage:      StageScan,
+		Code:       FailureDuplicate,
+		Error:      "already in the library",
+		Suggestion: "Nothing to do; the existing entry is kept.",
+	}
+}
+
+func classifyFailure(err error) (string, string) {
+	switch {
+	case errors.Is(err, errImportTimeout), errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		return FailureTimeout, "The drive may be slow or disconnected; try again once it responds."
+	case errors.Is(err, fs.ErrPermission):
+		return FailurePermission, "Grant Kitty access to the folder, then import again."
+	case errors.Is(err, fs.ErrNotExist):
+		return FailureNotFound, "The file was moved or deleted before it could be read."
+	case errors.Is(err, errUnsupportedFormat):
+		return FailureUnsupported, "Convert the file to a supported format such as MP3, FLAC or WAV."
+	case errors.Is(err, errEmptyFile):
+		return FailureCorrupt, "The file is empty; re-download or re-export it."
+	}
+	return FailureCorrupt, "The file could not be parsed; it may be damaged or incomplete."
+}
+
+func checkImportable(path string) error {
+	ext := strings.ToLower(filepath.Ext(path))
+	if cue, _, ok := metadata.SplitCueTrackPath(path); ok {
+		path, ext = cue, ".cue"
+	}
+	if !audioExts[ext] && ext != ".cue" {
+		if ext == "" {
+			return errUnsupportedFormat
+		}
+		return fmt.Errorf("%w: %s", errUnsupportedFormat, ext)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		return errEmptyFile
+	}
+	return nil
+}
+
+func loadWithTimeout(path string) (*metadata.TrackMetadata, error) {
+	type result struct {
+		md  *metadata.TrackMetadata
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		md, err := metadata.LoadMetadata(path)
+		done <- result{md, err}
+	}()
+	select {
+	case r := <-done:
+		return r.md, r.err
+	case <-time.After(importFileTimeout):
+		return nil, errImportTimeout
+	}
+}
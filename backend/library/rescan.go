@@ -0,0 +1,108 @@
+package library
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"kitty/backend/metadata"
+	"kitty/backend/storage"
+)
+
+type RescanResult struct {
+	Checked int                      `json:"checked"`
+	Changed []metadata.TrackMetadata `json:"changed"`
+	Missing []string                 `json:"missing"`
+	Errors  []string                 `json:"errors"`
+}
+
+func (m *Manager) Rescan(ctx context.Context, paths []string, progress func(done, total int, path string)) (*RescanResult, error) {
+	if len(paths) == 0 {
+		m.mu.Lock()
+		paths = append([]string{}, m.order...)
+		m.mu.Unlock()
+	}
+
+	result := &RescanResult{
+		Changed: make([]metadata.TrackMetadata, 0),
+		Missing: make([]string, 0),
+		Errors:  make([]string, 0),
+	}
+	for i, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if progress != nil {
+			progress(i, len(paths), path)
+		}
+		result.Checked++
+
+		stamp, err := statStamp(path)
+		if err != nil {
+			if offline(path) {
+				m.markOffline(path)
+				continue
+			}
+			if os.IsNotExist(err) {
+				result.Missing = append(result.Missing, path)
+			} else {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", path, err))
+			}
+			continue
+		}
+
+		m.mu.Lock()
+		prev, known := m.stamps[path]
+		_, loaded := m.tracks[path]
+		m.mu.Unlock()
+		if !loaded {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: not in library", path))
+			continue
+		}
+		if known && prev == stamp {
+			continue
+		}
+
+		md, err := metadata.LoadMetadata(path)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", path, err))
+			continue
+		}
+		m.mu.Lock()
+		m.putLocked(path, *md)
+		m.stamps[path] = stamp
+		m.mu.Unlock()
+		result.Changed = append(result.Changed, *md)
+	}
+	if progress != nil {
+		progress(len(paths), len(paths), "")
+	}
+
+	m.mu.Lock()
+	stamps := m.stampsLocked()
+	m.mu.Unlock()
+	if err := storage.SaveFileStamps(stamps); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("save file stamps failed: %v", err))
+	}
+	m.saveTrackCache()
+
+	log.Printf("[library] rescan checked=%d changed=%d missing=%d", result.Checked, len(result.Changed), len(result.Missing))
+	return result, nil
+}
+
+func (m *Manager) stampsLocked() map[string]storage.FileStamp {
+	out := make(map[string]storage.FileStamp, len(m.stamps))
+	for p, st := range m.stamps {
+		out[p] = st
+	}
+	return out
+}
+
+func statStamp(path string) (storage.FileStamp, error) {
+	info, err := os.Stat(metadata.SourcePath(path))
+	if err != nil {
+		return storage.FileStamp{}, err
+	}
+	return storage.FileStamp{ModTime: info.ModTime().UnixNano(), Size: info.Size()}, nil
+}
@@ -0,0 +1,175 @@
+package library
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"kitty/backend/metadata"
+	"kitty/backend/storage"
+)
+
+const (
+	MaxLoadRetries = 8
+
+	retryBaseDelay = time.Minute
+	retryMaxDelay  = 6 * time.Hour
+)
+
+type RetryResult struct {
+	Attempted int                      `json:"attempted"`
+	Recovered int                      `json:"recovered"`
+	Failed    []storage.FailedLoad     `json:"failed"`
+	Tracks    []metadata.TrackMetadata `json:"tracks"`
+}
+
+func retryable(code string) bool {
+	switch code {
+	case FailureDuplicate, FailureUnsupported:
+		return false
+	}
+	return true
+}
+
+func retryDelay(attempts int) time.Duration {
+	d := retryBaseDelay
+	for i := 1; i < attempts && d < retryMaxDelay; i++ {
+		d *= 2
+	}
+	if d > retryMaxDelay {
+		d = retryMaxDelay
+	}
+	return d
+}
+
+func (m *Manager) FailedLoads() ([]storage.FailedLoad, error) {
+	m.failedMu.Lock()
+	defer m.failedMu.Unlock()
+	return storage.LoadFailedLoads()
+}
+
+func (m *Manager) DueRetries(now time.Time) []string {
+	m.failedMu.Lock()
+	defer m.failedMu.Unlock()
+	failed, err := storage.LoadFailedLoads()
+	if err != nil {
+		log.Printf("[library] load failed paths: %v", err)
+		return nil
+	}
+	var due []string
+	for _, f := range failed {
+		if f.NextRetry > 0 && f.NextRetry <= now.Unix() {
+			due = append(due, f.Path)
+		}
+	}
+	return due
+}
+
+func (m *Manager) RetryFailed(paths []string) (*RetryResult, error) {
+	if paths == nil {
+		failed, err := m.FailedLoads()
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range failed {
+			paths = append(paths, f.Path)
+		}
+	}
+	out := &RetryResult{Attempted: len(paths)}
+	if len(paths) > 0 {
+		log.Printf("[library] retrying %d failed loads", len(paths))
+		if _, err := m.loadAndMerge(context.Background(), paths, true); err != nil {
+			return nil, err
+		}
+	}
+
+	m.mu.Lock()
+	for _, p := range paths {
+		if _, ok := m.tracks[p]; ok {
+			out.Recovered++
+		}
+	}
+	m.mu.Unlock()
+	failed, err := m.FailedLoads()
+	if err != nil {
+		return nil, err
+	}
+	out.Failed = failed
+	out.Tracks = m.snapshot()
+	return out, nil
+}
+
+func (m *Manager) DismissFailed(paths []string) error {
+	drop := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		drop[p] = true
+	}
+	m.failedMu.Lock()
+	defer m.failedMu.Unlock()
+	failed, err := storage.LoadFailedLoads()
+	if err != nil {
+		return err
+	}
+	kept := failed[:0]
+	for _, f := range failed {
+		if !drop[f.Path] {
+			kept = append(kept, f)
+		}
+	}
+	return storage.SaveFailedLoads(kept)
+}
+
+func (m *Manager) recordLoads(failures []ImportFailure, loaded map[string]storage.FileStamp) {
+	if len(failures) == 0 && len(loaded) == 0 {
+		return
+	}
+	m.failedMu.Lock()
+	defer m.failedMu.Unlock()
+	existing, err := storage.LoadFailedLoads()
+	if err != nil {
+		log.Printf("[library] load failed paths: %v", err)
+		return
+	}
+	if len(existing) == 0 && len(failures) == 0 {
+		return
+	}
+	byPath := make(map[string]storage.FailedLoad, len(existing)+len(failures))
+	for _, f := range existing {
+		byPath[f.Path] = f
+	}
+	for p := range loaded {
+		delete(byPath, p)
+	}
+	now := time.Now()
+	for _, f := range failures {
+		if f.Path == "" {
+			continue
+		}
+		if !retryable(f.Code) {
+			delete(byPath, f.Path)
+			continue
+		}
+		rec, ok := byPath[f.Path]
+		if !ok {
+			rec = storage.FailedLoad{Path: f.Path, FirstSeen: now.Unix()}
+		}
+		rec.Stage, rec.Code, rec.Error = f.Stage, f.Code, f.Error
+		rec.Attempts++
+		rec.LastTried = now.Unix()
+		rec.NextRetry = 0
+		if rec.Attempts < MaxLoadRetries {
+			rec.NextRetry = now.Add(retryDelay(rec.Attempts)).Unix()
+		}
+		byPath[f.Path] = rec
+	}
+
+	out := make([]storage.FailedLoad, 0, len(byPath))
+	for _, f := range byPath {
+		out = append(out, f)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	if err := storage.SaveFailedLoads(out); err != nil {
+		log.Printf("[library] save failed paths: %v", err)
+	}
+}
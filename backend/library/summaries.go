@@ -0,0 +1,167 @@
+package library
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"kitty/backend/analysis"
+	"kitty/backend/metadata"
+)
+
+const (
+	DefaultSummaryPage = 200
+	MaxSummaryPage     = 1000
+)
+
+type TrackSummary struct {
+	ID       string  `json:"id"`
+	Title    string  `json:"title"`
+	Artist   string  `json:"artist"`
+	Album    string  `json:"album"`
+	Duration float64 `json:"duration"`
+	HasCover bool    `json:"hasCover"`
+}
+
+type SummaryPage struct {
+	Total  int            `json:"total"`
+	Offset int            `json:"offset"`
+	Rows   []TrackSummary `json:"rows"`
+}
+
+func (m *Manager) Summaries(offset, limit int, sortBy string) (SummaryPage, error) {
+	sortBy = strings.TrimSpace(sortBy)
+	desc := strings.HasPrefix(sortBy, "-")
+	field := strings.TrimPrefix(sortBy, "-")
+	if !validSortField(field) {
+		return SummaryPage{}, fmt.Errorf("unknown sort field: %s", field)
+	}
+	if offset < 0 {
+		return SummaryPage{}, fmt.Errorf("offset must not be negative")
+	}
+	if limit <= 0 {
+		limit = DefaultSummaryPage
+	}
+	if limit > MaxSummaryPage {
+		limit = MaxSummaryPage
+	}
+
+	tracks := m.snapshot()
+	if field != "" {
+		sort.SliceStable(tracks, func(i, j int) bool {
+			c := compareField(tracks[i], tracks[j], field)
+			if desc {
+				return c > 0
+			}
+			return c < 0
+		})
+	}
+	page := SummaryPage{Total: len(tracks), Offset: offset, Rows: []TrackSummary{}}
+	if offset >= len(tracks) {
+		return page, nil
+	}
+	end := offset + limit
+	if end > len(tracks) {
+		end = len(tracks)
+	}
+	tracks = tracks[offset:end]
+
+	paths := make([]string, 0, len(tracks))
+	for _, t := range tracks {
+		if !t.CloudOnly && !t.Offline {
+			paths = append(paths, t.FilePath)
+		}
+	}
+	durations := m.durationsFor(paths)
+	for _, t := range tracks {
+		page.Rows = append(page.Rows, TrackSummary{
+			ID:       t.ID,
+			Title:    t.Title,
+			Artist:   t.Artist,
+			Album:    t.Album,
+			Duration: durations[t.FilePath],
+			HasCover: t.HasCover,
+		})
+	}
+	return page, nil
+}
+
+func (m *Manager) durationsFor(paths []string) map[string]float64 {
+	out := make(map[string]float64, len(paths))
+	var missing []string
+	m.mu.Lock()
+	for _, p := range paths {
+		if d, ok := m.durations[p]; ok {
+			out[p] = d
+		} else {
+			missing = append(missing, p)
+		}
+	}
+	m.mu.Unlock()
+	if len(missing) == 0 {
+		return out
+	}
+
+	workers := runtime.NumCPU()
+	if len(missing) < workers {
+		workers = len(missing)
+	}
+	jobs := make(chan string)
+	var (
+		wg    sync.WaitGroup
+		outMu sync.Mutex
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				d := probeDuration(p)
+				outMu.Lock()
+				out[p] = d
+				outMu.Unlock()
+			}
+		}()
+	}
+	for _, p := range missing {
+		jobs <- p
+	}
+	close(jobs)
+	wg.Wait()
+
+	m.mu.Lock()
+	if m.durations == nil {
+		m.durations = make(map[string]float64)
+	}
+	for _, p := range missing {
+		if _, ok := m.tracks[p]; ok {
+			m.durations[p] = out[p]
+		}
+	}
+	m.mu.Unlock()
+	return out
+}
+
+func probeDuration(path string) float64 {
+	if _, _, ok := metadata.SplitCueTrackPath(path); ok {
+		_, track, err := metadata.ResolveCueTrack(path)
+		if err != nil {
+			return 0
+		}
+		if track.End > track.Start {
+			return track.End - track.Start
+		}
+		props, err := analysis.GetAudioProperties(track.File)
+		if err != nil || props.Duration <= track.Start {
+			return 0
+		}
+		return props.Duration - track.Start
+	}
+	props, err := analysis.GetAudioProperties(path)
+	if err != nil {
+		return 0
+	}
+	return props.Duration
+}
@@ -0,0 +1,135 @@
+package library
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+
+	"kitty/backend/metadata"
+	"kitty/backend/storage"
+)
+
+type cachedTrack struct {
+	Stamp     storage.FileStamp      `json:"stamp"`
+	Sidecar   int64                  `json:"sidecar,omitempty"`
+	HasLyrics bool                   `json:"hasLyrics,omitempty"`
+	Track     metadata.TrackMetadata `json:"track"`
+}
+
+func trackCachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil || dir == "" {
+		return filepath.Join(os.TempDir(), "kitty-library-cache.json")
+	}
+	return filepath.Join(dir, "Kitty", "library_cache.json")
+}
+
+func ClearTrackCache() error {
+	if err := os.Remove(trackCachePath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func loadTrackCache() map[string]cachedTrack {
+	raw, err := os.ReadFile(trackCachePath())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("[library] read track cache failed: %v", err)
+		}
+		return nil
+	}
+	var cache map[string]cachedTrack
+	if err := json.Unmarshal(raw, &cache); err != nil {
+		log.Printf("[library] track cache is unreadable, ignoring it: %v", err)
+		return nil
+	}
+	return cache
+}
+
+func (m *Manager) restoreCached(paths []string) []string {
+	cache := loadTrackCache()
+	if len(cache) == 0 {
+		return paths
+	}
+	stored, err := storage.LoadFileStamps()
+	if err != nil {
+		log.Printf("[library] load file stamps failed: %v", err)
+		return paths
+	}
+
+	type hit struct {
+		path  string
+		entry cachedTrack
+	}
+	rest := make([]string, 0)
+	hits := make([]hit, 0, len(paths))
+	for _, p := range paths {
+		c, ok := cache[p]
+		prev, known := stored[p]
+		if !ok || !known || prev != c.Stamp || prev.Size == 0 {
+			rest = append(rest, p)
+			continue
+		}
+		if stamp, err := statStamp(p); err != nil || stamp != prev || metadata.SidecarStamp(p) != c.Sidecar {
+			rest = append(rest, p)
+			continue
+		}
+		hits = append(hits, hit{path: p, entry: c})
+	}
+
+	m.mu.Lock()
+	for _, h := range hits {
+		if _, dup := m.tracks[h.path]; dup {
+			continue
+		}
+		m.order = append(m.order, h.path)
+		m.putLocked(h.path, h.entry.Track)
+		if e := m.mem[h.path]; e != nil {
+			e.coverEvicted = h.entry.Track.HasCover
+			e.lyricsEvicted = h.entry.HasLyrics
+		}
+		m.stamps[h.path] = h.entry.Stamp
+	}
+	m.mu.Unlock()
+	log.Printf("[library] restored %d tracks from cache, reading %d", len(hits), len(rest))
+	return rest
+}
+
+func (m *Manager) saveTrackCache() {
+	m.mu.Lock()
+	paths := append([]string{}, m.order...)
+	m.mu.Unlock()
+	sidecars := make(map[string]int64, len(paths))
+	for _, p := range paths {
+		sidecars[p] = metadata.SidecarStamp(p)
+	}
+
+	m.mu.Lock()
+	cache := make(map[string]cachedTrack, len(paths))
+	for _, p := range paths {
+		t, ok := m.tracks[p]
+		stamp, known := m.stamps[p]
+		if !ok || !known || t.Offline || stamp.Size == 0 {
+			continue
+		}
+		e := m.mem[p]
+		c := cachedTrack{Stamp: stamp, Sidecar: sidecars[p], HasLyrics: t.Lyrics != "" || (e != nil && e.lyricsEvicted), Track: t}
+		c.Track.CoverImage = ""
+		c.Track.Lyrics = ""
+		cache[p] = c
+	}
+	m.mu.Unlock()
+
+	raw, err := json.Marshal(cache)
+	if err == nil {
+		path := trackCachePath()
+		if err = os.MkdirAll(filepath.Dir(path), 0o755); err == nil {
+			err = os.WriteFile(path, raw, 0o644)
+		}
+	}
+	if err != nil {
+		log.Printf("[library] save track cache failed: %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package library
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"kitty/backend/storage"
+)
+
+const MaxViewNameLength = 100
+
+func NewView(name string, q storage.TrackQuery) (storage.SavedView, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return storage.SavedView{}, err
+	}
+	now := time.Now().Unix()
+	view := storage.SavedView{ID: hex.EncodeToString(buf), CreatedAt: now}
+	return UpdateView(view, name, q)
+}
+
+func UpdateView(view storage.SavedView, name string, q storage.TrackQuery) (storage.SavedView, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return storage.SavedView{}, fmt.Errorf("view name is required")
+	}
+	if len(name) > MaxViewNameLength {
+		return storage.SavedView{}, fmt.Errorf("view name is longer than %d characters", MaxViewNameLength)
+	}
+	q, err := NormalizeQuery(q)
+	if err != nil {
+		return storage.SavedView{}, err
+	}
+	view.Name = name
+	view.Query = q
+	view.UpdatedAt = time.Now().Unix()
+	return view, nil
+}
@@ -0,0 +1,92 @@
+package library
+
+import (
+	"log"
+	"sort"
+
+	"kitty/backend/metadata"
+	"kitty/backend/volumes"
+)
+
+func offline(path string) bool {
+	root, removable := volumes.RootOf(path)
+	return removable && !volumes.IsMounted(root)
+}
+
+func (m *Manager) markOffline(path string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if t, ok := m.tracks[path]; ok && !t.Offline {
+		t.Offline = true
+		m.putLocked(path, t)
+	}
+}
+
+func (m *Manager) Volumes() []volumes.Volume {
+	byRoot := make(map[string]*volumes.Volume)
+	for _, t := range m.Tracks() {
+		root, removable := volumes.RootOf(t.FilePath)
+		if !removable {
+			continue
+		}
+		v, ok := byRoot[root]
+		if !ok {
+			v = &volumes.Volume{Root: root, Name: volumes.Name(root), Removable: true, Mounted: volumes.IsMounted(root)}
+			byRoot[root] = v
+		}
+		v.Tracks++
+		if t.Offline {
+			v.Offline++
+		}
+	}
+	out := make([]volumes.Volume, 0, len(byRoot))
+	for _, v := range byRoot {
+		out = append(out, *v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Root < out[j].Root })
+	return out
+}
+
+func (m *Manager) RefreshVolumes() bool {
+	mounted := make(map[string]bool)
+	var goneOffline, backOnline []string
+	for _, t := range m.Tracks() {
+		root, removable := volumes.RootOf(t.FilePath)
+		if !removable {
+			continue
+		}
+		up, ok := mounted[root]
+		if !ok {
+			up = volumes.IsMounted(root)
+			mounted[root] = up
+		}
+		switch {
+		case !up && !t.Offline:
+			goneOffline = append(goneOffline, t.FilePath)
+		case up && t.Offline:
+			backOnline = append(backOnline, t.FilePath)
+		}
+	}
+
+	for _, p := range goneOffline {
+		m.markOffline(p)
+	}
+	restored := 0
+	for _, p := range backOnline {
+		md, err := metadata.LoadMetadata(p)
+		if err != nil {
+			continue
+		}
+		stamp, _ := statStamp(p)
+		m.mu.Lock()
+		m.putLocked(p, *md)
+		m.stamps[p] = stamp
+		m.mu.Unlock()
+		restored++
+	}
+	if len(goneOffline) > 0 || restored > 0 {
+		log.Printf("[library] volumes changed: offline=%d restored=%d", len(goneOffline), restored)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package media
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	previewLengthMs  = 15000
+	previewBitrate   = "64k"
+	maxPreviewClips  = 200
+	previewDirectory = "previews"
+)
+
+var (
+	previewMu    sync.Mutex
+	previewLocks = map[string]*previewLock{}
+)
+
+type previewLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+type PreviewClip struct {
+	Path    string `json:"path"`
+	DataURL string `json:"dataUrl"`
+	Cached  bool   `json:"cached"`
+}
+
+func (s *Service) PreviewClip(ctx context.Context, path string) (*PreviewClip, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, errors.New("track path is empty")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s|%d|%d", path, info.Size(), info.ModTime().UnixNano())))
+	dir := filepath.Join(filepath.Dir(s.backupDir), previewDirectory)
+	out := filepath.Join(dir, hex.EncodeToString(sum[:])+".mp3")
+
+	unlock := lockPreview(out)
+	defer unlock()
+
+	clip := &PreviewClip{Path: path}
+	if _, err := os.Stat(out); err == nil {
+		clip.Cached = true
+		now := time.Now()
+		_ = os.Chtimes(out, now, now)
+	} else {
+		ffmpegPath, ffprobePath, err := s.resolveBinaries()
+		if err != nil {
+			return nil, err
+		}
+		probe, err := runFFprobe(ctx, ffprobePath, path)
+		if err != nil {
+			return nil, err
+		}
+		var startMs int64
+		if durationMs := parseDurationMs(probe.Format.Duration); durationMs > previewLengthMs {
+			startMs = (durationMs - previewLengthMs) / 2
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+		tmp := out + ".part"
+		args := []string{
+			"-v", "error", "-y",
+			"-ss", formatSeconds(startMs),
+			"-t", formatSeconds(previewLengthMs),
+			"-i", path,
+			"-vn", "-ac", "1", "-ar", "44100",
+			"-c:a", "libmp3lame", "-b:a", previewBitrate,
+			"-f", "mp3", tmp,
+		}
+		if _, err := runCommand(ctx, ffmpegPath, args...); err != nil {
+			_ = os.Remove(tmp)
+			return nil, err
+		}
+		if err := os.Rename(tmp, out); err != nil {
+			_ = os.Remove(tmp)
+			return nil, err
+		}
+		previewMu.Lock()
+		prunePreviews(dir, out)
+		previewMu.Unlock()
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		return nil, err
+	}
+	clip.DataURL = "data:audio/mpeg;base64," + base64.StdEncoding.EncodeToString(data)
+	return clip, nil
+}
+
+func lockPreview(out string) func() {
+	previewMu.Lock()
+	l := previewLocks[out]
+	if l == nil {
+		l = &previewLock{}
+		previewLocks[out] = l
+	}
+	l.refs++
+	previewMu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		previewMu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(previewLocks, out)
+		}
+		previewMu.Unlock()
+	}
+}
+
+func formatSeconds(ms int64) string {
+	return strconv.FormatFloat(float64(ms)/1000, 'f', 3, 64)
+}
+
+func prunePreviews(dir, keep string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	type cached struct {
+		path string
+		mod  time.Time
+	}
+	files := make([]cached, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".mp3") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, cached{path: filepath.Join(dir, e.Name()), mod: info.ModTime()})
+	}
+	if len(files) <= maxPreviewClips {
+		return
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].mod.After(files[j].mod) })
+	for _, f := range files[maxPreviewClips:] {
+		if f.path != keep {
+			_ = os.Remove(f.path)
+		}
+	}
+}
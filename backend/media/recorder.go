@@ -0,0 +1,250 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	recordingFormatWAV = "wav"
+	recordingFormatMP3 = "mp3"
+
+	recordingStopTimeout = 5 * time.Second
+)
+
+type InputDevice struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Default bool   `json:"default"`
+}
+
+type Recording struct {
+	Path      string `json:"path"`
+	Device    string `json:"device"`
+	Format    string `json:"format"`
+	StartedAt int64  `json:"startedAt"`
+	StoppedAt int64  `json:"stoppedAt,omitempty"`
+}
+
+type recorder struct {
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	done   chan error
+	stderr *bytes.Buffer
+	active *Recording
+}
+
+var (
+	avfoundationDevice = regexp.MustCompile(`\]\s*\[(\d+)\]\s*(.+)$`)
+	dshowAudioDevice   = regexp.MustCompile(`"([^"]+)"\s*\(audio\)`)
+)
+
+func (s *Service) ListInputDevices(ctx context.Context) ([]InputDevice, error) {
+	ffmpegPath, _, err := s.resolveBinaries()
+	if err != nil {
+		return nil, err
+	}
+
+	switch runtime.GOOS {
+	case "darwin":
+		out := listDevicesOutput(ctx, ffmpegPath, "-f", "avfoundation", "-list_devices", "true", "-i", "")
+		devices := []InputDevice{{ID: "default", Name: "System default input", Default: true}}
+		inAudio := false
+		for _, line := range strings.Split(out, "\n") {
+			if strings.Contains(line, "audio devices") {
+				inAudio = true
+				continue
+			}
+			if strings.Contains(line, "video devices") {
+				inAudio = false
+				continue
+			}
+			if !inAudio {
+				continue
+			}
+			if m := avfoundationDevice.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+				devices = append(devices, InputDevice{ID: m[1], Name: strings.TrimSpace(m[2])})
+			}
+		}
+		return devices, nil
+	case "windows":
+		out := listDevicesOutput(ctx, ffmpegPath, "-list_devices", "true", "-f", "dshow", "-i", "dummy")
+		var devices []InputDevice
+		for _, line := range strings.Split(out, "\n") {
+			if m := dshowAudioDevice.FindStringSubmatch(line); m != nil {
+				devices = append(devices, InputDevice{ID: m[1], Name: m[1], Default: len(devices) == 0})
+			}
+		}
+		if len(devices) == 0 {
+			return nil, errors.New("no audio input devices found")
+		}
+		return devices, nil
+	default:
+		return []InputDevice{{ID: "default", Name: "System default input", Default: true}}, nil
+	}
+}
+
+func (s *Service) StartRecording(ctx context.Context, device, format, outputDir string) (*Recording, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		format = recordingFormatWAV
+	}
+	if format != recordingFormatWAV && format != recordingFormatMP3 {
+		return nil, fmt.Errorf("unsupported recording format: %s", format)
+	}
+	outputDir = strings.TrimSpace(outputDir)
+	if outputDir == "" {
+		return nil, errors.New("output directory is required")
+	}
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	ffmpegPath, _, err := s.resolveBinaries()
+	if err != nil {
+		return nil, err
+	}
+
+	device = strings.TrimSpace(device)
+	if device == "" && runtime.GOOS == "windows" {
+		devices, err := s.ListInputDevices(ctx)
+		if err != nil {
+			return nil, err
+		}
+		device = devices[0].ID
+	}
+	if device == "" {
+		device = "default"
+	}
+
+	s.rec.mu.Lock()
+	defer s.rec.mu.Unlock()
+	if s.rec.active != nil {
+		return nil, errors.New("a recording is already in progress")
+	}
+
+	started := time.Now()
+	name := "Recording " + started.Format("2006-01-02 15-04-05")
+	outPath, err := uniquePathInDir(outputDir, name+"."+format, "", "."+format)
+	if err != nil {
+		return nil, err
+	}
+
+	args := append([]string{"-y", "-hide_banner", "-nostats"}, captureInputArgs(device)...)
+	if format == recordingFormatMP3 {
+		args = append(args, "-c:a", "libmp3lame", "-q:a", "2")
+	} else {
+		args = append(args, "-c:a", "pcm_s16le")
+	}
+	args = append(args, outPath)
+
+	cmd := exec.Command(ffmpegPath, args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = &tailWriter{buf: stderr, limit: 16 * 1024}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	rec := &Recording{
+		Path:      outPath,
+		Device:    device,
+		Format:    format,
+		StartedAt: started.Unix(),
+	}
+	s.rec.cmd = cmd
+	s.rec.stdin = stdin
+	s.rec.done = done
+	s.rec.stderr = stderr
+	s.rec.active = rec
+
+	snapshot := *rec
+	return &snapshot, nil
+}
+
+func (s *Service) ActiveRecording() *Recording {
+	s.rec.mu.Lock()
+	defer s.rec.mu.Unlock()
+	if s.rec.active == nil {
+		return nil
+	}
+	snapshot := *s.rec.active
+	return &snapshot
+}
+
+func (s *Service) StopRecording() (*Recording, error) {
+	s.rec.mu.Lock()
+	defer s.rec.mu.Unlock()
+	if s.rec.active == nil {
+		return nil, errors.New("no recording in progress")
+	}
+
+	_, _ = io.WriteString(s.rec.stdin, "q")
+	_ = s.rec.stdin.Close()
+
+	var waitErr error
+	select {
+	case waitErr = <-s.rec.done:
+	case <-time.After(recordingStopTimeout):
+		_ = s.rec.cmd.Process.Kill()
+		waitErr = <-s.rec.done
+	}
+
+	rec := *s.rec.active
+	rec.StoppedAt = time.Now().Unix()
+	stderr := strings.TrimSpace(s.rec.stderr.String())
+	s.rec.cmd = nil
+	s.rec.stdin = nil
+	s.rec.done = nil
+	s.rec.stderr = nil
+	s.rec.active = nil
+
+	if !isFile(rec.Path) {
+		if waitErr != nil && stderr != "" {
+			return nil, fmt.Errorf("recording failed: %w: %s", waitErr, stderr)
+		}
+		return nil, errors.New("recording produced no output")
+	}
+	if info, err := os.Stat(rec.Path); err == nil && info.Size() == 0 {
+		_ = os.Remove(rec.Path)
+		return nil, errors.New("recording produced no audio")
+	}
+	return &rec, nil
+}
+
+func captureInputArgs(device string) []string {
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"-f", "avfoundation", "-i", ":" + device}
+	case "windows":
+		return []string{"-f", "dshow", "-i", "audio=" + device}
+	default:
+		return []string{"-f", "pulse", "-i", device}
+	}
+}
+
+func listDevicesOutput(ctx context.Context, ffmpegPath string, args ...string) string {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	out, _ := exec.CommandContext(ctx, ffmpegPath, append([]string{"-hide_banner"}, args...)...).CombinedOutput()
+	return string(out)
+}
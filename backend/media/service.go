@@ -41,6 +41,9 @@ type Service struct {
 
 	ffmpegPath  string
 	ffprobePath string
+
+	stems stemJobs
+	rec   recorder
 }
 
 type WaveformResult struct {
@@ -61,7 +64,9 @@ type TrimBackup struct {
 
 type ffprobeOutput struct {
 	Streams []struct {
-		CodecName string `json:"codec_name"`
+		CodecName  string `json:"codec_name"`
+		SampleRate string `json:"sample_rate"`
+		Channels   int    `json:"channels"`
 	} `json:"streams"`
 	Format struct {
 		Duration string `json:"duration"`
@@ -552,6 +557,39 @@ func (s *Service) resolveBinaries() (ffmpegPath string, ffprobePath string, err
 	return ffmpegPath, ffprobePath, nil
 }
 
+func ResolveFFmpeg() (string, error) {
+	return resolveBinary("KITTY_FFMPEG_PATH", "ffmpeg")
+}
+
+type AudioInfo struct {
+	SampleRate int     `json:"sampleRate"`
+	Channels   int     `json:"channels"`
+	Duration   float64 `json:"duration"`
+}
+
+func ProbeAudio(ctx context.Context, path string) (*AudioInfo, error) {
+	ffprobePath, err := resolveBinary("KITTY_FFPROBE_PATH", "ffprobe")
+	if err != nil {
+		return nil, err
+	}
+	probe, err := runFFprobe(ctx, ffprobePath, path)
+	if err != nil {
+		return nil, err
+	}
+	if len(probe.Streams) == 0 {
+		return nil, fmt.Errorf("no audio stream in %s", filepath.Base(path))
+	}
+	rate, _ := strconv.Atoi(probe.Streams[0].SampleRate)
+	if rate <= 0 {
+		return nil, fmt.Errorf("unknown sample rate for %s", filepath.Base(path))
+	}
+	return &AudioInfo{
+		SampleRate: rate,
+		Channels:   probe.Streams[0].Channels,
+		Duration:   float64(parseDurationMs(probe.Format.Duration)) / 1000,
+	}, nil
+}
+
 func resolveBinary(envKey, fallback string) (string, error) {
 	if override := strings.TrimSpace(os.Getenv(envKey)); override != "" {
 		if isFile(override) {
@@ -699,7 +737,7 @@ func runFFprobe(ctx context.Context, ffprobePath, path string) (*ffprobeOutput,
 	args := []string{
 		"-v", "error",
 		"-select_streams", "a:0",
-		"-show_entries", "stream=codec_name:format=duration",
+		"-show_entries", "stream=codec_name,sample_rate,channels:format=duration",
 		"-of", "json",
 		path,
 	}
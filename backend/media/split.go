@@ -0,0 +1,318 @@
+package media
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultSilenceThresholdDb = -40.0
+	defaultMinSilenceMs       = 2000
+	minSegmentMs              = 1000
+	defaultSideGapMs          = 10000
+)
+
+type Segment struct {
+	StartMs int64  `json:"startMs"`
+	EndMs   int64  `json:"endMs"`
+	Title   string `json:"title"`
+	Artist  string `json:"artist"`
+	Side    string `json:"side,omitempty"`
+}
+
+type SilenceGap struct {
+	StartMs int64 `json:"startMs"`
+	EndMs   int64 `json:"endMs"`
+}
+
+type SplitPreview struct {
+	DurationMs   int64        `json:"durationMs"`
+	ThresholdDb  float64      `json:"thresholdDb"`
+	MinSilenceMs int64        `json:"minSilenceMs"`
+	SideGapMs    int64        `json:"sideGapMs"`
+	Gaps         []SilenceGap `json:"gaps"`
+	Segments     []Segment    `json:"segments"`
+}
+
+type SplitOutput struct {
+	Path    string  `json:"path"`
+	Segment Segment `json:"segment"`
+	Index   int     `json:"index"`
+}
+
+var (
+	tracklistLine = regexp.MustCompile(`^\s*(?:\d+[.)]\s*)?[\[(]?((?:\d{1,2}:)?\d{1,2}:\d{2})[\])]?\s*[-–—:|]?\s*(.*)$`)
+	silenceStart  = regexp.MustCompile(`silence_start:\s*(-?[0-9.]+)`)
+	silenceEnd    = regexp.MustCompile(`silence_end:\s*(-?[0-9.]+)`)
+)
+
+func ParseTracklist(text string) ([]Segment, error) {
+	var segments []Segment
+	sc := bufio.NewScanner(strings.NewReader(text))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		m := tracklistLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		startMs, ok := parseTimestamp(m[1])
+		if !ok {
+			continue
+		}
+		artist, title := splitArtistTitle(strings.TrimSpace(m[2]))
+		segments = append(segments, Segment{StartMs: startMs, Title: title, Artist: artist})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		return nil, errors.New("no timestamps found in tracklist")
+	}
+
+	sort.SliceStable(segments, func(i, j int) bool {
+		return segments[i].StartMs < segments[j].StartMs
+	})
+	for i := range segments {
+		if i+1 < len(segments) {
+			segments[i].EndMs = segments[i+1].StartMs
+		}
+	}
+	return segments, nil
+}
+
+func (s *Service) DetectSilence(ctx context.Context, path string, thresholdDb float64, minSilenceMs int64) ([]SilenceGap, int64, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, 0, errors.New("track path is empty")
+	}
+	thresholdDb, minSilenceMs = normalizeSilenceParams(thresholdDb, minSilenceMs)
+
+	ffmpegPath, ffprobePath, err := s.resolveBinaries()
+	if err != nil {
+		return nil, 0, err
+	}
+	probe, err := runFFprobe(ctx, ffprobePath, path)
+	if err != nil {
+		return nil, 0, err
+	}
+	durationMs := parseDurationMs(probe.Format.Duration)
+
+	args := []string{
+		"-v", "info", "-nostats",
+		"-i", path,
+		"-map", "0:a:0",
+		"-af", fmt.Sprintf("silencedetect=noise=%.1fdB:d=%.3f", thresholdDb, float64(minSilenceMs)/1000.0),
+		"-f", "null", "-",
+	}
+	out, err := runCommand(ctx, ffmpegPath, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var gaps []SilenceGap
+	var open *SilenceGap
+	sc := bufio.NewScanner(strings.NewReader(string(out)))
+	for sc.Scan() {
+		line := sc.Text()
+		if m := silenceStart.FindStringSubmatch(line); m != nil {
+			if ms, ok := secondsToMs(m[1]); ok {
+				open = &SilenceGap{StartMs: ms}
+			}
+			continue
+		}
+		if m := silenceEnd.FindStringSubmatch(line); m != nil && open != nil {
+			if ms, ok := secondsToMs(m[1]); ok {
+				open.EndMs = ms
+				gaps = append(gaps, *open)
+			}
+			open = nil
+		}
+	}
+	if open != nil && durationMs > open.StartMs {
+		open.EndMs = durationMs
+		gaps = append(gaps, *open)
+	}
+	return gaps, durationMs, nil
+}
+
+func SegmentsFromSilence(gaps []SilenceGap, durationMs int64) []Segment {
+	var segments []Segment
+	cursor := int64(0)
+	for _, g := range gaps {
+		if g.StartMs-cursor >= minSegmentMs {
+			segments = append(segments, Segment{StartMs: cursor, EndMs: g.StartMs})
+		}
+		cursor = g.EndMs
+	}
+	if durationMs <= 0 || durationMs-cursor >= minSegmentMs {
+		segments = append(segments, Segment{StartMs: cursor, EndMs: durationMs})
+	}
+	for i := range segments {
+		segments[i].Title = fmt.Sprintf("Track %02d", i+1)
+	}
+	return segments
+}
+
+func (s *Service) PreviewSilenceSplit(ctx context.Context, path string, thresholdDb float64, minSilenceMs, sideGapMs int64) (*SplitPreview, error) {
+	thresholdDb, minSilenceMs = normalizeSilenceParams(thresholdDb, minSilenceMs)
+	if sideGapMs <= 0 {
+		sideGapMs = defaultSideGapMs
+	}
+	gaps, durationMs, err := s.DetectSilence(ctx, path, thresholdDb, minSilenceMs)
+	if err != nil {
+		return nil, err
+	}
+	if gaps == nil {
+		gaps = []SilenceGap{}
+	}
+	return &SplitPreview{
+		DurationMs:   durationMs,
+		ThresholdDb:  thresholdDb,
+		MinSilenceMs: minSilenceMs,
+		SideGapMs:    sideGapMs,
+		Gaps:         gaps,
+		Segments:     LabelSides(SegmentsFromSilence(gaps, durationMs), sideGapMs),
+	}, nil
+}
+
+func LabelSides(segments []Segment, sideGapMs int64) []Segment {
+	if sideGapMs <= 0 {
+		sideGapMs = defaultSideGapMs
+	}
+	side, pos := 0, 0
+	for i := range segments {
+		if i > 0 && segments[i].StartMs-segments[i-1].EndMs >= sideGapMs {
+			side++
+			pos = 0
+		}
+		pos++
+		label := sideLabel(side)
+		segments[i].Side = label
+		segments[i].Title = fmt.Sprintf("%s%d", label, pos)
+	}
+	return segments
+}
+
+func sideLabel(n int) string {
+	label := ""
+	for {
+		label = string(rune('A'+n%26)) + label
+		n = n/26 - 1
+		if n < 0 {
+			return label
+		}
+	}
+}
+
+func normalizeSilenceParams(thresholdDb float64, minSilenceMs int64) (float64, int64) {
+	if thresholdDb >= 0 {
+		thresholdDb = defaultSilenceThresholdDb
+	}
+	if minSilenceMs <= 0 {
+		minSilenceMs = defaultMinSilenceMs
+	}
+	return thresholdDb, minSilenceMs
+}
+
+func (s *Service) SplitTrack(ctx context.Context, path, outputDir string, segments []Segment) ([]SplitOutput, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, errors.New("track path is empty")
+	}
+	if len(segments) == 0 {
+		return nil, errors.New("no segments to split")
+	}
+	outputDir = strings.TrimSpace(outputDir)
+	if outputDir == "" {
+		outputDir = filepath.Join(filepath.Dir(path), strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+	}
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	ffmpegPath, _, err := s.resolveBinaries()
+	if err != nil {
+		return nil, err
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	outputs := make([]SplitOutput, 0, len(segments))
+	for _, seg := range segments {
+		if seg.EndMs > 0 && seg.EndMs-seg.StartMs < minSegmentMs {
+			continue
+		}
+		n := len(outputs) + 1
+		name := fmt.Sprintf("%02d", n)
+		if t := strings.TrimSpace(seg.Title); t != "" {
+			name += " " + safeSegmentName(t)
+		}
+		outPath, err := uniquePathInDir(outputDir, name+ext, "", ext)
+		if err != nil {
+			return outputs, err
+		}
+
+		args := []string{"-y", "-v", "error", "-ss", fmt.Sprintf("%.3f", float64(seg.StartMs)/1000.0)}
+		if seg.EndMs > seg.StartMs {
+			args = append(args, "-to", fmt.Sprintf("%.3f", float64(seg.EndMs)/1000.0))
+		}
+		args = append(args, "-i", path, "-map", "0:a:0", "-map_metadata", "-1", "-c", "copy", "-avoid_negative_ts", "make_zero", outPath)
+		if _, err := runCommand(ctx, ffmpegPath, args...); err != nil {
+			return outputs, fmt.Errorf("split segment %d failed: %w", n, err)
+		}
+		outputs = append(outputs, SplitOutput{Path: outPath, Segment: seg, Index: n})
+	}
+	return outputs, nil
+}
+
+func parseTimestamp(v string) (int64, bool) {
+	parts := strings.Split(v, ":")
+	var total int64
+	for _, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return 0, false
+		}
+		total = total*60 + int64(n)
+	}
+	return total * 1000, true
+}
+
+func secondsToMs(v string) (int64, bool) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil {
+		return 0, false
+	}
+	if f < 0 {
+		f = 0
+	}
+	return int64(f * 1000), true
+}
+
+func splitArtistTitle(v string) (string, string) {
+	for _, sep := range []string{" - ", " – ", " — "} {
+		if parts := strings.SplitN(v, sep, 2); len(parts) == 2 {
+			return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		}
+	}
+	return "", v
+}
+
+func safeSegmentName(v string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`<>:"/\|?*`, r) || r < 32 {
+			return '_'
+		}
+		return r
+	}, v)
+}
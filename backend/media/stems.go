@@ -0,0 +1,276 @@
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	StemStatusRunning   = "running"
+	StemStatusCompleted = "completed"
+	StemStatusFailed    = "failed"
+	StemStatusCancelled = "cancelled"
+
+	defaultStemModel = "htdemucs"
+)
+
+type StemJob struct {
+	ID           string   `json:"id"`
+	Path         string   `json:"path"`
+	Model        string   `json:"model"`
+	Status       string   `json:"status"`
+	Progress     float64  `json:"progress"`
+	Instrumental string   `json:"instrumental,omitempty"`
+	Vocals       string   `json:"vocals,omitempty"`
+	Error        string   `json:"error,omitempty"`
+	StartedAt    int64    `json:"startedAt"`
+	FinishedAt   int64    `json:"finishedAt,omitempty"`
+	Outputs      []string `json:"outputs,omitempty"`
+
+	cancel context.CancelFunc
+}
+
+type stemJobs struct {
+	mu   sync.Mutex
+	jobs map[string]*StemJob
+}
+
+var progressPattern = regexp.MustCompile(`(\d{1,3})%\|`)
+
+func (s *Service) StemToolAvailable() (string, error) {
+	return resolveBinary("KITTY_DEMUCS_PATH", "demucs")
+}
+
+func (s *Service) StartStemSeparation(ctx context.Context, path, outputDir, model string, onUpdate func(StemJob)) (*StemJob, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, errors.New("track path is empty")
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	demucs, err := s.StemToolAvailable()
+	if err != nil {
+		return nil, fmt.Errorf("stem separation requires demucs (pip install demucs): %w", err)
+	}
+	model = strings.TrimSpace(model)
+	if model == "" {
+		model = defaultStemModel
+	}
+	outputDir = strings.TrimSpace(outputDir)
+	if outputDir == "" {
+		outputDir = filepath.Dir(path)
+	}
+
+	id, err := randomID(8)
+	if err != nil {
+		return nil, err
+	}
+	jobCtx, cancel := context.WithCancel(ctx)
+	job := &StemJob{
+		ID:        id,
+		Path:      path,
+		Model:     model,
+		Status:    StemStatusRunning,
+		StartedAt: time.Now().Unix(),
+		cancel:    cancel,
+	}
+
+	s.stems.mu.Lock()
+	if s.stems.jobs == nil {
+		s.stems.jobs = make(map[string]*StemJob)
+	}
+	s.stems.jobs[id] = job
+	s.stems.mu.Unlock()
+
+	go s.runStemJob(jobCtx, demucs, job, outputDir, onUpdate)
+
+	snapshot := *job
+	return &snapshot, nil
+}
+
+func (s *Service) CancelStemJob(id string) error {
+	s.stems.mu.Lock()
+	defer s.stems.mu.Unlock()
+	job, ok := s.stems.jobs[id]
+	if !ok {
+		return errors.New("stem job not found")
+	}
+	if job.cancel != nil {
+		job.cancel()
+	}
+	return nil
+}
+
+func (s *Service) ListStemJobs() []StemJob {
+	s.stems.mu.Lock()
+	defer s.stems.mu.Unlock()
+	out := make([]StemJob, 0, len(s.stems.jobs))
+	for _, j := range s.stems.jobs {
+		out = append(out, *j)
+	}
+	return out
+}
+
+func (s *Service) runStemJob(ctx context.Context, demucs string, job *StemJob, outputDir string, onUpdate func(StemJob)) {
+	defer s.pruneStemJob(job.ID)
+	update := func(fn func(j *StemJob)) {
+		s.stems.mu.Lock()
+		fn(job)
+		snapshot := *job
+		s.stems.mu.Unlock()
+		if onUpdate != nil {
+			onUpdate(snapshot)
+		}
+	}
+
+	workDir, err := os.MkdirTemp("", "kitty_stems_")
+	if err != nil {
+		update(func(j *StemJob) { failStemJob(j, err) })
+		return
+	}
+	defer os.RemoveAll(workDir)
+
+	cmd := exec.CommandContext(ctx, demucs, "--two-stems=vocals", "-n", job.Model, "-o", workDir, job.Path)
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		update(func(j *StemJob) { failStemJob(j, err) })
+		return
+	}
+	var tail bytes.Buffer
+	if err := cmd.Start(); err != nil {
+		update(func(j *StemJob) { failStemJob(j, err) })
+		return
+	}
+
+	sc := bufio.NewScanner(io.TeeReader(stderr, &tailWriter{buf: &tail, limit: 16 * 1024}))
+	sc.Split(scanCRLF)
+	last := -1
+	for sc.Scan() {
+		m := progressPattern.FindStringSubmatch(sc.Text())
+		if m == nil {
+			continue
+		}
+		pct, _ := strconv.Atoi(m[1])
+		if pct == last {
+			continue
+		}
+		last = pct
+		update(func(j *StemJob) { j.Progress = float64(pct) / 100.0 })
+	}
+
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			update(func(j *StemJob) {
+				j.Status = StemStatusCancelled
+				j.FinishedAt = time.Now().Unix()
+			})
+			return
+		}
+		msg := strings.TrimSpace(tail.String())
+		if len(msg) > 2048 {
+			msg = msg[len(msg)-2048:]
+		}
+		update(func(j *StemJob) { failStemJob(j, fmt.Errorf("demucs failed: %w: %s", err, msg)) })
+		return
+	}
+
+	base := strings.TrimSuffix(filepath.Base(job.Path), filepath.Ext(job.Path))
+	produced := filepath.Join(workDir, job.Model, base)
+	instrumental, err := moveStem(filepath.Join(produced, "no_vocals.wav"), outputDir, base, " (Instrumental)")
+	if err != nil {
+		update(func(j *StemJob) { failStemJob(j, err) })
+		return
+	}
+	vocals, err := moveStem(filepath.Join(produced, "vocals.wav"), outputDir, base, " (Vocals)")
+	if err != nil {
+		update(func(j *StemJob) { failStemJob(j, err) })
+		return
+	}
+
+	update(func(j *StemJob) {
+		j.Status = StemStatusCompleted
+		j.Progress = 1
+		j.Instrumental = instrumental
+		j.Vocals = vocals
+		j.Outputs = []string{instrumental, vocals}
+		j.FinishedAt = time.Now().Unix()
+	})
+}
+
+func (s *Service) pruneStemJob(id string) {
+	s.stems.mu.Lock()
+	defer s.stems.mu.Unlock()
+	if job, ok := s.stems.jobs[id]; ok {
+		job.cancel()
+		delete(s.stems.jobs, id)
+	}
+}
+
+func moveStem(src, outputDir, base, suffix string) (string, error) {
+	if _, err := os.Stat(src); err != nil {
+		return "", fmt.Errorf("expected stem output missing: %w", err)
+	}
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return "", err
+	}
+	dst, err := uniquePathInDir(outputDir, base+".wav", suffix, ".wav")
+	if err != nil {
+		return "", err
+	}
+	if err := os.Rename(src, dst); err != nil {
+		if copyErr := copyFile(src, dst); copyErr != nil {
+			return "", copyErr
+		}
+	}
+	return dst, nil
+}
+
+func failStemJob(j *StemJob, err error) {
+	j.Status = StemStatusFailed
+	j.Error = err.Error()
+	j.FinishedAt = time.Now().Unix()
+}
+
+func scanCRLF(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+type tailWriter struct {
+	buf   *bytes.Buffer
+	limit int
+}
+
+func (w *tailWriter) Write(p []byte) (int, error) {
+	if len(p) >= w.limit {
+		w.buf.Reset()
+		w.buf.Write(p[len(p)-w.limit:])
+		return len(p), nil
+	}
+	if over := w.buf.Len() + len(p) - w.limit; over > 0 {
+		w.buf.Next(over)
+	}
+	w.buf.Write(p)
+	return len(p), nil
+}
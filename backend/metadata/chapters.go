@@ -0,0 +1,210 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+const maxTagSize = 64 << 20
+
+type Chapter struct {
+	Index int     `json:"index"`
+	ID    string  `json:"id"`
+	Title string  `json:"title"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Image string  `json:"image,omitempty"`
+}
+
+func ReadChapters(path string) ([]Chapter, error) {
+	if strings.ToLower(filepath.Ext(path)) != ".mp3" {
+		return []Chapter{}, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	header := make([]byte, 10)
+	if _, err := io.ReadFull(f, header); err != nil || string(header[:3]) != "ID3" {
+		return []Chapter{}, nil
+	}
+	version, flags := header[3], header[5]
+	if version < 3 || version > 4 {
+		return []Chapter{}, nil
+	}
+	size := synchsafe(header[6:10])
+	if size > maxTagSize {
+		return nil, fmt.Errorf("id3 tag too large: %d bytes", size)
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(f, body); err != nil {
+		return nil, fmt.Errorf("read id3 tag: %w", err)
+	}
+	if flags&0x80 != 0 && version == 3 {
+		body = bytes.ReplaceAll(body, []byte{0xFF, 0x00}, []byte{0xFF})
+	}
+	if flags&0x40 != 0 && len(body) >= 4 {
+		ext := int(binary.BigEndian.Uint32(body[:4]))
+		if version == 4 {
+			ext = synchsafe(body[:4])
+		} else {
+			ext += 4
+		}
+		if ext > len(body) {
+			return []Chapter{}, nil
+		}
+		body = body[ext:]
+	}
+
+	chapters := []Chapter{}
+	eachFrame(body, version, func(id string, data []byte) {
+		if id != "CHAP" {
+			return
+		}
+		if ch, ok := parseChapter(data, version); ok {
+			chapters = append(chapters, ch)
+		}
+	})
+	sort.SliceStable(chapters, func(i, j int) bool { return chapters[i].Start < chapters[j].Start })
+	for i := range chapters {
+		chapters[i].Index = i
+		if chapters[i].Title == "" {
+			chapters[i].Title = fmt.Sprintf("Chapter %d", i+1)
+		}
+	}
+	return chapters, nil
+}
+
+func ChapterAt(chapters []Chapter, position float64) int {
+	idx := -1
+	for i, ch := range chapters {
+		if position < ch.Start {
+			break
+		}
+		if ch.End <= ch.Start || position < ch.End || i == len(chapters)-1 {
+			idx = i
+		}
+	}
+	return idx
+}
+
+func parseChapter(data []byte, version byte) (Chapter, bool) {
+	end := bytes.IndexByte(data, 0)
+	if end < 0 || len(data) < end+17 {
+		return Chapter{}, false
+	}
+	ch := Chapter{ID: string(data[:end])}
+	times := data[end+1:]
+	ch.Start = float64(binary.BigEndian.Uint32(times[0:4])) / 1000
+	ch.End = float64(binary.BigEndian.Uint32(times[4:8])) / 1000
+	eachFrame(times[16:], version, func(id string, sub []byte) {
+		switch id {
+		case "TIT2":
+			if len(sub) > 1 && ch.Title == "" {
+				ch.Title = strings.TrimSpace(strings.TrimRight(decodeID3Text(sub[0], sub[1:]), "\x00"))
+			}
+		case "APIC":
+			if ch.Image == "" {
+				ch.Image = pictureDataURL(sub)
+			}
+		}
+	})
+	return ch, true
+}
+
+func eachFrame(b []byte, version byte, fn func(id string, data []byte)) {
+	for len(b) >= 10 && b[0] != 0 {
+		id := string(b[:4])
+		size := int(binary.BigEndian.Uint32(b[4:8]))
+		if version == 4 {
+			size = synchsafe(b[4:8])
+		}
+		if size < 0 || 10+size > len(b) {
+			return
+		}
+		fn(id, b[10:10+size])
+		b = b[10+size:]
+	}
+}
+
+func pictureDataURL(b []byte) string {
+	if len(b) < 4 {
+		return ""
+	}
+	enc := b[0]
+	end := bytes.IndexByte(b[1:], 0)
+	if end < 0 {
+		return ""
+	}
+	mime := strings.ToLower(string(b[1 : 1+end]))
+	rest := b[2+end:]
+	if len(rest) < 1 {
+		return ""
+	}
+	rest = rest[1:]
+	if enc == 1 || enc == 2 {
+		i := 0
+		for i+1 < len(rest) && (rest[i] != 0 || rest[i+1] != 0) {
+			i += 2
+		}
+		if i+2 > len(rest) {
+			return ""
+		}
+		rest = rest[i+2:]
+	} else {
+		i := bytes.IndexByte(rest, 0)
+		if i < 0 {
+			return ""
+		}
+		rest = rest[i+1:]
+	}
+	if len(rest) == 0 {
+		return ""
+	}
+	if !strings.Contains(mime, "/") {
+		mime = "image/" + strings.TrimPrefix(strings.ReplaceAll(mime, "jpg", "jpeg"), "image/")
+	}
+	return fmt.Sprintf("data:%s;base64,%s", mime, base64.StdEncoding.EncodeToString(rest))
+}
+
+func decodeID3Text(enc byte, b []byte) string {
+	switch enc {
+	case 0:
+		runes := make([]rune, len(b))
+		for i, c := range b {
+			runes[i] = rune(c)
+		}
+		return string(runes)
+	case 1, 2:
+		big := enc == 2
+		if len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF {
+			big, b = true, b[2:]
+		} else if len(b) >= 2 && b[0] == 0xFF && b[1] == 0xFE {
+			big, b = false, b[2:]
+		}
+		units := make([]uint16, 0, len(b)/2)
+		for i := 0; i+1 < len(b); i += 2 {
+			if big {
+				units = append(units, binary.BigEndian.Uint16(b[i:]))
+			} else {
+				units = append(units, binary.LittleEndian.Uint16(b[i:]))
+			}
+		}
+		return string(utf16.Decode(units))
+	}
+	return string(b)
+}
+
+func synchsafe(b []byte) int {
+	return int(b[0]&0x7F)<<21 | int(b[1]&0x7F)<<14 | int(b[2]&0x7F)<<7 | int(b[3]&0x7F)
+}
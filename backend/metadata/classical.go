@@ -0,0 +1,159 @@
+package metadata
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/bogem/id3v2"
+	"github.com/dhowden/tag"
+)
+
+var (
+	workKeys          = []string{"TIT1", "TT1", "work", "\xa9wrk"}
+	movementKeys      = []string{"MVNM", "movementname", "movement_name", "\xa9mvn"}
+	movementNumKeys   = []string{"MVIN", "movement", "movementnumber", "\xa9mvi"}
+	movementTotalKeys = []string{"movementtotal", "movementcount", "\xa9mvc"}
+	conductorKeys     = []string{"TPE3", "TP3", "conductor"}
+	orchestraKeys     = []string{"orchestra", "ensemble"}
+	soloistKeys       = []string{"soloists", "soloist", "performer"}
+)
+
+type Classical struct {
+	Work           string   `json:"work,omitempty"`
+	Movement       string   `json:"movement,omitempty"`
+	MovementNumber int      `json:"movementNumber,omitempty"`
+	MovementCount  int      `json:"movementCount,omitempty"`
+	Conductor      string   `json:"conductor,omitempty"`
+	Orchestra      string   `json:"orchestra,omitempty"`
+	Soloists       []string `json:"soloists,omitempty"`
+}
+
+func (c Classical) IsZero() bool {
+	return c.Work == "" && c.Movement == "" && c.MovementNumber == 0 && c.Conductor == "" && c.Orchestra == "" && len(c.Soloists) == 0
+}
+
+func readClassical(path string, m tag.Metadata) *Classical {
+	raw := m.Raw()
+	c := Classical{
+		Work:      rawText(raw, workKeys),
+		Movement:  rawText(raw, movementKeys),
+		Conductor: rawText(raw, conductorKeys),
+		Orchestra: rawText(raw, orchestraKeys),
+	}
+	if n, total := splitCount(rawText(raw, movementNumKeys)); n > 0 {
+		c.MovementNumber, c.MovementCount = n, total
+	}
+	if total, _ := splitCount(rawText(raw, movementTotalKeys)); total > 0 {
+		c.MovementCount = total
+	}
+	if m.Format() == tag.ID3v2_4 || m.Format() == tag.ID3v2_3 {
+		parts := readCredits(path)
+		for i := 0; i+1 < len(parts); i += 2 {
+			role, name := strings.ToLower(strings.TrimSpace(parts[i])), strings.TrimSpace(parts[i+1])
+			switch {
+			case name == "":
+			case role == "orchestra" || role == "ensemble":
+				if c.Orchestra == "" {
+					c.Orchestra = name
+				}
+			default:
+				c.Soloists = append(c.Soloists, name)
+			}
+		}
+	}
+	if len(c.Soloists) == 0 {
+		if s := rawText(raw, soloistKeys); s != "" {
+			c.Soloists = splitNames(s)
+		}
+	}
+	if c.IsZero() {
+		return nil
+	}
+	return &c
+}
+
+func writeClassical(t *id3v2.Tag, c *Classical) {
+	for _, id := range []string{"TIT1", "MVNM", "MVIN", "TPE3", "TMCL"} {
+		t.DeleteFrames(id)
+	}
+	if c == nil {
+		return
+	}
+	if c.Work != "" {
+		t.AddTextFrame("TIT1", id3v2.EncodingUTF8, c.Work)
+	}
+	if c.Movement != "" {
+		t.AddTextFrame("MVNM", id3v2.EncodingUTF8, c.Movement)
+	}
+	if c.MovementNumber > 0 {
+		v := strconv.Itoa(c.MovementNumber)
+		if c.MovementCount > 0 {
+			v += "/" + strconv.Itoa(c.MovementCount)
+		}
+		t.AddTextFrame("MVIN", id3v2.EncodingUTF8, v)
+	}
+	if c.Conductor != "" {
+		t.AddTextFrame("TPE3", id3v2.EncodingUTF8, c.Conductor)
+	}
+	var credits []string
+	if c.Orchestra != "" {
+		credits = append(credits, "orchestra", c.Orchestra)
+	}
+	for _, s := range c.Soloists {
+		if s = strings.TrimSpace(s); s != "" {
+			credits = append(credits, "soloist", s)
+		}
+	}
+	if len(credits) > 0 {
+		t.AddTextFrame("TMCL", id3v2.EncodingUTF8, strings.Join(credits, "\x00"))
+	}
+}
+
+func readCredits(path string) []string {
+	t, err := id3v2.Open(path, id3v2.Options{Parse: true, ParseFrames: []string{"TMCL"}})
+	if err != nil {
+		return nil
+	}
+	defer t.Close()
+	if f, ok := t.GetLastFrame("TMCL").(id3v2.TextFrame); ok {
+		return strings.Split(strings.TrimRight(f.Text, "\x00"), "\x00")
+	}
+	return nil
+}
+
+func rawText(raw map[string]interface{}, keys []string) string {
+	for _, k := range keys {
+		if v, ok := lookupRaw(raw, k); ok {
+			if s := strings.TrimSpace(strings.Trim(rawString(v), "\x00")); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+func rawString(v interface{}) string {
+	switch t := v.(type) {
+	case int:
+		return strconv.Itoa(t)
+	default:
+		return lyricsValue(v)
+	}
+}
+
+func splitCount(s string) (int, int) {
+	num, total, _ := strings.Cut(strings.TrimSpace(s), "/")
+	n, _ := strconv.Atoi(strings.TrimSpace(num))
+	t, _ := strconv.Atoi(strings.TrimSpace(total))
+	return n, t
+}
+
+func splitNames(s string) []string {
+	var out []string
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == '\x00' || r == '/' }) {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
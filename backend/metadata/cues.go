@@ -0,0 +1,191 @@
+package metadata
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const MaxCuePoints = 8
+
+var cueColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+var defaultCueColors = []string{"#cc0000", "#cc8800", "#0000cc", "#cccc00", "#00cc00", "#cc00cc", "#00cccc", "#8800cc"}
+
+type CuePoint struct {
+	Index    int     `json:"index"`
+	Label    string  `json:"label"`
+	Position float64 `json:"position"`
+	Color    string  `json:"color"`
+}
+
+func NormalizeCuePoint(cue CuePoint) (CuePoint, error) {
+	if cue.Index < 0 || cue.Index >= MaxCuePoints {
+		return CuePoint{}, fmt.Errorf("cue index must be between 0 and %d", MaxCuePoints-1)
+	}
+	if cue.Position < 0 {
+		return CuePoint{}, fmt.Errorf("invalid cue position: %.3f", cue.Position)
+	}
+	cue.Label = strings.TrimSpace(cue.Label)
+	cue.Color = strings.TrimSpace(cue.Color)
+	if cue.Color == "" {
+		cue.Color = defaultCueColors[cue.Index]
+	}
+	if !cueColorPattern.MatchString(cue.Color) {
+		return CuePoint{}, fmt.Errorf("invalid cue color: %s", cue.Color)
+	}
+	cue.Color = strings.ToLower(cue.Color)
+	return cue, nil
+}
+
+func CuePoints(path string) []CuePoint {
+	side, err := readSidecar(path)
+	if err != nil || side.CuePoints == nil {
+		return []CuePoint{}
+	}
+	return side.CuePoints
+}
+
+func SetCuePoint(path string, cue CuePoint) ([]CuePoint, error) {
+	cue, err := NormalizeCuePoint(cue)
+	if err != nil {
+		return nil, err
+	}
+	return updateCuePoints(path, func(cues []CuePoint) []CuePoint {
+		out := make([]CuePoint, 0, len(cues)+1)
+		for _, c := range cues {
+			if c.Index != cue.Index {
+				out = append(out, c)
+			}
+		}
+		return append(out, cue)
+	})
+}
+
+func DeleteCuePoint(path string, index int) ([]CuePoint, error) {
+	return updateCuePoints(path, func(cues []CuePoint) []CuePoint {
+		out := make([]CuePoint, 0, len(cues))
+		for _, c := range cues {
+			if c.Index != index {
+				out = append(out, c)
+			}
+		}
+		return out
+	})
+}
+
+func updateCuePoints(path string, fn func([]CuePoint) []CuePoint) ([]CuePoint, error) {
+	var cues []CuePoint
+	err := updateSidecar(path, func(side *TrackMetadata) {
+		cues = fn(side.CuePoints)
+		sort.Slice(cues, func(i, j int) bool { return cues[i].Index < cues[j].Index })
+		side.CuePoints = cues
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cues, nil
+}
+
+type rekordboxCollection struct {
+	XMLName    xml.Name         `xml:"DJ_PLAYLISTS"`
+	Version    string           `xml:"Version,attr"`
+	Product    rekordboxProduct `xml:"PRODUCT"`
+	Collection struct {
+		Entries int              `xml:"Entries,attr"`
+		Tracks  []rekordboxTrack `xml:"TRACK"`
+	} `xml:"COLLECTION"`
+}
+
+type rekordboxProduct struct {
+	Name    string `xml:"Name,attr"`
+	Version string `xml:"Version,attr"`
+	Company string `xml:"Company,attr"`
+}
+
+type rekordboxTrack struct {
+	TrackID  int             `xml:"TrackID,attr"`
+	Name     string          `xml:"Name,attr"`
+	Artist   string          `xml:"Artist,attr"`
+	Album    string          `xml:"Album,attr,omitempty"`
+	Location string          `xml:"Location,attr"`
+	Marks    []rekordboxMark `xml:"POSITION_MARK"`
+}
+
+type rekordboxMark struct {
+	Name  string `xml:"Name,attr"`
+	Type  int    `xml:"Type,attr"`
+	Start string `xml:"Start,attr"`
+	Num   int    `xml:"Num,attr"`
+	Red   int    `xml:"Red,attr"`
+	Green int    `xml:"Green,attr"`
+	Blue  int    `xml:"Blue,attr"`
+}
+
+func ExportRekordboxXML(w io.Writer, tracks []TrackMetadata) error {
+	doc := rekordboxCollection{
+		Version: "1.0.0",
+		Product: rekordboxProduct{Name: "Kitty", Version: "1", Company: "Kitty"},
+	}
+	for i, t := range tracks {
+		rt := rekordboxTrack{
+			TrackID:  i + 1,
+			Name:     t.Title,
+			Artist:   t.Artist,
+			Album:    t.Album,
+			Location: rekordboxLocation(t.FilePath),
+		}
+		for _, c := range t.CuePoints {
+			r, g, b := hexRGB(c.Color)
+			rt.Marks = append(rt.Marks, rekordboxMark{
+				Name:  c.Label,
+				Type:  0,
+				Start: strconv.FormatFloat(c.Position, 'f', 3, 64),
+				Num:   c.Index,
+				Red:   r,
+				Green: g,
+				Blue:  b,
+			})
+		}
+		doc.Collection.Tracks = append(doc.Collection.Tracks, rt)
+	}
+	doc.Collection.Entries = len(doc.Collection.Tracks)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func rekordboxLocation(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	slashed := filepath.ToSlash(abs)
+	if !strings.HasPrefix(slashed, "/") {
+		slashed = "/" + slashed
+	}
+	u := url.URL{Scheme: "file", Host: "localhost", Path: slashed}
+	return u.String()
+}
+
+func hexRGB(hex string) (int, int, int) {
+	v, err := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32)
+	if err != nil {
+		return 0, 0, 0
+	}
+	return int(v >> 16 & 0xff), int(v >> 8 & 0xff), int(v & 0xff)
+}
@@ -0,0 +1,297 @@
+package metadata
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const cueFramesPerSecond = 75
+
+var ErrCueTrackNotFound = errors.New("cue sheet track not found")
+
+var cueAudioExts = []string{".flac", ".wav", ".ape", ".wv", ".mp3", ".ogg", ".m4a", ".aiff", ".aif"}
+
+type CueSheet struct {
+	Path      string          `json:"path"`
+	Title     string          `json:"title"`
+	Performer string          `json:"performer"`
+	Genre     string          `json:"genre,omitempty"`
+	Year      int             `json:"year,omitempty"`
+	Comment   string          `json:"comment,omitempty"`
+	Tracks    []CueSheetTrack `json:"tracks"`
+}
+
+type CueSheetTrack struct {
+	Number    int     `json:"number"`
+	Path      string  `json:"path"`
+	File      string  `json:"file"`
+	Title     string  `json:"title"`
+	Performer string  `json:"performer"`
+	Composer  string  `json:"composer,omitempty"`
+	Start     float64 `json:"start"`
+	End       float64 `json:"end"`
+}
+
+func IsCueSheet(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".cue")
+}
+
+func CueTrackPath(cuePath string, number int) string {
+	return fmt.Sprintf("%s#%d", cuePath, number)
+}
+
+func SplitCueTrackPath(path string) (string, int, bool) {
+	idx := strings.LastIndex(path, "#")
+	if idx <= 0 || !IsCueSheet(path[:idx]) {
+		return "", 0, false
+	}
+	n, err := strconv.Atoi(path[idx+1:])
+	if err != nil || n <= 0 {
+		return "", 0, false
+	}
+	return path[:idx], n, true
+}
+
+func SourcePath(path string) string {
+	if cue, _, ok := SplitCueTrackPath(path); ok {
+		return cue
+	}
+	return path
+}
+
+func ReadCueSheet(path string) (*CueSheet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sheet, err := ParseCueSheet(data, filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	sheet.Path = path
+	for i := range sheet.Tracks {
+		sheet.Tracks[i].Path = CueTrackPath(path, sheet.Tracks[i].Number)
+	}
+	return sheet, nil
+}
+
+func ResolveCueTrack(path string) (*CueSheet, CueSheetTrack, error) {
+	cue, n, ok := SplitCueTrackPath(path)
+	if !ok {
+		return nil, CueSheetTrack{}, ErrCueTrackNotFound
+	}
+	sheet, err := ReadCueSheet(cue)
+	if err != nil {
+		return nil, CueSheetTrack{}, err
+	}
+	for _, t := range sheet.Tracks {
+		if t.Number == n {
+			return sheet, t, nil
+		}
+	}
+	return nil, CueSheetTrack{}, ErrCueTrackNotFound
+}
+
+func ParseCueSheet(data []byte, dir string) (*CueSheet, error) {
+	data = bytes.TrimPrefix(data, []byte{0xEF, 0xBB, 0xBF})
+	if !utf8.Valid(data) {
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		data = []byte(string(runes))
+	}
+
+	sheet := &CueSheet{Tracks: []CueSheetTrack{}}
+	var (
+		file    string
+		current *CueSheetTrack
+		indexed bool
+	)
+	flush := func() {
+		if current != nil && indexed {
+			sheet.Tracks = append(sheet.Tracks, *current)
+		}
+		current, indexed = nil, false
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		keyword, rest, _ := strings.Cut(line, " ")
+		rest = strings.TrimSpace(rest)
+		switch strings.ToUpper(keyword) {
+		case "FILE":
+			flush()
+			file = resolveCueFile(dir, cueValue(rest, true))
+		case "TRACK":
+			flush()
+			fields := strings.Fields(rest)
+			if len(fields) < 2 || !strings.EqualFold(fields[1], "AUDIO") || file == "" {
+				continue
+			}
+			n, err := strconv.Atoi(fields[0])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid track number: %s", fields[0])
+			}
+			current = &CueSheetTrack{Number: n, File: file}
+		case "INDEX":
+			fields := strings.Fields(rest)
+			if current == nil || len(fields) < 2 || fields[0] != "01" {
+				continue
+			}
+			start, err := parseCueTime(fields[1])
+			if err != nil {
+				return nil, err
+			}
+			current.Start, indexed = start, true
+		case "TITLE":
+			if current != nil {
+				current.Title = cueValue(rest, false)
+			} else {
+				sheet.Title = cueValue(rest, false)
+			}
+		case "PERFORMER":
+			if current != nil {
+				current.Performer = cueValue(rest, false)
+			} else {
+				sheet.Performer = cueValue(rest, false)
+			}
+		case "SONGWRITER":
+			if current != nil {
+				current.Composer = cueValue(rest, false)
+			}
+		case "REM":
+			key, value, _ := strings.Cut(rest, " ")
+			value = cueValue(strings.TrimSpace(value), false)
+			switch strings.ToUpper(key) {
+			case "GENRE":
+				sheet.Genre = value
+			case "DATE":
+				if len(value) >= 4 {
+					sheet.Year, _ = strconv.Atoi(value[:4])
+				}
+			case "COMMENT":
+				sheet.Comment = value
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	if len(sheet.Tracks) == 0 {
+		return nil, errors.New("cue sheet has no audio tracks")
+	}
+
+	for i := range sheet.Tracks {
+		if i+1 < len(sheet.Tracks) && sheet.Tracks[i+1].File == sheet.Tracks[i].File {
+			sheet.Tracks[i].End = sheet.Tracks[i+1].Start
+		}
+	}
+	return sheet, nil
+}
+
+func cueValue(s string, file bool) string {
+	if strings.HasPrefix(s, "\"") {
+		if end := strings.Index(s[1:], "\""); end >= 0 {
+			return s[1 : end+1]
+		}
+		return strings.Trim(s, "\"")
+	}
+	if file {
+		if idx := strings.LastIndex(s, " "); idx > 0 {
+			return s[:idx]
+		}
+	}
+	return s
+}
+
+func resolveCueFile(dir, name string) string {
+	if name == "" {
+		return ""
+	}
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, filepath.FromSlash(strings.ReplaceAll(name, "\\", "/")))
+	}
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	for _, ext := range cueAudioExts {
+		if _, err := os.Stat(base + ext); err == nil {
+			return base + ext
+		}
+	}
+	return path
+}
+
+func parseCueTime(s string) (float64, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid cue index: %s", s)
+	}
+	var v [3]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid cue index: %s", s)
+		}
+		v[i] = n
+	}
+	if v[1] >= 60 || v[2] >= cueFramesPerSecond {
+		return 0, fmt.Errorf("invalid cue index: %s", s)
+	}
+	return float64(v[0]*60+v[1]) + float64(v[2])/cueFramesPerSecond, nil
+}
+
+func loadCueTrack(path string) (*TrackMetadata, error) {
+	sheet, track, err := ResolveCueTrack(path)
+	if err != nil {
+		return nil, err
+	}
+	base, err := LoadMetadata(track.File)
+	if err != nil {
+		return nil, err
+	}
+	md := &TrackMetadata{
+		FilePath:    path,
+		FileName:    filepath.Base(track.File),
+		Title:       firstNonEmpty(track.Title, fmt.Sprintf("Track %d", track.Number)),
+		Artist:      firstNonEmpty(track.Performer, sheet.Performer, base.Artist),
+		Album:       firstNonEmpty(sheet.Title, base.Album),
+		AlbumArtist: firstNonEmpty(sheet.Performer, base.AlbumArtist),
+		TrackNumber: track.Number,
+		DiscNumber:  base.DiscNumber,
+		Genre:       firstNonEmpty(sheet.Genre, base.Genre),
+		Year:        base.Year,
+		Comment:     sheet.Comment,
+		Composer:    firstNonEmpty(track.Composer, base.Composer),
+		Label:       base.Label,
+		HasCover:    base.HasCover,
+		CoverImage:  base.CoverImage,
+		Format:      base.Format,
+		Bitrate:     base.Bitrate,
+		SampleRate:  base.SampleRate,
+		CloudOnly:   base.CloudOnly,
+	}
+	if sheet.Year > 0 {
+		md.Year = sheet.Year
+	}
+	if rg := base.ReplayGain; rg != nil && rg.HasAlbum {
+		md.ReplayGain = &ReplayGain{AlbumGain: rg.AlbumGain, AlbumPeak: rg.AlbumPeak, HasAlbum: true}
+	}
+	if side, err := readSidecar(path); err == nil {
+		md = mergeMetadata(md, side)
+	}
+	md.Links = FindLinks(md.Comment)
+	return md, nil
+}
@@ -0,0 +1,35 @@
+package metadata
+
+import (
+	"fmt"
+	"math"
+)
+
+const MaxFadeSeconds = 30.0
+
+type FadeProfile struct {
+	In  float64 `json:"in"`
+	Out float64 `json:"out"`
+}
+
+func NormalizeFadeProfile(p FadeProfile) (*FadeProfile, error) {
+	for _, v := range []float64{p.In, p.Out} {
+		if math.IsNaN(v) || v < 0 || v > MaxFadeSeconds {
+			return nil, fmt.Errorf("fade lengths must be between 0 and %.0f seconds", MaxFadeSeconds)
+		}
+	}
+	if p.In == 0 && p.Out == 0 {
+		return nil, nil
+	}
+	return &p, nil
+}
+
+func SetFadeProfile(path string, p FadeProfile) (*FadeProfile, error) {
+	fades, err := NormalizeFadeProfile(p)
+	if err != nil {
+		return nil, err
+	}
+	return fades, updateSidecar(path, func(side *TrackMetadata) {
+		side.Fades = fades
+	})
+}
@@ -0,0 +1,132 @@
+package metadata
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dhowden/tag"
+)
+
+var (
+	bpmKeys         = []string{"TBPM", "TBP", "bpm", "tempo", "tmpo"}
+	musicalKeyKeys  = []string{"TKEY", "TKE", "initialkey", "key"}
+	pitchClassNames = map[string]int{
+		"c": 0, "b#": 0, "c#": 1, "db": 1, "d": 2, "d#": 3, "eb": 3, "e": 4, "fb": 4, "e#": 5, "f": 5,
+		"f#": 6, "gb": 6, "g": 7, "g#": 8, "ab": 8, "a": 9, "a#": 10, "bb": 10, "b": 11, "cb": 11,
+	}
+)
+
+func readBPM(m tag.Metadata) float64 {
+	raw := m.Raw()
+	for _, k := range bpmKeys {
+		v, ok := lookupRaw(raw, k)
+		if !ok {
+			continue
+		}
+		var bpm float64
+		switch t := v.(type) {
+		case int:
+			bpm = float64(t)
+		case float64:
+			bpm = t
+		default:
+			s := strings.TrimSpace(lyricsValue(v))
+			s = strings.ReplaceAll(s, ",", ".")
+			bpm, _ = strconv.ParseFloat(s, 64)
+		}
+		if bpm > 0 && bpm < 1000 {
+			return bpm
+		}
+	}
+	return 0
+}
+
+func readMusicalKey(m tag.Metadata) string {
+	raw := m.Raw()
+	for _, k := range musicalKeyKeys {
+		v, ok := lookupRaw(raw, k)
+		if !ok {
+			continue
+		}
+		if key, err := CamelotKey(lyricsValue(v)); err == nil {
+			return key
+		}
+	}
+	return ""
+}
+
+func lookupRaw(raw map[string]interface{}, key string) (interface{}, bool) {
+	if v, ok := raw[key]; ok {
+		return v, true
+	}
+	for k, v := range raw {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func CamelotKey(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", fmt.Errorf("key is empty")
+	}
+	lower := strings.ToLower(strings.ReplaceAll(s, " ", ""))
+	lower = strings.ReplaceAll(lower, "♯", "#")
+	lower = strings.ReplaceAll(lower, "♭", "b")
+
+	if n, letter, ok := splitWheelKey(lower); ok {
+		switch letter {
+		case "a", "b":
+			return fmt.Sprintf("%d%s", n, strings.ToUpper(letter)), nil
+		case "m":
+			return fmt.Sprintf("%dA", (n+6)%12+1), nil
+		case "d":
+			return fmt.Sprintf("%dB", (n+6)%12+1), nil
+		}
+	}
+
+	minor := false
+	switch {
+	case strings.HasSuffix(lower, "minor"):
+		minor, lower = true, strings.TrimSuffix(lower, "minor")
+	case strings.HasSuffix(lower, "min"):
+		minor, lower = true, strings.TrimSuffix(lower, "min")
+	case strings.HasSuffix(lower, "major"):
+		lower = strings.TrimSuffix(lower, "major")
+	case strings.HasSuffix(lower, "maj"):
+		lower = strings.TrimSuffix(lower, "maj")
+	case strings.HasSuffix(lower, "m"):
+		minor, lower = true, strings.TrimSuffix(lower, "m")
+	}
+	pc, ok := pitchClassNames[lower]
+	if !ok {
+		return "", fmt.Errorf("unknown key: %s", s)
+	}
+	if minor {
+		return fmt.Sprintf("%dA", (pc*7+4)%12+1), nil
+	}
+	return fmt.Sprintf("%dB", (pc*7+7)%12+1), nil
+}
+
+func ParseCamelot(key string) (int, byte, bool) {
+	n, letter, ok := splitWheelKey(strings.ToLower(strings.TrimSpace(key)))
+	if !ok || (letter != "a" && letter != "b") {
+		return 0, 0, false
+	}
+	return n, strings.ToUpper(letter)[0], true
+}
+
+func splitWheelKey(s string) (int, string, bool) {
+	if len(s) < 2 {
+		return 0, "", false
+	}
+	letter := s[len(s)-1:]
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n < 1 || n > 12 {
+		return 0, "", false
+	}
+	return n, letter, true
+}
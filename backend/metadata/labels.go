@@ -0,0 +1,117 @@
+package metadata
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	ColumnText   = "text"
+	ColumnNumber = "number"
+
+	MaxCustomValueLength = 500
+)
+
+var ColorLabels = []string{"red", "orange", "yellow", "green", "blue", "purple", "gray"}
+
+func NormalizeColorLabel(label string) (string, error) {
+	label = strings.ToLower(strings.TrimSpace(label))
+	if label == "" || label == "none" {
+		return "", nil
+	}
+	if label == "grey" {
+		label = "gray"
+	}
+	for _, l := range ColorLabels {
+		if l == label {
+			return label, nil
+		}
+	}
+	return "", fmt.Errorf("unknown color label: %s", label)
+}
+
+func ColorLabelRank(label string) int {
+	for i, l := range ColorLabels {
+		if l == label {
+			return i
+		}
+	}
+	return len(ColorLabels)
+}
+
+func NormalizeColumnType(kind string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "", ColumnText:
+		return ColumnText, nil
+	case ColumnNumber:
+		return ColumnNumber, nil
+	default:
+		return "", fmt.Errorf("unknown column type: %s", kind)
+	}
+}
+
+func NormalizeCustomValue(kind, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	if len(value) > MaxCustomValueLength {
+		return "", fmt.Errorf("value is longer than %d characters", MaxCustomValueLength)
+	}
+	if kind != ColumnNumber {
+		return value, nil
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return "", fmt.Errorf("not a number: %s", value)
+	}
+	return strconv.FormatFloat(n, 'f', -1, 64), nil
+}
+
+func SetColorLabel(path, label string) error {
+	label, err := NormalizeColorLabel(label)
+	if err != nil {
+		return err
+	}
+	return updateSidecar(path, func(side *TrackMetadata) {
+		side.ColorLabel = label
+	})
+}
+
+func SetCustomValue(path, key, value string) (map[string]string, error) {
+	var out map[string]string
+	err := updateSidecar(path, func(side *TrackMetadata) {
+		if side.Custom == nil {
+			side.Custom = map[string]string{}
+		}
+		if value == "" {
+			delete(side.Custom, key)
+		} else {
+			side.Custom[key] = value
+		}
+		if len(side.Custom) == 0 {
+			side.Custom = nil
+		}
+		out = make(map[string]string, len(side.Custom))
+		for k, v := range side.Custom {
+			out[k] = v
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func updateSidecar(path string, fn func(side *TrackMetadata)) error {
+	return serializeWrite(path, func() error {
+		side, err := readSidecar(path)
+		if err != nil {
+			side = &TrackMetadata{FilePath: path, FileName: filepath.Base(path)}
+		}
+		fn(side)
+		return writeSidecar(*side)
+	})
+}
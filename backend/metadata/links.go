@@ -0,0 +1,74 @@
+package metadata
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+type Link struct {
+	URL   string `json:"url"`
+	Label string `json:"label"`
+	Kind  string `json:"kind"`
+}
+
+var (
+	urlPattern  = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"'\x60]+`)
+	trailingURL = ".,;:!?)]}>'\""
+)
+
+var linkKinds = []struct {
+	host  string
+	kind  string
+	label string
+}{
+	{"bandcamp.com", "bandcamp", "Bandcamp"},
+	{"soundcloud.com", "soundcloud", "SoundCloud"},
+	{"youtube.com", "youtube", "YouTube"},
+	{"youtu.be", "youtube", "YouTube"},
+	{"spotify.com", "spotify", "Spotify"},
+	{"music.apple.com", "apple", "Apple Music"},
+	{"beatport.com", "beatport", "Beatport"},
+	{"discogs.com", "discogs", "Discogs"},
+	{"musicbrainz.org", "musicbrainz", "MusicBrainz"},
+	{"junodownload.com", "juno", "Juno Download"},
+	{"traxsource.com", "traxsource", "Traxsource"},
+	{"qobuz.com", "qobuz", "Qobuz"},
+	{"tidal.com", "tidal", "TIDAL"},
+	{"deezer.com", "deezer", "Deezer"},
+	{"mixcloud.com", "mixcloud", "Mixcloud"},
+}
+
+func FindLinks(texts ...string) []Link {
+	var links []Link
+	seen := make(map[string]struct{})
+	for _, text := range texts {
+		for _, raw := range urlPattern.FindAllString(text, -1) {
+			raw = strings.TrimRight(raw, trailingURL)
+			if strings.HasPrefix(strings.ToLower(raw), "www.") {
+				raw = "https://" + raw
+			}
+			u, err := url.Parse(raw)
+			if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+				continue
+			}
+			key := strings.ToLower(u.Host) + u.EscapedPath() + "?" + u.RawQuery
+			if _, dup := seen[key]; dup {
+				continue
+			}
+			seen[key] = struct{}{}
+			links = append(links, classifyLink(u))
+		}
+	}
+	return links
+}
+
+func classifyLink(u *url.URL) Link {
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	for _, k := range linkKinds {
+		if host == k.host || strings.HasSuffix(host, "."+k.host) {
+			return Link{URL: u.String(), Label: k.label, Kind: k.kind}
+		}
+	}
+	return Link{URL: u.String(), Label: host, Kind: "web"}
+}
@@ -0,0 +1,213 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+type LyricLine struct {
+	TimeMs int64  `json:"timeMs"`
+	Text   string `json:"text"`
+}
+
+func ParseLRC(s string) ([]LyricLine, error) {
+	var lines []LyricLine
+	for n, raw := range strings.Split(normalizeLyrics(s), "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "[") {
+			return nil, fmt.Errorf("line %d: missing [mm:ss.xx] timestamp", n+1)
+		}
+		var stamps []int64
+		rest := line
+		for strings.HasPrefix(rest, "[") {
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated tag", n+1)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+			if isLRCMetaTag(inner) {
+				continue
+			}
+			ms, err := parseLRCTime(inner)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n+1, err)
+			}
+			stamps = append(stamps, ms)
+		}
+		text := strings.TrimSpace(rest)
+		for _, ms := range stamps {
+			lines = append(lines, LyricLine{TimeMs: ms, Text: text})
+		}
+	}
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].TimeMs < lines[j].TimeMs })
+	return lines, nil
+}
+
+func isLRCMetaTag(s string) bool {
+	i := strings.Index(s, ":")
+	if i <= 0 {
+		return false
+	}
+	for _, r := range s[:i] {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && r != '#' {
+			return false
+		}
+	}
+	return true
+}
+
+func parseLRCTime(s string) (int64, error) {
+	min, sec, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, fmt.Errorf("invalid timestamp [%s]", s)
+	}
+	m, err := strconv.Atoi(min)
+	if err != nil || m < 0 {
+		return 0, fmt.Errorf("invalid minutes in [%s]", s)
+	}
+	secs, err := strconv.ParseFloat(strings.Replace(sec, ":", ".", 1), 64)
+	if err != nil || secs < 0 || secs >= 60 {
+		return 0, fmt.Errorf("invalid seconds in [%s]", s)
+	}
+	return int64(m)*60000 + int64(secs*1000+0.5), nil
+}
+
+func plainFromLRC(lines []LyricLine) string {
+	out := make([]string, 0, len(lines))
+	for _, l := range lines {
+		out = append(out, l.Text)
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+func syltBody(lines []LyricLine) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(3)
+	buf.WriteString("eng")
+	buf.WriteByte(2)
+	buf.WriteByte(1)
+	buf.WriteByte(0)
+	for _, l := range lines {
+		buf.WriteString(l.Text)
+		buf.WriteByte(0)
+		_ = binary.Write(&buf, binary.BigEndian, uint32(l.TimeMs))
+	}
+	return buf.Bytes()
+}
+
+func FormatLRC(lines []LyricLine) string {
+	out := make([]string, 0, len(lines))
+	for _, l := range lines {
+		out = append(out, fmt.Sprintf("[%02d:%02d.%02d]%s", l.TimeMs/60000, l.TimeMs/1000%60, l.TimeMs%1000/10, l.Text))
+	}
+	return strings.Join(out, "\n")
+}
+
+func parseSYLT(body []byte) ([]LyricLine, error) {
+	if len(body) < 6 {
+		return nil, fmt.Errorf("SYLT frame too short")
+	}
+	enc, format := body[0], body[4]
+	if format != 2 {
+		return nil, fmt.Errorf("SYLT timestamps in format %d are not supported", format)
+	}
+	rest := body[6:]
+	if _, n, ok := cutSYLTText(rest, enc); ok {
+		rest = rest[n:]
+	} else {
+		return nil, fmt.Errorf("SYLT descriptor is not terminated")
+	}
+	var lines []LyricLine
+	for len(rest) > 0 {
+		text, n, ok := cutSYLTText(rest, enc)
+		if !ok || len(rest) < n+4 {
+			break
+		}
+		ms := binary.BigEndian.Uint32(rest[n : n+4])
+		rest = rest[n+4:]
+		lines = append(lines, LyricLine{TimeMs: int64(ms), Text: strings.TrimSpace(strings.TrimPrefix(text, "\n"))})
+	}
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].TimeMs < lines[j].TimeMs })
+	return lines, nil
+}
+
+func cutSYLTText(b []byte, enc byte) (string, int, bool) {
+	if enc == 0 || enc == 3 {
+		i := bytes.IndexByte(b, 0)
+		if i < 0 {
+			return "", 0, false
+		}
+		if enc == 0 {
+			runes := make([]rune, i)
+			for j, c := range b[:i] {
+				runes[j] = rune(c)
+			}
+			return string(runes), i + 1, true
+		}
+		return string(b[:i]), i + 1, true
+	}
+	for i := 0; i+1 < len(b); i += 2 {
+		if b[i] != 0 || b[i+1] != 0 {
+			continue
+		}
+		units := b[:i]
+		bigEndian := enc == 2
+		if len(units) >= 2 && units[0] == 0xFE && units[1] == 0xFF {
+			units, bigEndian = units[2:], true
+		} else if len(units) >= 2 && units[0] == 0xFF && units[1] == 0xFE {
+			units, bigEndian = units[2:], false
+		}
+		u16 := make([]uint16, len(units)/2)
+		for j := range u16 {
+			if bigEndian {
+				u16[j] = binary.BigEndian.Uint16(units[2*j:])
+			} else {
+				u16[j] = binary.LittleEndian.Uint16(units[2*j:])
+			}
+		}
+		return string(utf16.Decode(u16)), i + 2, true
+	}
+	return "", 0, false
+}
+
+func SaveLyrics(path, plain, synced string) (*TrackMetadata, error) {
+	synced = normalizeLyrics(synced)
+	plain = normalizeLyrics(plain)
+	if synced != "" {
+		lines, err := ParseLRC(synced)
+		if err != nil {
+			return nil, err
+		}
+		if len(lines) == 0 {
+			return nil, fmt.Errorf("synced lyrics have no timed lines")
+		}
+		if plain == "" {
+			plain = plainFromLRC(lines)
+		}
+	}
+
+	var saved *TrackMetadata
+	err := serializeWrite(path, func() error {
+		md, err := LoadMetadata(path)
+		if err != nil {
+			return err
+		}
+		md.Lyrics = plain
+		md.SyncedLyrics = synced
+		if err := writeTags(*md, true); err != nil {
+			return err
+		}
+		saved = md
+		return nil
+	})
+	return saved, err
+}
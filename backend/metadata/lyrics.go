@@ -0,0 +1,101 @@
+package metadata
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/dhowden/tag"
+)
+
+var lyricsKeys = []string{"lyrics", "unsyncedlyrics", "unsynced lyrics", "unsynced_lyrics", "\xa9lyr", "uslt"}
+
+func readLyrics(m tag.Metadata) string {
+	if l := normalizeLyrics(m.Lyrics()); l != "" {
+		return l
+	}
+
+	raw := m.Raw()
+	keys := make([]string, 0, len(raw))
+	for k := range raw {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, want := range lyricsKeys {
+		for _, k := range keys {
+			if !strings.EqualFold(k, want) && !strings.HasPrefix(strings.ToLower(k), want+"_") {
+				continue
+			}
+			if l := normalizeLyrics(lyricsValue(raw[k])); l != "" {
+				return l
+			}
+		}
+	}
+
+	for _, k := range keys {
+		if !strings.HasPrefix(k, "TXXX") {
+			continue
+		}
+		c, ok := raw[k].(*tag.Comm)
+		if !ok {
+			continue
+		}
+		desc := strings.ToLower(strings.TrimSpace(c.Description))
+		if desc == "lyrics" || desc == "unsyncedlyrics" || desc == "unsynced lyrics" {
+			if l := normalizeLyrics(c.Text); l != "" {
+				return l
+			}
+		}
+	}
+	return ""
+}
+
+func lyricsValue(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case *tag.Comm:
+		return t.Text
+	case []byte:
+		return string(t)
+	}
+	return ""
+}
+
+func normalizeLyrics(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	s = strings.TrimPrefix(s, "\ufeff")
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\x00")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func readSyncedLyrics(m tag.Metadata) string {
+	raw := m.Raw()
+	keys := make([]string, 0, len(raw))
+	for k := range raw {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		switch {
+		case k == "SYLT" || strings.HasPrefix(k, "SYLT_"):
+			body, ok := raw[k].([]byte)
+			if !ok {
+				continue
+			}
+			if lines, err := parseSYLT(body); err == nil && len(lines) > 0 {
+				return FormatLRC(lines)
+			}
+		case strings.EqualFold(k, "syncedlyrics"):
+			synced := normalizeLyrics(lyricsValue(raw[k]))
+			if lines, err := ParseLRC(synced); err == nil && len(lines) > 0 {
+				return synced
+			}
+		}
+	}
+	return ""
+}
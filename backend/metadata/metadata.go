@@ -13,33 +13,63 @@ import (
 	"strings"
 
 	"kitty/backend/analysis"
+	"kitty/backend/cloudfile"
 
 	"github.com/bogem/id3v2"
 	"github.com/dhowden/tag"
 )
 
 type TrackMetadata struct {
-	FilePath    string `json:"filePath"`
-	FileName    string `json:"fileName"`
-	Title       string `json:"title"`
-	Artist      string `json:"artist"`
-	Album       string `json:"album"`
-	AlbumArtist string `json:"albumArtist"`
-	TrackNumber int    `json:"trackNumber"`
-	DiscNumber  int    `json:"discNumber"`
-	Genre       string `json:"genre"`
-	Year        int    `json:"year"`
-	Comment     string `json:"comment"`
-	Composer    string `json:"composer"`
-	Lyrics      string `json:"lyrics"`
-	HasCover    bool   `json:"hasCover"`
-	CoverImage  string `json:"coverImage"`
-	Format      string `json:"format"`
-	Bitrate     int    `json:"bitrate"`
-	SampleRate  int    `json:"sampleRate"`
+	ID           string            `json:"id,omitempty"`
+	FilePath     string            `json:"filePath"`
+	FileName     string            `json:"fileName"`
+	Title        string            `json:"title"`
+	Artist       string            `json:"artist"`
+	Album        string            `json:"album"`
+	AlbumArtist  string            `json:"albumArtist"`
+	TrackNumber  int               `json:"trackNumber"`
+	DiscNumber   int               `json:"discNumber"`
+	Genre        string            `json:"genre"`
+	Year         int               `json:"year"`
+	Comment      string            `json:"comment"`
+	Composer     string            `json:"composer"`
+	Label        string            `json:"label"`
+	Lyrics       string            `json:"lyrics"`
+	SyncedLyrics string            `json:"syncedLyrics,omitempty"`
+	HasCover     bool              `json:"hasCover"`
+	CoverImage   string            `json:"coverImage"`
+	Format       string            `json:"format"`
+	Bitrate      int               `json:"bitrate"`
+	SampleRate   int               `json:"sampleRate"`
+	BPM          float64           `json:"bpm,omitempty"`
+	Key          string            `json:"key,omitempty"`
+	ReplayGain   *ReplayGain       `json:"replayGain,omitempty"`
+	Classical    *Classical        `json:"classical,omitempty"`
+	SourceURL    string            `json:"sourceUrl"`
+	Source       string            `json:"source"`
+	Links        []Link            `json:"links"`
+	CuePoints    []CuePoint        `json:"cuePoints,omitempty"`
+	ColorLabel   string            `json:"colorLabel,omitempty"`
+	Custom       map[string]string `json:"custom,omitempty"`
+	Fades        *FadeProfile      `json:"fades,omitempty"`
+	CloudOnly    bool              `json:"cloudOnly,omitempty"`
+	Offline      bool              `json:"offline,omitempty"`
 }
 
 func LoadMetadata(path string) (*TrackMetadata, error) {
+	if _, _, ok := SplitCueTrackPath(path); ok {
+		return loadCueTrack(path)
+	}
+	if cloudfile.IsPlaceholder(path) {
+		md := minimalMetadata(path)
+		if side, err := readSidecar(path); err == nil {
+			md = mergeMetadata(md, side)
+		}
+		md.CloudOnly = true
+		md.Links = FindLinks(md.Comment)
+		return md, nil
+	}
+
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
@@ -53,6 +83,7 @@ func LoadMetadata(path string) (*TrackMetadata, error) {
 		if side, sideErr := readSidecar(path); sideErr == nil {
 			md = mergeMetadata(md, side)
 		}
+		md.Links = FindLinks(md.Comment)
 		return md, nil
 	}
 
@@ -60,20 +91,32 @@ func LoadMetadata(path string) (*TrackMetadata, error) {
 	disc, _ := m.Disc()
 
 	md := &TrackMetadata{
-		FilePath:    path,
-		FileName:    filepath.Base(path),
-		Title:       firstNonEmpty(m.Title(), trimExt(filepath.Base(path))),
-		Artist:      firstNonEmpty(m.Artist(), "Unknown Artist"),
-		Album:       firstNonEmpty(m.Album(), "Unknown Album"),
-		AlbumArtist: m.AlbumArtist(),
-		TrackNumber: track,
-		DiscNumber:  disc,
-		Genre:       m.Genre(),
-		Year:        m.Year(),
-		Comment:     m.Comment(),
-		Composer:    m.Composer(),
-		Lyrics:      m.Lyrics(),
-		Format:      firstNonEmpty(string(m.Format()), strings.TrimPrefix(strings.ToUpper(filepath.Ext(path)), ".")),
+		FilePath:     path,
+		FileName:     filepath.Base(path),
+		Title:        firstNonEmpty(m.Title(), trimExt(filepath.Base(path))),
+		Artist:       firstNonEmpty(m.Artist(), "Unknown Artist"),
+		Album:        firstNonEmpty(m.Album(), "Unknown Album"),
+		AlbumArtist:  m.AlbumArtist(),
+		TrackNumber:  track,
+		DiscNumber:   disc,
+		Genre:        m.Genre(),
+		Year:         m.Year(),
+		Comment:      m.Comment(),
+		Composer:     m.Composer(),
+		Lyrics:       readLyrics(m),
+		SyncedLyrics: readSyncedLyrics(m),
+		BPM:          readBPM(m),
+		Key:          readMusicalKey(m),
+		ReplayGain:   readReplayGain(m),
+		Classical:    readClassical(path, m),
+		Format:       firstNonEmpty(string(m.Format()), strings.TrimPrefix(strings.ToUpper(filepath.Ext(path)), ".")),
+	}
+
+	if tpub, ok := m.Raw()["TPUB"].(string); ok {
+		md.Label = strings.TrimSpace(tpub)
+	}
+	if woas, ok := m.Raw()["WOAS"].(string); ok && strings.TrimSpace(woas) != "" {
+		md.SourceURL = strings.TrimSpace(woas)
 	}
 
 	if pic := m.Picture(); pic != nil {
@@ -100,24 +143,43 @@ func LoadMetadata(path string) (*TrackMetadata, error) {
 		md = mergeMetadata(md, side)
 	}
 
+	description, _ := m.Raw()["description"].(string)
+	md.Links = FindLinks(md.Comment, description)
+
 	return md, nil
 }
 
 func SaveMetadata(md TrackMetadata) error {
+	return serializeWrite(md.FilePath, func() error {
+		return saveMetadata(md)
+	})
+}
+
+func saveMetadata(md TrackMetadata) error {
+	return writeTags(md, false)
+}
+
+func writeTags(md TrackMetadata, replaceSynced bool) error {
 	ext := strings.ToLower(filepath.Ext(md.FilePath))
 	if ext == ".mp3" {
 		log.Printf("[metadata] SaveMetadata %s coverLen=%d hasCover=%v", md.FilePath, len(md.CoverImage), md.HasCover)
-		return saveID3v2(md)
+		return saveID3v2(md, replaceSynced)
 	}
 	if err := writeSidecar(md); err != nil {
 		log.Printf("[metadata] sidecar write failed for %s: %v", md.FilePath, err)
 		return err
 	}
+	if vorbisCommentExts[ext] {
+		if err := writeVorbisLyrics(md.FilePath, md.Lyrics, md.SyncedLyrics, replaceSynced); err != nil {
+			log.Printf("[metadata] lyrics comment write failed for %s: %v", md.FilePath, err)
+			return err
+		}
+	}
 	log.Printf("[metadata] saved sidecar for %s (format %s)", md.FilePath, ext)
 	return nil
 }
 
-func saveID3v2(md TrackMetadata) error {
+func saveID3v2(md TrackMetadata, replaceSynced bool) error {
 	id3Tag, err := id3v2.Open(md.FilePath, id3v2.Options{Parse: true})
 	if err != nil {
 		log.Printf("[metadata] open ID3v2 failed: %v", err)
@@ -143,6 +205,11 @@ func saveID3v2(md TrackMetadata) error {
 	id3Tag.DeleteFrames("TCOM")
 	id3Tag.AddTextFrame("TCOM", id3v2.EncodingUTF8, md.Composer)
 
+	id3Tag.DeleteFrames("TPUB")
+	if strings.TrimSpace(md.Label) != "" {
+		id3Tag.AddTextFrame("TPUB", id3v2.EncodingUTF8, md.Label)
+	}
+
 	id3Tag.DeleteFrames("COMM")
 	id3Tag.AddCommentFrame(id3v2.CommentFrame{
 		Encoding: id3v2.EncodingUTF8,
@@ -150,6 +217,11 @@ func saveID3v2(md TrackMetadata) error {
 		Text:     md.Comment,
 	})
 
+	id3Tag.DeleteFrames("WOAS")
+	if src := strings.TrimSpace(md.SourceURL); src != "" {
+		id3Tag.AddFrame("WOAS", id3v2.UnknownFrame{Body: []byte(src)})
+	}
+
 	id3Tag.DeleteFrames("USLT")
 	if strings.TrimSpace(md.Lyrics) != "" {
 		id3Tag.AddUnsynchronisedLyricsFrame(id3v2.UnsynchronisedLyricsFrame{
@@ -159,6 +231,19 @@ func saveID3v2(md TrackMetadata) error {
 		})
 	}
 
+	writeClassical(id3Tag, md.Classical)
+
+	if synced := strings.TrimSpace(md.SyncedLyrics); synced != "" {
+		if lines, err := ParseLRC(synced); err == nil && len(lines) > 0 {
+			id3Tag.DeleteFrames("SYLT")
+			id3Tag.AddFrame("SYLT", id3v2.UnknownFrame{Body: syltBody(lines)})
+		} else if err != nil {
+			log.Printf("[metadata] skipping SYLT for %s: %v", md.FilePath, err)
+		}
+	} else if replaceSynced {
+		id3Tag.DeleteFrames("SYLT")
+	}
+
 	coverData := strings.TrimSpace(md.CoverImage)
 	if coverData != "" {
 		id3Tag.DeleteFrames("APIC")
@@ -180,7 +265,7 @@ func saveID3v2(md TrackMetadata) error {
 				log.Printf("[metadata] cover decode failed: %v", err)
 			}
 		}
-	} else {
+	} else if !md.HasCover {
 		log.Printf("[metadata] removing cover art")
 		id3Tag.DeleteFrames("APIC")
 	}
@@ -208,6 +293,16 @@ func saveID3v2(md TrackMetadata) error {
 	return nil
 }
 
+func OfflineMetadata(path string) *TrackMetadata {
+	md := minimalMetadata(path)
+	if side, err := readSidecar(path); err == nil {
+		md = mergeMetadata(md, side)
+	}
+	md.Offline = true
+	md.Links = FindLinks(md.Comment)
+	return md
+}
+
 func minimalMetadata(path string) *TrackMetadata {
 	base := filepath.Base(path)
 	return &TrackMetadata{
@@ -260,6 +355,13 @@ func sidecarKey(path string) string {
 }
 
 func sidecarPath(path string) string {
+	if SidecarLocation() == SidecarBesideFile {
+		return legacySidecarPath(path)
+	}
+	return configSidecarPath(path)
+}
+
+func configSidecarPath(path string) string {
 	dir, err := sidecarDir()
 	if err != nil {
 		return legacySidecarPath(path)
@@ -269,15 +371,22 @@ func sidecarPath(path string) string {
 	return filepath.Join(dir, name)
 }
 
+func alternateSidecarPath(path string) string {
+	if SidecarLocation() == SidecarBesideFile {
+		return configSidecarPath(path)
+	}
+	return legacySidecarPath(path)
+}
+
 func readSidecar(path string) (*TrackMetadata, error) {
 	primary := sidecarPath(path)
+	alternate := alternateSidecarPath(path)
 	data, err := os.ReadFile(primary)
 	if err != nil {
-		legacy := legacySidecarPath(path)
-		if legacy == primary {
+		if alternate == primary {
 			return nil, err
 		}
-		alt, altErr := os.ReadFile(legacy)
+		alt, altErr := os.ReadFile(alternate)
 		if altErr != nil {
 			return nil, err
 		}
@@ -290,7 +399,7 @@ func readSidecar(path string) (*TrackMetadata, error) {
 	md.FilePath = path
 	md.FileName = filepath.Base(path)
 
-	if legacy := legacySidecarPath(path); legacy != primary {
+	if alternate != primary {
 		if _, statErr := os.Stat(primary); os.IsNotExist(statErr) {
 			_ = writeSidecar(md)
 		}
@@ -299,6 +408,7 @@ func readSidecar(path string) (*TrackMetadata, error) {
 }
 
 func writeSidecar(md TrackMetadata) error {
+	md.ID = ""
 	data, err := json.Marshal(md)
 	if err != nil {
 		return err
@@ -311,9 +421,10 @@ func writeSidecar(md TrackMetadata) error {
 		return err
 	}
 
-	legacy := legacySidecarPath(md.FilePath)
-	if legacy != path {
-		if err := os.Remove(legacy); err != nil && !os.IsNotExist(err) {
+	alternate := alternateSidecarPath(md.FilePath)
+	if alternate != path {
+		if err := os.Remove(alternate); err != nil && !os.IsNotExist(err) {
+			log.Printf("[metadata] remove stale sidecar %s failed: %v", alternate, err)
 		}
 	}
 	return nil
@@ -351,9 +462,15 @@ func mergeMetadata(base *TrackMetadata, override *TrackMetadata) *TrackMetadata
 	if strings.TrimSpace(override.Composer) != "" {
 		result.Composer = override.Composer
 	}
+	if strings.TrimSpace(override.Label) != "" {
+		result.Label = override.Label
+	}
 	if strings.TrimSpace(override.Lyrics) != "" {
 		result.Lyrics = override.Lyrics
 	}
+	if strings.TrimSpace(override.SyncedLyrics) != "" {
+		result.SyncedLyrics = override.SyncedLyrics
+	}
 	if override.TrackNumber > 0 {
 		result.TrackNumber = override.TrackNumber
 	}
@@ -376,6 +493,36 @@ func mergeMetadata(base *TrackMetadata, override *TrackMetadata) *TrackMetadata
 	if override.SampleRate > 0 {
 		result.SampleRate = override.SampleRate
 	}
+	if override.BPM > 0 {
+		result.BPM = override.BPM
+	}
+	if strings.TrimSpace(override.Key) != "" {
+		result.Key = override.Key
+	}
+	if override.ReplayGain != nil {
+		result.ReplayGain = override.ReplayGain
+	}
+	if override.Classical != nil {
+		result.Classical = override.Classical
+	}
+	if strings.TrimSpace(override.SourceURL) != "" {
+		result.SourceURL = override.SourceURL
+	}
+	if strings.TrimSpace(override.Source) != "" {
+		result.Source = override.Source
+	}
+	if len(override.CuePoints) > 0 {
+		result.CuePoints = override.CuePoints
+	}
+	if override.ColorLabel != "" {
+		result.ColorLabel = override.ColorLabel
+	}
+	if len(override.Custom) > 0 {
+		result.Custom = override.Custom
+	}
+	if override.Fades != nil {
+		result.Fades = override.Fades
+	}
 
 	return &result
 }
@@ -0,0 +1,64 @@
+package metadata
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/dhowden/tag"
+)
+
+type ReplayGain struct {
+	TrackGain float64 `json:"trackGain"`
+	TrackPeak float64 `json:"trackPeak,omitempty"`
+	AlbumGain float64 `json:"albumGain,omitempty"`
+	AlbumPeak float64 `json:"albumPeak,omitempty"`
+	HasTrack  bool    `json:"hasTrack"`
+	HasAlbum  bool    `json:"hasAlbum"`
+}
+
+func readReplayGain(m tag.Metadata) *ReplayGain {
+	values := make(map[string]string)
+	for k, v := range m.Raw() {
+		name := strings.ToLower(k)
+		if c, ok := v.(*tag.Comm); ok && strings.HasPrefix(k, "TXXX") {
+			name = strings.ToLower(strings.TrimSpace(c.Description))
+		}
+		if i := strings.Index(name, "replaygain_"); i >= 0 {
+			values[name[i:]] = lyricsValue(v)
+		}
+	}
+
+	rg := &ReplayGain{}
+	if g, ok := parseGain(values["replaygain_track_gain"]); ok {
+		rg.TrackGain, rg.HasTrack = g, true
+		rg.TrackPeak = parsePeak(values["replaygain_track_peak"])
+	}
+	if g, ok := parseGain(values["replaygain_album_gain"]); ok {
+		rg.AlbumGain, rg.HasAlbum = g, true
+		rg.AlbumPeak = parsePeak(values["replaygain_album_peak"])
+	}
+	if !rg.HasTrack && !rg.HasAlbum {
+		return nil
+	}
+	return rg
+}
+
+func parseGain(s string) (float64, bool) {
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(strings.ToLower(s)), "db"))
+	if s == "" {
+		return 0, false
+	}
+	g, err := strconv.ParseFloat(s, 64)
+	if err != nil || g < -60 || g > 60 {
+		return 0, false
+	}
+	return g, true
+}
+
+func parsePeak(s string) float64 {
+	p, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || p <= 0 {
+		return 0
+	}
+	return p
+}
@@ -0,0 +1,161 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	SidecarConfigDir  = "config"
+	SidecarBesideFile = "beside"
+)
+
+type SidecarHealth struct {
+	Dir     string   `json:"dir"`
+	Files   int      `json:"files"`
+	Checked int      `json:"checked"`
+	Corrupt []string `json:"corrupt"`
+}
+
+type SidecarMigration struct {
+	Location string   `json:"location"`
+	Checked  int      `json:"checked"`
+	Moved    int      `json:"moved"`
+	Errors   []string `json:"errors"`
+}
+
+var (
+	sidecarMu       sync.RWMutex
+	sidecarLocation = SidecarConfigDir
+)
+
+func NormalizeSidecarLocation(location string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(location)) {
+	case "", SidecarConfigDir:
+		return SidecarConfigDir, nil
+	case SidecarBesideFile, "adjacent", "legacy":
+		return SidecarBesideFile, nil
+	default:
+		return "", fmt.Errorf("unknown sidecar location: %s", location)
+	}
+}
+
+func SidecarStamp(path string) int64 {
+	for _, p := range []string{sidecarPath(path), alternateSidecarPath(path)} {
+		if info, err := os.Stat(p); err == nil {
+			return info.ModTime().UnixNano()
+		}
+	}
+	return 0
+}
+
+func SidecarLocation() string {
+	sidecarMu.RLock()
+	defer sidecarMu.RUnlock()
+	return sidecarLocation
+}
+
+func SetSidecarLocation(location string) error {
+	location, err := NormalizeSidecarLocation(location)
+	if err != nil {
+		return err
+	}
+	sidecarMu.Lock()
+	sidecarLocation = location
+	sidecarMu.Unlock()
+	return nil
+}
+
+func MigrateSidecars(ctx context.Context, paths []string, progress func(done, total int)) (*SidecarMigration, error) {
+	result := &SidecarMigration{Location: SidecarLocation(), Errors: []string{}}
+	for i, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if progress != nil {
+			progress(i, len(paths))
+		}
+		result.Checked++
+
+		from := alternateSidecarPath(path)
+		to := sidecarPath(path)
+		if from == to {
+			continue
+		}
+		fromInfo, err := os.Stat(from)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", path, err))
+			}
+			continue
+		}
+		if toInfo, err := os.Stat(to); err == nil && !toInfo.ModTime().Before(fromInfo.ModTime()) {
+			if err := os.Remove(from); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", path, err))
+			}
+			continue
+		}
+		data, err := os.ReadFile(from)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", path, err))
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", path, err))
+			continue
+		}
+		if err := os.WriteFile(to, data, 0o644); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", path, err))
+			continue
+		}
+		if err := os.Remove(from); err != nil && !os.IsNotExist(err) {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: moved but old sidecar not removed: %v", path, err))
+		}
+		result.Moved++
+	}
+	if progress != nil {
+		progress(len(paths), len(paths))
+	}
+	return result, nil
+}
+
+func CheckSidecars(limit int) (*SidecarHealth, error) {
+	dir, err := sidecarDir()
+	if err != nil {
+		return nil, err
+	}
+	health := &SidecarHealth{Dir: dir, Corrupt: []string{}}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return health, nil
+		}
+		return health, err
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".kittymeta.json") {
+			continue
+		}
+		health.Files++
+		if health.Checked >= limit {
+			continue
+		}
+		health.Checked++
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		var md TrackMetadata
+		if err == nil {
+			err = json.Unmarshal(data, &md)
+		}
+		if err != nil {
+			health.Corrupt = append(health.Corrupt, e.Name())
+		}
+	}
+	return health, nil
+}
@@ -0,0 +1,439 @@
+package metadata
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var vorbisCommentExts = map[string]bool{".flac": true, ".ogg": true, ".oga": true, ".opus": true}
+
+var errNoVorbisComment = errors.New("no vorbis comment header found")
+
+type vorbisComments struct {
+	vendor   string
+	comments []string
+}
+
+func writeVorbisLyrics(path, plain, synced string, replaceSynced bool) error {
+	return updateVorbisComments(path, func(vc *vorbisComments) bool {
+		changed := vc.set("LYRICS", strings.TrimSpace(plain))
+		if synced = strings.TrimSpace(synced); synced != "" || replaceSynced {
+			changed = vc.set("SYNCEDLYRICS", synced) || changed
+		}
+		return changed
+	})
+}
+
+func (vc *vorbisComments) set(key, value string) bool {
+	kept := make([]string, 0, len(vc.comments)+1)
+	var old []string
+	for _, c := range vc.comments {
+		k, v, _ := strings.Cut(c, "=")
+		if strings.EqualFold(k, key) {
+			old = append(old, v)
+			continue
+		}
+		kept = append(kept, c)
+	}
+	if value != "" {
+		kept = append(kept, key+"="+value)
+	}
+	vc.comments = kept
+	return !(len(old) == 0 && value == "") && !(len(old) == 1 && old[0] == value)
+}
+
+func (vc *vorbisComments) encode() []byte {
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(len(vc.vendor)))
+	buf.WriteString(vc.vendor)
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(len(vc.comments)))
+	for _, c := range vc.comments {
+		_ = binary.Write(&buf, binary.LittleEndian, uint32(len(c)))
+		buf.WriteString(c)
+	}
+	return buf.Bytes()
+}
+
+func decodeVorbisComments(b []byte) (*vorbisComments, int, error) {
+	r := bytes.NewReader(b)
+	readString := func() (string, error) {
+		var n uint32
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return "", err
+		}
+		if int64(n) > int64(r.Len()) {
+			return "", io.ErrUnexpectedEOF
+		}
+		s := make([]byte, n)
+		_, err := io.ReadFull(r, s)
+		return string(s), err
+	}
+	vendor, err := readString()
+	if err != nil {
+		return nil, 0, fmt.Errorf("vorbis comment vendor: %w", err)
+	}
+	var count uint32
+	if err := binary.Read(r, binary.LittleEndian, &count); err != nil {
+		return nil, 0, fmt.Errorf("vorbis comment count: %w", err)
+	}
+	vc := &vorbisComments{vendor: vendor}
+	for i := uint32(0); i < count; i++ {
+		c, err := readString()
+		if err != nil {
+			return nil, 0, fmt.Errorf("vorbis comment %d: %w", i, err)
+		}
+		vc.comments = append(vc.comments, c)
+	}
+	return vc, len(b) - r.Len(), nil
+}
+
+func updateVorbisComments(path string, edit func(vc *vorbisComments) bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	var rewrite func(w io.Writer) error
+	switch string(magic) {
+	case "fLaC":
+		rewrite, err = flacCommentRewrite(f, edit)
+	case "OggS":
+		rewrite, err = oggCommentRewrite(f, edit)
+	default:
+		return fmt.Errorf("%s is not a FLAC or Ogg file", filepath.Base(path))
+	}
+	if err != nil || rewrite == nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tags-*")
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriterSize(tmp, 256*1024)
+	if err = rewrite(w); err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = tmp.Chmod(info.Mode().Perm())
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		f.Close()
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+	return err
+}
+
+type flacBlock struct {
+	kind byte
+	data []byte
+}
+
+func flacCommentRewrite(f *os.File, edit func(vc *vorbisComments) bool) (func(w io.Writer) error, error) {
+	r := bufio.NewReader(f)
+	if _, err := r.Discard(4); err != nil {
+		return nil, err
+	}
+	var blocks []flacBlock
+	for last := false; !last; {
+		var header [4]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return nil, fmt.Errorf("flac metadata: %w", err)
+		}
+		last = header[0]&0x80 != 0
+		size := int(header[1])<<16 | int(header[2])<<8 | int(header[3])
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, fmt.Errorf("flac metadata: %w", err)
+		}
+		blocks = append(blocks, flacBlock{kind: header[0] & 0x7f, data: data})
+	}
+	if len(blocks) == 0 || blocks[0].kind != 0 {
+		return nil, errors.New("flac stream info is missing")
+	}
+
+	idx := -1
+	vc := &vorbisComments{vendor: "Kitty"}
+	for i, b := range blocks {
+		if b.kind == 4 {
+			parsed, _, err := decodeVorbisComments(b.data)
+			if err != nil {
+				return nil, err
+			}
+			idx, vc = i, parsed
+			break
+		}
+	}
+	if !edit(vc) {
+		return nil, nil
+	}
+	encoded := vc.encode()
+	if len(encoded) >= 1<<24 {
+		return nil, errors.New("vorbis comment block is too large")
+	}
+	if idx < 0 {
+		blocks = append(blocks[:1], append([]flacBlock{{kind: 4}}, blocks[1:]...)...)
+		idx = 1
+	}
+	grow := len(encoded) - len(blocks[idx].data)
+	blocks[idx].data = encoded
+	for i := range blocks {
+		if blocks[i].kind == 1 && grow != 0 && len(blocks[i].data) >= grow {
+			blocks[i].data = make([]byte, len(blocks[i].data)-grow)
+			break
+		}
+	}
+
+	return func(w io.Writer) error {
+		if _, err := io.WriteString(w, "fLaC"); err != nil {
+			return err
+		}
+		for i, b := range blocks {
+			header := [4]byte{b.kind, byte(len(b.data) >> 16), byte(len(b.data) >> 8), byte(len(b.data))}
+			if i == len(blocks)-1 {
+				header[0] |= 0x80
+			}
+			if _, err := w.Write(header[:]); err != nil {
+				return err
+			}
+			if _, err := w.Write(b.data); err != nil {
+				return err
+			}
+		}
+		_, err := io.Copy(w, r)
+		return err
+	}, nil
+}
+
+type oggPage struct {
+	headerType byte
+	granule    uint64
+	serial     uint32
+	seq        uint32
+	segments   []byte
+	body       []byte
+}
+
+func readOggPage(r io.Reader) (*oggPage, error) {
+	var h [27]byte
+	if _, err := io.ReadFull(r, h[:]); err != nil {
+		return nil, err
+	}
+	if string(h[:4]) != "OggS" {
+		return nil, errors.New("ogg page capture pattern missing")
+	}
+	p := &oggPage{
+		headerType: h[5],
+		granule:    binary.LittleEndian.Uint64(h[6:14]),
+		serial:     binary.LittleEndian.Uint32(h[14:18]),
+		seq:        binary.LittleEndian.Uint32(h[18:22]),
+		segments:   make([]byte, h[26]),
+	}
+	if _, err := io.ReadFull(r, p.segments); err != nil {
+		return nil, err
+	}
+	size := 0
+	for _, s := range p.segments {
+		size += int(s)
+	}
+	p.body = make([]byte, size)
+	if _, err := io.ReadFull(r, p.body); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *oggPage) encode() []byte {
+	out := make([]byte, 27+len(p.segments)+len(p.body))
+	copy(out, "OggS")
+	out[5] = p.headerType
+	binary.LittleEndian.PutUint64(out[6:14], p.granule)
+	binary.LittleEndian.PutUint32(out[14:18], p.serial)
+	binary.LittleEndian.PutUint32(out[18:22], p.seq)
+	out[26] = byte(len(p.segments))
+	copy(out[27:], p.segments)
+	copy(out[27+len(p.segments):], p.body)
+	binary.LittleEndian.PutUint32(out[22:26], oggCRC(out))
+	return out
+}
+
+func oggCommentRewrite(f *os.File, edit func(vc *vorbisComments) bool) (func(w io.Writer) error, error) {
+	r := bufio.NewReader(f)
+	first, err := readOggPage(r)
+	if err != nil {
+		return nil, fmt.Errorf("ogg: %w", err)
+	}
+	var (
+		prefix  []byte
+		trailer []byte
+		headers int
+	)
+	switch {
+	case bytes.HasPrefix(first.body, []byte("\x01vorbis")):
+		prefix, trailer, headers = []byte("\x03vorbis"), []byte{1}, 2
+	case bytes.HasPrefix(first.body, []byte("OpusHead")):
+		prefix, headers = []byte("OpusTags"), 1
+	default:
+		return nil, errors.New("ogg stream is not Vorbis or Opus")
+	}
+
+	var (
+		packets [][]byte
+		partial []byte
+		pages   int
+	)
+	for len(packets) < headers {
+		p, err := readOggPage(r)
+		if err != nil {
+			return nil, fmt.Errorf("ogg headers: %w", err)
+		}
+		if p.serial != first.serial {
+			return nil, errors.New("ogg headers are interleaved with another stream")
+		}
+		pages++
+		off := 0
+		for _, s := range p.segments {
+			partial = append(partial, p.body[off:off+int(s)]...)
+			off += int(s)
+			if s < 255 {
+				packets = append(packets, partial)
+				partial = nil
+			}
+		}
+	}
+	if len(packets) != headers || partial != nil {
+		return nil, errors.New("ogg header packets do not end on a page boundary")
+	}
+	if !bytes.HasPrefix(packets[0], prefix) {
+		return nil, errNoVorbisComment
+	}
+	vc, n, err := decodeVorbisComments(packets[0][len(prefix):])
+	if err != nil {
+		return nil, err
+	}
+	if !edit(vc) {
+		return nil, nil
+	}
+	comment := append(append([]byte{}, prefix...), vc.encode()...)
+	if trailer != nil {
+		comment = append(comment, trailer...)
+	} else {
+		comment = append(comment, packets[0][len(prefix)+n:]...)
+	}
+	packets[0] = comment
+
+	rebuilt := paginateOgg(packets, first.serial, 1)
+	shift := uint32(len(rebuilt) - pages)
+
+	return func(w io.Writer) error {
+		if _, err := w.Write(first.encode()); err != nil {
+			return err
+		}
+		for _, p := range rebuilt {
+			if _, err := w.Write(p.encode()); err != nil {
+				return err
+			}
+		}
+		for {
+			p, err := readOggPage(r)
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("ogg: %w", err)
+			}
+			if p.serial == first.serial {
+				p.seq += shift
+			}
+			if _, err := w.Write(p.encode()); err != nil {
+				return err
+			}
+		}
+	}, nil
+}
+
+func paginateOgg(packets [][]byte, serial, seq uint32) []*oggPage {
+	var (
+		pages     []*oggPage
+		cur       = &oggPage{serial: serial, seq: seq}
+		completed bool
+	)
+	flush := func() {
+		cur.granule = ^uint64(0)
+		if completed {
+			cur.granule = 0
+		}
+		pages = append(pages, cur)
+		next := &oggPage{serial: serial, seq: cur.seq + 1}
+		if cur.segments[len(cur.segments)-1] == 255 {
+			next.headerType = 1
+		}
+		cur, completed = next, false
+	}
+	for _, pkt := range packets {
+		rest := pkt
+		for {
+			if len(cur.segments) == 255 {
+				flush()
+			}
+			chunk := min(len(rest), 255)
+			cur.segments = append(cur.segments, byte(chunk))
+			cur.body = append(cur.body, rest[:chunk]...)
+			rest = rest[chunk:]
+			if chunk < 255 {
+				completed = true
+				break
+			}
+		}
+	}
+	flush()
+	return pages
+}
+
+var oggCRCTable = func() [256]uint32 {
+	var t [256]uint32
+	for i := range t {
+		r := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if r&0x80000000 != 0 {
+				r = r<<1 ^ 0x04c11db7
+			} else {
+				r <<= 1
+			}
+		}
+		t[i] = r
+	}
+	return t
+}()
+
+func oggCRC(b []byte) uint32 {
+	var crc uint32
+	for _, c := range b {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^c]
+	}
+	return crc
+}
@@ -0,0 +1,61 @@
+package metadata
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+type writeJob struct {
+	fn   func() error
+	done chan error
+}
+
+var (
+	writeMu     sync.Mutex
+	writeQueues = make(map[string][]*writeJob)
+)
+
+func serializeWrite(path string, fn func() error) error {
+	key := writeKey(path)
+	job := &writeJob{fn: fn, done: make(chan error, 1)}
+
+	writeMu.Lock()
+	pending, active := writeQueues[key]
+	writeQueues[key] = append(pending, job)
+	writeMu.Unlock()
+
+	if !active {
+		go drainWrites(key)
+	}
+	return <-job.done
+}
+
+func drainWrites(key string) {
+	for {
+		writeMu.Lock()
+		queue := writeQueues[key]
+		if len(queue) == 0 {
+			delete(writeQueues, key)
+			writeMu.Unlock()
+			return
+		}
+		job := queue[0]
+		writeQueues[key] = queue[1:]
+		writeMu.Unlock()
+
+		job.done <- job.fn()
+	}
+}
+
+func writeKey(path string) string {
+	key := filepath.Clean(path)
+	if abs, err := filepath.Abs(key); err == nil {
+		key = abs
+	}
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		key = strings.ToLower(key)
+	}
+	return key
+}
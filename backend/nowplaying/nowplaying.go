@@ -0,0 +1,115 @@
+package nowplaying
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+)
+
+const (
+	CommandPlay     = "play"
+	CommandPause    = "pause"
+	CommandToggle   = "toggle"
+	CommandStop     = "stop"
+	CommandNext     = "next"
+	CommandPrevious = "previous"
+
+	StatePlaying = "playing"
+	StatePaused  = "paused"
+	StateStopped = "stopped"
+)
+
+var ErrUnsupported = errors.New("system media controls are not supported on this platform")
+
+type Track struct {
+	Path        string
+	Title       string
+	Artist      string
+	Album       string
+	AlbumArtist string
+	Duration    float64
+	Cover       string
+}
+
+type Controls struct {
+	mu     sync.Mutex
+	p      *platform
+	path   string
+	state  string
+	closed bool
+}
+
+func Start(onCommand func(command string)) (*Controls, error) {
+	p, err := startPlatform(onCommand)
+	if err != nil {
+		return nil, err
+	}
+	return &Controls{p: p}, nil
+}
+
+func (c *Controls) Update(t Track, state string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	if t.Path != c.path {
+		c.path = t.Path
+		if err := c.p.setTrack(t); err != nil {
+			log.Printf("[nowplaying] update track failed: %v", err)
+		}
+	}
+	if state != c.state {
+		c.state = state
+		if err := c.p.setState(state); err != nil {
+			log.Printf("[nowplaying] update state failed: %v", err)
+		}
+	}
+}
+
+func (c *Controls) Current() string {
+	if c == nil {
+		return ""
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.path
+}
+
+func (c *Controls) Close() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	c.p.close()
+}
+
+func decodeCover(dataURL string) ([]byte, string, error) {
+	if dataURL == "" {
+		return nil, "", nil
+	}
+	header, payload, ok := strings.Cut(dataURL, ",")
+	if !ok || !strings.HasPrefix(header, "data:") || !strings.HasSuffix(header, ";base64") {
+		return nil, "", fmt.Errorf("cover is not a base64 data URL")
+	}
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, "", err
+	}
+	ext := ".jpg"
+	if strings.Contains(header, "image/png") {
+		ext = ".png"
+	}
+	return data, ext, nil
+}
@@ -0,0 +1,159 @@
+//go:build darwin && cgo
+
+package nowplaying
+
+/*
+#cgo CFLAGS: -x objective-c
+#cgo LDFLAGS: -framework Foundation -framework AppKit -framework MediaPlayer
+#import <AppKit/AppKit.h>
+#import <Foundation/Foundation.h>
+#import <MediaPlayer/MediaPlayer.h>
+#include <stdlib.h>
+
+extern void kittyNowPlayingCommand(int command);
+
+static NSArray *kittyRemoteCommands(void) {
+	MPRemoteCommandCenter *center = [MPRemoteCommandCenter sharedCommandCenter];
+	return @[center.playCommand, center.pauseCommand, center.togglePlayPauseCommand,
+		center.stopCommand, center.nextTrackCommand, center.previousTrackCommand];
+}
+
+static void kittyNowPlayingStart(void) {
+	@autoreleasepool {
+		NSArray *commands = kittyRemoteCommands();
+		for (NSUInteger i = 0; i < [commands count]; i++) {
+			MPRemoteCommand *command = commands[i];
+			int code = (int)i;
+			command.enabled = YES;
+			[command addTargetWithHandler:^MPRemoteCommandHandlerStatus(MPRemoteCommandEvent *event) {
+				kittyNowPlayingCommand(code);
+				return MPRemoteCommandHandlerStatusSuccess;
+			}];
+		}
+	}
+}
+
+static void kittyNowPlayingSetTrack(const char *title, const char *artist, const char *album, const char *albumArtist, double duration, const void *cover, int coverLength) {
+	@autoreleasepool {
+		NSMutableDictionary *info = [NSMutableDictionary dictionary];
+		info[MPMediaItemPropertyTitle] = [NSString stringWithUTF8String:title];
+		info[MPMediaItemPropertyArtist] = [NSString stringWithUTF8String:artist];
+		info[MPMediaItemPropertyAlbumTitle] = [NSString stringWithUTF8String:album];
+		info[MPMediaItemPropertyAlbumArtist] = [NSString stringWithUTF8String:albumArtist];
+		info[MPMediaItemPropertyPlaybackDuration] = @(duration);
+		info[MPNowPlayingInfoPropertyMediaType] = @(MPNowPlayingInfoMediaTypeAudio);
+		if (coverLength > 0) {
+			NSImage *image = [[[NSImage alloc] initWithData:[NSData dataWithBytes:cover length:coverLength]] autorelease];
+			if (image != nil) {
+				info[MPMediaItemPropertyArtwork] = [[[MPMediaItemArtwork alloc] initWithBoundsSize:image.size requestHandler:^NSImage *(CGSize size) {
+					return image;
+				}] autorelease];
+			}
+		}
+		[MPNowPlayingInfoCenter defaultCenter].nowPlayingInfo = info;
+	}
+}
+
+static void kittyNowPlayingSetState(int state) {
+	@autoreleasepool {
+		MPNowPlayingInfoCenter *center = [MPNowPlayingInfoCenter defaultCenter];
+		NSMutableDictionary *info = [NSMutableDictionary dictionaryWithDictionary:center.nowPlayingInfo ?: @{}];
+		info[MPNowPlayingInfoPropertyPlaybackRate] = @(state == MPNowPlayingPlaybackStatePlaying ? 1.0 : 0.0);
+		center.nowPlayingInfo = info;
+		center.playbackState = (MPNowPlayingPlaybackState)state;
+	}
+}
+
+static void kittyNowPlayingStop(void) {
+	@autoreleasepool {
+		for (MPRemoteCommand *command in kittyRemoteCommands()) {
+			[command removeTarget:nil];
+			command.enabled = NO;
+		}
+		MPNowPlayingInfoCenter *center = [MPNowPlayingInfoCenter defaultCenter];
+		center.nowPlayingInfo = nil;
+		center.playbackState = MPNowPlayingPlaybackStateStopped;
+	}
+}
+*/
+import "C"
+
+import (
+	"log"
+	"path/filepath"
+	"sync"
+	"unsafe"
+)
+
+var (
+	handlerMu      sync.Mutex
+	handlerCommand func(string)
+
+	remoteCommands = []string{CommandPlay, CommandPause, CommandToggle, CommandStop, CommandNext, CommandPrevious}
+)
+
+//export kittyNowPlayingCommand
+func kittyNowPlayingCommand(command C.int) {
+	if int(command) < 0 || int(command) >= len(remoteCommands) {
+		return
+	}
+	handlerMu.Lock()
+	fn := handlerCommand
+	handlerMu.Unlock()
+	if fn != nil {
+		go fn(remoteCommands[command])
+	}
+}
+
+type platform struct{}
+
+func startPlatform(onCommand func(string)) (*platform, error) {
+	handlerMu.Lock()
+	handlerCommand = onCommand
+	handlerMu.Unlock()
+	C.kittyNowPlayingStart()
+	return &platform{}, nil
+}
+
+func (p *platform) setTrack(t Track) error {
+	cover, _, err := decodeCover(t.Cover)
+	if err != nil {
+		log.Printf("[nowplaying] cover for %s unavailable: %v", filepath.Base(t.Path), err)
+		cover = nil
+	}
+	title := C.CString(t.Title)
+	defer C.free(unsafe.Pointer(title))
+	artist := C.CString(t.Artist)
+	defer C.free(unsafe.Pointer(artist))
+	album := C.CString(t.Album)
+	defer C.free(unsafe.Pointer(album))
+	albumArtist := C.CString(t.AlbumArtist)
+	defer C.free(unsafe.Pointer(albumArtist))
+
+	var data unsafe.Pointer
+	if len(cover) > 0 {
+		data = C.CBytes(cover)
+		defer C.free(data)
+	}
+	C.kittyNowPlayingSetTrack(title, artist, album, albumArtist, C.double(t.Duration), data, C.int(len(cover)))
+	return nil
+}
+
+func (p *platform) setState(state string) error {
+	status := C.MPNowPlayingPlaybackStateStopped
+	switch state {
+	case StatePlaying:
+		status = C.MPNowPlayingPlaybackStatePlaying
+	case StatePaused:
+		status = C.MPNowPlayingPlaybackStatePaused
+	}
+	C.kittyNowPlayingSetState(C.int(status))
+	return nil
+}
+
+func (p *platform) close() {
+	C.kittyNowPlayingStop()
+	handlerMu.Lock()
+	handlerCommand = nil
+	handlerMu.Unlock()
+}
@@ -0,0 +1,15 @@
+//go:build !windows && !(darwin && cgo)
+
+package nowplaying
+
+type platform struct{}
+
+func startPlatform(func(string)) (*platform, error) {
+	return nil, ErrUnsupported
+}
+
+func (p *platform) setTrack(Track) error { return nil }
+
+func (p *platform) setState(string) error { return nil }
+
+func (p *platform) close() {}
@@ -0,0 +1,458 @@
+package nowplaying
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const (
+	smtcClass      = "Windows.Media.SystemMediaTransportControls"
+	storageClass   = "Windows.Storage.StorageFile"
+	streamRefClass = "Windows.Storage.Streams.RandomAccessStreamReference"
+
+	windowClass = "wailsWindow"
+
+	roInitMultithreaded = 1
+	rpcEChangedMode     = 0x80010106
+	eNoInterface        = 0x80004002
+
+	playbackStopped = 2
+	playbackPlaying = 3
+	playbackPaused  = 4
+	mediaTypeMusic  = 1
+
+	asyncCompleted = 1
+
+	smtcPutPlaybackStatus   = 7
+	smtcGetDisplayUpdater   = 8
+	smtcPutIsEnabled        = 11
+	smtcPutIsPlayEnabled    = 13
+	smtcPutIsStopEnabled    = 15
+	smtcPutIsPauseEnabled   = 17
+	smtcPutIsPrevEnabled    = 25
+	smtcPutIsNextEnabled    = 27
+	smtcAddButtonPressed    = 32
+	smtcRemoveButtonPressed = 33
+	updaterPutType          = 7
+	updaterPutThumbnail     = 11
+	updaterGetMusicProps    = 12
+	updaterUpdate           = 17
+	musicPutTitle           = 7
+	musicPutAlbumArtist     = 9
+	musicPutArtist          = 11
+	buttonArgsGetButton     = 6
+	interopGetForWindow     = 6
+	storageFromPathAsync    = 6
+	streamRefFromFile       = 6
+	asyncInfoGetStatus      = 7
+	asyncGetResults         = 8
+)
+
+type guid struct {
+	Data1 uint32
+	Data2 uint16
+	Data3 uint16
+	Data4 [8]byte
+}
+
+var (
+	iidUnknown          = guid{0x00000000, 0x0000, 0x0000, [8]byte{0xc0, 0, 0, 0, 0, 0, 0, 0x46}}
+	iidAgileObject      = guid{0x94ea2b94, 0xe9cc, 0x49e0, [8]byte{0xc0, 0xff, 0xee, 0x64, 0xca, 0x8f, 0x5b, 0x90}}
+	iidAsyncInfo        = guid{0x00000036, 0x0000, 0x0000, [8]byte{0xc0, 0, 0, 0, 0, 0, 0, 0x46}}
+	iidSMTCInterop      = guid{0xddb0472d, 0xc911, 0x4a1f, [8]byte{0x86, 0xd9, 0xdc, 0x3d, 0x71, 0xa9, 0x5f, 0x5a}}
+	iidSMTC             = guid{0x99fa3ff4, 0x1742, 0x42a6, [8]byte{0x90, 0x2e, 0x08, 0x7d, 0x41, 0xf9, 0x65, 0xec}}
+	iidButtonHandler    = guid{0x0557e996, 0x7b23, 0x5bae, [8]byte{0xaa, 0x81, 0xea, 0x0d, 0x67, 0x11, 0x43, 0xa4}}
+	iidStorageStatics   = guid{0x5984c710, 0xdaf2, 0x43c8, [8]byte{0x8b, 0xb4, 0xa4, 0xd3, 0xea, 0xcf, 0xd0, 0x3f}}
+	iidStreamRefStatics = guid{0x857309dc, 0x3fbf, 0x4e7d, [8]byte{0x98, 0x6f, 0xef, 0x3b, 0x1a, 0x07, 0xa9, 0x64}}
+)
+
+var (
+	combase                   = syscall.NewLazyDLL("combase.dll")
+	procRoInitialize          = combase.NewProc("RoInitialize")
+	procRoGetActivationFactor = combase.NewProc("RoGetActivationFactory")
+	procWindowsCreateString   = combase.NewProc("WindowsCreateString")
+	procWindowsDeleteString   = combase.NewProc("WindowsDeleteString")
+
+	user32                       = syscall.NewLazyDLL("user32.dll")
+	procEnumWindows              = user32.NewProc("EnumWindows")
+	procGetWindowThreadProcessId = user32.NewProc("GetWindowThreadProcessId")
+	procGetClassNameW            = user32.NewProc("GetClassNameW")
+)
+
+type comObject struct {
+	vtbl *[64]uintptr
+}
+
+func (o *comObject) call(slot int, args ...uintptr) error {
+	all := append([]uintptr{uintptr(unsafe.Pointer(o))}, args...)
+	hr, _, _ := syscall.SyscallN(o.vtbl[slot], all...)
+	if int32(hr) < 0 {
+		return hresult(hr)
+	}
+	return nil
+}
+
+func (o *comObject) release() {
+	if o != nil {
+		_ = o.call(2)
+	}
+}
+
+type hresult uintptr
+
+func (h hresult) Error() string {
+	return fmt.Sprintf("HRESULT 0x%08x", uint32(h))
+}
+
+type hstring uintptr
+
+func newHString(s string) (hstring, error) {
+	u16, err := syscall.UTF16FromString(s)
+	if err != nil {
+		return 0, err
+	}
+	var h hstring
+	hr, _, _ := procWindowsCreateString.Call(uintptr(unsafe.Pointer(&u16[0])), uintptr(len(u16)-1), uintptr(unsafe.Pointer(&h)))
+	if int32(hr) < 0 {
+		return 0, hresult(hr)
+	}
+	return h, nil
+}
+
+func (h hstring) free() {
+	if h != 0 {
+		procWindowsDeleteString.Call(uintptr(h))
+	}
+}
+
+func activationFactory(class string, iid *guid) (*comObject, error) {
+	name, err := newHString(class)
+	if err != nil {
+		return nil, err
+	}
+	defer name.free()
+	var factory *comObject
+	hr, _, _ := procRoGetActivationFactor.Call(uintptr(name), uintptr(unsafe.Pointer(iid)), uintptr(unsafe.Pointer(&factory)))
+	if int32(hr) < 0 {
+		return nil, fmt.Errorf("activate %s: %w", class, hresult(hr))
+	}
+	return factory, nil
+}
+
+type buttonHandler struct {
+	vtbl *[4]uintptr
+}
+
+var (
+	handlerMu      sync.Mutex
+	handlerCommand func(string)
+	handlerVtbl    = [4]uintptr{
+		syscall.NewCallback(handlerQueryInterface),
+		syscall.NewCallback(handlerAddRef),
+		syscall.NewCallback(handlerRelease),
+		syscall.NewCallback(handlerInvoke),
+	}
+	handler = &buttonHandler{vtbl: &handlerVtbl}
+
+	findPID      uint32
+	findHWND     uintptr
+	enumCallback = syscall.NewCallback(enumWindow)
+)
+
+func handlerQueryInterface(this *buttonHandler, iid *guid, out **buttonHandler) uintptr {
+	if *iid == iidUnknown || *iid == iidAgileObject || *iid == iidButtonHandler {
+		*out = this
+		return 0
+	}
+	*out = nil
+	return eNoInterface
+}
+
+func handlerAddRef(this *buttonHandler) uintptr { return 1 }
+
+func handlerRelease(this *buttonHandler) uintptr { return 1 }
+
+func handlerInvoke(this *buttonHandler, sender *comObject, args *comObject) uintptr {
+	var button int32
+	if err := args.call(buttonArgsGetButton, uintptr(unsafe.Pointer(&button))); err != nil {
+		return 0
+	}
+	var cmd string
+	switch button {
+	case 0:
+		cmd = CommandPlay
+	case 1:
+		cmd = CommandPause
+	case 2:
+		cmd = CommandStop
+	case 6:
+		cmd = CommandNext
+	case 7:
+		cmd = CommandPrevious
+	default:
+		return 0
+	}
+	handlerMu.Lock()
+	fn := handlerCommand
+	handlerMu.Unlock()
+	if fn != nil {
+		go fn(cmd)
+	}
+	return 0
+}
+
+func enumWindow(hwnd, _ uintptr) uintptr {
+	var pid uint32
+	procGetWindowThreadProcessId.Call(hwnd, uintptr(unsafe.Pointer(&pid)))
+	if pid != findPID {
+		return 1
+	}
+	buf := make([]uint16, 64)
+	n, _, _ := procGetClassNameW.Call(hwnd, uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	if syscall.UTF16ToString(buf[:n]) != windowClass {
+		return 1
+	}
+	findHWND = hwnd
+	return 0
+}
+
+func mainWindow() uintptr {
+	for i := 0; i < 50; i++ {
+		findPID, findHWND = uint32(os.Getpid()), 0
+		procEnumWindows.Call(enumCallback, 0)
+		if findHWND != 0 {
+			return findHWND
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return 0
+}
+
+type platform struct {
+	calls   chan func()
+	smtc    *comObject
+	updater *comObject
+	token   int64
+	cover   string
+}
+
+func startPlatform(onCommand func(string)) (*platform, error) {
+	p := &platform{calls: make(chan func())}
+	ready := make(chan error, 1)
+	go func() {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		hr, _, _ := procRoInitialize.Call(roInitMultithreaded)
+		if int32(hr) < 0 && uint32(hr) != rpcEChangedMode {
+			ready <- fmt.Errorf("RoInitialize: %w", hresult(hr))
+			return
+		}
+		if err := p.init(onCommand); err != nil {
+			p.release()
+			ready <- err
+			return
+		}
+		ready <- nil
+		for fn := range p.calls {
+			fn()
+		}
+	}()
+	if err := <-ready; err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *platform) init(onCommand func(string)) error {
+	hwnd := mainWindow()
+	if hwnd == 0 {
+		return errors.New("main window not found")
+	}
+	interop, err := activationFactory(smtcClass, &iidSMTCInterop)
+	if err != nil {
+		return err
+	}
+	defer interop.release()
+	if err := interop.call(interopGetForWindow, hwnd, uintptr(unsafe.Pointer(&iidSMTC)), uintptr(unsafe.Pointer(&p.smtc))); err != nil {
+		return fmt.Errorf("media controls for window: %w", err)
+	}
+	for _, slot := range []int{smtcPutIsEnabled, smtcPutIsPlayEnabled, smtcPutIsPauseEnabled, smtcPutIsStopEnabled, smtcPutIsNextEnabled, smtcPutIsPrevEnabled} {
+		if err := p.smtc.call(slot, 1); err != nil {
+			return err
+		}
+	}
+	if err := p.smtc.call(smtcGetDisplayUpdater, uintptr(unsafe.Pointer(&p.updater))); err != nil {
+		return err
+	}
+	if err := p.updater.call(updaterPutType, mediaTypeMusic); err != nil {
+		return err
+	}
+	handlerMu.Lock()
+	handlerCommand = onCommand
+	handlerMu.Unlock()
+	return p.smtc.call(smtcAddButtonPressed, uintptr(unsafe.Pointer(handler)), uintptr(unsafe.Pointer(&p.token)))
+}
+
+func (p *platform) do(fn func() error) error {
+	done := make(chan error, 1)
+	p.calls <- func() { done <- fn() }
+	return <-done
+}
+
+func (p *platform) setTrack(t Track) error {
+	return p.do(func() error {
+		var props *comObject
+		if err := p.updater.call(updaterGetMusicProps, uintptr(unsafe.Pointer(&props))); err != nil {
+			return err
+		}
+		defer props.release()
+		for slot, value := range map[int]string{musicPutTitle: t.Title, musicPutArtist: t.Artist, musicPutAlbumArtist: t.AlbumArtist} {
+			h, err := newHString(value)
+			if err != nil {
+				return err
+			}
+			err = props.call(slot, uintptr(h))
+			h.free()
+			if err != nil {
+				return err
+			}
+		}
+		ref, err := p.thumbnail(t.Cover)
+		if err != nil {
+			log.Printf("[nowplaying] cover for %s unavailable: %v", filepath.Base(t.Path), err)
+			ref = nil
+		}
+		err = p.updater.call(updaterPutThumbnail, uintptr(unsafe.Pointer(ref)))
+		ref.release()
+		if err != nil {
+			return err
+		}
+		return p.updater.call(updaterUpdate)
+	})
+}
+
+func (p *platform) thumbnail(cover string) (*comObject, error) {
+	data, ext, err := decodeCover(cover)
+	if err != nil || len(data) == 0 {
+		return nil, err
+	}
+	sum := sha1.Sum(data)
+	path := filepath.Join(os.TempDir(), "kitty-cover-"+hex.EncodeToString(sum[:6])+ext)
+	if path != p.cover {
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return nil, err
+		}
+		if p.cover != "" {
+			_ = os.Remove(p.cover)
+		}
+		p.cover = path
+	}
+
+	statics, err := activationFactory(storageClass, &iidStorageStatics)
+	if err != nil {
+		return nil, err
+	}
+	defer statics.release()
+	name, err := newHString(path)
+	if err != nil {
+		return nil, err
+	}
+	defer name.free()
+	var op *comObject
+	if err := statics.call(storageFromPathAsync, uintptr(name), uintptr(unsafe.Pointer(&op))); err != nil {
+		return nil, err
+	}
+	defer op.release()
+	if err := awaitAsync(op); err != nil {
+		return nil, err
+	}
+	var file *comObject
+	if err := op.call(asyncGetResults, uintptr(unsafe.Pointer(&file))); err != nil {
+		return nil, err
+	}
+	defer file.release()
+
+	refs, err := activationFactory(streamRefClass, &iidStreamRefStatics)
+	if err != nil {
+		return nil, err
+	}
+	defer refs.release()
+	var ref *comObject
+	if err := refs.call(streamRefFromFile, uintptr(unsafe.Pointer(file)), uintptr(unsafe.Pointer(&ref))); err != nil {
+		return nil, err
+	}
+	return ref, nil
+}
+
+func awaitAsync(op *comObject) error {
+	var info *comObject
+	if err := op.call(0, uintptr(unsafe.Pointer(&iidAsyncInfo)), uintptr(unsafe.Pointer(&info))); err != nil {
+		return err
+	}
+	defer info.release()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var status int32
+		if err := info.call(asyncInfoGetStatus, uintptr(unsafe.Pointer(&status))); err != nil {
+			return err
+		}
+		switch {
+		case status == asyncCompleted:
+			return nil
+		case status != 0:
+			return fmt.Errorf("async operation ended with status %d", status)
+		case time.Now().After(deadline):
+			return errors.New("async operation timed out")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (p *platform) setState(state string) error {
+	status := uintptr(playbackStopped)
+	switch state {
+	case StatePlaying:
+		status = playbackPlaying
+	case StatePaused:
+		status = playbackPaused
+	}
+	return p.do(func() error {
+		return p.smtc.call(smtcPutPlaybackStatus, status)
+	})
+}
+
+func (p *platform) close() {
+	_ = p.do(func() error {
+		if p.smtc != nil && p.token != 0 {
+			_ = p.smtc.call(smtcRemoveButtonPressed, uintptr(p.token))
+		}
+		if p.smtc != nil {
+			_ = p.smtc.call(smtcPutIsEnabled, 0)
+		}
+		p.release()
+		return nil
+	})
+	close(p.calls)
+	handlerMu.Lock()
+	handlerCommand = nil
+	handlerMu.Unlock()
+	if p.cover != "" {
+		_ = os.Remove(p.cover)
+	}
+}
+
+func (p *platform) release() {
+	p.updater.release()
+	p.smtc.release()
+	p.updater, p.smtc = nil, nil
+}
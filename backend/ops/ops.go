@@ -0,0 +1,235 @@
+package ops
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"kitty/backend/apperror"
+)
+
+const (
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+
+	maxFinished = 100
+)
+
+type Operation struct {
+	ID         string             `json:"id"`
+	Kind       string             `json:"kind"`
+	Title      string             `json:"title"`
+	Status     string             `json:"status"`
+	Progress   float64            `json:"progress"`
+	Done       int                `json:"done"`
+	Total      int                `json:"total"`
+	Message    string             `json:"message,omitempty"`
+	Error      *apperror.AppError `json:"error,omitempty"`
+	Result     interface{}        `json:"result,omitempty"`
+	StartedAt  int64              `json:"startedAt"`
+	FinishedAt int64              `json:"finishedAt,omitempty"`
+}
+
+type Func func(ctx context.Context, r *Reporter) (interface{}, error)
+
+type Manager struct {
+	mu       sync.Mutex
+	ops      map[string]*Operation
+	order    []string
+	cancels  map[string]context.CancelFunc
+	onUpdate func(Operation)
+}
+
+type Reporter struct {
+	m  *Manager
+	id string
+}
+
+func New(onUpdate func(Operation)) *Manager {
+	return &Manager{
+		ops:      make(map[string]*Operation),
+		cancels:  make(map[string]context.CancelFunc),
+		onUpdate: onUpdate,
+	}
+}
+
+func (m *Manager) Start(ctx context.Context, kind, title string, fn Func) Operation {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	opCtx, cancel := context.WithCancel(ctx)
+	op := &Operation{
+		ID:        newID(),
+		Kind:      kind,
+		Title:     title,
+		Status:    StatusRunning,
+		StartedAt: time.Now().Unix(),
+	}
+
+	m.mu.Lock()
+	m.ops[op.ID] = op
+	m.order = append(m.order, op.ID)
+	m.cancels[op.ID] = cancel
+	m.pruneLocked()
+	snapshot := *op
+	m.mu.Unlock()
+
+	m.publish(snapshot)
+
+	go func() {
+		defer cancel()
+		r := &Reporter{m: m, id: op.ID}
+		result, err := fn(opCtx, r)
+		m.finish(op.ID, result, err, opCtx.Err() != nil)
+	}()
+	return snapshot
+}
+
+func (m *Manager) Cancel(id string) error {
+	m.mu.Lock()
+	cancel, ok := m.cancels[id]
+	m.mu.Unlock()
+	if !ok {
+		return apperror.NotFound("operation not found or already finished")
+	}
+	cancel()
+	return nil
+}
+
+func (m *Manager) Get(id string) (Operation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	op, ok := m.ops[id]
+	if !ok {
+		return Operation{}, apperror.NotFound("operation not found")
+	}
+	return *op, nil
+}
+
+func (m *Manager) List() []Operation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]Operation, 0, len(m.order))
+	for _, id := range m.order {
+		if op, ok := m.ops[id]; ok {
+			out = append(out, *op)
+		}
+	}
+	return out
+}
+
+func (r *Reporter) Progress(done, total int, message string) {
+	r.m.update(r.id, func(op *Operation) {
+		op.Done = done
+		op.Total = total
+		if total > 0 {
+			op.Progress = float64(done) / float64(total)
+		}
+		if message != "" {
+			op.Message = message
+		}
+	})
+}
+
+func (r *Reporter) Note(message string) {
+	r.m.update(r.id, func(op *Operation) {
+		op.Message = message
+	})
+}
+
+func (r *Reporter) Fraction(progress float64, message string) {
+	r.m.update(r.id, func(op *Operation) {
+		if progress < 0 {
+			progress = 0
+		} else if progress > 1 {
+			progress = 1
+		}
+		op.Progress = progress
+		if message != "" {
+			op.Message = message
+		}
+	})
+}
+
+func (m *Manager) update(id string, fn func(op *Operation)) {
+	m.mu.Lock()
+	op, ok := m.ops[id]
+	if !ok || op.Status != StatusRunning {
+		m.mu.Unlock()
+		return
+	}
+	fn(op)
+	snapshot := *op
+	m.mu.Unlock()
+	m.publish(snapshot)
+}
+
+func (m *Manager) finish(id string, result interface{}, err error, cancelled bool) {
+	m.mu.Lock()
+	op, ok := m.ops[id]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+	delete(m.cancels, id)
+	op.FinishedAt = time.Now().Unix()
+	op.Result = result
+	switch {
+	case cancelled || errors.Is(err, context.Canceled):
+		op.Status = StatusCancelled
+	case err != nil:
+		op.Status = StatusFailed
+		op.Error = apperror.From(err)
+	default:
+		op.Status = StatusCompleted
+		op.Progress = 1
+		if op.Total > 0 {
+			op.Done = op.Total
+		}
+	}
+	snapshot := *op
+	m.mu.Unlock()
+	m.publish(snapshot)
+}
+
+func (m *Manager) publish(op Operation) {
+	if m.onUpdate != nil {
+		m.onUpdate(op)
+	}
+}
+
+func (m *Manager) pruneLocked() {
+	finished := 0
+	for _, id := range m.order {
+		if op := m.ops[id]; op != nil && op.Status != StatusRunning {
+			finished++
+		}
+	}
+	if finished <= maxFinished {
+		return
+	}
+	kept := m.order[:0]
+	for _, id := range m.order {
+		op := m.ops[id]
+		if finished > maxFinished && op != nil && op.Status != StatusRunning {
+			delete(m.ops, id)
+			finished--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	m.order = kept
+}
+
+func newID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return hex.EncodeToString([]byte(time.Now().Format("150405.000000")))
+	}
+	return hex.EncodeToString(buf)
+}
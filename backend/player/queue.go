@@ -0,0 +1,627 @@
+package player
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ShuffleOff    = "off"
+	ShuffleTracks = "tracks"
+	ShuffleAlbums = "albums"
+
+	RepeatOff = "off"
+	RepeatOne = "one"
+	RepeatAll = "all"
+
+	EndStop           = "stop"
+	EndRepeat         = "repeat"
+	EndAutoDJ         = "auto-dj"
+	EndSleep          = "sleep"
+	EndStopDownloader = "stop-downloader"
+
+	defaultShuffleMemory = 25
+	defaultArtistSpacing = 2
+	maxBackHistory       = 500
+)
+
+type Item struct {
+	Path        string `json:"path"`
+	Title       string `json:"title"`
+	Artist      string `json:"artist"`
+	Album       string `json:"album"`
+	AlbumArtist string `json:"albumArtist"`
+	DiscNumber  int    `json:"discNumber"`
+	TrackNumber int    `json:"trackNumber"`
+}
+
+type State struct {
+	Items       []Item `json:"items"`
+	Current     int    `json:"current"`
+	Shuffle     string `json:"shuffle"`
+	Repeat      string `json:"repeat"`
+	EndAction   string `json:"endAction"`
+	EndOverride string `json:"endOverride,omitempty"`
+}
+
+type HistoryFunc func(n int) []Item
+
+type Queue struct {
+	mu      sync.Mutex
+	items   []Item
+	order   []int
+	pos     int
+	shuffle string
+	repeat  string
+	rng     *rand.Rand
+	back    []int
+
+	endAction   string
+	endOverride string
+
+	history       HistoryFunc
+	played        []Item
+	memory        int
+	artistSpacing int
+}
+
+func NewQueue() *Queue {
+	return &Queue{
+		pos:           -1,
+		shuffle:       ShuffleOff,
+		repeat:        RepeatOff,
+		endAction:     EndStop,
+		rng:           rand.New(rand.NewSource(time.Now().UnixNano())),
+		memory:        defaultShuffleMemory,
+		artistSpacing: defaultArtistSpacing,
+	}
+}
+
+func NormalizeShuffle(mode string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", ShuffleOff:
+		return ShuffleOff, nil
+	case ShuffleTracks, "on":
+		return ShuffleTracks, nil
+	case ShuffleAlbums, "album":
+		return ShuffleAlbums, nil
+	default:
+		return "", fmt.Errorf("unknown shuffle mode: %s", mode)
+	}
+}
+
+func NormalizeRepeat(mode string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", RepeatOff:
+		return RepeatOff, nil
+	case RepeatOne:
+		return RepeatOne, nil
+	case RepeatAll:
+		return RepeatAll, nil
+	default:
+		return "", fmt.Errorf("unknown repeat mode: %s", mode)
+	}
+}
+
+func NormalizeEndAction(action string) (string, error) {
+	switch a := strings.ToLower(strings.TrimSpace(action)); a {
+	case "":
+		return EndStop, nil
+	case EndStop, EndRepeat, EndAutoDJ, EndSleep, EndStopDownloader:
+		return a, nil
+	}
+	return "", fmt.Errorf("unknown end-of-queue action: %s", action)
+}
+
+func (q *Queue) SetEndAction(action string) (State, error) {
+	action, err := NormalizeEndAction(action)
+	if err != nil {
+		return State{}, err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.endAction = action
+	return q.stateLocked(), nil
+}
+
+func (q *Queue) SetEndOverride(action string) (State, error) {
+	if strings.TrimSpace(action) != "" {
+		var err error
+		if action, err = NormalizeEndAction(action); err != nil {
+			return State{}, err
+		}
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.endOverride = action
+	return q.stateLocked(), nil
+}
+
+func (q *Queue) Set(items []Item, start int) State {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.items = append([]Item{}, items...)
+	q.back = nil
+	current := -1
+	if start >= 0 && start < len(q.items) {
+		current = start
+	}
+	q.rebuildLocked(current)
+	return q.stateLocked()
+}
+
+func (q *Queue) State() State {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.stateLocked()
+}
+
+func (q *Queue) SetShuffle(mode string) (State, error) {
+	mode, err := NormalizeShuffle(mode)
+	if err != nil {
+		return State{}, err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.shuffle = mode
+	q.back = nil
+	q.rebuildLocked(q.currentIndexLocked())
+	return q.stateLocked(), nil
+}
+
+func (q *Queue) SetRepeat(mode string) (State, error) {
+	mode, err := NormalizeRepeat(mode)
+	if err != nil {
+		return State{}, err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.repeat = mode
+	return q.stateLocked(), nil
+}
+
+func (q *Queue) SetShuffleHistory(history HistoryFunc, memory, artistSpacing int) {
+	q.mu.Lock()
+	q.history = history
+	q.memory = historyDepth(memory, defaultShuffleMemory)
+	q.artistSpacing = historyDepth(artistSpacing, defaultArtistSpacing)
+	q.mu.Unlock()
+	q.RefreshHistory()
+}
+
+func (q *Queue) RefreshHistory() {
+	q.mu.Lock()
+	history, n := q.history, max(q.memory, q.artistSpacing)
+	q.mu.Unlock()
+	var played []Item
+	if history != nil && n > 0 {
+		played = history(n)
+	}
+	q.mu.Lock()
+	q.played = played
+	q.mu.Unlock()
+}
+
+func historyDepth(n, fallback int) int {
+	switch {
+	case n == 0:
+		return fallback
+	case n < 0:
+		return 0
+	}
+	return n
+}
+
+func (q *Queue) Current() (Item, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	idx := q.currentIndexLocked()
+	if idx < 0 {
+		return Item{}, false
+	}
+	return q.items[idx], true
+}
+
+func (q *Queue) Next() (Item, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.nextLocked(q.repeat == RepeatAll)
+}
+
+func (q *Queue) Advance() (Item, string, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.pos+1 >= len(q.order) && q.repeat == RepeatOff {
+		action := q.endAction
+		if q.endOverride != "" {
+			action, q.endOverride = q.endOverride, ""
+		}
+		if action != EndRepeat {
+			return Item{}, action, false
+		}
+		item, ok := q.nextLocked(true)
+		return item, "", ok
+	}
+	item, ok := q.nextLocked(q.repeat == RepeatAll)
+	if !ok {
+		return Item{}, EndStop, false
+	}
+	return item, "", true
+}
+
+func (q *Queue) AlbumSequential(path string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.shuffle == ShuffleTracks {
+		return false
+	}
+	idx := q.currentIndexLocked()
+	if idx < 0 || q.items[idx].Path != path {
+		return false
+	}
+	key := albumKey(q.items[idx])
+	if strings.HasPrefix(key, "track:") {
+		return false
+	}
+	for _, p := range []int{q.pos - 1, q.pos + 1} {
+		if p >= 0 && p < len(q.order) && albumKey(q.items[q.order[p]]) == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *Queue) Peek() (Item, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	switch {
+	case len(q.order) == 0:
+		return Item{}, false
+	case q.repeat == RepeatOne && q.pos >= 0:
+		return q.items[q.order[q.pos]], true
+	case q.pos+1 < len(q.order):
+		return q.items[q.order[q.pos+1]], true
+	case q.repeat == RepeatAll && q.shuffle == ShuffleOff:
+		return q.items[q.order[0]], true
+	}
+	return Item{}, false
+}
+
+func (q *Queue) nextLocked(wrap bool) (Item, bool) {
+	if len(q.order) == 0 {
+		return Item{}, false
+	}
+	if q.repeat == RepeatOne && q.pos >= 0 {
+		return q.items[q.order[q.pos]], true
+	}
+	if q.pos+1 < len(q.order) {
+		q.pos++
+		return q.items[q.order[q.pos]], true
+	}
+	if !wrap {
+		return Item{}, false
+	}
+	last := q.currentIndexLocked()
+	played := append([]int{}, q.order[:q.pos+1]...)
+	q.rebuildLocked(-1)
+	if q.shuffle != ShuffleOff {
+		q.back = append(q.back, played...)
+		if len(q.back) > maxBackHistory {
+			q.back = q.back[len(q.back)-maxBackHistory:]
+		}
+		if len(q.order) > 1 && q.order[0] == last {
+			q.order[0], q.order[1] = q.order[1], q.order[0]
+		}
+	}
+	q.pos = 0
+	return q.items[q.order[q.pos]], true
+}
+
+func (q *Queue) Previous() (Item, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.order) == 0 {
+		return Item{}, false
+	}
+	if q.pos > 0 {
+		q.pos--
+		return q.items[q.order[q.pos]], true
+	}
+	if n := len(q.back); n > 0 && q.pos == 0 {
+		idx := q.back[n-1]
+		q.back = q.back[:n-1]
+		q.moveToFrontLocked(idx)
+		q.pos = 0
+		return q.items[idx], true
+	}
+	if q.repeat == RepeatAll {
+		q.pos = len(q.order) - 1
+		return q.items[q.order[q.pos]], true
+	}
+	if q.pos < 0 {
+		return Item{}, false
+	}
+	return q.items[q.order[q.pos]], true
+}
+
+func (q *Queue) Enqueue(items ...Item) State {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, it := range items {
+		q.items = append(q.items, it)
+		q.order = append(q.order, len(q.items)-1)
+	}
+	return q.stateLocked()
+}
+
+func (q *Queue) PlayNext(items ...Item) State {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	at := q.pos + 1
+	added := make([]int, 0, len(items))
+	for _, it := range items {
+		q.items = append(q.items, it)
+		added = append(added, len(q.items)-1)
+	}
+	order := make([]int, 0, len(q.order)+len(added))
+	order = append(order, q.order[:at]...)
+	order = append(order, added...)
+	q.order = append(order, q.order[at:]...)
+	return q.stateLocked()
+}
+
+func (q *Queue) RemoveAt(index int) (State, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if index < 0 || index >= len(q.order) {
+		return State{}, fmt.Errorf("queue index %d out of range", index)
+	}
+	removed := q.order[index]
+	q.items = append(q.items[:removed], q.items[removed+1:]...)
+	q.order = append(q.order[:index], q.order[index+1:]...)
+	for i, idx := range q.order {
+		if idx > removed {
+			q.order[i] = idx - 1
+		}
+	}
+	if index <= q.pos {
+		q.pos--
+	}
+	back := q.back[:0]
+	for _, idx := range q.back {
+		switch {
+		case idx == removed:
+		case idx > removed:
+			back = append(back, idx-1)
+		default:
+			back = append(back, idx)
+		}
+	}
+	q.back = back
+	return q.stateLocked(), nil
+}
+
+func (q *Queue) Clear() State {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.items = nil
+	q.order = nil
+	q.back = nil
+	q.pos = -1
+	return q.stateLocked()
+}
+
+func (q *Queue) moveToFrontLocked(idx int) {
+	for i, v := range q.order {
+		if v == idx {
+			copy(q.order[1:i+1], q.order[:i])
+			q.order[0] = idx
+			return
+		}
+	}
+	q.order = append([]int{idx}, q.order...)
+}
+
+func (q *Queue) rebuildLocked(current int) {
+	switch q.shuffle {
+	case ShuffleTracks:
+		q.order = q.weightedOrderLocked(current)
+	case ShuffleAlbums:
+		q.order = q.albumOrderLocked(current)
+	default:
+		q.order = make([]int, len(q.items))
+		for i := range q.order {
+			q.order[i] = i
+		}
+	}
+
+	q.pos = -1
+	if current < 0 {
+		return
+	}
+	for i, idx := range q.order {
+		if idx == current {
+			q.pos = i
+			return
+		}
+	}
+}
+
+func (q *Queue) weightedOrderLocked(current int) []int {
+	order := make([]int, 0, len(q.items))
+	var artists []string
+	recent := make(map[string]struct{})
+
+	for i, it := range q.played {
+		if len(q.played)-i <= q.memory {
+			recent[it.Path] = struct{}{}
+		}
+		artists = append(artists, artistKey(it))
+	}
+
+	if current >= 0 && current < len(q.items) {
+		order = append(order, current)
+		artists = append(artists, artistKey(q.items[current]))
+	}
+
+	var stale []int
+	var keys []string
+	buckets := make(map[string][]int)
+	for _, idx := range q.rng.Perm(len(q.items)) {
+		if idx == current {
+			continue
+		}
+		if _, played := recent[q.items[idx].Path]; played {
+			stale = append(stale, idx)
+			continue
+		}
+		key := artistKey(q.items[idx])
+		if _, ok := buckets[key]; !ok {
+			keys = append(keys, key)
+		}
+		buckets[key] = append(buckets[key], idx)
+	}
+
+	for len(keys) > 0 {
+		blocked := q.spacedArtistsLocked(artists)
+		total, open := 0, 0
+		for _, k := range keys {
+			total += len(buckets[k])
+			if !blocked[k] {
+				open += len(buckets[k])
+			}
+		}
+		n := open
+		if n == 0 {
+			n = total
+		}
+		r := q.rng.Intn(n)
+		pick := 0
+		for i, k := range keys {
+			if open > 0 && blocked[k] {
+				continue
+			}
+			if r < len(buckets[k]) {
+				pick = i
+				break
+			}
+			r -= len(buckets[k])
+		}
+
+		key := keys[pick]
+		bucket := buckets[key]
+		order = append(order, bucket[len(bucket)-1])
+		artists = append(artists, key)
+		if len(bucket) == 1 {
+			delete(buckets, key)
+			keys[pick] = keys[len(keys)-1]
+			keys = keys[:len(keys)-1]
+		} else {
+			buckets[key] = bucket[:len(bucket)-1]
+		}
+	}
+	return append(order, stale...)
+}
+
+func (q *Queue) spacedArtistsLocked(artists []string) map[string]bool {
+	blocked := make(map[string]bool)
+	if q.artistSpacing <= 0 {
+		return blocked
+	}
+	start := len(artists) - q.artistSpacing
+	if start < 0 {
+		start = 0
+	}
+	for _, a := range artists[start:] {
+		if a != "" {
+			blocked[a] = true
+		}
+	}
+	return blocked
+}
+
+func (q *Queue) albumOrderLocked(current int) []int {
+	groups := make(map[string][]int)
+	var keys []string
+	for i, it := range q.items {
+		key := albumKey(it)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+	for _, key := range keys {
+		g := groups[key]
+		sort.SliceStable(g, func(a, b int) bool {
+			ia, ib := q.items[g[a]], q.items[g[b]]
+			if ia.DiscNumber != ib.DiscNumber {
+				return ia.DiscNumber < ib.DiscNumber
+			}
+			if ia.TrackNumber != ib.TrackNumber {
+				return ia.TrackNumber < ib.TrackNumber
+			}
+			return g[a] < g[b]
+		})
+	}
+
+	q.rng.Shuffle(len(keys), func(i, j int) {
+		keys[i], keys[j] = keys[j], keys[i]
+	})
+	if current >= 0 {
+		currentKey := albumKey(q.items[current])
+		for i, key := range keys {
+			if key == currentKey {
+				keys[0], keys[i] = keys[i], keys[0]
+				break
+			}
+		}
+	}
+
+	order := make([]int, 0, len(q.items))
+	for _, key := range keys {
+		order = append(order, groups[key]...)
+	}
+	return order
+}
+
+func (q *Queue) currentIndexLocked() int {
+	if q.pos < 0 || q.pos >= len(q.order) {
+		return -1
+	}
+	return q.order[q.pos]
+}
+
+func (q *Queue) stateLocked() State {
+	items := make([]Item, 0, len(q.order))
+	for _, idx := range q.order {
+		items = append(items, q.items[idx])
+	}
+	return State{
+		Items:       items,
+		Current:     q.pos,
+		Shuffle:     q.shuffle,
+		Repeat:      q.repeat,
+		EndAction:   q.endAction,
+		EndOverride: q.endOverride,
+	}
+}
+
+func artistKey(it Item) string {
+	return strings.ToLower(strings.TrimSpace(it.Artist))
+}
+
+func albumKey(it Item) string {
+	album := strings.ToLower(strings.TrimSpace(it.Album))
+	if album == "" {
+		return "track:" + it.Path
+	}
+	artist := strings.TrimSpace(it.AlbumArtist)
+	if artist == "" {
+		artist = it.Artist
+	}
+	return strings.ToLower(strings.TrimSpace(artist)) + "\x00" + album
+}
@@ -0,0 +1,111 @@
+package playlist
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+type FolderList struct {
+	Folder string   `json:"folder"`
+	Name   string   `json:"name"`
+	Tracks []string `json:"tracks"`
+}
+
+type FolderSync struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Removed int `json:"removed"`
+}
+
+func (r FolderSync) Changed() bool {
+	return r.Created+r.Updated+r.Removed > 0
+}
+
+func (s *Store) SyncFolders(lists []FolderList, keepRoots []string) (FolderSync, error) {
+	var res FolderSync
+	wanted := make(map[string]FolderList, len(lists))
+	for _, l := range lists {
+		wanted[folderKey(l.Folder)] = l
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all, err := s.loadLocked()
+	if err != nil {
+		return res, err
+	}
+	now := time.Now().Unix()
+	kept := make([]Playlist, 0, len(all))
+	seen := make(map[string]bool, len(lists))
+	for _, p := range all {
+		if p.Folder == "" {
+			kept = append(kept, p)
+			continue
+		}
+		key := folderKey(p.Folder)
+		l, ok := wanted[key]
+		if seen[key] || (!ok && !underAny(p.Folder, keepRoots)) {
+			res.Removed++
+			continue
+		}
+		seen[key] = true
+		if ok && (p.Name != l.Name || !sameTracks(p.Tracks, l.Tracks)) {
+			p.Name = l.Name
+			p.Tracks = append([]string{}, l.Tracks...)
+			p.UpdatedAt = now
+			res.Updated++
+		}
+		kept = append(kept, p)
+	}
+	for _, l := range lists {
+		key := folderKey(l.Folder)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		p, err := newPlaylist(l.Name)
+		if err != nil {
+			return res, err
+		}
+		p.Folder = l.Folder
+		p.Tracks = append(p.Tracks, l.Tracks...)
+		kept = append(kept, p)
+		res.Created++
+	}
+	if !res.Changed() {
+		return res, nil
+	}
+	return res, s.saveLocked(kept)
+}
+
+func folderKey(dir string) string {
+	dir = filepath.Clean(dir)
+	if runtime.GOOS == "windows" {
+		dir = strings.ToLower(dir)
+	}
+	return dir
+}
+
+func underAny(dir string, roots []string) bool {
+	for _, root := range roots {
+		rel, err := filepath.Rel(root, dir)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameTracks(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
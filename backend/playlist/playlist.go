@@ -0,0 +1,334 @@
+package playlist
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Playlist struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Tracks    []string          `json:"tracks"`
+	CreatedAt int64             `json:"createdAt"`
+	UpdatedAt int64             `json:"updatedAt"`
+	Remote    map[string]Remote `json:"remote,omitempty"`
+	Folder    string            `json:"folder,omitempty"`
+}
+
+type Remote struct {
+	ID       string            `json:"id"`
+	URL      string            `json:"url"`
+	Tracks   map[string]string `json:"tracks,omitempty"`
+	PushedAt int64             `json:"pushedAt,omitempty"`
+}
+
+type Store struct {
+	mu   sync.Mutex
+	path string
+}
+
+var ErrNotFound = errors.New("playlist not found")
+
+func NewStore() *Store {
+	return &Store{path: storePath()}
+}
+
+func storePath() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil || configDir == "" {
+		return "kitty_playlists.json"
+	}
+	return filepath.Join(configDir, "Kitty", "playlists.json")
+}
+
+func (s *Store) Path() string {
+	return s.path
+}
+
+func (s *Store) List() ([]Playlist, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loadLocked()
+}
+
+func (s *Store) Get(id string) (*Playlist, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lists, err := s.loadLocked()
+	if err != nil {
+		return nil, err
+	}
+	idx := indexOf(lists, id)
+	if idx < 0 {
+		return nil, ErrNotFound
+	}
+	p := lists[idx]
+	return &p, nil
+}
+
+func (s *Store) Create(name string) (*Playlist, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("playlist name is empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lists, err := s.loadLocked()
+	if err != nil {
+		return nil, err
+	}
+	p, err := newPlaylist(name)
+	if err != nil {
+		return nil, err
+	}
+	lists = append(lists, p)
+	if err := s.saveLocked(lists); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (s *Store) Resolve(ref string) (*Playlist, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, errors.New("playlist reference is empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lists, err := s.loadLocked()
+	if err != nil {
+		return nil, err
+	}
+	if idx := indexOf(lists, ref); idx >= 0 {
+		p := lists[idx]
+		return &p, nil
+	}
+	for _, p := range lists {
+		if strings.EqualFold(strings.TrimSpace(p.Name), ref) {
+			return &p, nil
+		}
+	}
+
+	p, err := newPlaylist(ref)
+	if err != nil {
+		return nil, err
+	}
+	lists = append(lists, p)
+	if err := s.saveLocked(lists); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (s *Store) Rename(id, name string) (*Playlist, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("playlist name is empty")
+	}
+	return s.update(id, func(p *Playlist) {
+		p.Name = name
+	})
+}
+
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lists, err := s.loadLocked()
+	if err != nil {
+		return err
+	}
+	idx := indexOf(lists, id)
+	if idx < 0 {
+		return ErrNotFound
+	}
+	lists = append(lists[:idx], lists[idx+1:]...)
+	return s.saveLocked(lists)
+}
+
+func (s *Store) AddTracks(id string, paths []string) (*Playlist, error) {
+	return s.update(id, func(p *Playlist) {
+		seen := make(map[string]struct{}, len(p.Tracks))
+		for _, t := range p.Tracks {
+			seen[t] = struct{}{}
+		}
+		for _, path := range paths {
+			path = strings.TrimSpace(path)
+			if path == "" {
+				continue
+			}
+			if _, ok := seen[path]; ok {
+				continue
+			}
+			seen[path] = struct{}{}
+			p.Tracks = append(p.Tracks, path)
+		}
+	})
+}
+
+func (s *Store) RemoveTracks(id string, paths []string) (*Playlist, error) {
+	drop := make(map[string]struct{}, len(paths))
+	for _, p := range paths {
+		drop[p] = struct{}{}
+	}
+	return s.update(id, func(p *Playlist) {
+		out := p.Tracks[:0]
+		for _, t := range p.Tracks {
+			if _, ok := drop[t]; !ok {
+				out = append(out, t)
+			}
+		}
+		p.Tracks = out
+	})
+}
+
+func (s *Store) SetTracks(id string, paths []string) (*Playlist, error) {
+	return s.update(id, func(p *Playlist) {
+		p.Tracks = append([]string{}, paths...)
+	})
+}
+
+func (s *Store) ReplacePath(oldPath, newPath string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lists, err := s.loadLocked()
+	if err != nil {
+		return 0, err
+	}
+	changed := 0
+	for i := range lists {
+		hit := false
+		for j, t := range lists[i].Tracks {
+			if t == oldPath {
+				lists[i].Tracks[j] = newPath
+				hit = true
+			}
+		}
+		for provider, r := range lists[i].Remote {
+			if id, ok := r.Tracks[oldPath]; ok {
+				delete(r.Tracks, oldPath)
+				r.Tracks[newPath] = id
+				lists[i].Remote[provider] = r
+				hit = true
+			}
+		}
+		if hit {
+			lists[i].UpdatedAt = time.Now().Unix()
+			changed++
+		}
+	}
+	if changed == 0 {
+		return 0, nil
+	}
+	return changed, s.saveLocked(lists)
+}
+
+func (s *Store) SetRemote(id, provider string, fn func(r *Remote)) (*Playlist, error) {
+	return s.update(id, func(p *Playlist) {
+		if p.Remote == nil {
+			p.Remote = map[string]Remote{}
+		}
+		r := p.Remote[provider]
+		if r.Tracks == nil {
+			r.Tracks = map[string]string{}
+		}
+		fn(&r)
+		p.Remote[provider] = r
+	})
+}
+
+func (s *Store) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *Store) update(id string, fn func(p *Playlist)) (*Playlist, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lists, err := s.loadLocked()
+	if err != nil {
+		return nil, err
+	}
+	idx := indexOf(lists, id)
+	if idx < 0 {
+		return nil, ErrNotFound
+	}
+	fn(&lists[idx])
+	lists[idx].UpdatedAt = time.Now().Unix()
+	if err := s.saveLocked(lists); err != nil {
+		return nil, err
+	}
+	p := lists[idx]
+	return &p, nil
+}
+
+func (s *Store) loadLocked() ([]Playlist, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Playlist{}, nil
+		}
+		return nil, err
+	}
+	if strings.TrimSpace(string(data)) == "" {
+		return []Playlist{}, nil
+	}
+	var lists []Playlist
+	if err := json.Unmarshal(data, &lists); err != nil {
+		return nil, err
+	}
+	for i := range lists {
+		if lists[i].Tracks == nil {
+			lists[i].Tracks = []string{}
+		}
+	}
+	return lists, nil
+}
+
+func (s *Store) saveLocked(lists []Playlist) error {
+	data, err := json.Marshal(lists)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0o644)
+}
+
+func newPlaylist(name string) (Playlist, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return Playlist{}, err
+	}
+	now := time.Now().Unix()
+	return Playlist{
+		ID:        hex.EncodeToString(buf),
+		Name:      name,
+		Tracks:    []string{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+func indexOf(lists []Playlist, id string) int {
+	id = strings.TrimSpace(id)
+	for i := range lists {
+		if lists[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
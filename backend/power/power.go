@@ -0,0 +1,166 @@
+package power
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	PolicyAuto    = "auto"
+	PolicyBattery = "battery"
+	PolicyOff     = "off"
+
+	DefaultBatteryThreshold = 50
+	PollInterval            = 30 * time.Second
+)
+
+type Status struct {
+	Known     bool `json:"known"`
+	OnBattery bool `json:"onBattery"`
+	Percent   int  `json:"percent"`
+	LowPower  bool `json:"lowPower"`
+}
+
+type Policy struct {
+	Mode             string `json:"mode"`
+	BatteryThreshold int    `json:"batteryThreshold"`
+}
+
+type State struct {
+	Status Status `json:"status"`
+	Policy Policy `json:"policy"`
+	Paused bool   `json:"paused"`
+	Reason string `json:"reason,omitempty"`
+}
+
+func NormalizePolicy(p Policy) (Policy, error) {
+	mode := strings.ToLower(strings.TrimSpace(p.Mode))
+	switch mode {
+	case "":
+		mode = PolicyAuto
+	case PolicyAuto, PolicyBattery, PolicyOff:
+	default:
+		return Policy{}, fmt.Errorf("unknown power policy: %s", p.Mode)
+	}
+	if p.BatteryThreshold < 0 || p.BatteryThreshold > 100 {
+		return Policy{}, fmt.Errorf("battery threshold must be between 0 and 100")
+	}
+	if p.BatteryThreshold == 0 {
+		p.BatteryThreshold = DefaultBatteryThreshold
+	}
+	p.Mode = mode
+	return p, nil
+}
+
+func (p Policy) Reason(s Status) string {
+	if !s.Known || p.Mode == PolicyOff {
+		return ""
+	}
+	if s.LowPower {
+		return "low power mode is on"
+	}
+	if !s.OnBattery {
+		return ""
+	}
+	if p.Mode == PolicyBattery {
+		return "running on battery"
+	}
+	if s.Percent >= 0 && s.Percent < p.BatteryThreshold {
+		return fmt.Sprintf("battery at %d%%", s.Percent)
+	}
+	return ""
+}
+
+type Governor struct {
+	mu     sync.Mutex
+	policy Policy
+	status Status
+	reason string
+	resume chan struct{}
+}
+
+func NewGovernor() *Governor {
+	return &Governor{
+		policy: Policy{Mode: PolicyAuto, BatteryThreshold: DefaultBatteryThreshold},
+		resume: make(chan struct{}),
+	}
+}
+
+func (g *Governor) SetPolicy(p Policy) {
+	g.mu.Lock()
+	g.policy = p
+	g.applyLocked()
+	g.mu.Unlock()
+}
+
+func (g *Governor) State() State {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return State{Status: g.status, Policy: g.policy, Paused: g.reason != "", Reason: g.reason}
+}
+
+func (g *Governor) Refresh() State {
+	status, err := readStatus()
+	if err != nil {
+		log.Printf("[power] read status failed: %v", err)
+		status = Status{}
+	}
+	g.mu.Lock()
+	g.status = status
+	g.applyLocked()
+	g.mu.Unlock()
+	return g.State()
+}
+
+func (g *Governor) applyLocked() {
+	was := g.reason
+	g.reason = g.policy.Reason(g.status)
+	if was != "" && g.reason == "" {
+		close(g.resume)
+		g.resume = make(chan struct{})
+	}
+}
+
+func (g *Governor) Watch(ctx context.Context, onChange func(State)) {
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+	last := g.Refresh()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		st := g.Refresh()
+		if st.Paused != last.Paused || st.Reason != last.Reason {
+			log.Printf("[power] paused=%v %s", st.Paused, st.Reason)
+			if onChange != nil {
+				onChange(st)
+			}
+		}
+		last = st
+	}
+}
+
+func (g *Governor) Wait(ctx context.Context, onPause func(reason string)) error {
+	for {
+		g.mu.Lock()
+		reason, resume := g.reason, g.resume
+		g.mu.Unlock()
+		if reason == "" {
+			return nil
+		}
+		if onPause != nil {
+			onPause(reason)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-resume:
+		}
+	}
+}
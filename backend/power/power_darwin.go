@@ -0,0 +1,34 @@
+package power
+
+import (
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var battPercent = regexp.MustCompile(`(\d+)%`)
+
+func readStatus() (Status, error) {
+	out, err := exec.Command("pmset", "-g", "batt").Output()
+	if err != nil {
+		return Status{}, err
+	}
+	text := string(out)
+	st := Status{Known: true, Percent: -1}
+	st.OnBattery = strings.Contains(text, "'Battery Power'")
+	if m := battPercent.FindStringSubmatch(text); m != nil {
+		st.Percent, _ = strconv.Atoi(m[1])
+	} else {
+		st.OnBattery = false
+	}
+	if settings, err := exec.Command("pmset", "-g").Output(); err == nil {
+		for _, line := range strings.Split(string(settings), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) == 2 && fields[0] == "lowpowermode" {
+				st.LowPower = fields[1] == "1"
+			}
+		}
+	}
+	return st, nil
+}
@@ -0,0 +1,55 @@
+//go:build !darwin && !windows
+
+package power
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const powerSupplyDir = "/sys/class/power_supply"
+
+func readStatus() (Status, error) {
+	entries, err := os.ReadDir(powerSupplyDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Status{}, nil
+		}
+		return Status{}, err
+	}
+	st := Status{Known: true, Percent: -1}
+	mains, online, battery := false, false, false
+	for _, e := range entries {
+		dir := filepath.Join(powerSupplyDir, e.Name())
+		switch readValue(dir, "type") {
+		case "Mains":
+			mains = true
+			if readValue(dir, "online") == "1" {
+				online = true
+			}
+		case "Battery":
+			battery = true
+			if n, err := strconv.Atoi(readValue(dir, "capacity")); err == nil {
+				st.Percent = n
+			}
+			if readValue(dir, "status") == "Discharging" {
+				st.OnBattery = true
+			}
+		}
+	}
+	if mains && !online && battery {
+		st.OnBattery = true
+	}
+	st.LowPower = readValue("/sys/firmware/acpi", "platform_profile") == "low-power"
+	return st, nil
+}
+
+func readValue(dir, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,31 @@
+package power
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+type systemPowerStatus struct {
+	ACLineStatus        byte
+	BatteryFlag         byte
+	BatteryLifePercent  byte
+	SystemStatusFlag    byte
+	BatteryLifeTime     uint32
+	BatteryFullLifeTime uint32
+}
+
+var procGetSystemPowerStatus = syscall.NewLazyDLL("kernel32.dll").NewProc("GetSystemPowerStatus")
+
+func readStatus() (Status, error) {
+	var sps systemPowerStatus
+	if r, _, err := procGetSystemPowerStatus.Call(uintptr(unsafe.Pointer(&sps))); r == 0 {
+		return Status{}, err
+	}
+	st := Status{Known: true, Percent: -1}
+	if sps.BatteryFlag&128 == 0 && sps.BatteryLifePercent != 255 {
+		st.Percent = int(sps.BatteryLifePercent)
+		st.OnBattery = sps.ACLineStatus == 0
+	}
+	st.LowPower = sps.SystemStatusFlag == 1
+	return st, nil
+}
@@ -0,0 +1,126 @@
+package selftest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+
+	probeTimeout = 10 * time.Second
+)
+
+type Warning string
+
+func (w Warning) Error() string { return string(w) }
+
+func Warnf(format string, args ...interface{}) error {
+	return Warning(fmt.Sprintf(format, args...))
+}
+
+type Probe struct {
+	Name       string
+	Suggestion string
+	Run        func(ctx context.Context) (string, error)
+}
+
+type Check struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Detail     string `json:"detail,omitempty"`
+	Suggestion string `json:"suggestion,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+type Report struct {
+	StartedAt  time.Time `json:"startedAt"`
+	DurationMs int64     `json:"durationMs"`
+	OK         bool      `json:"ok"`
+	Warnings   int       `json:"warnings"`
+	Failures   int       `json:"failures"`
+	Checks     []Check   `json:"checks"`
+}
+
+func Run(ctx context.Context, probes []Probe) Report {
+	report := Report{StartedAt: time.Now(), Checks: make([]Check, 0, len(probes))}
+	for _, p := range probes {
+		check := run(ctx, p)
+		switch check.Status {
+		case StatusWarn:
+			report.Warnings++
+		case StatusFail:
+			report.Failures++
+		}
+		report.Checks = append(report.Checks, check)
+	}
+	report.OK = report.Failures == 0
+	report.DurationMs = time.Since(report.StartedAt).Milliseconds()
+	return report
+}
+
+func run(ctx context.Context, p Probe) Check {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	start := time.Now()
+	type result struct {
+		detail string
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		detail, err := p.Run(ctx)
+		done <- result{detail, err}
+	}()
+
+	var r result
+	select {
+	case r = <-done:
+	case <-ctx.Done():
+		r.err = fmt.Errorf("timed out after %s", probeTimeout)
+	}
+	check := Check{Name: p.Name, Status: StatusOK, Detail: r.detail, DurationMs: time.Since(start).Milliseconds()}
+	var warn Warning
+	switch {
+	case r.err == nil:
+	case errors.As(r.err, &warn):
+		check.Status = StatusWarn
+		check.Detail = r.err.Error()
+		check.Suggestion = p.Suggestion
+	default:
+		check.Status = StatusFail
+		check.Detail = r.err.Error()
+		check.Suggestion = p.Suggestion
+	}
+	return check
+}
+
+func Writable(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".kitty-selftest-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	_, werr := f.Write([]byte("ok"))
+	cerr := f.Close()
+	rerr := os.Remove(name)
+	if werr != nil {
+		return werr
+	}
+	if cerr != nil {
+		return cerr
+	}
+	if rerr != nil {
+		return fmt.Errorf("remove %s: %w", filepath.Base(name), rerr)
+	}
+	return nil
+}
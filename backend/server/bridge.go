@@ -0,0 +1,70 @@
+package server
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const bridgePath = "/kitty-bridge.js"
+
+//go:embed bridge.js
+var bridgeJS []byte
+
+func serveBridge(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	_, _ = w.Write(bridgeJS)
+}
+
+func (s *Server) serveAssets() http.Handler {
+	files := http.FileServer(http.FS(s.assets))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" && r.URL.Path != "/index.html" {
+			files.ServeHTTP(w, r)
+			return
+		}
+		index, err := fs.ReadFile(s.assets, "index.html")
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-cache")
+		_, _ = w.Write(injectBridge(index))
+	})
+}
+
+func injectBridge(index []byte) []byte {
+	tag := []byte(`<script src="` + bridgePath + `"></script>`)
+	at := bytes.Index(index, []byte("<script"))
+	if at < 0 {
+		at = bytes.Index(index, []byte("</head>"))
+	}
+	if at < 0 {
+		return append(tag, index...)
+	}
+	out := make([]byte, 0, len(index)+len(tag))
+	out = append(out, index[:at]...)
+	out = append(out, tag...)
+	return append(out, index[at:]...)
+}
+
+func checkOrigin(r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return errors.New("invalid origin")
+	}
+	if !strings.EqualFold(u.Host, r.Host) {
+		return errors.New("cross-origin websocket connections are not allowed")
+	}
+	return nil
+}
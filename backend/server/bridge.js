@@ -0,0 +1,217 @@
+(function () {
+  if (window.go && window.go.main && window.go.main.App) {
+    return;
+  }
+
+  var tokenKey = 'kitty.token';
+  var params = new URLSearchParams(window.location.search);
+  var token = params.get('token') || window.sessionStorage.getItem(tokenKey) || '';
+  if (params.has('token')) {
+    window.sessionStorage.setItem(tokenKey, token);
+    params.delete('token');
+    var query = params.toString();
+    window.history.replaceState(null, '', window.location.pathname + (query ? '?' + query : '') + window.location.hash);
+  }
+
+  function askToken() {
+    var entered = window.prompt('Kitty server token');
+    if (entered) {
+      token = entered.trim();
+      window.sessionStorage.setItem(tokenKey, token);
+    }
+    return !!entered;
+  }
+
+  function call(method, args) {
+    return fetch('/api/call/' + encodeURIComponent(method), {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json', Authorization: 'Bearer ' + token },
+      body: JSON.stringify(args),
+    }).then(function (res) {
+      return res.json().then(function (body) {
+        if (res.status === 401 && askToken()) {
+          connect();
+          return call(method, args);
+        }
+        if (body && body.error) {
+          var err = new Error(body.error.message || body.error.code || 'request failed');
+          err.code = body.error.code;
+          throw err;
+        }
+        return body ? body.result : undefined;
+      });
+    });
+  }
+
+  var App = new Proxy({}, {
+    get: function (target, name) {
+      if (typeof name !== 'string') {
+        return undefined;
+      }
+      if (!target[name]) {
+        target[name] = function () {
+          return call(name, Array.prototype.slice.call(arguments));
+        };
+      }
+      return target[name];
+    },
+  });
+  window.go = { main: { App: App } };
+
+  var listeners = {};
+
+  function dispatch(eventName, data) {
+    var list = listeners[eventName];
+    if (!list) {
+      return;
+    }
+    list.slice().forEach(function (l) {
+      if (l.remaining === 0) {
+        return;
+      }
+      if (l.remaining > 0) {
+        l.remaining--;
+      }
+      try {
+        l.callback.apply(null, data || []);
+      } catch (e) {
+        console.error(e);
+      }
+    });
+    listeners[eventName] = list.filter(function (l) {
+      return l.remaining !== 0;
+    });
+  }
+
+  var socket = null;
+  var retryDelay = 500;
+
+  function connect() {
+    if (socket) {
+      socket.onclose = null;
+      socket.close();
+    }
+    var scheme = window.location.protocol === 'https:' ? 'wss://' : 'ws://';
+    socket = new WebSocket(scheme + window.location.host + '/api/ws?token=' + encodeURIComponent(token));
+    socket.onopen = function () {
+      retryDelay = 500;
+    };
+    socket.onmessage = function (msg) {
+      var payload;
+      try {
+        payload = JSON.parse(msg.data);
+      } catch (e) {
+        return;
+      }
+      if (payload && payload.event) {
+        dispatch(payload.event, payload.data);
+      }
+    };
+    socket.onclose = function () {
+      socket = null;
+      setTimeout(connect, retryDelay);
+      retryDelay = Math.min(retryDelay * 2, 10000);
+    };
+  }
+  connect();
+
+  function noop() {}
+
+  function log(fn) {
+    return function (message) {
+      fn.call(console, message);
+    };
+  }
+
+  window.runtime = {
+    LogPrint: log(console.log),
+    LogTrace: log(console.debug),
+    LogDebug: log(console.debug),
+    LogInfo: log(console.info),
+    LogWarning: log(console.warn),
+    LogError: log(console.error),
+    LogFatal: log(console.error),
+    EventsOnMultiple: function (eventName, callback, maxCallbacks) {
+      var l = { callback: callback, remaining: maxCallbacks };
+      (listeners[eventName] = listeners[eventName] || []).push(l);
+      return function () {
+        listeners[eventName] = (listeners[eventName] || []).filter(function (x) {
+          return x !== l;
+        });
+      };
+    },
+    EventsOff: function () {
+      Array.prototype.slice.call(arguments).forEach(function (eventName) {
+        delete listeners[eventName];
+      });
+    },
+    EventsOffAll: function () {
+      listeners = {};
+    },
+    EventsEmit: function (eventName) {
+      dispatch(eventName, Array.prototype.slice.call(arguments, 1));
+    },
+    Environment: function () {
+      return Promise.resolve({ buildType: 'production', platform: 'web', arch: '' });
+    },
+    BrowserOpenURL: function (url) {
+      window.open(url, '_blank', 'noopener');
+    },
+    ClipboardGetText: function () {
+      return navigator.clipboard ? navigator.clipboard.readText() : Promise.resolve('');
+    },
+    ClipboardSetText: function (text) {
+      if (!navigator.clipboard) {
+        return Promise.resolve(false);
+      }
+      return navigator.clipboard.writeText(text).then(function () {
+        return true;
+      });
+    },
+    OnFileDrop: noop,
+    OnFileDropOff: noop,
+    CanResolveFilePaths: function () {
+      return false;
+    },
+    ResolveFilePaths: noop,
+    WindowReload: function () {
+      window.location.reload();
+    },
+    WindowReloadApp: function () {
+      window.location.reload();
+    },
+    WindowSetTitle: function (title) {
+      document.title = title;
+    },
+    WindowIsFullscreen: function () {
+      return Promise.resolve(!!document.fullscreenElement);
+    },
+    WindowIsMaximised: function () {
+      return Promise.resolve(false);
+    },
+    WindowIsMinimised: function () {
+      return Promise.resolve(false);
+    },
+    WindowIsNormal: function () {
+      return Promise.resolve(true);
+    },
+    WindowGetSize: function () {
+      return Promise.resolve({ w: window.innerWidth, h: window.innerHeight });
+    },
+    WindowGetPosition: function () {
+      return Promise.resolve({ x: 0, y: 0 });
+    },
+    ScreenGetAll: function () {
+      return Promise.resolve([]);
+    },
+  };
+  [
+    'WindowSetAlwaysOnTop', 'WindowSetSystemDefaultTheme', 'WindowSetLightTheme', 'WindowSetDarkTheme',
+    'WindowCenter', 'WindowFullscreen', 'WindowUnfullscreen', 'WindowSetSize', 'WindowSetMaxSize',
+    'WindowSetMinSize', 'WindowSetPosition', 'WindowHide', 'WindowShow', 'WindowMaximise',
+    'WindowToggleMaximise', 'WindowUnmaximise', 'WindowMinimise', 'WindowUnminimise',
+    'WindowSetBackgroundColour', 'Quit', 'Hide', 'Show',
+  ].forEach(function (name) {
+    window.runtime[name] = noop;
+  });
+})();
@@ -0,0 +1,322 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"kitty/backend/apperror"
+)
+
+const (
+	DefaultAddr  = ":17878"
+	maxCallBytes = 16 << 20
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+type Event struct {
+	Event string        `json:"event"`
+	Data  []interface{} `json:"data"`
+}
+
+type callRequest struct {
+	ID     interface{}       `json:"id,omitempty"`
+	Method string            `json:"method"`
+	Args   []json.RawMessage `json:"args"`
+}
+
+type callResponse struct {
+	ID     interface{}        `json:"id,omitempty"`
+	Result interface{}        `json:"result"`
+	Error  *apperror.AppError `json:"error,omitempty"`
+}
+
+type Server struct {
+	token   string
+	target  reflect.Value
+	methods map[string]reflect.Method
+	assets  fs.FS
+	media   http.Handler
+
+	mu      sync.Mutex
+	clients map[*wsConn]struct{}
+}
+
+func New(target interface{}, token string, assets fs.FS) *Server {
+	s := &Server{
+		token:   strings.TrimSpace(token),
+		target:  reflect.ValueOf(target),
+		methods: make(map[string]reflect.Method),
+		assets:  assets,
+		clients: make(map[*wsConn]struct{}),
+	}
+	t := s.target.Type()
+	for i := 0; i < t.NumMethod(); i++ {
+		m := t.Method(i)
+		if m.Type.NumOut() > 2 {
+			continue
+		}
+		s.methods[m.Name] = m
+	}
+	return s
+}
+
+func (s *Server) SetMediaHandler(h http.Handler) {
+	s.media = h
+}
+
+func GenerateToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func (s *Server) Methods() []string {
+	names := make([]string, 0, len(s.methods))
+	for name := range s.methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	if strings.TrimSpace(addr) == "" {
+		addr = DefaultAddr
+	}
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		s.closeClients()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	log.Printf("[server] listening on %s", addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/methods", s.auth(s.handleMethods))
+	mux.HandleFunc("/api/call/", s.auth(s.handleCall))
+	mux.HandleFunc("/api/ws", s.auth(s.handleWS))
+	if s.media != nil {
+		mux.HandleFunc(MediaPrefix, s.auth(s.media.ServeHTTP))
+	}
+	if s.assets != nil {
+		mux.HandleFunc(bridgePath, serveBridge)
+		mux.Handle("/", s.serveAssets())
+	}
+	return mux
+}
+
+func (s *Server) Broadcast(event string, data ...interface{}) {
+	if data == nil {
+		data = []interface{}{}
+	}
+	raw, err := json.Marshal(Event{Event: event, Data: data})
+	if err != nil {
+		log.Printf("[server] encode event %s failed: %v", event, err)
+		return
+	}
+	s.mu.Lock()
+	clients := make([]*wsConn, 0, len(s.clients))
+	for c := range s.clients {
+		clients = append(clients, c)
+	}
+	s.mu.Unlock()
+	for _, c := range clients {
+		if err := c.writeText(raw); err != nil {
+			s.drop(c)
+		}
+	}
+}
+
+func (s *Server) auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.token != "" {
+			got := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+			if got == "" {
+				got = r.URL.Query().Get("token")
+			}
+			if subtle.ConstantTimeCompare([]byte(got), []byte(s.token)) != 1 {
+				writeJSON(w, http.StatusUnauthorized, callResponse{Error: apperror.New(apperror.CodeUnauthorized, "invalid or missing token")})
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) handleMethods(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Methods())
+}
+
+func (s *Server) handleCall(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, callResponse{Error: apperror.Invalid("use POST")})
+		return
+	}
+	req := callRequest{Method: strings.TrimPrefix(r.URL.Path, "/api/call/")}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxCallBytes))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, callResponse{Error: apperror.From(err)})
+		return
+	}
+	if strings.TrimSpace(string(body)) != "" {
+		if err := json.Unmarshal(body, &req.Args); err != nil {
+			writeJSON(w, http.StatusBadRequest, callResponse{Error: apperror.Invalid(fmt.Sprintf("arguments must be a JSON array: %v", err))})
+			return
+		}
+	}
+	res := s.call(req)
+	status := http.StatusOK
+	if res.Error != nil {
+		switch res.Error.Code {
+		case apperror.CodeNotFound:
+			status = http.StatusNotFound
+		case apperror.CodeInvalidInput:
+			status = http.StatusBadRequest
+		default:
+			status = http.StatusInternalServerError
+		}
+	}
+	writeJSON(w, status, res)
+}
+
+func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
+	if err := checkOrigin(r); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	c, err := upgrade(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	s.clients[c] = struct{}{}
+	s.mu.Unlock()
+	log.Printf("[server] client connected %s", r.RemoteAddr)
+	defer func() {
+		s.drop(c)
+		log.Printf("[server] client disconnected %s", r.RemoteAddr)
+	}()
+
+	for {
+		msg, err := c.readMessage()
+		if err != nil {
+			return
+		}
+		var req callRequest
+		if err := json.Unmarshal(msg, &req); err != nil {
+			_ = c.writeJSON(callResponse{Error: apperror.Invalid(fmt.Sprintf("invalid call: %v", err))})
+			continue
+		}
+		go func(req callRequest) {
+			if err := c.writeJSON(s.call(req)); err != nil {
+				s.drop(c)
+			}
+		}(req)
+	}
+}
+
+func (s *Server) call(req callRequest) (res callResponse) {
+	res.ID = req.ID
+	m, ok := s.methods[req.Method]
+	if !ok {
+		res.Error = apperror.NotFound(fmt.Sprintf("unknown method: %s", req.Method))
+		return res
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("[server] %s panicked: %v", req.Method, p)
+			res.Result = nil
+			res.Error = apperror.New(apperror.CodeInternal, fmt.Sprintf("%s failed: %v", req.Method, p))
+		}
+	}()
+
+	numIn := m.Type.NumIn() - 1
+	if len(req.Args) > numIn {
+		res.Error = apperror.Invalid(fmt.Sprintf("%s takes %d arguments, got %d", req.Method, numIn, len(req.Args)))
+		return res
+	}
+	args := make([]reflect.Value, 0, numIn+1)
+	args = append(args, s.target)
+	for i := 0; i < numIn; i++ {
+		pt := m.Type.In(i + 1)
+		v := reflect.New(pt)
+		if i < len(req.Args) && len(req.Args[i]) > 0 {
+			if err := json.Unmarshal(req.Args[i], v.Interface()); err != nil {
+				res.Error = apperror.Invalid(fmt.Sprintf("argument %d of %s: %v", i+1, req.Method, err))
+				return res
+			}
+		}
+		args = append(args, v.Elem())
+	}
+
+	out := m.Func.Call(args)
+	for _, o := range out {
+		if o.Type().Implements(errorType) {
+			if !o.IsNil() {
+				res.Error = apperror.From(o.Interface().(error))
+			}
+			continue
+		}
+		res.Result = o.Interface()
+	}
+	return res
+}
+
+func (s *Server) drop(c *wsConn) {
+	s.mu.Lock()
+	_, ok := s.clients[c]
+	delete(s.clients, c)
+	s.mu.Unlock()
+	if ok {
+		_ = c.Close()
+	}
+}
+
+func (s *Server) closeClients() {
+	s.mu.Lock()
+	clients := s.clients
+	s.clients = make(map[*wsConn]struct{})
+	s.mu.Unlock()
+	for c := range clients {
+		_ = c.Close()
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,178 @@
+package shortcuts
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	ScopeApp    = "app"
+	ScopeGlobal = "global"
+)
+
+type Shortcut struct {
+	Action   string `json:"action"`
+	Label    string `json:"label"`
+	Keys     string `json:"keys"`
+	Default  string `json:"default"`
+	Scope    string `json:"scope"`
+	Reserved bool   `json:"reserved"`
+}
+
+var defaults = []Shortcut{
+	{Action: "playback.toggle", Label: "Play / pause", Default: "Space", Scope: ScopeApp},
+	{Action: "playback.next", Label: "Next track", Default: "CmdOrCtrl+Right", Scope: ScopeApp},
+	{Action: "playback.previous", Label: "Previous track", Default: "CmdOrCtrl+Left", Scope: ScopeApp},
+	{Action: "playback.stop", Label: "Stop", Default: "CmdOrCtrl+.", Scope: ScopeApp},
+	{Action: "playback.seekForward", Label: "Seek forward", Default: "Shift+Right", Scope: ScopeApp},
+	{Action: "playback.seekBack", Label: "Seek back", Default: "Shift+Left", Scope: ScopeApp},
+	{Action: "volume.up", Label: "Volume up", Default: "CmdOrCtrl+Up", Scope: ScopeApp},
+	{Action: "volume.down", Label: "Volume down", Default: "CmdOrCtrl+Down", Scope: ScopeApp},
+	{Action: "volume.mute", Label: "Mute", Default: "CmdOrCtrl+M", Scope: ScopeApp},
+	{Action: "library.search", Label: "Search library", Default: "CmdOrCtrl+F", Scope: ScopeApp},
+	{Action: "library.import", Label: "Import files", Default: "CmdOrCtrl+O", Scope: ScopeApp},
+	{Action: "download.open", Label: "Download from link", Default: "CmdOrCtrl+D", Scope: ScopeApp},
+	{Action: "app.settings", Label: "Open settings", Default: "CmdOrCtrl+,", Scope: ScopeApp},
+	{Action: "app.quit", Label: "Quit Kitty", Default: "CmdOrCtrl+Q", Scope: ScopeApp, Reserved: true},
+	{Action: "global.toggle", Label: "Play / pause (system)", Default: "MediaPlayPause", Scope: ScopeGlobal, Reserved: true},
+	{Action: "global.next", Label: "Next track (system)", Default: "MediaNextTrack", Scope: ScopeGlobal, Reserved: true},
+	{Action: "global.previous", Label: "Previous track (system)", Default: "MediaPreviousTrack", Scope: ScopeGlobal, Reserved: true},
+	{Action: "global.show", Label: "Show Kitty", Default: "", Scope: ScopeGlobal},
+}
+
+var modifierOrder = []string{"CmdOrCtrl", "Cmd", "Ctrl", "Alt", "Shift", "Super"}
+
+var modifierAliases = map[string]string{
+	"cmdorctrl":        "CmdOrCtrl",
+	"commandorcontrol": "CmdOrCtrl",
+	"cmd":              "Cmd",
+	"command":          "Cmd",
+	"ctrl":             "Ctrl",
+	"control":          "Ctrl",
+	"alt":              "Alt",
+	"option":           "Alt",
+	"shift":            "Shift",
+	"super":            "Super",
+	"meta":             "Super",
+	"win":              "Super",
+}
+
+var namedKeys = map[string]string{}
+
+func init() {
+	for _, k := range []string{
+		"Space", "Enter", "Escape", "Tab", "Backspace", "Delete", "Insert",
+		"Up", "Down", "Left", "Right", "Home", "End", "PageUp", "PageDown",
+		"MediaPlayPause", "MediaNextTrack", "MediaPreviousTrack", "MediaStop",
+		"VolumeUp", "VolumeDown", "VolumeMute", "Plus",
+	} {
+		namedKeys[strings.ToLower(k)] = k
+	}
+	for i := 1; i <= 24; i++ {
+		k := fmt.Sprintf("F%d", i)
+		namedKeys[strings.ToLower(k)] = k
+	}
+	namedKeys["esc"] = "Escape"
+	namedKeys["return"] = "Enter"
+}
+
+func Defaults() []Shortcut {
+	return Resolve(nil)
+}
+
+func Resolve(overrides map[string]string) []Shortcut {
+	out := make([]Shortcut, len(defaults))
+	for i, s := range defaults {
+		s.Keys = s.Default
+		if keys, ok := overrides[s.Action]; ok && !s.Reserved {
+			if norm, err := NormalizeKeys(keys); err == nil {
+				s.Keys = norm
+			}
+		}
+		out[i] = s
+	}
+	return out
+}
+
+func Validate(overrides map[string]string) (map[string]string, error) {
+	byAction := make(map[string]Shortcut, len(defaults))
+	for _, s := range defaults {
+		byAction[s.Action] = s
+	}
+	clean := map[string]string{}
+	for action, keys := range overrides {
+		s, ok := byAction[action]
+		if !ok {
+			return nil, fmt.Errorf("unknown shortcut action: %s", action)
+		}
+		norm, err := NormalizeKeys(keys)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s.Label, err)
+		}
+		if norm == s.Default {
+			continue
+		}
+		if s.Reserved {
+			return nil, fmt.Errorf("%s is reserved and cannot be changed", s.Label)
+		}
+		clean[action] = norm
+	}
+
+	used := map[string]Shortcut{}
+	resolved := Resolve(clean)
+	sort.SliceStable(resolved, func(i, j int) bool {
+		return resolved[i].Reserved && !resolved[j].Reserved
+	})
+	for _, s := range resolved {
+		if s.Keys == "" {
+			continue
+		}
+		if other, ok := used[s.Keys]; ok && (other.Scope == s.Scope || other.Scope == ScopeGlobal || s.Scope == ScopeGlobal) {
+			return nil, fmt.Errorf("%s is already used by %s", s.Keys, other.Label)
+		}
+		used[s.Keys] = s
+	}
+	return clean, nil
+}
+
+func NormalizeKeys(keys string) (string, error) {
+	keys = strings.TrimSpace(keys)
+	if keys == "" {
+		return "", nil
+	}
+	parts := strings.Split(keys, "+")
+	mods := map[string]bool{}
+	var key string
+	for i, p := range parts {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			return "", fmt.Errorf("invalid shortcut: %s", keys)
+		}
+		if mod, ok := modifierAliases[strings.ToLower(p)]; ok && i < len(parts)-1 {
+			mods[mod] = true
+			continue
+		}
+		if i != len(parts)-1 {
+			return "", fmt.Errorf("unknown modifier %q in %s", p, keys)
+		}
+		switch named, ok := namedKeys[strings.ToLower(p)]; {
+		case ok:
+			key = named
+		case len([]rune(p)) == 1:
+			key = strings.ToUpper(p)
+		default:
+			return "", fmt.Errorf("unknown key %q in %s", p, keys)
+		}
+	}
+	if mods["CmdOrCtrl"] && (mods["Cmd"] || mods["Ctrl"]) {
+		return "", fmt.Errorf("CmdOrCtrl cannot be combined with Cmd or Ctrl: %s", keys)
+	}
+	out := make([]string, 0, len(mods)+1)
+	for _, m := range modifierOrder {
+		if mods[m] {
+			out = append(out, m)
+		}
+	}
+	return strings.Join(append(out, key), "+"), nil
+}
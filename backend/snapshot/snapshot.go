@@ -0,0 +1,277 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultKeep     = 7
+	MaxKeep         = 100
+	DefaultInterval = 24 * time.Hour
+
+	stampLayout = "20060102-150405"
+)
+
+var ErrNotFound = errors.New("snapshot not found")
+
+type Snapshot struct {
+	ID        string   `json:"id"`
+	CreatedAt int64    `json:"createdAt"`
+	Files     []string `json:"files"`
+	Size      int64    `json:"size"`
+}
+
+type Manager struct {
+	mu      sync.Mutex
+	dir     string
+	sources map[string]string
+	keep    int
+}
+
+func New(sources map[string]string, keep int) *Manager {
+	if keep <= 0 {
+		keep = DefaultKeep
+	}
+	return &Manager{dir: snapshotDir(), sources: sources, keep: keep}
+}
+
+func (m *Manager) Keep() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.keep
+}
+
+func (m *Manager) SetKeep(keep int) error {
+	if keep < 0 || keep > MaxKeep {
+		return fmt.Errorf("snapshot count must be between 1 and %d", MaxKeep)
+	}
+	if keep == 0 {
+		keep = DefaultKeep
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keep = keep
+	return m.rotateLocked()
+}
+
+func snapshotDir() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil || configDir == "" {
+		return "kitty_snapshots"
+	}
+	return filepath.Join(configDir, "Kitty", "snapshots")
+}
+
+func (m *Manager) List() ([]Snapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.listLocked()
+}
+
+func (m *Manager) Create() (*Snapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.createRotatedLocked(time.Now())
+}
+
+func (m *Manager) EnsureRecent(interval time.Duration) (*Snapshot, error) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snaps, err := m.listLocked()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if len(snaps) > 0 && now.Sub(time.Unix(snaps[0].CreatedAt, 0)) < interval {
+		return nil, nil
+	}
+	return m.createRotatedLocked(now)
+}
+
+func (m *Manager) Restore(id string) (*Snapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	src := filepath.Join(m.dir, filepath.Base(strings.TrimSpace(id)))
+	info, err := os.Stat(src)
+	if err != nil || !info.IsDir() {
+		return nil, ErrNotFound
+	}
+	restore := make(map[string]bool, len(m.sources))
+	for name := range m.sources {
+		if _, err := os.Stat(filepath.Join(src, name)); err == nil {
+			restore[name] = true
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	if len(restore) == 0 {
+		return nil, ErrNotFound
+	}
+	snap, err := readSnapshot(src)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.createLocked(time.Now()); err != nil {
+		return nil, fmt.Errorf("pre-restore snapshot failed: %w", err)
+	}
+
+	for name, dst := range m.sources {
+		if !restore[name] {
+			if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+				return nil, err
+			}
+			continue
+		}
+		if err := copyFile(filepath.Join(src, name), dst); err != nil {
+			return nil, fmt.Errorf("restore %s: %w", name, err)
+		}
+	}
+	if err := m.rotateLocked(); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+func (m *Manager) createRotatedLocked(now time.Time) (*Snapshot, error) {
+	snap, err := m.createLocked(now)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.rotateLocked(); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+func (m *Manager) createLocked(now time.Time) (*Snapshot, error) {
+	id := now.Format(stampLayout)
+	dst := filepath.Join(m.dir, id)
+	for i := 2; ; i++ {
+		if _, err := os.Stat(dst); os.IsNotExist(err) {
+			break
+		}
+		id = fmt.Sprintf("%s-%d", now.Format(stampLayout), i)
+		dst = filepath.Join(m.dir, id)
+	}
+	if err := os.MkdirAll(dst, 0o700); err != nil {
+		return nil, err
+	}
+
+	for name, src := range m.sources {
+		if _, err := os.Stat(src); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		if err := copyFile(src, filepath.Join(dst, name)); err != nil {
+			_ = os.RemoveAll(dst)
+			return nil, fmt.Errorf("snapshot %s: %w", name, err)
+		}
+	}
+
+	return readSnapshot(dst)
+}
+
+func (m *Manager) rotateLocked() error {
+	snaps, err := m.listLocked()
+	if err != nil {
+		return err
+	}
+	for i := m.keep; i < len(snaps); i++ {
+		if err := os.RemoveAll(filepath.Join(m.dir, snaps[i].ID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Manager) listLocked() ([]Snapshot, error) {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Snapshot{}, nil
+		}
+		return nil, err
+	}
+	snaps := make([]Snapshot, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		s, err := readSnapshot(filepath.Join(m.dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		snaps = append(snaps, *s)
+	}
+	sort.Slice(snaps, func(i, j int) bool {
+		if snaps[i].CreatedAt != snaps[j].CreatedAt {
+			return snaps[i].CreatedAt > snaps[j].CreatedAt
+		}
+		return snaps[i].ID > snaps[j].ID
+	})
+	return snaps, nil
+}
+
+func readSnapshot(dir string) (*Snapshot, error) {
+	id := filepath.Base(dir)
+	created, err := time.ParseInLocation(stampLayout, id[:min(len(id), len(stampLayout))], time.Local)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	s := &Snapshot{ID: id, CreatedAt: created.Unix(), Files: []string{}}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		s.Files = append(s.Files, e.Name())
+		if info, err := e.Info(); err == nil {
+			s.Size += info.Size()
+		}
+	}
+	return s, nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	tmp := dst + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
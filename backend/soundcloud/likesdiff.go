@@ -0,0 +1,187 @@
+package soundcloud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type LikesDiff struct {
+	SyncedAt     int64   `json:"syncedAt"`
+	PreviousSync int64   `json:"previousSync,omitempty"`
+	FirstSync    bool    `json:"firstSync"`
+	Total        int     `json:"total"`
+	Added        []Track `json:"added"`
+	Unliked      []Track `json:"unliked"`
+	Removed      []Track `json:"removed"`
+	InLibrary    []int64 `json:"inLibrary"`
+}
+
+type likesSnapshot struct {
+	SyncedAt int64      `json:"syncedAt"`
+	Tracks   []Track    `json:"tracks"`
+	LastDiff *LikesDiff `json:"lastDiff,omitempty"`
+}
+
+var likesSnapshotMu sync.Mutex
+
+func likesSnapshotPath() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil || configDir == "" {
+		return "kitty_soundcloud_likes.json"
+	}
+	return filepath.Join(configDir, "Kitty", "soundcloud_likes.json")
+}
+
+func (s *Service) LikesDiff(ctx context.Context, onPage func(LikesProgress)) (*LikesDiff, error) {
+	current := []Track{}
+	if _, err := s.PrefetchLikes(ctx, func(p LikesProgress) {
+		current = append(current, p.Tracks...)
+		if onPage != nil {
+			onPage(p)
+		}
+	}); err != nil {
+		return nil, err
+	}
+
+	likesSnapshotMu.Lock()
+	defer likesSnapshotMu.Unlock()
+	prev, err := loadLikesSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &LikesDiff{
+		SyncedAt:  time.Now().Unix(),
+		FirstSync: prev == nil,
+		Total:     len(current),
+		Added:     []Track{},
+		Unliked:   []Track{},
+		Removed:   []Track{},
+		InLibrary: []int64{},
+	}
+	if prev != nil {
+		diff.PreviousSync = prev.SyncedAt
+		now := make(map[int64]bool, len(current))
+		for _, t := range current {
+			now[t.ID] = true
+		}
+		before := make(map[int64]bool, len(prev.Tracks))
+		for _, t := range prev.Tracks {
+			before[t.ID] = true
+			if now[t.ID] {
+				continue
+			}
+			exists, err := s.trackExists(ctx, t.ID)
+			if err != nil {
+				return nil, err
+			}
+			if exists {
+				diff.Unliked = append(diff.Unliked, t)
+			} else {
+				diff.Removed = append(diff.Removed, t)
+			}
+		}
+		for _, t := range current {
+			if !before[t.ID] {
+				diff.Added = append(diff.Added, t)
+			}
+		}
+	}
+
+	if err := saveLikesSnapshot(likesSnapshot{SyncedAt: diff.SyncedAt, Tracks: current, LastDiff: diff}); err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
+
+func LastLikesDiff() (*LikesDiff, error) {
+	likesSnapshotMu.Lock()
+	defer likesSnapshotMu.Unlock()
+	snap, err := loadLikesSnapshot()
+	if err != nil || snap == nil {
+		return nil, err
+	}
+	return snap.LastDiff, nil
+}
+
+func ClearLikesSnapshot() error {
+	likesSnapshotMu.Lock()
+	defer likesSnapshotMu.Unlock()
+	if err := os.Remove(likesSnapshotPath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *Service) trackExists(ctx context.Context, id int64) (bool, error) {
+	token, err := s.ensureAccessToken(ctx)
+	if err != nil {
+		return false, err
+	}
+	var res *http.Response
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiBase+"/tracks/"+strconv.FormatInt(id, 10), nil)
+		if err != nil {
+			return false, err
+		}
+		req.Header.Set("Authorization", "OAuth "+token)
+		req.Header.Set("Accept", "application/json")
+
+		res, err = s.http.Do(req)
+		if err != nil {
+			return false, err
+		}
+		io.Copy(io.Discard, io.LimitReader(res.Body, 64*1024))
+		res.Body.Close()
+		if res.StatusCode != http.StatusTooManyRequests || attempt >= maxRateLimitRetries {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(retryAfter(res.Header.Get("Retry-After"), attempt)):
+		}
+	}
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode < 300:
+		return true, nil
+	case res.StatusCode == http.StatusNotFound, res.StatusCode == http.StatusGone, res.StatusCode == http.StatusForbidden:
+		return false, nil
+	}
+	return false, &apiStatusError{label: "track lookup", code: res.StatusCode, status: res.Status}
+}
+
+func loadLikesSnapshot() (*likesSnapshot, error) {
+	raw, err := os.ReadFile(likesSnapshotPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var snap likesSnapshot
+	if err := json.Unmarshal(raw, &snap); err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
+
+func saveLikesSnapshot(snap likesSnapshot) error {
+	raw, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	path := likesSnapshotPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, raw, 0o644)
+}
@@ -0,0 +1,154 @@
+package soundcloud
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+const (
+	searchLimit   = 8
+	minMatchScore = 0.6
+)
+
+type RemotePlaylist struct {
+	ID           int64  `json:"id"`
+	Title        string `json:"title"`
+	PermalinkURL string `json:"permalinkUrl"`
+	TrackCount   int    `json:"trackCount"`
+}
+
+var ErrPlaylistGone = errors.New("soundcloud playlist no longer exists")
+
+func (s *Service) SearchTracks(ctx context.Context, query string) ([]Track, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return []Track{}, nil
+	}
+	token, err := s.ensureAccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := fmt.Sprintf("%s/tracks?q=%s&limit=%d&linked_partitioning=true", apiBase, url.QueryEscape(query), searchLimit)
+	page, err := s.fetchTrackPage(ctx, token, endpoint, "search", nil)
+	if err != nil {
+		return nil, err
+	}
+	return page.Tracks, nil
+}
+
+func BestMatch(candidates []Track, title, artist string) (Track, bool) {
+	wantTitle, wantArtist := matchKey(title), matchKey(artist)
+	best, bestScore := Track{}, 0.0
+	for _, c := range candidates {
+		if c.ID == 0 {
+			continue
+		}
+		score := overlap(wantTitle, matchKey(c.Title))
+		if wantArtist != "" {
+			gotArtist := matchKey(c.Artist)
+			switch {
+			case strings.Contains(gotArtist, wantArtist) || strings.Contains(matchKey(c.Title), wantArtist):
+				score += 0.3
+			case overlap(wantArtist, gotArtist) < 0.5:
+				score -= 0.3
+			}
+		}
+		if score > bestScore {
+			best, bestScore = c, score
+		}
+	}
+	return best, bestScore >= minMatchScore
+}
+
+func (s *Service) SavePlaylist(ctx context.Context, remoteID int64, title, sharing string, trackIDs []int64) (*RemotePlaylist, error) {
+	token, err := s.ensureAccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tracks := make([]map[string]int64, 0, len(trackIDs))
+	for _, id := range trackIDs {
+		tracks = append(tracks, map[string]int64{"id": id})
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"playlist": map[string]interface{}{"title": title, "sharing": sharing, "tracks": tracks},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	method, endpoint := http.MethodPost, apiBase+"/playlists"
+	if remoteID > 0 {
+		method, endpoint = http.MethodPut, fmt.Sprintf("%s/playlists/%d", apiBase, remoteID)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "OAuth "+token)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := s.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if remoteID > 0 && res.StatusCode == http.StatusNotFound {
+		return nil, ErrPlaylistGone
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		raw, _ := io.ReadAll(io.LimitReader(res.Body, 16*1024))
+		return nil, &apiStatusError{label: "playlist save", code: res.StatusCode, status: res.Status, body: strings.TrimSpace(string(raw))}
+	}
+	var parsed struct {
+		ID           int64  `json:"id"`
+		Title        string `json:"title"`
+		PermalinkURL string `json:"permalink_url"`
+		TrackCount   int    `json:"track_count"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return nil, err
+	}
+	return &RemotePlaylist{ID: parsed.ID, Title: parsed.Title, PermalinkURL: parsed.PermalinkURL, TrackCount: parsed.TrackCount}, nil
+}
+
+func matchKey(s string) string {
+	s = strings.ToLower(s)
+	for _, cut := range []string{" (", " [", " feat.", " ft."} {
+		if i := strings.Index(s, cut); i > 0 {
+			s = s[:i]
+		}
+	}
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}), " ")
+}
+
+func overlap(want, got string) float64 {
+	if want == "" || got == "" {
+		return 0
+	}
+	if want == got {
+		return 1
+	}
+	words := strings.Fields(want)
+	have := map[string]bool{}
+	for _, w := range strings.Fields(got) {
+		have[w] = true
+	}
+	hits := 0
+	for _, w := range words {
+		if have[w] {
+			hits++
+		}
+	}
+	return float64(hits) / float64(len(words))
+}
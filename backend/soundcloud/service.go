@@ -13,10 +13,12 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"kitty/backend/httpclient"
 	"kitty/backend/storage"
 )
 
@@ -24,6 +26,11 @@ const (
 	authorizeURL = "https://secure.soundcloud.com/authorize"
 	tokenURL     = "https://secure.soundcloud.com/oauth/token"
 	apiBase      = "https://api.soundcloud.com"
+
+	maxRateLimitRetries = 4
+	prefetchPageDelay   = 300 * time.Millisecond
+	tokenRefreshLead    = 10 * time.Minute
+	tokenCheckInterval  = 5 * time.Minute
 )
 
 type AuthStatus struct {
@@ -34,8 +41,22 @@ type AuthStatus struct {
 }
 
 type Track struct {
+	ID           int64  `json:"id,omitempty"`
+	Title        string `json:"title"`
+	Artist       string `json:"artist"`
+	PermalinkURL string `json:"permalinkUrl"`
+	ArtworkURL   string `json:"artworkUrl"`
+	DurationMs   int    `json:"durationMs"`
+}
+
+type TrackDetails struct {
+	ID           int64  `json:"id,omitempty"`
 	Title        string `json:"title"`
 	Artist       string `json:"artist"`
+	Genre        string `json:"genre"`
+	Label        string `json:"label"`
+	Description  string `json:"description"`
+	ReleaseYear  int    `json:"releaseYear"`
 	PermalinkURL string `json:"permalinkUrl"`
 	ArtworkURL   string `json:"artworkUrl"`
 	DurationMs   int    `json:"durationMs"`
@@ -46,6 +67,14 @@ type LikesPage struct {
 	NextHref string  `json:"nextHref"`
 }
 
+type LikesProgress struct {
+	Tracks []Track `json:"tracks"`
+	Page   int     `json:"page"`
+	Loaded int     `json:"loaded"`
+	Total  int     `json:"total"`
+	Done   bool    `json:"done"`
+}
+
 type Service struct {
 	redirectURI string
 	cbAddr      string
@@ -60,9 +89,7 @@ func New(redirectURI, callbackAddr string) *Service {
 	return &Service{
 		redirectURI: redirectURI,
 		cbAddr:      callbackAddr,
-		http: &http.Client{
-			Timeout: 20 * time.Second,
-		},
+		http:        httpclient.New(httpclient.KindSoundCloud),
 	}
 }
 
@@ -294,23 +321,100 @@ func (s *Service) ListLikes(ctx context.Context, nextHref string) (*LikesPage, e
 	if strings.TrimSpace(endpoint) == "" {
 		endpoint = apiBase + "/me/likes/tracks?linked_partitioning=true&limit=50"
 	}
+	return s.fetchTrackPage(ctx, token, endpoint, "likes", nil)
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+func (s *Service) ListReposts(ctx context.Context, nextHref string) (*LikesPage, error) {
+	token, err := s.ensureAccessToken(ctx)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Authorization", "OAuth "+token)
-	req.Header.Set("Accept", "application/json")
 
-	res, err := s.http.Do(req)
+	endpoint := nextHref
+	if strings.TrimSpace(endpoint) == "" {
+		endpoint = apiBase + "/me/activities/all/own?linked_partitioning=true&limit=50"
+	}
+	return s.fetchTrackPage(ctx, token, endpoint, "reposts", isTrackRepost)
+}
+
+func (s *Service) ListPlayHistory(ctx context.Context, nextHref string) (*LikesPage, error) {
+	token, err := s.ensureAccessToken(ctx)
 	if err != nil {
 		return nil, err
 	}
+
+	endpoint := nextHref
+	if strings.TrimSpace(endpoint) == "" {
+		endpoint = apiBase + "/me/play-history/tracks?linked_partitioning=true&limit=50"
+	}
+	page, err := s.fetchTrackPage(ctx, token, endpoint, "play history", nil)
+	if err != nil {
+		var statusErr *apiStatusError
+		if errors.As(err, &statusErr) && (statusErr.code == http.StatusNotFound || statusErr.code == http.StatusForbidden) {
+			return nil, errors.New("soundcloud play history is not available for this app through the public API")
+		}
+		return nil, err
+	}
+	return page, nil
+}
+
+type apiStatusError struct {
+	label  string
+	code   int
+	status string
+	body   string
+}
+
+func (e *apiStatusError) Error() string {
+	return fmt.Sprintf("soundcloud %s failed: %s (%s)", e.label, e.status, e.body)
+}
+
+func isTrackRepost(raw json.RawMessage) bool {
+	var item struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(raw, &item); err != nil {
+		return false
+	}
+	return item.Type == "track-repost"
+}
+
+func (s *Service) fetchTrackPage(ctx context.Context, token, endpoint, label string, keep func(json.RawMessage) bool) (*LikesPage, error) {
+	var res *http.Response
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "OAuth "+token)
+		req.Header.Set("Accept", "application/json")
+
+		res, err = s.http.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode != http.StatusTooManyRequests || attempt >= maxRateLimitRetries {
+			break
+		}
+		wait := retryAfter(res.Header.Get("Retry-After"), attempt)
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
 	defer res.Body.Close()
 
 	if res.StatusCode < 200 || res.StatusCode >= 300 {
 		raw, _ := io.ReadAll(io.LimitReader(res.Body, 16*1024))
-		return nil, fmt.Errorf("soundcloud likes failed: %s (%s)", res.Status, strings.TrimSpace(string(raw)))
+		return nil, &apiStatusError{
+			label:  label,
+			code:   res.StatusCode,
+			status: res.Status,
+			body:   strings.TrimSpace(string(raw)),
+		}
 	}
 
 	var parsed likesResponse
@@ -320,6 +424,9 @@ func (s *Service) ListLikes(ctx context.Context, nextHref string) (*LikesPage, e
 
 	tracks := make([]Track, 0, len(parsed.Collection))
 	for _, item := range parsed.Collection {
+		if keep != nil && !keep(item) {
+			continue
+		}
 		if t := normalizeTrack(item); t != nil {
 			tracks = append(tracks, *t)
 		}
@@ -331,6 +438,175 @@ func (s *Service) ListLikes(ctx context.Context, nextHref string) (*LikesPage, e
 	}, nil
 }
 
+func (s *Service) LikesCount(ctx context.Context) (int, error) {
+	token, err := s.ensureAccessToken(ctx)
+	if err != nil {
+		return 0, err
+	}
+	me, err := s.fetchMe(ctx, token)
+	if err != nil {
+		return 0, err
+	}
+	return me.LikesCount, nil
+}
+
+func (s *Service) PrefetchLikes(ctx context.Context, onPage func(LikesProgress)) (int, error) {
+	total, err := s.LikesCount(ctx)
+	if err != nil {
+		total = 0
+	}
+
+	loaded := 0
+	next := ""
+	for page := 0; ; page++ {
+		if page > 0 {
+			select {
+			case <-ctx.Done():
+				return loaded, ctx.Err()
+			case <-time.After(prefetchPageDelay):
+			}
+		}
+		res, err := s.ListLikes(ctx, next)
+		if err != nil {
+			return loaded, err
+		}
+		loaded += len(res.Tracks)
+		if total > 0 && loaded > total {
+			total = loaded
+		}
+		next = res.NextHref
+		if onPage != nil {
+			onPage(LikesProgress{
+				Tracks: res.Tracks,
+				Page:   page,
+				Loaded: loaded,
+				Total:  total,
+				Done:   next == "",
+			})
+		}
+		if next == "" {
+			return loaded, nil
+		}
+	}
+}
+
+func retryAfter(header string, attempt int) time.Duration {
+	if secs, err := strconv.Atoi(strings.TrimSpace(header)); err == nil && secs > 0 {
+		if secs > 120 {
+			secs = 120
+		}
+		return time.Duration(secs) * time.Second
+	}
+	return time.Duration(1<<attempt) * 2 * time.Second
+}
+
+func (s *Service) ResolveTrack(ctx context.Context, permalink string) (*TrackDetails, error) {
+	permalink = strings.TrimSpace(permalink)
+	if permalink == "" {
+		return nil, errors.New("missing soundcloud permalink")
+	}
+	token, err := s.ensureAccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := apiBase + "/resolve?url=" + url.QueryEscape(permalink)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "OAuth "+token)
+	req.Header.Set("Accept", "application/json")
+
+	res, err := s.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		raw, _ := io.ReadAll(io.LimitReader(res.Body, 16*1024))
+		return nil, fmt.Errorf("soundcloud resolve failed: %s (%s)", res.Status, strings.TrimSpace(string(raw)))
+	}
+
+	var parsed struct {
+		ID           int64  `json:"id"`
+		Kind         string `json:"kind"`
+		Title        string `json:"title"`
+		Genre        string `json:"genre"`
+		LabelName    string `json:"label_name"`
+		Description  string `json:"description"`
+		ReleaseYear  int    `json:"release_year"`
+		ReleaseDate  string `json:"release_date"`
+		CreatedAt    string `json:"created_at"`
+		PermalinkURL string `json:"permalink_url"`
+		ArtworkURL   string `json:"artwork_url"`
+		Duration     int    `json:"duration"`
+		User         struct {
+			Username  string `json:"username"`
+			AvatarURL string `json:"avatar_url"`
+		} `json:"user"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return nil, err
+	}
+	if parsed.Kind != "" && parsed.Kind != "track" {
+		return nil, fmt.Errorf("soundcloud url resolves to a %s, not a track", parsed.Kind)
+	}
+
+	year := parsed.ReleaseYear
+	if year <= 0 {
+		year = yearFromDate(parsed.ReleaseDate)
+	}
+	if year <= 0 {
+		year = yearFromDate(parsed.CreatedAt)
+	}
+	artwork := strings.TrimSpace(parsed.ArtworkURL)
+	if artwork == "" {
+		artwork = strings.TrimSpace(parsed.User.AvatarURL)
+	}
+
+	return &TrackDetails{
+		ID:           parsed.ID,
+		Title:        strings.TrimSpace(parsed.Title),
+		Artist:       strings.TrimSpace(parsed.User.Username),
+		Genre:        strings.TrimSpace(parsed.Genre),
+		Label:        strings.TrimSpace(parsed.LabelName),
+		Description:  strings.TrimSpace(parsed.Description),
+		ReleaseYear:  year,
+		PermalinkURL: strings.TrimSpace(parsed.PermalinkURL),
+		ArtworkURL:   HighResArtworkURL(artwork),
+		DurationMs:   parsed.Duration,
+	}, nil
+}
+
+func HighResArtworkURL(artwork string) string {
+	if artwork == "" {
+		return ""
+	}
+	for _, size := range []string{"-large.", "-t300x300.", "-crop.", "-badge.", "-small.", "-tiny.", "-mini."} {
+		if strings.Contains(artwork, size) {
+			return strings.Replace(artwork, size, "-t500x500.", 1)
+		}
+	}
+	return artwork
+}
+
+func yearFromDate(v string) int {
+	v = strings.TrimSpace(v)
+	if len(v) < 4 {
+		return 0
+	}
+	year := 0
+	for _, r := range v[:4] {
+		if r < '0' || r > '9' {
+			return 0
+		}
+		year = year*10 + int(r-'0')
+	}
+	return year
+}
+
 type tokenResponse struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
@@ -396,7 +672,12 @@ func (s *Service) refresh(ctx context.Context, clientID, clientSecret, refreshTo
 
 	if res.StatusCode < 200 || res.StatusCode >= 300 {
 		raw, _ := io.ReadAll(io.LimitReader(res.Body, 16*1024))
-		return tokenResponse{}, fmt.Errorf("soundcloud token refresh failed: %s (%s)", res.Status, strings.TrimSpace(string(raw)))
+		return tokenResponse{}, &apiStatusError{
+			label:  "token refresh",
+			code:   res.StatusCode,
+			status: res.Status,
+			body:   strings.TrimSpace(string(raw)),
+		}
 	}
 
 	var tr tokenResponse
@@ -438,31 +719,130 @@ func (s *Service) ensureAccessToken(ctx context.Context) (string, error) {
 	return tr.AccessToken, nil
 }
 
-func (s *Service) fetchUsername(ctx context.Context, accessToken string) (string, error) {
+type meResponse struct {
+	Username   string `json:"username"`
+	LikesCount int    `json:"public_favorites_count"`
+}
+
+func (s *Service) fetchMe(ctx context.Context, accessToken string) (*meResponse, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiBase+"/me", nil)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	req.Header.Set("Authorization", "OAuth "+accessToken)
 	req.Header.Set("Accept", "application/json")
 
 	res, err := s.http.Do(req)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer res.Body.Close()
 
 	if res.StatusCode < 200 || res.StatusCode >= 300 {
-		return "", fmt.Errorf("soundcloud /me failed: %s", res.Status)
+		return nil, fmt.Errorf("soundcloud /me failed: %s", res.Status)
 	}
 
-	var out struct {
-		Username string `json:"username"`
-	}
+	var out meResponse
 	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	out.Username = strings.TrimSpace(out.Username)
+	return &out, nil
+}
+
+type TokenHealth struct {
+	Connected      bool   `json:"connected"`
+	ExpiresAt      int64  `json:"expiresAt"`
+	Refreshed      bool   `json:"refreshed"`
+	NeedsReconnect bool   `json:"needsReconnect"`
+	Error          string `json:"error,omitempty"`
+}
+
+func (s *Service) CheckToken(ctx context.Context) TokenHealth {
+	set, err := storage.LoadSettings()
+	if err != nil {
+		return TokenHealth{Error: err.Error()}
+	}
+	sc := set.SoundCloud
+	health := TokenHealth{
+		Connected: strings.TrimSpace(sc.AccessToken) != "" || strings.TrimSpace(sc.RefreshToken) != "",
+		ExpiresAt: sc.ExpiresAt,
+	}
+	if !health.Connected {
+		return health
+	}
+
+	now := time.Now().Unix()
+	if sc.ExpiresAt == 0 || now < sc.ExpiresAt-int64(tokenRefreshLead/time.Second) {
+		return health
+	}
+	if strings.TrimSpace(sc.RefreshToken) == "" {
+		health.NeedsReconnect = now >= sc.ExpiresAt
+		if health.NeedsReconnect {
+			health.Error = "soundcloud session expired and no refresh token is stored"
+		}
+		return health
+	}
+
+	clientID, clientSecret, err := s.credentials()
+	if err != nil {
+		health.Error = err.Error()
+		return health
+	}
+	tr, err := s.refresh(ctx, clientID, clientSecret, sc.RefreshToken)
+	if err != nil {
+		health.Error = err.Error()
+		var statusErr *apiStatusError
+		if errors.As(err, &statusErr) && (statusErr.code == http.StatusBadRequest || statusErr.code == http.StatusUnauthorized) {
+			health.NeedsReconnect = true
+		}
+		return health
+	}
+	username, _ := s.fetchUsername(ctx, tr.AccessToken)
+	if err := s.saveToken(tr, username); err != nil {
+		health.Error = err.Error()
+		return health
+	}
+	health.Refreshed = true
+	if updated, err := storage.LoadSettings(); err == nil {
+		health.ExpiresAt = updated.SoundCloud.ExpiresAt
+	}
+	return health
+}
+
+func (s *Service) MonitorToken(ctx context.Context, onReconnectNeeded func(TokenHealth)) {
+	ticker := time.NewTicker(tokenCheckInterval)
+	defer ticker.Stop()
+
+	notified := false
+	for {
+		checkCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		health := s.CheckToken(checkCtx)
+		cancel()
+
+		if health.NeedsReconnect {
+			if !notified && onReconnectNeeded != nil {
+				onReconnectNeeded(health)
+			}
+			notified = true
+		} else {
+			notified = false
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) fetchUsername(ctx context.Context, accessToken string) (string, error) {
+	me, err := s.fetchMe(ctx, accessToken)
+	if err != nil {
 		return "", err
 	}
-	return strings.TrimSpace(out.Username), nil
+	return me.Username, nil
 }
 
 func (s *Service) saveToken(tr tokenResponse, username string) error {
@@ -544,6 +924,7 @@ type likesResponse struct {
 
 func normalizeTrack(raw json.RawMessage) *Track {
 	var direct struct {
+		ID           int64  `json:"id"`
 		Title        string `json:"title"`
 		PermalinkURL string `json:"permalink_url"`
 		ArtworkURL   string `json:"artwork_url"`
@@ -554,6 +935,7 @@ func normalizeTrack(raw json.RawMessage) *Track {
 	}
 	if err := json.Unmarshal(raw, &direct); err == nil && strings.TrimSpace(direct.Title) != "" {
 		return &Track{
+			ID:           direct.ID,
 			Title:        strings.TrimSpace(direct.Title),
 			Artist:       strings.TrimSpace(direct.User.Username),
 			PermalinkURL: strings.TrimSpace(direct.PermalinkURL),
@@ -563,10 +945,16 @@ func normalizeTrack(raw json.RawMessage) *Track {
 	}
 
 	var wrapped struct {
-		Track json.RawMessage `json:"track"`
+		Track  json.RawMessage `json:"track"`
+		Origin json.RawMessage `json:"origin"`
 	}
-	if err := json.Unmarshal(raw, &wrapped); err == nil && len(wrapped.Track) > 0 {
-		return normalizeTrack(wrapped.Track)
+	if err := json.Unmarshal(raw, &wrapped); err == nil {
+		if len(wrapped.Track) > 0 {
+			return normalizeTrack(wrapped.Track)
+		}
+		if len(wrapped.Origin) > 0 {
+			return normalizeTrack(wrapped.Origin)
+		}
 	}
 
 	return nil
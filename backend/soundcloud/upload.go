@@ -0,0 +1,294 @@
+package soundcloud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	PrivacyPublic  = "public"
+	PrivacyPrivate = "private"
+
+	UploadStageSending     = "uploading"
+	UploadStageTranscoding = "transcoding"
+	UploadStageFinished    = "finished"
+
+	transcodePollInterval = 3 * time.Second
+	transcodeTimeout      = 30 * time.Minute
+)
+
+type UploadRequest struct {
+	Path        string   `json:"path"`
+	Title       string   `json:"title"`
+	Genre       string   `json:"genre"`
+	Description string   `json:"description"`
+	Label       string   `json:"label"`
+	ReleaseYear int      `json:"releaseYear"`
+	Tags        []string `json:"tags"`
+	Privacy     string   `json:"privacy"`
+	Artwork     []byte   `json:"-"`
+	ArtworkMime string   `json:"-"`
+}
+
+type UploadProgress struct {
+	Stage string `json:"stage"`
+	Sent  int64  `json:"sent"`
+	Total int64  `json:"total"`
+	State string `json:"state,omitempty"`
+}
+
+type UploadedTrack struct {
+	ID           int64  `json:"id"`
+	Title        string `json:"title"`
+	PermalinkURL string `json:"permalinkUrl"`
+	State        string `json:"state"`
+	Sharing      string `json:"sharing"`
+}
+
+type uploadQuota struct {
+	Quota *struct {
+		Unlimited   bool `json:"unlimited_upload_quota"`
+		SecondsLeft int  `json:"upload_seconds_left"`
+	} `json:"quota"`
+}
+
+type trackResponse struct {
+	ID           int64  `json:"id"`
+	Title        string `json:"title"`
+	PermalinkURL string `json:"permalink_url"`
+	State        string `json:"state"`
+	Sharing      string `json:"sharing"`
+}
+
+func NormalizePrivacy(privacy string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(privacy)) {
+	case "", PrivacyPrivate:
+		return PrivacyPrivate, nil
+	case PrivacyPublic:
+		return PrivacyPublic, nil
+	}
+	return "", fmt.Errorf("privacy must be %q or %q", PrivacyPublic, PrivacyPrivate)
+}
+
+func (s *Service) UploadTrack(ctx context.Context, req UploadRequest, onProgress func(UploadProgress)) (*UploadedTrack, error) {
+	privacy, err := NormalizePrivacy(req.Privacy)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(req.Path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(req.Title) == "" {
+		req.Title = strings.TrimSuffix(filepath.Base(req.Path), filepath.Ext(req.Path))
+	}
+	token, err := s.ensureAccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkUploadRights(ctx, token); err != nil {
+		return nil, err
+	}
+	if onProgress == nil {
+		onProgress = func(UploadProgress) {}
+	}
+
+	pr, pw := io.Pipe()
+	form := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(writeUploadForm(form, req, privacy, info.Size(), onProgress))
+	}()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, apiBase+"/tracks", pr)
+	if err != nil {
+		pr.Close()
+		return nil, err
+	}
+	httpReq.Header.Set("Authorization", "OAuth "+token)
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("Content-Type", form.FormDataContentType())
+
+	res, err := (&http.Client{}).Do(httpReq)
+	if err != nil {
+		pr.Close()
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		raw, _ := io.ReadAll(io.LimitReader(res.Body, 16*1024))
+		return nil, &apiStatusError{label: "upload", code: res.StatusCode, status: res.Status, body: strings.TrimSpace(string(raw))}
+	}
+	var created trackResponse
+	if err := json.NewDecoder(res.Body).Decode(&created); err != nil {
+		return nil, err
+	}
+	return s.waitTranscoded(ctx, token, created, info.Size(), onProgress)
+}
+
+func (s *Service) checkUploadRights(ctx context.Context, token string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiBase+"/me", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "OAuth "+token)
+	req.Header.Set("Accept", "application/json")
+	res, err := s.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("soundcloud /me failed: %s", res.Status)
+	}
+	var me uploadQuota
+	if err := json.NewDecoder(res.Body).Decode(&me); err != nil {
+		return err
+	}
+	if me.Quota != nil && !me.Quota.Unlimited && me.Quota.SecondsLeft <= 0 {
+		return errors.New("soundcloud account has no upload time left")
+	}
+	return nil
+}
+
+func writeUploadForm(form *multipart.Writer, req UploadRequest, privacy string, size int64, onProgress func(UploadProgress)) error {
+	fields := [][2]string{
+		{"track[title]", req.Title},
+		{"track[sharing]", privacy},
+		{"track[genre]", req.Genre},
+		{"track[description]", req.Description},
+		{"track[label_name]", req.Label},
+		{"track[tag_list]", tagList(req.Tags)},
+	}
+	if req.ReleaseYear > 0 {
+		fields = append(fields, [2]string{"track[release_year]", strconv.Itoa(req.ReleaseYear)})
+	}
+	for _, f := range fields {
+		if strings.TrimSpace(f[1]) == "" {
+			continue
+		}
+		if err := form.WriteField(f[0], f[1]); err != nil {
+			return err
+		}
+	}
+	if len(req.Artwork) > 0 {
+		w, err := form.CreateFormFile("track[artwork_data]", "artwork"+artworkExt(req.ArtworkMime))
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(req.Artwork); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Open(req.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w, err := form.CreateFormFile("track[asset_data]", filepath.Base(req.Path))
+	if err != nil {
+		return err
+	}
+	var sent int64
+	buf := make([]byte, 256*1024)
+	for {
+		n, readErr := f.Read(buf)
+		if n > 0 {
+			if _, err := w.Write(buf[:n]); err != nil {
+				return err
+			}
+			sent += int64(n)
+			onProgress(UploadProgress{Stage: UploadStageSending, Sent: sent, Total: size})
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+	return form.Close()
+}
+
+func (s *Service) waitTranscoded(ctx context.Context, token string, track trackResponse, size int64, onProgress func(UploadProgress)) (*UploadedTrack, error) {
+	deadline := time.Now().Add(transcodeTimeout)
+	for {
+		onProgress(UploadProgress{Stage: UploadStageTranscoding, Sent: size, Total: size, State: track.State})
+		switch track.State {
+		case "", "finished":
+			onProgress(UploadProgress{Stage: UploadStageFinished, Sent: size, Total: size, State: track.State})
+			return &UploadedTrack{
+				ID:           track.ID,
+				Title:        track.Title,
+				PermalinkURL: track.PermalinkURL,
+				State:        track.State,
+				Sharing:      track.Sharing,
+			}, nil
+		case "failed":
+			return nil, fmt.Errorf("soundcloud could not transcode %q", track.Title)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("soundcloud is still transcoding %q; check it later on soundcloud.com", track.Title)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(transcodePollInterval):
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/tracks/%d", apiBase, track.ID), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "OAuth "+token)
+		req.Header.Set("Accept", "application/json")
+		res, err := s.http.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode < 200 || res.StatusCode >= 300 {
+			res.Body.Close()
+			return nil, fmt.Errorf("soundcloud track status failed: %s", res.Status)
+		}
+		err = json.NewDecoder(res.Body).Decode(&track)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func tagList(tags []string) string {
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.TrimSpace(strings.ReplaceAll(t, `"`, ""))
+		if t == "" {
+			continue
+		}
+		if strings.ContainsAny(t, " \t") {
+			t = `"` + t + `"`
+		}
+		out = append(out, t)
+	}
+	return strings.Join(out, " ")
+}
+
+func artworkExt(mime string) string {
+	switch strings.ToLower(mime) {
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	}
+	return ".jpg"
+}
@@ -0,0 +1,117 @@
+package startup
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type Timing struct {
+	Name       string `json:"name"`
+	OffsetMs   int64  `json:"offsetMs"`
+	DurationMs int64  `json:"durationMs"`
+	Background bool   `json:"background"`
+	Done       bool   `json:"done"`
+	Error      string `json:"error,omitempty"`
+}
+
+type Report struct {
+	StartedAt time.Time `json:"startedAt"`
+	ReadyMs   int64     `json:"readyMs"`
+	Timings   []Timing  `json:"timings"`
+}
+
+type Tracker struct {
+	mu      sync.Mutex
+	started time.Time
+	ready   time.Duration
+	timings []Timing
+	onDone  func(Timing)
+}
+
+func New(onDone func(Timing)) *Tracker {
+	return &Tracker{started: time.Now(), onDone: onDone}
+}
+
+func (t *Tracker) Measure(name string, fn func() error) error {
+	idx := t.begin(name, false)
+	start := time.Now()
+	err := fn()
+	t.finish(idx, time.Since(start), err)
+	return err
+}
+
+func (t *Tracker) Go(ctx context.Context, name string, delay time.Duration, fn func(ctx context.Context) error) {
+	idx := t.begin(name, true)
+	go func() {
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			defer timer.Stop()
+			select {
+			case <-ctx.Done():
+				t.finish(idx, 0, ctx.Err())
+				return
+			case <-timer.C:
+			}
+		}
+		start := time.Now()
+		err := fn(ctx)
+		t.finish(idx, time.Since(start), err)
+	}()
+}
+
+func (t *Tracker) MarkReady() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.ready == 0 {
+		t.ready = time.Since(t.started)
+	}
+}
+
+func (t *Tracker) Ready(name string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, tm := range t.timings {
+		if tm.Name == name {
+			return tm.Done && tm.Error == ""
+		}
+	}
+	return false
+}
+
+func (t *Tracker) Report() Report {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]Timing, len(t.timings))
+	copy(out, t.timings)
+	return Report{StartedAt: t.started, ReadyMs: t.ready.Milliseconds(), Timings: out}
+}
+
+func (t *Tracker) begin(name string, background bool) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tm := Timing{Name: name, OffsetMs: time.Since(t.started).Milliseconds(), Background: background}
+	for i := range t.timings {
+		if t.timings[i].Name == name {
+			t.timings[i] = tm
+			return i
+		}
+	}
+	t.timings = append(t.timings, tm)
+	return len(t.timings) - 1
+}
+
+func (t *Tracker) finish(idx int, d time.Duration, err error) {
+	t.mu.Lock()
+	tm := &t.timings[idx]
+	tm.DurationMs = d.Milliseconds()
+	tm.Done = true
+	if err != nil {
+		tm.Error = err.Error()
+	}
+	done := *tm
+	t.mu.Unlock()
+	if t.onDone != nil {
+		t.onDone(done)
+	}
+}
@@ -0,0 +1,144 @@
+package stats
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const maxHistory = 500
+
+type TrackStats struct {
+	Path       string `json:"path"`
+	PlayCount  int    `json:"playCount"`
+	LastPlayed int64  `json:"lastPlayed"`
+}
+
+type Play struct {
+	Path     string `json:"path"`
+	Artist   string `json:"artist"`
+	PlayedAt int64  `json:"playedAt"`
+}
+
+type data struct {
+	Tracks  map[string]TrackStats `json:"tracks"`
+	History []Play                `json:"history"`
+}
+
+type Store struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewStore() *Store {
+	return &Store{path: storePath()}
+}
+
+func storePath() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil || configDir == "" {
+		return "kitty_play_stats.json"
+	}
+	return filepath.Join(configDir, "Kitty", "play_stats.json")
+}
+
+func (s *Store) Path() string {
+	return s.path
+}
+
+func (s *Store) RecordPlay(path, artist string) error {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, err := s.loadLocked()
+	if err != nil {
+		return err
+	}
+	now := time.Now().Unix()
+	st := d.Tracks[path]
+	st.Path = path
+	st.PlayCount++
+	st.LastPlayed = now
+	d.Tracks[path] = st
+
+	d.History = append(d.History, Play{Path: path, Artist: strings.TrimSpace(artist), PlayedAt: now})
+	if len(d.History) > maxHistory {
+		d.History = d.History[len(d.History)-maxHistory:]
+	}
+	return s.saveLocked(d)
+}
+
+func (s *Store) Get(path string) (TrackStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, err := s.loadLocked()
+	if err != nil {
+		return TrackStats{}, err
+	}
+	st, ok := d.Tracks[path]
+	if !ok {
+		return TrackStats{Path: path}, nil
+	}
+	return st, nil
+}
+
+func (s *Store) Recent(n int) ([]Play, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, err := s.loadLocked()
+	if err != nil {
+		return nil, err
+	}
+	if n <= 0 || n > len(d.History) {
+		n = len(d.History)
+	}
+	return append([]Play{}, d.History[len(d.History)-n:]...), nil
+}
+
+func (s *Store) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *Store) loadLocked() (*data, error) {
+	d := &data{Tracks: make(map[string]TrackStats)}
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return d, nil
+		}
+		return nil, err
+	}
+	if strings.TrimSpace(string(raw)) == "" {
+		return d, nil
+	}
+	if err := json.Unmarshal(raw, d); err != nil {
+		return nil, err
+	}
+	if d.Tracks == nil {
+		d.Tracks = make(map[string]TrackStats)
+	}
+	return d, nil
+}
+
+func (s *Store) saveLocked(d *data) error {
+	raw, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, raw, 0o644)
+}
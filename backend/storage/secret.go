@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const sealedPrefix = "enc:v1:"
+
+var (
+	secretKeyMu sync.Mutex
+	secretKey   []byte
+)
+
+func secretKeyPath() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil || configDir == "" {
+		return "kitty_secret.key"
+	}
+	return filepath.Join(configDir, "Kitty", "secret.key")
+}
+
+func loadSecretKey() ([]byte, error) {
+	secretKeyMu.Lock()
+	defer secretKeyMu.Unlock()
+	if secretKey != nil {
+		return secretKey, nil
+	}
+	path := secretKeyPath()
+	key, err := os.ReadFile(path)
+	if err == nil {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("secret key file %s is corrupt (%d bytes, want 32)", path, len(key))
+		}
+		secretKey = key
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	key = make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, key, 0o600); err != nil {
+		return nil, err
+	}
+	secretKey = key
+	return key, nil
+}
+
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, sealedPrefix)
+}
+
+func Seal(plain string) (string, error) {
+	if plain == "" {
+		return "", nil
+	}
+	gcm, err := secretCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	out := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return sealedPrefix + base64.StdEncoding.EncodeToString(out), nil
+}
+
+func Unseal(value string) (string, error) {
+	if !IsSealed(value) {
+		return value, nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, sealedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("sealed secret is corrupt: %w", err)
+	}
+	gcm, err := secretCipher()
+	if err != nil {
+		return "", err
+	}
+	if len(raw) < gcm.NonceSize() {
+		return "", errors.New("sealed secret is corrupt")
+	}
+	plain, err := gcm.Open(nil, raw[:gcm.NonceSize()], raw[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("sealed secret cannot be decrypted with this machine's key")
+	}
+	return string(plain), nil
+}
+
+func secretCipher() (cipher.AEAD, error) {
+	key, err := loadSecretKey()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
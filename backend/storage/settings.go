@@ -9,6 +9,19 @@ import (
 type Settings struct {
 	SoundCloud SoundCloudSettings `json:"soundcloud"`
 	Downloader DownloaderSettings `json:"downloader"`
+	Webhooks   WebhookSettings    `json:"webhooks"`
+	Playback   PlaybackSettings   `json:"playback"`
+	Metadata   MetadataSettings   `json:"metadata"`
+	Import     ImportSettings     `json:"import"`
+	Cache      CacheSettings      `json:"cache"`
+	NowPlaying NowPlayingSettings `json:"nowPlaying"`
+	Resume     ResumeState        `json:"resume"`
+	Power      PowerSettings      `json:"power"`
+	Views      []SavedView        `json:"views,omitempty"`
+	Shortcuts  map[string]string  `json:"shortcuts,omitempty"`
+	Playlists  PlaylistSettings   `json:"playlists"`
+	Network    NetworkSettings    `json:"network"`
+	Snapshots  SnapshotSettings   `json:"snapshots"`
 }
 
 type SoundCloudSettings struct {
@@ -22,7 +35,137 @@ type SoundCloudSettings struct {
 }
 
 type DownloaderSettings struct {
-	AutoStart bool `json:"autoStart"`
+	AutoStart         bool     `json:"autoStart"`
+	PlaylistNumbering string   `json:"playlistNumbering"`
+	Transliterate     bool     `json:"transliterate"`
+	Scripts           []string `json:"transliterateScripts,omitempty"`
+	Duplicates        string   `json:"duplicates,omitempty"`
+	RetentionDays     int      `json:"retentionDays,omitempty"`
+}
+
+type WebhookSettings struct {
+	Enabled bool     `json:"enabled"`
+	URL     string   `json:"url"`
+	Secret  string   `json:"secret"`
+	Events  []string `json:"events"`
+}
+
+type PlaybackSettings struct {
+	SkipSilence       bool            `json:"skipSilence"`
+	SkipSilenceMinGap float64         `json:"skipSilenceMinGap"`
+	Shuffle           string          `json:"shuffle"`
+	Repeat            string          `json:"repeat"`
+	EndAction         string          `json:"endAction,omitempty"`
+	ShuffleMemory     int             `json:"shuffleMemory"`
+	ArtistSpacing     int             `json:"artistSpacing"`
+	NightMode         map[string]bool `json:"nightMode,omitempty"`
+	EQ                []float64       `json:"eq,omitempty"`
+	EQPreset          string          `json:"eqPreset,omitempty"`
+	ReplayGain        string          `json:"replayGain,omitempty"`
+	Normalize         bool            `json:"normalize,omitempty"`
+	LoudnessTarget    float64         `json:"loudnessTarget,omitempty"`
+	ResampleQuality   string          `json:"resampleQuality,omitempty"`
+	Mono              bool            `json:"mono,omitempty"`
+	Balance           float64         `json:"balance,omitempty"`
+	Preamp            float64         `json:"preamp,omitempty"`
+	FadeMs            int             `json:"fadeMs,omitempty"`
+	DisableFades      bool            `json:"disableFades,omitempty"`
+	FollowDevice      bool            `json:"followDevice,omitempty"`
+	BitPerfect        bool            `json:"bitPerfect,omitempty"`
+	DisableResume     bool            `json:"disableResume,omitempty"`
+	Crossfeed         bool            `json:"crossfeed,omitempty"`
+	CrossfeedLevel    float64         `json:"crossfeedLevel,omitempty"`
+}
+
+type MetadataSettings struct {
+	SidecarLocation string         `json:"sidecarLocation"`
+	BrowseMode      string         `json:"browseMode,omitempty"`
+	CustomColumns   []CustomColumn `json:"customColumns,omitempty"`
+}
+
+type CustomColumn struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type TrackQuery struct {
+	Search      string   `json:"search,omitempty"`
+	ColorLabels []string `json:"colorLabels,omitempty"`
+	Column      string   `json:"column,omitempty"`
+	Value       string   `json:"value,omitempty"`
+	Min         *float64 `json:"min,omitempty"`
+	Max         *float64 `json:"max,omitempty"`
+	SortBy      string   `json:"sortBy,omitempty"`
+	Descending  bool     `json:"descending,omitempty"`
+}
+
+type SavedView struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Query     TrackQuery `json:"query"`
+	CreatedAt int64      `json:"createdAt"`
+	UpdatedAt int64      `json:"updatedAt"`
+}
+
+type ImportSettings struct {
+	IgnoreFolders  []string `json:"ignoreFolders"`
+	IgnoreGlobs    []string `json:"ignoreGlobs"`
+	MinFileSizeKB  int64    `json:"minFileSizeKb"`
+	MinDurationSec float64  `json:"minDurationSec"`
+}
+
+type PlaylistSettings struct {
+	FolderRoots []string `json:"folderRoots,omitempty"`
+}
+
+type SnapshotSettings struct {
+	Keep int `json:"keep,omitempty"`
+}
+
+type PowerSettings struct {
+	Mode             string `json:"mode,omitempty"`
+	BatteryThreshold int    `json:"batteryThreshold,omitempty"`
+}
+
+type NowPlayingSettings struct {
+	Enabled         bool   `json:"enabled"`
+	Webhook         bool   `json:"webhook"`
+	MQTTBroker      string `json:"mqttBroker"`
+	MQTTTopic       string `json:"mqttTopic"`
+	MQTTUsername    string `json:"mqttUsername"`
+	MQTTPassword    string `json:"mqttPassword"`
+	MQTTRetain      bool   `json:"mqttRetain"`
+	PayloadTemplate string `json:"payloadTemplate"`
+}
+
+type ResumeState struct {
+	Path       string   `json:"path"`
+	Position   float64  `json:"position"`
+	Queue      []string `json:"queue,omitempty"`
+	QueueIndex int      `json:"queueIndex"`
+	SavedAt    int64    `json:"savedAt,omitempty"`
+}
+
+type NetworkSettings struct {
+	Timeouts map[string]HTTPPolicy `json:"timeouts,omitempty"`
+}
+
+type HTTPPolicy struct {
+	ConnectSec float64 `json:"connectSec"`
+	ReadSec    float64 `json:"readSec"`
+	TotalSec   float64 `json:"totalSec"`
+	StallSec   float64 `json:"stallSec"`
+	Retries    int     `json:"retries"`
+}
+
+type CacheSettings struct {
+	CoverMB    int64 `json:"coverMb"`
+	MetadataMB int64 `json:"metadataMb"`
+}
+
+func SettingsPath() string {
+	return settingsPath()
 }
 
 func settingsPath() string {
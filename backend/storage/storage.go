@@ -7,7 +7,39 @@ import (
 )
 
 type Library struct {
-	Files []string `json:"files"`
+	Files  []string             `json:"files"`
+	Stamps map[string]FileStamp `json:"stamps,omitempty"`
+	IDs    map[string]string    `json:"ids,omitempty"`
+	Failed []FailedLoad         `json:"failed,omitempty"`
+}
+
+type FileStamp struct {
+	ModTime int64 `json:"modTime"`
+	Size    int64 `json:"size"`
+}
+
+type FailedLoad struct {
+	Path      string `json:"path"`
+	Stage     string `json:"stage"`
+	Code      string `json:"code"`
+	Error     string `json:"error"`
+	Attempts  int    `json:"attempts"`
+	FirstSeen int64  `json:"firstSeen"`
+	LastTried int64  `json:"lastTried"`
+	NextRetry int64  `json:"nextRetry,omitempty"`
+}
+
+type LibraryHealth struct {
+	Path         string `json:"path"`
+	Files        int    `json:"files"`
+	Duplicates   int    `json:"duplicates"`
+	OrphanIDs    int    `json:"orphanIds"`
+	DuplicateIDs int    `json:"duplicateIds"`
+	OrphanStamps int    `json:"orphanStamps"`
+}
+
+func (h LibraryHealth) Clean() bool {
+	return h.Duplicates == 0 && h.OrphanIDs == 0 && h.DuplicateIDs == 0 && h.OrphanStamps == 0
 }
 
 func GetConfigPath() string {
@@ -28,6 +60,114 @@ func getLegacyConfigPath() string {
 
 func SaveLibrary(files []string) error {
 	lib := Library{Files: files}
+	if stamps, err := LoadFileStamps(); err == nil && len(stamps) > 0 {
+		lib.Stamps = make(map[string]FileStamp, len(files))
+		for _, f := range files {
+			if st, ok := stamps[f]; ok {
+				lib.Stamps[f] = st
+			}
+		}
+	}
+	if ids, err := LoadTrackIDs(); err == nil && len(ids) > 0 {
+		lib.IDs = keepIDs(ids, files)
+	}
+	if failed, err := LoadFailedLoads(); err == nil {
+		lib.Failed = failed
+	}
+	return writeLibrary(lib)
+}
+
+func LoadFailedLoads() ([]FailedLoad, error) {
+	lib, err := readLibraryFile()
+	if err != nil {
+		return nil, err
+	}
+	if lib.Failed == nil {
+		lib.Failed = []FailedLoad{}
+	}
+	return lib.Failed, nil
+}
+
+func SaveFailedLoads(failed []FailedLoad) error {
+	lib, err := readLibraryFile()
+	if err != nil {
+		return err
+	}
+	lib.Failed = failed
+	return writeLibrary(lib)
+}
+
+func LoadFileStamps() (map[string]FileStamp, error) {
+	lib, err := readLibraryFile()
+	if err != nil {
+		return nil, err
+	}
+	if lib.Stamps == nil {
+		lib.Stamps = map[string]FileStamp{}
+	}
+	return lib.Stamps, nil
+}
+
+func SaveFileStamps(stamps map[string]FileStamp) error {
+	lib, err := readLibraryFile()
+	if err != nil {
+		return err
+	}
+	lib.Stamps = make(map[string]FileStamp, len(lib.Files))
+	for _, f := range lib.Files {
+		if st, ok := stamps[f]; ok {
+			lib.Stamps[f] = st
+		}
+	}
+	return writeLibrary(lib)
+}
+
+func LoadTrackIDs() (map[string]string, error) {
+	lib, err := readLibraryFile()
+	if err != nil {
+		return nil, err
+	}
+	if lib.IDs == nil {
+		lib.IDs = map[string]string{}
+	}
+	return lib.IDs, nil
+}
+
+func SaveTrackIDs(ids map[string]string) error {
+	lib, err := readLibraryFile()
+	if err != nil {
+		return err
+	}
+	lib.IDs = keepIDs(ids, lib.Files)
+	return writeLibrary(lib)
+}
+
+func keepIDs(ids map[string]string, files []string) map[string]string {
+	out := make(map[string]string, len(files))
+	for _, f := range files {
+		if id, ok := ids[f]; ok {
+			out[f] = id
+		}
+	}
+	return out
+}
+
+func readLibraryFile() (Library, error) {
+	data, err := os.ReadFile(GetConfigPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Library{Files: []string{}}, nil
+		}
+		return Library{}, err
+	}
+	var lib Library
+	if err := json.Unmarshal(data, &lib); err != nil {
+		return Library{}, err
+	}
+	return lib, nil
+}
+
+func writeLibrary(lib Library) error {
 	data, err := json.Marshal(lib)
 	if err != nil {
 		return err
@@ -76,3 +216,36 @@ func ClearLibrary() error {
 	}
 	return firstErr
 }
+
+func CheckLibrary() (LibraryHealth, error) {
+	health := LibraryHealth{Path: GetConfigPath()}
+	lib, err := readLibraryFile()
+	if err != nil {
+		return health, err
+	}
+	files := make(map[string]struct{}, len(lib.Files))
+	for _, f := range lib.Files {
+		if _, ok := files[f]; ok {
+			health.Duplicates++
+			continue
+		}
+		files[f] = struct{}{}
+	}
+	health.Files = len(files)
+	seen := make(map[string]struct{}, len(lib.IDs))
+	for path, id := range lib.IDs {
+		if _, ok := files[path]; !ok {
+			health.OrphanIDs++
+		}
+		if _, ok := seen[id]; ok {
+			health.DuplicateIDs++
+		}
+		seen[id] = struct{}{}
+	}
+	for path := range lib.Stamps {
+		if _, ok := files[path]; !ok {
+			health.OrphanStamps++
+		}
+	}
+	return health, nil
+}
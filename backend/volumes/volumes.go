@@ -0,0 +1,45 @@
+package volumes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type Volume struct {
+	Root      string `json:"root"`
+	Name      string `json:"name"`
+	Removable bool   `json:"removable"`
+	Mounted   bool   `json:"mounted"`
+	Tracks    int    `json:"tracks"`
+	Offline   int    `json:"offline"`
+}
+
+func RootOf(path string) (string, bool) {
+	clean := filepath.Clean(path)
+	return removableRoot(clean)
+}
+
+func IsMounted(root string) bool {
+	info, err := os.Stat(root)
+	if err != nil || !info.IsDir() {
+		return false
+	}
+	return mounted(root)
+}
+
+func Name(root string) string {
+	name := filepath.Base(strings.TrimRight(root, `/\`))
+	if name == "" || name == "." || name == string(filepath.Separator) {
+		return root
+	}
+	return name
+}
+
+func nthRoot(path string, depth int) (string, bool) {
+	parts := strings.Split(strings.Trim(filepath.ToSlash(path), "/"), "/")
+	if len(parts) <= depth {
+		return "", false
+	}
+	return "/" + strings.Join(parts[:depth], "/"), true
+}
@@ -0,0 +1,25 @@
+package volumes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func removableRoot(path string) (string, bool) {
+	if !strings.HasPrefix(path, "/Volumes/") {
+		return "", false
+	}
+	root, ok := nthRoot(path, 2)
+	if !ok {
+		return "", false
+	}
+	if target, err := os.Readlink(root); err == nil && filepath.Clean(target) == "/" {
+		return "", false
+	}
+	return root, true
+}
+
+func mounted(root string) bool {
+	return true
+}
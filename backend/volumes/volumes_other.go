@@ -0,0 +1,44 @@
+//go:build !darwin && !windows
+
+package volumes
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+func removableRoot(path string) (string, bool) {
+	switch {
+	case strings.HasPrefix(path, "/run/media/"):
+		return nthRoot(path, 4)
+	case strings.HasPrefix(path, "/media/"):
+		if root, ok := nthRoot(path, 2); ok && isMountPoint(root) {
+			return root, true
+		}
+		return nthRoot(path, 3)
+	case strings.HasPrefix(path, "/mnt/"):
+		return nthRoot(path, 2)
+	}
+	return "", false
+}
+
+func mounted(root string) bool {
+	return isMountPoint(root)
+}
+
+func isMountPoint(dir string) bool {
+	f, err := os.Open("/proc/mounts")
+	if err != nil {
+		return true
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && strings.ReplaceAll(fields[1], `\040`, " ") == dir {
+			return true
+		}
+	}
+	return false
+}
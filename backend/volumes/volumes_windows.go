@@ -0,0 +1,47 @@
+package volumes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	driveNoRoot    = 1
+	driveRemovable = 2
+	driveRemote    = 4
+	driveCDROM     = 5
+)
+
+var procGetDriveType = syscall.NewLazyDLL("kernel32.dll").NewProc("GetDriveTypeW")
+
+func removableRoot(path string) (string, bool) {
+	vol := filepath.VolumeName(path)
+	if len(vol) != 2 || vol[1] != ':' {
+		return "", false
+	}
+	root := strings.ToUpper(vol) + `\`
+	if sys := os.Getenv("SystemDrive"); sys != "" && strings.EqualFold(sys+`\`, root) {
+		return "", false
+	}
+	switch driveType(root) {
+	case driveNoRoot, driveRemovable, driveRemote, driveCDROM:
+		return root, true
+	}
+	return "", false
+}
+
+func driveType(root string) uintptr {
+	p, err := syscall.UTF16PtrFromString(root)
+	if err != nil {
+		return 0
+	}
+	t, _, _ := procGetDriveType.Call(uintptr(unsafe.Pointer(p)))
+	return t
+}
+
+func mounted(root string) bool {
+	return true
+}
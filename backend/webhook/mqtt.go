@@ -0,0 +1,246 @@
+package webhook
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	mqttKeepAlive   = 30
+	mqttIdleTimeout = 10 * time.Minute
+)
+
+type mqttBroker struct {
+	addr   string
+	secure bool
+}
+
+func parseBroker(raw string) (mqttBroker, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return mqttBroker{}, errors.New("mqtt broker is empty")
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "mqtt://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return mqttBroker{}, fmt.Errorf("invalid mqtt broker: %w", err)
+	}
+	b := mqttBroker{}
+	port := "1883"
+	switch u.Scheme {
+	case "mqtt", "tcp":
+	case "mqtts", "ssl", "tls":
+		b.secure = true
+		port = "8883"
+	default:
+		return mqttBroker{}, fmt.Errorf("unsupported mqtt scheme: %s", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return mqttBroker{}, errors.New("mqtt broker is missing a host")
+	}
+	if u.Port() != "" {
+		port = u.Port()
+	}
+	b.addr = net.JoinHostPort(u.Hostname(), port)
+	return b, nil
+}
+
+type mqttMessage struct {
+	broker   string
+	username string
+	password string
+	topic    string
+	payload  []byte
+	retain   bool
+}
+
+func (m mqttMessage) session() string {
+	return m.broker + "\x00" + m.username + "\x00" + m.password
+}
+
+type mqttClient struct {
+	session  string
+	conn     net.Conn
+	lastUsed time.Time
+}
+
+func (n *Notifier) queueMQTT(msg mqttMessage) {
+	n.mqttOnce.Do(func() {
+		go n.mqttLoop()
+	})
+	for {
+		select {
+		case n.mqttQueue <- msg:
+			return
+		default:
+		}
+		select {
+		case <-n.mqttQueue:
+		default:
+		}
+	}
+}
+
+func (n *Notifier) mqttLoop() {
+	var c mqttClient
+	ticker := time.NewTicker(mqttKeepAlive / 2 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case msg := <-n.mqttQueue:
+			if err := c.publish(msg); err != nil {
+				log.Printf("[webhook] mqtt publish to %s failed: %v", msg.topic, err)
+			}
+		case <-ticker.C:
+			c.keepAlive()
+		}
+	}
+}
+
+func (c *mqttClient) publish(msg mqttMessage) error {
+	if strings.TrimSpace(msg.topic) == "" {
+		return errors.New("mqtt topic is empty")
+	}
+	var header byte = 0x30
+	if msg.retain {
+		header |= 0x01
+	}
+	packet := mqttPacket(header, append(mqttString(msg.topic), msg.payload...))
+	if c.conn != nil && c.session == msg.session() {
+		if err := mqttWrite(c.conn, packet); err == nil {
+			c.lastUsed = time.Now()
+			return nil
+		}
+	}
+	c.close()
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	conn, err := mqttConnect(ctx, msg.broker, msg.username, msg.password)
+	if err != nil {
+		return err
+	}
+	c.conn, c.session = conn, msg.session()
+	if err := mqttWrite(conn, packet); err != nil {
+		c.close()
+		return err
+	}
+	c.lastUsed = time.Now()
+	return nil
+}
+
+func (c *mqttClient) keepAlive() {
+	if c.conn == nil {
+		return
+	}
+	if time.Since(c.lastUsed) >= mqttIdleTimeout {
+		c.close()
+		return
+	}
+	if err := mqttWrite(c.conn, []byte{0xC0, 0x00}); err != nil {
+		c.close()
+	}
+}
+
+func (c *mqttClient) close() {
+	if c.conn == nil {
+		return
+	}
+	_ = mqttWrite(c.conn, []byte{0xE0, 0x00})
+	_ = c.conn.Close()
+	c.conn, c.session = nil, ""
+}
+
+func mqttConnect(ctx context.Context, broker, username, password string) (net.Conn, error) {
+	b, err := parseBroker(broker)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var conn net.Conn
+	if b.secure {
+		host, _, _ := net.SplitHostPort(b.addr)
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", b.addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", b.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	var flags byte = 0x02
+	body := mqttString("MQTT")
+	body = append(body, 0x04)
+	vars := []byte{}
+	vars = append(vars, mqttString(fmt.Sprintf("kitty-%d", time.Now().UnixNano()%1e9))...)
+	if username != "" {
+		flags |= 0x80
+		vars = append(vars, mqttString(username)...)
+		if password != "" {
+			flags |= 0x40
+			vars = append(vars, mqttString(password)...)
+		}
+	}
+	body = append(body, flags, 0, mqttKeepAlive)
+	body = append(body, vars...)
+	if _, err := conn.Write(mqttPacket(0x10, body)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	r := bufio.NewReader(conn)
+	ack := make([]byte, 4)
+	if _, err := io.ReadFull(r, ack); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("mqtt connack: %w", err)
+	}
+	if ack[0] != 0x20 || ack[3] != 0 {
+		conn.Close()
+		return nil, fmt.Errorf("mqtt broker refused connection (code %d)", ack[3])
+	}
+	_ = conn.SetDeadline(time.Time{})
+	go func() {
+		_, _ = io.Copy(io.Discard, r)
+	}()
+	return conn, nil
+}
+
+func mqttWrite(conn net.Conn, packet []byte) error {
+	_ = conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	_, err := conn.Write(packet)
+	return err
+}
+
+func mqttString(s string) []byte {
+	return append([]byte{byte(len(s) >> 8), byte(len(s))}, s...)
+}
+
+func mqttPacket(header byte, body []byte) []byte {
+	out := []byte{header}
+	n := len(body)
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		out = append(out, digit)
+		if n == 0 {
+			break
+		}
+	}
+	return append(out, body...)
+}
@@ -0,0 +1,125 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+
+	"kitty/backend/storage"
+)
+
+const (
+	EventPlaybackChanged = "playback.changed"
+
+	DefaultNowPlayingTopic = "kitty/now-playing"
+)
+
+type NowPlaying struct {
+	State    string  `json:"state"`
+	Path     string  `json:"path,omitempty"`
+	Title    string  `json:"title,omitempty"`
+	Artist   string  `json:"artist,omitempty"`
+	Album    string  `json:"album,omitempty"`
+	Position float64 `json:"position"`
+	Duration float64 `json:"duration"`
+}
+
+func ValidateNowPlaying(cfg storage.NowPlayingSettings) error {
+	if strings.TrimSpace(cfg.MQTTBroker) != "" {
+		if _, err := parseBroker(cfg.MQTTBroker); err != nil {
+			return err
+		}
+	}
+	if strings.TrimSpace(cfg.PayloadTemplate) != "" {
+		if _, err := template.New("payload").Parse(cfg.PayloadTemplate); err != nil {
+			return fmt.Errorf("invalid payload template: %w", err)
+		}
+	}
+	return nil
+}
+
+func SealNowPlaying(cfg storage.NowPlayingSettings) (storage.NowPlayingSettings, error) {
+	if cfg.MQTTPassword == "" || storage.IsSealed(cfg.MQTTPassword) {
+		return cfg, nil
+	}
+	sealed, err := storage.Seal(cfg.MQTTPassword)
+	if err != nil {
+		return cfg, fmt.Errorf("encrypt mqtt password: %w", err)
+	}
+	cfg.MQTTPassword = sealed
+	return cfg, nil
+}
+
+func (n *Notifier) PublishNowPlaying(np NowPlaying) {
+	set, err := storage.LoadSettings()
+	if err != nil {
+		log.Printf("[webhook] load settings failed: %v", err)
+		return
+	}
+	cfg := set.NowPlaying
+	if !cfg.Enabled {
+		return
+	}
+
+	n.mu.Lock()
+	if n.lastNowPlaying.State == np.State && n.lastNowPlaying.Path == np.Path {
+		n.mu.Unlock()
+		return
+	}
+	n.lastNowPlaying = np
+	n.mu.Unlock()
+
+	summary := fmt.Sprintf("Kitty %s", np.State)
+	if np.Title != "" {
+		summary = fmt.Sprintf("Kitty %s: %s", np.State, np.Title)
+		if np.Artist != "" {
+			summary += " - " + np.Artist
+		}
+	}
+	if cfg.Webhook {
+		n.Notify(EventPlaybackChanged, summary, np)
+	}
+	if strings.TrimSpace(cfg.MQTTBroker) == "" {
+		return
+	}
+	payload, err := nowPlayingPayload(cfg.PayloadTemplate, np)
+	if err != nil {
+		log.Printf("[webhook] now playing payload failed: %v", err)
+		return
+	}
+	topic := strings.TrimSpace(cfg.MQTTTopic)
+	if topic == "" {
+		topic = DefaultNowPlayingTopic
+	}
+	password, err := storage.Unseal(cfg.MQTTPassword)
+	if err != nil {
+		log.Printf("[webhook] mqtt password: %v", err)
+		return
+	}
+	n.queueMQTT(mqttMessage{
+		broker:   cfg.MQTTBroker,
+		username: cfg.MQTTUsername,
+		password: password,
+		topic:    topic,
+		payload:  payload,
+		retain:   cfg.MQTTRetain,
+	})
+}
+
+func nowPlayingPayload(tmpl string, np NowPlaying) ([]byte, error) {
+	if strings.TrimSpace(tmpl) == "" {
+		return json.Marshal(np)
+	}
+	t, err := template.New("payload").Parse(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, np); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
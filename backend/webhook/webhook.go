@@ -0,0 +1,151 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"kitty/backend/httpclient"
+	"kitty/backend/storage"
+)
+
+const (
+	EventDownloadCompleted = "download.completed"
+	EventDownloadFailed    = "download.failed"
+	EventLibrarySynced     = "library.synced"
+	EventTest              = "test"
+)
+
+type Payload struct {
+	Event     string      `json:"event"`
+	Content   string      `json:"content"`
+	Timestamp int64       `json:"timestamp"`
+	Data      interface{} `json:"data,omitempty"`
+}
+
+type Notifier struct {
+	http *http.Client
+
+	mu             sync.Mutex
+	lastNowPlaying NowPlaying
+
+	mqttOnce  sync.Once
+	mqttQueue chan mqttMessage
+}
+
+func New() *Notifier {
+	return &Notifier{
+		http:      httpclient.New(httpclient.KindWebhook),
+		mqttQueue: make(chan mqttMessage, 1),
+	}
+}
+
+func (n *Notifier) Notify(event, summary string, data interface{}) {
+	set, err := storage.LoadSettings()
+	if err != nil {
+		log.Printf("[webhook] load settings failed: %v", err)
+		return
+	}
+	cfg := set.Webhooks
+	if !cfg.Enabled || strings.TrimSpace(cfg.URL) == "" || !wantsEvent(cfg.Events, event) {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		if err := n.send(ctx, cfg, event, summary, data); err != nil {
+			log.Printf("[webhook] %s delivery failed: %v", event, err)
+		}
+	}()
+}
+
+func (n *Notifier) Test(ctx context.Context, cfg storage.WebhookSettings) error {
+	if strings.TrimSpace(cfg.URL) == "" {
+		return errors.New("webhook url is empty")
+	}
+	return n.send(ctx, cfg, EventTest, "Kitty webhook test", nil)
+}
+
+func ValidateURL(raw string) error {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid webhook url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported webhook url scheme: %s", u.Scheme)
+	}
+	if u.Host == "" {
+		return errors.New("webhook url is missing a host")
+	}
+	return nil
+}
+
+func (n *Notifier) send(ctx context.Context, cfg storage.WebhookSettings, event, summary string, data interface{}) error {
+	if err := ValidateURL(cfg.URL); err != nil {
+		return err
+	}
+	body, err := json.Marshal(Payload{
+		Event:     event,
+		Content:   summary,
+		Timestamp: time.Now().Unix(),
+		Data:      data,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSpace(cfg.URL), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Kitty")
+	req.Header.Set("X-Kitty-Event", event)
+	if secret := strings.TrimSpace(cfg.Secret); secret != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		req.Header.Set("X-Kitty-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	res, err := n.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		raw, _ := io.ReadAll(io.LimitReader(res.Body, 4*1024))
+		return fmt.Errorf("webhook responded with %s: %s", res.Status, strings.TrimSpace(string(raw)))
+	}
+	io.Copy(io.Discard, res.Body)
+	return nil
+}
+
+func wantsEvent(events []string, event string) bool {
+	if len(events) == 0 {
+		return true
+	}
+	for _, e := range events {
+		if strings.EqualFold(strings.TrimSpace(e), event) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,287 @@
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"kitty/backend/httpclient"
+)
+
+const (
+	playlistURL = "https://www.youtube.com/playlist?list="
+	watchURL    = "https://www.youtube.com/watch?v="
+	browseURL   = "https://www.youtube.com/youtubei/v1/browse?prettyPrint=false"
+	maxPageSize = 8 * 1024 * 1024
+
+	maxContinuations     = 200
+	defaultClientVersion = "2.20240101.00.00"
+)
+
+var (
+	apiKeyPattern        = regexp.MustCompile(`"INNERTUBE_API_KEY"\s*:\s*"([^"]+)"`)
+	clientVersionPattern = regexp.MustCompile(`"INNERTUBE_CLIENT_VERSION"\s*:\s*"([^"]+)"`)
+)
+
+type Entry struct {
+	VideoID     string `json:"videoId"`
+	Title       string `json:"title"`
+	Channel     string `json:"channel"`
+	DurationSec int    `json:"durationSec"`
+	URL         string `json:"url"`
+	Position    int    `json:"position"`
+}
+
+type Playlist struct {
+	ID        string  `json:"id"`
+	Title     string  `json:"title"`
+	Entries   []Entry `json:"entries"`
+	Truncated bool    `json:"truncated,omitempty"`
+}
+
+type Client struct {
+	http *http.Client
+}
+
+func New() *Client {
+	return &Client{
+		http: httpclient.New(httpclient.KindYouTube),
+	}
+}
+
+func PlaylistID(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", fmt.Errorf("invalid playlist url: %w", err)
+	}
+	host := strings.ToLower(strings.TrimPrefix(u.Hostname(), "www."))
+	if host != "youtube.com" && host != "music.youtube.com" && host != "m.youtube.com" && host != "youtu.be" {
+		return "", fmt.Errorf("not a youtube url: %s", raw)
+	}
+	id := strings.TrimSpace(u.Query().Get("list"))
+	if id == "" {
+		return "", errors.New("youtube url does not contain a playlist id (list=...)")
+	}
+	return id, nil
+}
+
+func (c *Client) FetchPlaylist(ctx context.Context, rawURL string) (*Playlist, error) {
+	id, err := PlaylistID(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, playlistURL+url.QueryEscape(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	page, err := c.fetch(req)
+	if err != nil {
+		return nil, fmt.Errorf("youtube playlist fetch failed: %w", err)
+	}
+	data, err := extractInitialData(page)
+	if err != nil {
+		return nil, err
+	}
+
+	col := &collector{pl: &Playlist{ID: id}, seen: make(map[string]struct{})}
+	col.walk(data)
+	if len(col.pl.Entries) == 0 {
+		return nil, errors.New("no playlist entries found (the playlist may be private or unavailable)")
+	}
+
+	cfg := innertubeConfig(page)
+	for pages := 0; col.token != ""; pages++ {
+		if pages >= maxContinuations {
+			col.pl.Truncated = true
+			break
+		}
+		next, err := c.fetchContinuation(ctx, cfg, col.token)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Printf("[youtube] playlist %s continuation failed after %d entries: %v", id, len(col.pl.Entries), err)
+			col.pl.Truncated = true
+			break
+		}
+		col.token = ""
+		col.walk(next)
+	}
+	return col.pl, nil
+}
+
+type collector struct {
+	pl    *Playlist
+	seen  map[string]struct{}
+	token string
+}
+
+func (c *collector) walk(v interface{}) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if r, ok := t["playlistVideoRenderer"].(map[string]interface{}); ok {
+			if e, ok := parseEntry(r); ok {
+				if _, dup := c.seen[e.VideoID]; dup {
+					return
+				}
+				c.seen[e.VideoID] = struct{}{}
+				e.Position = len(c.pl.Entries) + 1
+				c.pl.Entries = append(c.pl.Entries, e)
+			}
+			return
+		}
+		if r, ok := t["continuationItemRenderer"].(map[string]interface{}); ok {
+			if token := continuationToken(r); token != "" {
+				c.token = token
+			}
+			return
+		}
+		if r, ok := t["playlistHeaderRenderer"].(map[string]interface{}); ok && c.pl.Title == "" {
+			c.pl.Title = text(r["title"])
+		}
+		if r, ok := t["playlistMetadataRenderer"].(map[string]interface{}); ok && c.pl.Title == "" {
+			c.pl.Title, _ = r["title"].(string)
+		}
+		for _, child := range t {
+			c.walk(child)
+		}
+	case []interface{}:
+		for _, child := range t {
+			c.walk(child)
+		}
+	}
+}
+
+func continuationToken(r map[string]interface{}) string {
+	endpoint, _ := r["continuationEndpoint"].(map[string]interface{})
+	cmd, _ := endpoint["continuationCommand"].(map[string]interface{})
+	token, _ := cmd["token"].(string)
+	return strings.TrimSpace(token)
+}
+
+type innertube struct {
+	key     string
+	version string
+}
+
+func innertubeConfig(page []byte) innertube {
+	cfg := innertube{version: defaultClientVersion}
+	if m := apiKeyPattern.FindSubmatch(page); m != nil {
+		cfg.key = string(m[1])
+	}
+	if m := clientVersionPattern.FindSubmatch(page); m != nil {
+		cfg.version = string(m[1])
+	}
+	return cfg
+}
+
+func (c *Client) fetchContinuation(ctx context.Context, cfg innertube, token string) (interface{}, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"context": map[string]interface{}{
+			"client": map[string]interface{}{"clientName": "WEB", "clientVersion": cfg.version, "hl": "en"},
+		},
+		"continuation": token,
+	})
+	if err != nil {
+		return nil, err
+	}
+	endpoint := browseURL
+	if cfg.key != "" {
+		endpoint += "&key=" + url.QueryEscape(cfg.key)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	raw, err := c.fetch(req)
+	if err != nil {
+		return nil, err
+	}
+	var data interface{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("parse youtube continuation: %w", err)
+	}
+	return data, nil
+}
+
+func (c *Client) fetch(req *http.Request) ([]byte, error) {
+	req.Header.Set("Accept-Language", "en-US,en;q=0.8")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0 Safari/537.36")
+	res, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return nil, errors.New(res.Status)
+	}
+	return io.ReadAll(io.LimitReader(res.Body, maxPageSize))
+}
+
+func extractInitialData(page []byte) (interface{}, error) {
+	markers := [][]byte{[]byte("var ytInitialData = "), []byte("window[\"ytInitialData\"] = "), []byte("ytInitialData = ")}
+	for _, marker := range markers {
+		idx := bytes.Index(page, marker)
+		if idx < 0 {
+			continue
+		}
+		dec := json.NewDecoder(bytes.NewReader(page[idx+len(marker):]))
+		var data interface{}
+		if err := dec.Decode(&data); err != nil {
+			return nil, fmt.Errorf("parse youtube playlist data: %w", err)
+		}
+		return data, nil
+	}
+	return nil, errors.New("youtube playlist data not found in page")
+}
+
+func parseEntry(r map[string]interface{}) (Entry, bool) {
+	id, _ := r["videoId"].(string)
+	if strings.TrimSpace(id) == "" {
+		return Entry{}, false
+	}
+	e := Entry{
+		VideoID: id,
+		Title:   strings.TrimSpace(text(r["title"])),
+		Channel: strings.TrimSuffix(strings.TrimSpace(text(r["shortBylineText"])), " - Topic"),
+		URL:     watchURL + id,
+	}
+	if secs, ok := r["lengthSeconds"].(string); ok {
+		e.DurationSec, _ = strconv.Atoi(secs)
+	}
+	return e, true
+}
+
+func text(v interface{}) string {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	if s, ok := m["simpleText"].(string); ok {
+		return s
+	}
+	runs, ok := m["runs"].([]interface{})
+	if !ok {
+		return ""
+	}
+	var b strings.Builder
+	for _, run := range runs {
+		if rm, ok := run.(map[string]interface{}); ok {
+			if s, ok := rm["text"].(string); ok {
+				b.WriteString(s)
+			}
+		}
+	}
+	return b.String()
+}
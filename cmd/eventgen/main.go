@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"log"
+	"os"
+
+	"kitty/backend/events"
+)
+
+func main() {
+	out := flag.String("out", events.DefaultTSOutput, "output TypeScript file")
+	check := flag.Bool("check", false, "fail if the output file is out of date")
+	flag.Parse()
+
+	var buf bytes.Buffer
+	if err := events.WriteTypeScript(&buf); err != nil {
+		log.Fatalf("[eventgen] generate failed: %v", err)
+	}
+	if *check {
+		existing, err := os.ReadFile(*out)
+		if err != nil || !bytes.Equal(existing, buf.Bytes()) {
+			log.Fatalf("[eventgen] %s is out of date, run go generate", *out)
+		}
+		return
+	}
+	if err := os.WriteFile(*out, buf.Bytes(), 0o644); err != nil {
+		log.Fatalf("[eventgen] write failed: %v", err)
+	}
+}
@@ -3,6 +3,7 @@ import { metadata } from '../../wailsjs/go/models';
 import { BulkUpdateMetadata } from '../../wailsjs/go/main/App';
 import { useMetadata } from '../hooks/useMetadata';
 import { Search, CheckSquare, Square, ImagePlus, Save, AlertTriangle, CheckCircle2 } from 'lucide-react';
+import { errorMessage } from '../errors';
 
 type SortBy = 'recent' | 'title' | 'artist' | 'album';
 
@@ -246,7 +247,7 @@ export const BulkEditor: React.FC<BulkEditorProps> = ({ metadataHook }) => {
             setStatus(`Updated ${succeeded}/${total} tracks${failed > 0 ? ` (${failed} failed)` : ''}.`);
             setBatchErrors(res?.errors ?? []);
         } catch (e: any) {
-            setError(e?.toString?.() ?? 'Bulk update failed.');
+            setError(errorMessage(e, 'Bulk update failed.'));
         } finally {
             setIsApplying(false);
         }
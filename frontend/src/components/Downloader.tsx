@@ -3,6 +3,7 @@ import { DownloadMedia, StartDownloader, ChooseDownloadFolder } from '../../wail
 import { useMetadata } from '../hooks/useMetadata';
 import { DownloadCloud, FolderOpen, CheckCircle2 } from 'lucide-react';
 import { SoundCloudLikes } from './SoundCloudLikes';
+import { errorMessage } from '../errors';
 
 interface DownloaderProps {
     metadataHook: ReturnType<typeof useMetadata>;
@@ -30,7 +31,7 @@ export const Downloader: React.FC<DownloaderProps> = ({ metadataHook, openSettin
                 localStorage.setItem(DIR_KEY, dir);
             }
         } catch (err: any) {
-            setError(err?.toString?.() ?? 'Failed to choose folder');
+            setError(errorMessage(err, 'Failed to choose folder'));
         }
     };
 
@@ -58,7 +59,7 @@ export const Downloader: React.FC<DownloaderProps> = ({ metadataHook, openSettin
                 setStatus('Download cancelled.');
             }
         } catch (err: any) {
-            setError(err?.toString?.() ?? 'Download failed');
+            setError(errorMessage(err, 'Download failed'));
         } finally {
             setIsDownloading(false);
         }
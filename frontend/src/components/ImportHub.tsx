@@ -2,6 +2,7 @@ import React, { useState } from 'react';
 import { Film, Music2, FolderUp, Clapperboard, Loader2, CheckCircle2, FolderOpen } from 'lucide-react';
 import { SelectVideoFile, ExtractAudioFromVideo, ChooseDownloadFolder } from '../../wailsjs/go/main/App';
 import { useMetadata } from '../hooks/useMetadata';
+import { errorMessage } from '../errors';
 
 interface ImportHubProps {
     metadataHook: ReturnType<typeof useMetadata>;
@@ -26,7 +27,7 @@ export const ImportHub: React.FC<ImportHubProps> = ({ metadataHook }) => {
                 setVideoPath(picked.trim());
             }
         } catch (e: any) {
-            setError(e?.toString?.() ?? 'Failed to select video file');
+            setError(errorMessage(e, 'Failed to select video file'));
         }
     };
 
@@ -41,7 +42,7 @@ export const ImportHub: React.FC<ImportHubProps> = ({ metadataHook }) => {
                 localStorage.setItem(DIR_KEY, cleaned);
             }
         } catch (e: any) {
-            setError(e?.toString?.() ?? 'Failed to choose output folder');
+            setError(errorMessage(e, 'Failed to choose output folder'));
         }
     };
 
@@ -72,7 +73,7 @@ export const ImportHub: React.FC<ImportHubProps> = ({ metadataHook }) => {
             }
             setStatus(`Extracted and imported: ${savedPath}`);
         } catch (e: any) {
-            setError(e?.toString?.() ?? 'Audio extraction failed');
+            setError(errorMessage(e, 'Audio extraction failed'));
         } finally {
             setIsExtracting(false);
         }
@@ -2,6 +2,7 @@ import React, { useMemo, useState } from 'react';
 import { Trash2, AlertTriangle } from 'lucide-react';
 import { DeleteTrimBackup, GetDownloaderAutoStart, ListTrimBackups, ResetAppData, RestoreTrimBackup, SetDownloaderAutoStart, SoundCloudLogout, SoundCloudSetCredentials, SoundCloudStatus, SoundCloudValidateCredentials } from '../../wailsjs/go/main/App';
 import { useMetadata } from '../hooks/useMetadata';
+import { errorMessage } from '../errors';
 
 interface SettingsProps {
     metadataHook: ReturnType<typeof useMetadata>;
@@ -59,7 +60,7 @@ export const Settings: React.FC<SettingsProps> = ({ metadataHook }) => {
             const auto = await GetDownloaderAutoStart();
             setDlAutoStart(!!auto);
         } catch (e: any) {
-            if (!silent) setDlError(e?.toString?.() ?? 'Failed to load downloader setting');
+            if (!silent) setDlError(errorMessage(e, 'Failed to load downloader setting'));
         }
     };
 
@@ -78,7 +79,7 @@ export const Settings: React.FC<SettingsProps> = ({ metadataHook }) => {
             });
             setScClientId(prev => (prev.trim() === '' && cid ? cid : prev));
         } catch (e: any) {
-            if (!silent) setScError(e?.toString?.() ?? 'Failed to load SoundCloud status');
+            if (!silent) setScError(errorMessage(e, 'Failed to load SoundCloud status'));
         }
     };
 
@@ -91,7 +92,7 @@ export const Settings: React.FC<SettingsProps> = ({ metadataHook }) => {
             const list = await ListTrimBackups('');
             setTrimBackups(Array.isArray(list) ? (list as any[]) : []);
         } catch (e: any) {
-            if (!silent) setTrimError(e?.toString?.() ?? 'Failed to load trim backups');
+            if (!silent) setTrimError(errorMessage(e, 'Failed to load trim backups'));
         }
     };
 
@@ -108,7 +109,7 @@ export const Settings: React.FC<SettingsProps> = ({ metadataHook }) => {
             setDlAutoStart(autoStart);
             setDlInfo(autoStart ? 'Set to start on app launch.' : 'Set to start when downloading.');
         } catch (e: any) {
-            setDlError(e?.toString?.() ?? 'Failed to save setting');
+            setDlError(errorMessage(e, 'Failed to save setting'));
         } finally {
             setDlBusy(false);
         }
@@ -131,7 +132,7 @@ export const Settings: React.FC<SettingsProps> = ({ metadataHook }) => {
             setScInfo(scStatus.connected ? 'Updated. You were disconnected; reconnect from Downloader → SoundCloud Likes.' : 'Saved. You can now connect from Downloader → SoundCloud Likes.');
             await refreshSoundCloud(true);
         } catch (e: any) {
-            setScError(e?.toString?.() ?? 'Failed to save credentials');
+            setScError(errorMessage(e, 'Failed to save credentials'));
         } finally {
             setScBusy(false);
         }
@@ -145,7 +146,7 @@ export const Settings: React.FC<SettingsProps> = ({ metadataHook }) => {
             await SoundCloudValidateCredentials();
             setScInfo('Credentials look valid.');
         } catch (e: any) {
-            setScError(e?.toString?.() ?? 'Credential check failed');
+            setScError(errorMessage(e, 'Credential check failed'));
         } finally {
             setScBusy(false);
         }
@@ -165,7 +166,7 @@ export const Settings: React.FC<SettingsProps> = ({ metadataHook }) => {
             setScInfo('Cleared.');
             await refreshSoundCloud(true);
         } catch (e: any) {
-            setScError(e?.toString?.() ?? 'Failed to clear credentials');
+            setScError(errorMessage(e, 'Failed to clear credentials'));
         } finally {
             setScBusy(false);
         }
@@ -180,7 +181,7 @@ export const Settings: React.FC<SettingsProps> = ({ metadataHook }) => {
             setScInfo('Disconnected.');
             await refreshSoundCloud(true);
         } catch (e: any) {
-            setScError(e?.toString?.() ?? 'Failed to disconnect');
+            setScError(errorMessage(e, 'Failed to disconnect'));
         } finally {
             setScBusy(false);
         }
@@ -199,7 +200,7 @@ export const Settings: React.FC<SettingsProps> = ({ metadataHook }) => {
             await loadTrimBackups(true);
             setTrimInfo('Backup restored.');
         } catch (e: any) {
-            setTrimError(e?.toString?.() ?? 'Failed to restore backup');
+            setTrimError(errorMessage(e, 'Failed to restore backup'));
         } finally {
             setTrimBusy(false);
         }
@@ -213,7 +214,7 @@ export const Settings: React.FC<SettingsProps> = ({ metadataHook }) => {
             await DeleteTrimBackup(backupID);
             await loadTrimBackups(true);
         } catch (e: any) {
-            setTrimError(e?.toString?.() ?? 'Failed to delete backup');
+            setTrimError(errorMessage(e, 'Failed to delete backup'));
         } finally {
             setTrimBusy(false);
         }
@@ -232,7 +233,7 @@ export const Settings: React.FC<SettingsProps> = ({ metadataHook }) => {
             }
             window.location.reload();
         } catch (e: any) {
-            setError(e?.toString?.() ?? 'Reset failed');
+            setError(errorMessage(e, 'Reset failed'));
         } finally {
             setIsResetting(false);
         }
@@ -2,6 +2,7 @@ import React, { useEffect, useMemo, useRef, useState } from 'react';
 import { DownloadCloud, FolderOpen, CheckCircle2, LogIn, LogOut, RefreshCw, AlertTriangle } from 'lucide-react';
 import { DownloadMedia, SoundCloudBeginAuth, SoundCloudListLikes, SoundCloudLogout, SoundCloudStatus } from '../../wailsjs/go/main/App';
 import { useMetadata } from '../hooks/useMetadata';
+import { errorMessage } from '../errors';
 
 type Track = {
     title: string;
@@ -90,7 +91,7 @@ export const SoundCloudLikes: React.FC<Props> = ({
             setStatus({ configured: !!(s as any)?.configured, connected: !!(s as any)?.connected, username: (s as any)?.username || '' });
         } catch (e: any) {
             if (!silent) {
-                setError(e?.toString?.() ?? 'Failed to load SoundCloud status');
+                setError(errorMessage(e, 'Failed to load SoundCloud status'));
             }
         }
     };
@@ -141,7 +142,7 @@ export const SoundCloudLikes: React.FC<Props> = ({
             setLoadInfo(total > 0 ? `Loaded ${total} likes.` : 'No liked tracks found.');
         } catch (e: any) {
             if (seq !== loadSeq.current) return;
-            setError(e?.toString?.() ?? 'Failed to load likes');
+            setError(errorMessage(e, 'Failed to load likes'));
         } finally {
             if (seq === loadSeq.current) setIsLoading(false);
         }
@@ -170,7 +171,7 @@ export const SoundCloudLikes: React.FC<Props> = ({
             }
             setError("Login timed out. If the SoundCloud page is blank, try a different browser or disable content blockers, then try again.");
         } catch (e: any) {
-            setError(e?.toString?.() ?? 'Failed to start SoundCloud login');
+            setError(errorMessage(e, 'Failed to start SoundCloud login'));
         } finally {
             setIsConnecting(false);
         }
@@ -188,7 +189,7 @@ export const SoundCloudLikes: React.FC<Props> = ({
             setDownloadState({});
             await refreshStatus();
         } catch (e: any) {
-            setError(e?.toString?.() ?? 'Failed to disconnect');
+            setError(errorMessage(e, 'Failed to disconnect'));
         }
     };
 
@@ -226,12 +227,12 @@ export const SoundCloudLikes: React.FC<Props> = ({
             try {
                 await metadataHook.addFilesByPath([savedPath]);
             } catch (e: any) {
-                setIndicator(url, { state: 'error', message: e?.toString?.() ?? 'Saved, but failed to import into library.' });
+                setIndicator(url, { state: 'error', message: errorMessage(e, 'Saved, but failed to import into library.') });
                 return;
             }
             markSuccess(url);
         } catch (e: any) {
-            setIndicator(url, { state: 'error', message: e?.toString?.() ?? 'Download failed' });
+            setIndicator(url, { state: 'error', message: errorMessage(e, 'Download failed') });
         } finally {
             setDownloadingUrl(null);
         }
@@ -2,6 +2,7 @@ import React, { useEffect, useMemo, useRef, useState } from 'react';
 import { AlertTriangle, CheckCircle2, Loader2, Pause, Play, RefreshCw, Scissors } from 'lucide-react';
 import { useMetadata } from '../hooks/useMetadata';
 import { GetAudioState, GetTrimWaveform, LoadAudio, PauseAudio, PlayAudio, SeekAudio, TrimTrack } from '../../wailsjs/go/main/App';
+import { errorMessage } from '../errors';
 
 interface TrimEditorProps {
     metadataHook: ReturnType<typeof useMetadata>;
@@ -178,7 +179,7 @@ export const TrimEditor: React.FC<TrimEditorProps> = ({ metadataHook }) => {
             setStartMs(0);
             setEndMs(duration > 0 ? duration : 0);
         } catch (e: any) {
-            setError(e?.toString?.() ?? 'Failed to load waveform');
+            setError(errorMessage(e, 'Failed to load waveform'));
         } finally {
             setIsLoadingWave(false);
         }
@@ -504,7 +505,7 @@ export const TrimEditor: React.FC<TrimEditorProps> = ({ metadataHook }) => {
             }, 60);
         } catch (e: any) {
             setIsPreviewing(false);
-            setError(e?.toString?.() ?? 'Preview failed');
+            setError(errorMessage(e, 'Preview failed'));
         }
     };
 
@@ -525,7 +526,7 @@ export const TrimEditor: React.FC<TrimEditorProps> = ({ metadataHook }) => {
             await loadWaveform(currentTrack.filePath);
             setStatus('Trimmed successfully.');
         } catch (e: any) {
-            setError(e?.toString?.() ?? 'Trim failed');
+            setError(errorMessage(e, 'Trim failed'));
         } finally {
             setIsTrimming(false);
         }
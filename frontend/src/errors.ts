@@ -0,0 +1,31 @@
+export interface AppError {
+    code: string;
+    message: string;
+    details?: string;
+    retryable: boolean;
+}
+
+export function asAppError(e: unknown): AppError | null {
+    if (e && typeof e === 'object' && 'code' in e && 'message' in e) {
+        return e as AppError;
+    }
+    return null;
+}
+
+export function errorMessage(e: unknown, fallback = 'Something went wrong'): string {
+    const appErr = asAppError(e);
+    if (appErr) {
+        return appErr.details ? `${appErr.message}: ${appErr.details}` : appErr.message;
+    }
+    if (typeof e === 'string' && e) {
+        return e;
+    }
+    if (e instanceof Error && e.message) {
+        return e.message;
+    }
+    return fallback;
+}
+
+export function isRetryable(e: unknown): boolean {
+    return asAppError(e)?.retryable ?? false;
+}
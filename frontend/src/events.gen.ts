@@ -0,0 +1,356 @@
+// Code generated by cmd/eventgen. DO NOT EDIT.
+
+import { EventsOn } from '../wailsjs/runtime/runtime';
+
+export const API_VERSION = 1;
+
+export interface Operation {
+  id: string;
+  kind: string;
+  title: string;
+  status: string;
+  progress: number;
+  done: number;
+  total: number;
+  message?: string;
+  error?: AppError | null;
+  result?: any;
+  startedAt: number;
+  finishedAt?: number;
+}
+
+export interface TokenHealth {
+  connected: boolean;
+  expiresAt: number;
+  refreshed: boolean;
+  needsReconnect: boolean;
+  error?: string;
+}
+
+export interface LikesProgress {
+  tracks: Track[];
+  page: number;
+  loaded: number;
+  total: number;
+  done: boolean;
+}
+
+export interface LikesDone {
+  loaded: number;
+  total: number;
+  error?: string;
+}
+
+export interface RescanProgress {
+  done: number;
+  total: number;
+  path: string;
+}
+
+export interface StemJob {
+  id: string;
+  path: string;
+  model: string;
+  status: string;
+  progress: number;
+  instrumental?: string;
+  vocals?: string;
+  error?: string;
+  startedAt: number;
+  finishedAt?: number;
+  outputs?: string[];
+}
+
+export interface Recording {
+  path: string;
+  device: string;
+  format: string;
+  startedAt: number;
+  stoppedAt?: number;
+}
+
+export interface HydrateProgress {
+  path: string;
+  done: number;
+  total: number;
+  finished: boolean;
+  error?: string;
+}
+
+export interface Volume {
+  root: string;
+  name: string;
+  removable: boolean;
+  mounted: boolean;
+  tracks: number;
+  offline: number;
+}
+
+export interface Timing {
+  name: string;
+  offsetMs: number;
+  durationMs: number;
+  background: boolean;
+  done: boolean;
+  error?: string;
+}
+
+export interface State {
+  items: Item[];
+  current: number;
+  shuffle: string;
+  repeat: string;
+  endAction: string;
+  endOverride?: string;
+}
+
+export interface Position {
+  path: string;
+  position: number;
+  duration: number;
+  playing: boolean;
+}
+
+export interface TrackEnded {
+  path: string;
+}
+
+export interface ChapterChange {
+  path: string;
+  chapter: Chapter;
+}
+
+export interface PowerState {
+  status: Status;
+  policy: Policy;
+  paused: boolean;
+  reason?: string;
+}
+
+export interface MediaKey {
+  command: string;
+  error?: string;
+}
+
+export interface OutputChange {
+  device: string;
+  previous?: string;
+  followed: boolean;
+  returned: boolean;
+  stalled: boolean;
+  error?: string;
+}
+
+export interface Shortcut {
+  action: string;
+  label: string;
+  keys: string;
+  default: string;
+  scope: string;
+  reserved: boolean;
+}
+
+export interface FolderSync {
+  created: number;
+  updated: number;
+  removed: number;
+}
+
+export interface RetryResult {
+  attempted: number;
+  recovered: number;
+  failed: FailedLoad[];
+  tracks: TrackMetadata[];
+}
+
+export interface AppError {
+  code: string;
+  message: string;
+  details?: string;
+  retryable: boolean;
+}
+
+export interface Track {
+  id?: number;
+  title: string;
+  artist: string;
+  permalinkUrl: string;
+  artworkUrl: string;
+  durationMs: number;
+}
+
+export interface Item {
+  path: string;
+  title: string;
+  artist: string;
+  album: string;
+  albumArtist: string;
+  discNumber: number;
+  trackNumber: number;
+}
+
+export interface Chapter {
+  index: number;
+  id: string;
+  title: string;
+  start: number;
+  end: number;
+  image?: string;
+}
+
+export interface Status {
+  known: boolean;
+  onBattery: boolean;
+  percent: number;
+  lowPower: boolean;
+}
+
+export interface Policy {
+  mode: string;
+  batteryThreshold: number;
+}
+
+export interface FailedLoad {
+  path: string;
+  stage: string;
+  code: string;
+  error: string;
+  attempts: number;
+  firstSeen: number;
+  lastTried: number;
+  nextRetry?: number;
+}
+
+export interface TrackMetadata {
+  id?: string;
+  filePath: string;
+  fileName: string;
+  title: string;
+  artist: string;
+  album: string;
+  albumArtist: string;
+  trackNumber: number;
+  discNumber: number;
+  genre: string;
+  year: number;
+  comment: string;
+  composer: string;
+  label: string;
+  lyrics: string;
+  syncedLyrics?: string;
+  hasCover: boolean;
+  coverImage: string;
+  format: string;
+  bitrate: number;
+  sampleRate: number;
+  bpm?: number;
+  key?: string;
+  replayGain?: ReplayGain | null;
+  classical?: Classical | null;
+  sourceUrl: string;
+  source: string;
+  links: Link[];
+  cuePoints?: CuePoint[];
+  colorLabel?: string;
+  custom?: Record<string, string>;
+  fades?: FadeProfile | null;
+  cloudOnly?: boolean;
+  offline?: boolean;
+}
+
+export interface ReplayGain {
+  trackGain: number;
+  trackPeak?: number;
+  albumGain?: number;
+  albumPeak?: number;
+  hasTrack: boolean;
+  hasAlbum: boolean;
+}
+
+export interface Classical {
+  work?: string;
+  movement?: string;
+  movementNumber?: number;
+  movementCount?: number;
+  conductor?: string;
+  orchestra?: string;
+  soloists?: string[];
+}
+
+export interface Link {
+  url: string;
+  label: string;
+  kind: string;
+}
+
+export interface CuePoint {
+  index: number;
+  label: string;
+  position: number;
+  color: string;
+}
+
+export interface FadeProfile {
+  in: number;
+  out: number;
+}
+
+export interface EventPayloads {
+  "operation:update": Operation;
+  "soundcloud:reconnect-needed": TokenHealth;
+  "soundcloud:likes:page": LikesProgress;
+  "soundcloud:likes:done": LikesDone;
+  "library:rescan": RescanProgress;
+  "snapshot:restored": string;
+  "stems:progress": StemJob;
+  "stems:done": StemJob;
+  "recording:started": Recording;
+  "recording:stopped": Recording | null;
+  "cloud:hydrate": HydrateProgress;
+  "library:volumes": Volume[];
+  "startup:service-ready": Timing;
+  "queue:advanced": State;
+  "queue:ended": State;
+  "playback:position": Position;
+  "playback:ended": TrackEnded;
+  "playback:chapter": ChapterChange;
+  "power:changed": PowerState;
+  "media:command": MediaKey;
+  "audio:output-changed": OutputChange;
+  "shortcuts:changed": Shortcut[];
+  "playlists:folder-sync": FolderSync;
+  "library:retry": RetryResult;
+}
+
+export type EventName = keyof EventPayloads;
+
+export const EVENT_NAMES: EventName[] = [
+  "operation:update",
+  "soundcloud:reconnect-needed",
+  "soundcloud:likes:page",
+  "soundcloud:likes:done",
+  "library:rescan",
+  "snapshot:restored",
+  "stems:progress",
+  "stems:done",
+  "recording:started",
+  "recording:stopped",
+  "cloud:hydrate",
+  "library:volumes",
+  "startup:service-ready",
+  "queue:advanced",
+  "queue:ended",
+  "playback:position",
+  "playback:ended",
+  "playback:chapter",
+  "power:changed",
+  "media:command",
+  "audio:output-changed",
+  "shortcuts:changed",
+  "playlists:folder-sync",
+  "library:retry",
+];
+
+export function onEvent<K extends EventName>(name: K, cb: (payload: EventPayloads[K]) => void): () => void {
+  return EventsOn(name, (payload: EventPayloads[K]) => cb(payload));
+}
@@ -1,6 +1,7 @@
 import { useEffect, useState } from 'react';
 import { AddFiles, LoadLibraryWithMetadata, SaveMetadataAndRefresh, SelectFiles } from '../../wailsjs/go/main/App';
 import { metadata } from '../../wailsjs/go/models';
+import { errorMessage } from '../errors';
 
 export function useMetadata() {
     const [fileList, setFileList] = useState<metadata.TrackMetadata[]>([]);
@@ -24,7 +25,7 @@ export function useMetadata() {
                     setError(res.errors.join('\n'));
                 }
             } catch (e: any) {
-                setError(e?.toString?.() ?? 'Failed to load library');
+                setError(errorMessage(e, 'Failed to load library'));
             } finally {
                 setIsBooting(false);
             }
@@ -40,7 +41,7 @@ export function useMetadata() {
                 await addFilesByPath(paths);
             }
         } catch (err: any) {
-            setError(err.toString());
+            setError(errorMessage(err));
         } finally {
             setIsLoading(false);
         }
@@ -64,7 +65,7 @@ export function useMetadata() {
                 setError(res.errors.join('\n'));
             }
         } catch (err: any) {
-            setError(err.toString());
+            setError(errorMessage(err));
         } finally {
             setIsLoading(false);
         }
@@ -83,7 +84,7 @@ export function useMetadata() {
                 setCurrentTrack(refreshed);
             }
         } catch (err: any) {
-            setError(err.toString());
+            setError(errorMessage(err));
         } finally {
             setIsLoading(false);
         }
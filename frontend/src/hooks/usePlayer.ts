@@ -3,6 +3,7 @@ import {
     LoadAudio, PlayAudio, PauseAudio, ToggleAudio, 
     SeekAudio, SetVolume, GetAudioState 
 } from '../../wailsjs/go/main/App';
+import { errorMessage } from '../errors';
 
 export function usePlayer() {
     const [isPlaying, setIsPlaying] = useState(false);
@@ -30,7 +31,7 @@ export function usePlayer() {
             setError(null);
             return true;
         } catch (err: any) {
-            setError(err?.toString?.() ?? 'Failed to load audio');
+            setError(errorMessage(err, 'Failed to load audio'));
             setIsPlaying(false);
             return false;
         }
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"io/fs"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"kitty/backend/server"
+)
+
+type headlessOptions struct {
+	enabled bool
+	addr    string
+}
+
+func parseHeadlessOptions(args []string) headlessOptions {
+	opts := headlessOptions{
+		enabled: os.Getenv("KITTY_HEADLESS") == "1",
+		addr:    strings.TrimSpace(os.Getenv("KITTY_LISTEN")),
+	}
+	for i := 0; i < len(args); i++ {
+		arg := strings.TrimLeft(args[i], "-")
+		switch {
+		case arg == "headless":
+			opts.enabled = true
+		case strings.HasPrefix(arg, "listen="):
+			opts.addr = strings.TrimPrefix(arg, "listen=")
+		case arg == "listen" && i+1 < len(args):
+			opts.addr = args[i+1]
+			i++
+		}
+	}
+	if opts.addr == "" {
+		opts.addr = server.DefaultAddr
+	}
+	return opts
+}
+
+func runHeadless(app *App, opts headlessOptions) error {
+	token := strings.TrimSpace(os.Getenv("KITTY_SERVER_TOKEN"))
+	if token == "" {
+		generated, err := server.GenerateToken()
+		if err != nil {
+			return err
+		}
+		token = generated
+		log.Printf("[server] no KITTY_SERVER_TOKEN set, generated access token: %s", token)
+	}
+
+	dist, err := fs.Sub(assets, "frontend/dist")
+	if err != nil {
+		return err
+	}
+	srv := server.New(app, token, dist)
+	srv.SetMediaHandler(app.mediaHandler())
+	app.headless = true
+	app.onEvent = srv.Broadcast
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	app.startup(ctx)
+	defer app.shutdown(ctx)
+	return srv.ListenAndServe(ctx, opts.addr)
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"embed"
+	"kitty/backend/apperror"
+	"os"
 	goRuntime "runtime"
 
 	"github.com/wailsapp/wails/v2"
@@ -11,11 +13,20 @@ import (
 	"github.com/wailsapp/wails/v2/pkg/options/windows"
 )
 
+//go:generate go run ./cmd/eventgen -out frontend/src/events.gen.ts
+
 //go:embed all:frontend/dist
 var assets embed.FS
 
 func main() {
 	app := NewApp()
+	if opts := parseHeadlessOptions(os.Args[1:]); opts.enabled {
+		if err := runHeadless(app, opts); err != nil {
+			println("Error:", err.Error())
+		}
+		return
+	}
+
 	width := 1120
 	height := 760
 	minWidth := 980
@@ -35,11 +46,13 @@ func main() {
 		MinWidth:  minWidth,
 		MinHeight: minHeight,
 		AssetServer: &assetserver.Options{
-			Assets: assets,
+			Assets:  assets,
+			Handler: app.mediaHandler(),
 		},
 		BackgroundColour: &options.RGBA{R: 11, G: 11, B: 15, A: 255},
 		OnStartup:        app.startup,
 		OnShutdown:       app.shutdown,
+		ErrorFormatter:   apperror.Format,
 		Windows: &windows.Options{
 			WebviewIsTransparent: true,
 			WindowIsTranslucent:  false,